@@ -0,0 +1,408 @@
+// Package client is a Go client for the rsshub REST API described by the
+// OpenAPI document served at /openapi.json.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Client talks to one rsshub server. Token, if set, is sent as a bearer
+// token with every request; Actor, if set, names the user in the server's
+// audit log.
+type Client struct {
+	BaseURL    string
+	Token      string
+	Actor      string
+	HTTPClient *http.Client
+}
+
+func New(baseURL string) *Client {
+	return &Client{BaseURL: strings.TrimRight(baseURL, "/"), HTTPClient: http.DefaultClient}
+}
+
+// APIError is returned for non-2xx responses.
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("rsshub API: %d: %s", e.StatusCode, e.Message)
+}
+
+// ListOptions pages through a list endpoint. Zero values use server
+// defaults.
+type ListOptions struct {
+	Limit  int
+	Cursor string
+}
+
+func (o ListOptions) values() url.Values {
+	v := url.Values{}
+	if o.Limit > 0 {
+		v.Set("limit", strconv.Itoa(o.Limit))
+	}
+	if o.Cursor != "" {
+		v.Set("cursor", o.Cursor)
+	}
+	return v
+}
+
+// ArticleOptions filters and pages the article list.
+type ArticleOptions struct {
+	ListOptions
+	Feed    string
+	Author  string
+	Unread  bool
+	Starred bool
+	// Near ("lat,lon") and Radius (e.g. "50km") select articles by
+	// location; both are needed.
+	Near   string
+	Radius string
+	// MaxReadTime keeps only articles estimated to take no longer to read.
+	MaxReadTime time.Duration
+	// ShowMuted includes articles hidden by mute rules.
+	ShowMuted bool
+}
+
+// RandomOptions selects the articles RandomArticles picks from. With both
+// Unread and Starred set, either will do. OlderThan keeps only articles
+// published at least that long ago.
+type RandomOptions struct {
+	Limit     int
+	Feed      string
+	Unread    bool
+	Starred   bool
+	OlderThan time.Duration
+}
+
+// FeedOptions filters and pages the feed list. Match keeps feeds whose
+// name or URL contains it, Errored those whose last fetch failed and
+// Disabled those disabled after failing too often. Wide adds each feed's
+// fetch status.
+type FeedOptions struct {
+	ListOptions
+	Match    string
+	Errored  bool
+	Disabled bool
+	Wide     bool
+}
+
+func (c *Client) ListFeeds(ctx context.Context, opts FeedOptions) (*FeedList, error) {
+	q := opts.values()
+	if opts.Match != "" {
+		q.Set("match", opts.Match)
+	}
+	if opts.Errored {
+		q.Set("errored", "true")
+	}
+	if opts.Disabled {
+		q.Set("disabled", "true")
+	}
+	if opts.Wide {
+		q.Set("wide", "true")
+	}
+	var out FeedList
+	err := c.do(ctx, http.MethodGet, "/api/feeds", q, nil, &out)
+	return &out, err
+}
+
+func (c *Client) GetFeed(ctx context.Context, name string) (*Feed, error) {
+	var out Feed
+	err := c.do(ctx, http.MethodGet, "/api/feeds/"+url.PathEscape(name), nil, nil, &out)
+	return &out, err
+}
+
+func (c *Client) AddFeed(ctx context.Context, feed NewFeed) (*Feed, error) {
+	var out Feed
+	err := c.do(ctx, http.MethodPost, "/api/feeds", nil, feed, &out)
+	return &out, err
+}
+
+// DeleteFeed soft-deletes a feed; RestoreFeed brings it back.
+func (c *Client) DeleteFeed(ctx context.Context, name string) error {
+	return c.do(ctx, http.MethodDelete, "/api/feeds/"+url.PathEscape(name), nil, nil, nil)
+}
+
+// PurgeFeed permanently removes a feed and its articles.
+func (c *Client) PurgeFeed(ctx context.Context, name string) error {
+	q := url.Values{"purge": {"true"}, "keep_articles": {"false"}}
+	return c.do(ctx, http.MethodDelete, "/api/feeds/"+url.PathEscape(name), q, nil, nil)
+}
+
+// PurgeFeedKeepArticles permanently removes a feed after archiving its
+// articles, and returns how many were archived.
+func (c *Client) PurgeFeedKeepArticles(ctx context.Context, name string) (int64, error) {
+	q := url.Values{"purge": {"true"}, "keep_articles": {"true"}}
+	var out PurgeResult
+	err := c.do(ctx, http.MethodDelete, "/api/feeds/"+url.PathEscape(name), q, nil, &out)
+	return out.Archived, err
+}
+
+// ArchivedArticles lists articles kept from purged feeds, newest first,
+// only those of feed if it is not empty.
+func (c *Client) ArchivedArticles(ctx context.Context, feed string, opts ListOptions) (*ArchivedArticleList, error) {
+	q := opts.values()
+	if feed != "" {
+		q.Set("feed", feed)
+	}
+	var out ArchivedArticleList
+	err := c.do(ctx, http.MethodGet, "/api/archived-articles", q, nil, &out)
+	return &out, err
+}
+
+// FeedImpact counts the articles, read and starred state and revisions a
+// delete or purge of the feed would affect.
+func (c *Client) FeedImpact(ctx context.Context, name string) (*FeedImpact, error) {
+	var out FeedImpact
+	err := c.do(ctx, http.MethodGet, "/api/feeds/"+url.PathEscape(name)+"/impact", nil, nil, &out)
+	return &out, err
+}
+
+// FeedQuirks returns the feed's parser overrides.
+func (c *Client) FeedQuirks(ctx context.Context, name string) (*FeedQuirks, error) {
+	var out FeedQuirks
+	err := c.do(ctx, http.MethodGet, "/api/feeds/"+url.PathEscape(name)+"/quirks", nil, nil, &out)
+	return &out, err
+}
+
+// SetFeedQuirks replaces the feed's parser overrides.
+func (c *Client) SetFeedQuirks(ctx context.Context, name string, q FeedQuirks) (*FeedQuirks, error) {
+	var out FeedQuirks
+	err := c.do(ctx, http.MethodPut, "/api/feeds/"+url.PathEscape(name)+"/quirks", nil, q, &out)
+	return &out, err
+}
+
+// MergeFeed moves the articles of feed from into feed into and removes
+// from.
+func (c *Client) MergeFeed(ctx context.Context, from, into string) (*MergeResult, error) {
+	var out MergeResult
+	err := c.do(ctx, http.MethodPost, "/api/feeds/"+url.PathEscape(from)+"/merge", nil, MergeRequest{Into: into}, &out)
+	return &out, err
+}
+
+func (c *Client) RestoreFeed(ctx context.Context, name string) (*Feed, error) {
+	var out Feed
+	err := c.do(ctx, http.MethodPost, "/api/feeds/"+url.PathEscape(name)+"/restore", nil, nil, &out)
+	return &out, err
+}
+
+// EnableFeed resumes fetching a feed that was disabled after failing too
+// many fetches in a row.
+func (c *Client) EnableFeed(ctx context.Context, name string) (*Feed, error) {
+	var out Feed
+	err := c.do(ctx, http.MethodPost, "/api/feeds/"+url.PathEscape(name)+"/enable", nil, nil, &out)
+	return &out, err
+}
+
+// RefreshFeed asks the daemon to fetch a feed now, outside its schedule.
+func (c *Client) RefreshFeed(ctx context.Context, name string) error {
+	return c.do(ctx, http.MethodPost, "/api/feeds/"+url.PathEscape(name)+"/refresh", nil, nil, nil)
+}
+
+func (c *Client) ListArticles(ctx context.Context, opts ArticleOptions) (*ArticleList, error) {
+	q := opts.values()
+	if opts.Feed != "" {
+		q.Set("feed", opts.Feed)
+	}
+	if opts.Author != "" {
+		q.Set("author", opts.Author)
+	}
+	if opts.Near != "" {
+		q.Set("near", opts.Near)
+		q.Set("radius", opts.Radius)
+	}
+	if opts.Unread {
+		q.Set("unread", "true")
+	}
+	if opts.Starred {
+		q.Set("starred", "true")
+	}
+	if opts.MaxReadTime > 0 {
+		q.Set("max_read_time", opts.MaxReadTime.String())
+	}
+	if opts.ShowMuted {
+		q.Set("show_muted", "true")
+	}
+	var out ArticleList
+	err := c.do(ctx, http.MethodGet, "/api/articles", q, nil, &out)
+	return &out, err
+}
+
+// RandomArticles picks articles at random from those opts selects.
+func (c *Client) RandomArticles(ctx context.Context, opts RandomOptions) (*ArticleList, error) {
+	q := url.Values{}
+	if opts.Limit > 0 {
+		q.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	if opts.Feed != "" {
+		q.Set("feed", opts.Feed)
+	}
+	if opts.Unread {
+		q.Set("unread", "true")
+	}
+	if opts.Starred {
+		q.Set("starred", "true")
+	}
+	if opts.OlderThan > 0 {
+		q.Set("older_than", opts.OlderThan.String())
+	}
+	var out ArticleList
+	err := c.do(ctx, http.MethodGet, "/api/articles/random", q, nil, &out)
+	return &out, err
+}
+
+func (c *Client) GetArticle(ctx context.Context, id string) (*Article, error) {
+	var out Article
+	err := c.do(ctx, http.MethodGet, "/api/articles/"+url.PathEscape(id), nil, nil, &out)
+	return &out, err
+}
+
+// ArticleRevisions returns the earlier versions of an article.
+func (c *Client) ArticleRevisions(ctx context.Context, id string) (*RevisionList, error) {
+	var out RevisionList
+	err := c.do(ctx, http.MethodGet, "/api/articles/"+url.PathEscape(id)+"/revisions", nil, nil, &out)
+	return &out, err
+}
+
+// ArticleNotes returns an article's notes, oldest first.
+func (c *Client) ArticleNotes(ctx context.Context, id string) (*NoteList, error) {
+	var out NoteList
+	err := c.do(ctx, http.MethodGet, "/api/articles/"+url.PathEscape(id)+"/notes", nil, nil, &out)
+	return &out, err
+}
+
+// AddNote attaches a note to an article.
+func (c *Client) AddNote(ctx context.Context, id string, note NewNote) (*Note, error) {
+	var out Note
+	err := c.do(ctx, http.MethodPost, "/api/articles/"+url.PathEscape(id)+"/notes", nil, note, &out)
+	return &out, err
+}
+
+// DeleteNote removes a note.
+func (c *Client) DeleteNote(ctx context.Context, id int64) error {
+	return c.do(ctx, http.MethodDelete, "/api/notes/"+strconv.FormatInt(id, 10), nil, nil, nil)
+}
+
+// SearchNotes returns up to limit notes containing query, newest first,
+// or the latest notes when query is empty.
+func (c *Client) SearchNotes(ctx context.Context, query string, limit int) (*NoteList, error) {
+	q := url.Values{}
+	if query != "" {
+		q.Set("q", query)
+	}
+	if limit > 0 {
+		q.Set("limit", strconv.Itoa(limit))
+	}
+	var out NoteList
+	err := c.do(ctx, http.MethodGet, "/api/notes", q, nil, &out)
+	return &out, err
+}
+
+func (c *Client) MarkRead(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodPost, "/api/articles/"+url.PathEscape(id)+"/read", nil, nil, nil)
+}
+
+// SetStarred stars or unstars an article.
+func (c *Client) SetStarred(ctx context.Context, id string, starred bool) error {
+	method := http.MethodPut
+	if !starred {
+		method = http.MethodDelete
+	}
+	return c.do(ctx, method, "/api/articles/"+url.PathEscape(id)+"/star", nil, nil, nil)
+}
+
+// ListAudit returns a page of audit entries, newest first, only those
+// about target if it is not empty.
+func (c *Client) ListAudit(ctx context.Context, target string, opts ListOptions) (*AuditList, error) {
+	q := opts.values()
+	if target != "" {
+		q.Set("target", target)
+	}
+	var out AuditList
+	err := c.do(ctx, http.MethodGet, "/api/audit", q, nil, &out)
+	return &out, err
+}
+
+// ListAuthors counts articles per author, most prolific first, in all
+// feeds or only feed's. A limit of 0 uses the server's default.
+func (c *Client) ListAuthors(ctx context.Context, feed string, limit int) (*AuthorList, error) {
+	q := ListOptions{Limit: limit}.values()
+	if feed != "" {
+		q.Set("feed", feed)
+	}
+	var out AuthorList
+	err := c.do(ctx, http.MethodGet, "/api/authors", q, nil, &out)
+	return &out, err
+}
+
+// Version returns the server's build information.
+func (c *Client) Version(ctx context.Context) (*VersionInfo, error) {
+	var out VersionInfo
+	err := c.do(ctx, http.MethodGet, "/api/version", nil, nil, &out)
+	return &out, err
+}
+
+// Control runs a daemon control command and returns its reply.
+func (c *Client) Control(ctx context.Context, command string) (string, error) {
+	var out ControlReply
+	err := c.do(ctx, http.MethodPost, "/api/control", nil, ControlRequest{Command: command}, &out)
+	return out.Reply, err
+}
+
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out any) error {
+	u := c.BaseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, reqBody)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	if c.Actor != "" {
+		req.Header.Set("X-Rsshub-Actor", c.Actor)
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var e ErrorBody
+		json.NewDecoder(resp.Body).Decode(&e)
+		if e.Error == "" {
+			e.Error = resp.Status
+		}
+		return &APIError{StatusCode: resp.StatusCode, Message: e.Error}
+	}
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,299 @@
+package client
+
+import "time"
+
+// Feed is a subscribed feed as returned by the REST API.
+type Feed struct {
+	ID              string     `json:"id"`
+	Name            string     `json:"name"`
+	URL             string     `json:"url"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       *time.Time `json:"updated_at,omitempty"`
+	ArticleCount    int64      `json:"article_count"`
+	LastPublishedAt *time.Time `json:"last_published_at,omitempty"`
+
+	// DisabledAt is set once the feed stopped being fetched after failing
+	// too often; DisabledReason is its last error.
+	DisabledAt     *time.Time `json:"disabled_at,omitempty"`
+	DisabledReason string     `json:"disabled_reason,omitempty"`
+
+	// Status is only returned by wide listings.
+	Status *FeedStatus `json:"status,omitempty"`
+}
+
+// FeedStatus is how a feed's fetches are going: the last attempt, which
+// failed if LastError is set, the fetches in a row that have failed, and
+// the articles not yet read.
+type FeedStatus struct {
+	LastFetchedAt *time.Time `json:"last_fetched_at,omitempty"`
+	LastStatus    int        `json:"last_status,omitempty"`
+	LastError     string     `json:"last_error,omitempty"`
+	Failures      int        `json:"failures"`
+	UnreadCount   int64      `json:"unread_count"`
+}
+
+// Article is a stored feed item as returned by the REST API.
+type Article struct {
+	ID          string    `json:"id"`
+	FeedID      string    `json:"feed_id"`
+	Title       string    `json:"title"`
+	Link        string    `json:"link"`
+	Description string    `json:"description"`
+	Author      string    `json:"author,omitempty"`
+	PublishedAt time.Time `json:"published_at"`
+	// PublishedAtSource says where PublishedAt came from: published,
+	// updated or dc_date from the item itself, else last_modified from the
+	// feed response or first_seen.
+	PublishedAtSource string     `json:"published_at_source,omitempty"`
+	UpdatedAt         *time.Time `json:"updated_at,omitempty"`
+	ReadAt            *time.Time `json:"read_at,omitempty"`
+	StarredAt         *time.Time `json:"starred_at,omitempty"`
+	GUID              string     `json:"guid,omitempty"`
+	Comments          string     `json:"comments,omitempty"`
+	Enclosure         *Enclosure `json:"enclosure,omitempty"`
+	Location          *Location  `json:"location,omitempty"`
+	Podcast           *Podcast   `json:"podcast,omitempty"`
+	Thumbnail         string     `json:"thumbnail,omitempty"`
+	Video             *Video     `json:"video,omitempty"`
+	// WordCount and ReadingTimeSeconds measure the description; both are
+	// omitted when it was not counted.
+	WordCount          int   `json:"word_count,omitempty"`
+	ReadingTimeSeconds int64 `json:"reading_time_seconds,omitempty"`
+	// ShortID is the shortest prefix of ID, at least eight hex digits,
+	// that no other article's ID starts with; omitted where not worked out.
+	ShortID string `json:"short_id,omitempty"`
+}
+
+// Video identifies a YouTube video. Views is the count when the article
+// was first stored, 0 if unknown.
+type Video struct {
+	ID    string `json:"id"`
+	Views int64  `json:"views,omitempty"`
+}
+
+// Podcast is an article's iTunes episode metadata; each field is omitted
+// when the feed did not give it.
+type Podcast struct {
+	DurationSeconds int64  `json:"duration_seconds,omitempty"`
+	Episode         int    `json:"episode,omitempty"`
+	Season          int    `json:"season,omitempty"`
+	Image           string `json:"image,omitempty"`
+}
+
+// Location is the WGS84 position an article is about, in decimal degrees.
+type Location struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+// Enclosure is a media file attached to an article. Length is in bytes,
+// 0 if the feed did not give it.
+type Enclosure struct {
+	URL    string `json:"url"`
+	Type   string `json:"type,omitempty"`
+	Length int64  `json:"length,omitempty"`
+}
+
+// Revision is an earlier version of an article, current from ValidFrom
+// until the upstream item changed at ReplacedAt.
+type Revision struct {
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	ValidFrom   time.Time `json:"valid_from"`
+	ReplacedAt  time.Time `json:"replaced_at"`
+}
+
+// RevisionList holds an article's earlier versions, oldest first.
+type RevisionList struct {
+	Revisions []Revision `json:"revisions"`
+}
+
+// Note is a reader's note on an article, optionally about a quoted
+// passage. ArticleTitle and ArticleLink are set in search results.
+type Note struct {
+	ID           int64     `json:"id"`
+	ArticleID    string    `json:"article_id"`
+	Quote        string    `json:"quote,omitempty"`
+	Text         string    `json:"text"`
+	CreatedBy    string    `json:"created_by"`
+	CreatedAt    time.Time `json:"created_at"`
+	ArticleTitle string    `json:"article_title,omitempty"`
+	ArticleLink  string    `json:"article_link,omitempty"`
+}
+
+// NewNote is the request body for adding a note to an article.
+type NewNote struct {
+	Quote string `json:"quote,omitempty"`
+	Text  string `json:"text"`
+}
+
+// NoteList holds an article's notes, oldest first, or search results,
+// newest first.
+type NoteList struct {
+	Notes []Note `json:"notes"`
+}
+
+// NewFeed is the request body for subscribing to a feed.
+type NewFeed struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// FeedCheck lists the feeds found for a web page and whether each is
+// already subscribed, for browser extensions.
+type FeedCheck struct {
+	URL   string          `json:"url"`
+	Feeds []FeedCandidate `json:"feeds"`
+}
+
+// FeedCandidate is a feed found for a page. SubscribedAs names the feed
+// that already has its URL; Deleted is set when that feed is deleted.
+type FeedCandidate struct {
+	URL          string `json:"url"`
+	Title        string `json:"title,omitempty"`
+	SubscribedAs string `json:"subscribed_as,omitempty"`
+	Deleted      bool   `json:"deleted,omitempty"`
+}
+
+// FeedList is one page of feeds. NextCursor is empty on the last page;
+// Next is the same page request as a relative URL.
+type FeedList struct {
+	Feeds      []Feed `json:"feeds"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	Next       string `json:"next,omitempty"`
+}
+
+// FeedImpact counts what deleting or purging a feed affects.
+type FeedImpact struct {
+	Deleted   bool  `json:"deleted"`
+	Articles  int64 `json:"articles"`
+	Read      int64 `json:"read"`
+	Starred   int64 `json:"starred"`
+	Revisions int64 `json:"revisions"`
+}
+
+// ArticleList is one page of articles. NextCursor is empty on the last page;
+// Next is the same page request as a relative URL.
+type ArticleList struct {
+	Articles   []Article `json:"articles"`
+	NextCursor string    `json:"next_cursor,omitempty"`
+	Next       string    `json:"next,omitempty"`
+}
+
+// ArchivedArticle is an article kept after its feed was purged.
+type ArchivedArticle struct {
+	Article
+	FeedName   string    `json:"feed_name"`
+	FeedURL    string    `json:"feed_url"`
+	ArchivedAt time.Time `json:"archived_at"`
+}
+
+// ArchivedArticleList is one page of archived articles.
+type ArchivedArticleList struct {
+	Articles   []ArchivedArticle `json:"articles"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+	Next       string            `json:"next,omitempty"`
+}
+
+// FeedQuirks are per-feed parser overrides. DateLayout is a Go time layout
+// tried before the standard date formats. CABundle is PEM certificates to
+// verify the feed's server against, CertPins comma-separated
+// sha256/<base64> public key hashes it must present.
+type FeedQuirks struct {
+	DateLayout       string `json:"date_layout"`
+	InsecureTLS      bool   `json:"insecure_tls"`
+	CABundle         string `json:"ca_bundle"`
+	CertPins         string `json:"cert_pins"`
+	PlainDescription bool   `json:"plain_description"`
+	StripTitlePrefix string `json:"strip_title_prefix"`
+}
+
+// MergeRequest names the feed to merge into.
+type MergeRequest struct {
+	Into string `json:"into"`
+}
+
+// MergeResult counts the articles a merge moved and the duplicates it
+// dropped because the target feed already had them.
+type MergeResult struct {
+	Moved      int64 `json:"moved"`
+	Duplicates int64 `json:"duplicates"`
+}
+
+// PurgeResult reports how many articles a purge archived.
+type PurgeResult struct {
+	Archived int64 `json:"archived"`
+}
+
+// ErrorBody is returned with every non-2xx response.
+type ErrorBody struct {
+	Error string `json:"error"`
+}
+
+// ControlRequest carries a daemon control command such as "pause" or
+// "set-interval 5m".
+type ControlRequest struct {
+	Command string `json:"command"`
+}
+
+// ControlReply is the daemon's human-readable answer to a control command.
+type ControlReply struct {
+	Reply string `json:"reply"`
+}
+
+// AuditEntry records one administrative operation. OldValue and NewValue
+// are empty when they do not apply.
+type AuditEntry struct {
+	ID       int64     `json:"id"`
+	At       time.Time `json:"at"`
+	Actor    string    `json:"actor"`
+	Source   string    `json:"source"`
+	Action   string    `json:"action"`
+	Target   string    `json:"target,omitempty"`
+	OldValue string    `json:"old_value,omitempty"`
+	NewValue string    `json:"new_value,omitempty"`
+}
+
+// AuditList is the newest-first audit log.
+type AuditList struct {
+	Entries    []AuditEntry `json:"entries"`
+	NextCursor string       `json:"next_cursor,omitempty"`
+	Next       string       `json:"next,omitempty"`
+}
+
+// AuthorStats is how much one author has written.
+type AuthorStats struct {
+	Author          string    `json:"author"`
+	Articles        int64     `json:"articles"`
+	Feeds           int64     `json:"feeds"`
+	LastPublishedAt time.Time `json:"last_published_at"`
+}
+
+// AuthorList is authors by article count, most prolific first.
+type AuthorList struct {
+	Authors []AuthorStats `json:"authors"`
+}
+
+// VersionInfo identifies the server's build.
+type VersionInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	Date      string `json:"date"`
+	GoVersion string `json:"go_version"`
+}
+
+// User is a signed-in user of the web API. Password and SSO report which
+// sign-in methods the user has.
+type User struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	Password  bool      `json:"password"`
+	SSO       bool      `json:"sso"`
+}
+
+// Login signs a user in with a password.
+type Login struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/google/uuid"
+	"rsshub/internal/models"
+	"rsshub/internal/output"
+)
+
+// handleNote attaches a note to an article, or without text shows the
+// article's notes.
+func handleNote(database store) {
+	const usage = `Usage: rsshub note <article-id> [--quote "passage"] ["note text"]`
+	if len(os.Args) < 3 {
+		fmt.Println(usage)
+		os.Exit(1)
+	}
+	id := articleArg(database, os.Args[2])
+	fs := flag.NewFlagSet("note", flag.ExitOnError)
+	quote := fs.String("quote", "", "Passage of the article the note is about, kept as a highlight")
+	fs.Parse(os.Args[3:])
+	text := strings.Join(fs.Args(), " ")
+
+	if text == "" {
+		if *quote != "" {
+			fmt.Println(usage)
+			os.Exit(1)
+		}
+		showNotes(database, id)
+		return
+	}
+	added, err := database.AddNote(id, *quote, text)
+	if err != nil {
+		fmt.Printf("Error adding note: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Note %d added to %s\n", added.ID, models.ShortID(id))
+}
+
+func showNotes(database store, id uuid.UUID) {
+	article, err := database.GetArticle(id)
+	if err != nil {
+		fmt.Printf("Error loading article: %v\n", err)
+		os.Exit(1)
+	}
+	notes, err := database.ArticleNotes(id)
+	if err != nil {
+		fmt.Printf("Error loading notes: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("%s\n%s\n", article.Title, article.Link)
+	if len(notes) == 0 {
+		fmt.Println("\nNo notes yet")
+		return
+	}
+	for _, n := range notes {
+		printNote(n)
+	}
+}
+
+// handleNotes searches notes, lists the latest ones, or deletes one.
+func handleNotes(database store) {
+	fs := flag.NewFlagSet("notes", flag.ExitOnError)
+	search := fs.String("search", "", "Only notes whose text or quote contains this, ignoring case")
+	num := fs.Int("num", 20, "Number of notes to show")
+	del := fs.Int64("delete", 0, "Delete the note with this number")
+	fs.Parse(os.Args[2:])
+
+	if *del != 0 {
+		if err := database.DeleteNote(*del); err != nil {
+			fmt.Printf("Error deleting note: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Note %d deleted\n", *del)
+		return
+	}
+	if *num < 1 {
+		fmt.Println("--num must be at least 1")
+		os.Exit(1)
+	}
+	notes, err := database.SearchNotes(*search, *num)
+	if err != nil {
+		fmt.Printf("Error searching notes: %v\n", err)
+		os.Exit(1)
+	}
+	if len(notes) == 0 {
+		if *search != "" {
+			fmt.Printf("No notes mention %q\n", *search)
+		} else {
+			fmt.Println("No notes yet (add one with rsshub note <article-id> \"text\")")
+		}
+		return
+	}
+	for _, n := range notes {
+		fmt.Printf("\n%s\n%s  (article %s)", n.ArticleTitle, n.ArticleLink, models.ShortID(n.ArticleID))
+		printNote(n)
+	}
+}
+
+func printNote(n models.ArticleNote) {
+	fmt.Printf("\n[%d] %s, by %s\n", n.ID, output.Date(n.CreatedAt), n.CreatedBy)
+	if n.Quote != "" {
+		fmt.Printf("  > %s\n", strings.ReplaceAll(n.Quote, "\n", "\n  > "))
+	}
+	fmt.Printf("  %s\n", strings.ReplaceAll(n.Text, "\n", "\n  "))
+}
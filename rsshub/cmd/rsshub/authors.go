@@ -0,0 +1,61 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+
+	"rsshub/client"
+	"rsshub/internal/models"
+	"rsshub/internal/output"
+)
+
+// handleAuthors counts articles per author, to find the writers worth
+// following in multi-author publications with articles --author.
+func handleAuthors(database store) {
+	fs := flag.NewFlagSet("authors", flag.ExitOnError)
+	feedName := fs.String("feed-name", "", "Only articles of this feed (default: all feeds)")
+	num := fs.Int("num", 20, "Number of authors to show, most prolific first")
+	formatFlag := fs.String("format", output.Table, `Output format: table, json with the REST API's fields, or a template such as '{{.Author}}\t{{.Articles}}'`)
+	fs.Parse(os.Args[2:])
+
+	if *num < 1 {
+		fmt.Println("--num must be at least 1")
+		os.Exit(1)
+	}
+	format, err := output.Parse(*formatFlag)
+	if err != nil {
+		fmt.Printf("Invalid --format: %v\n", err)
+		os.Exit(1)
+	}
+	authors, err := database.GetAuthorStats(*feedName, *num)
+	if err != nil {
+		fmt.Printf("Error getting authors: %v\n", err)
+		os.Exit(1)
+	}
+	if format.Name == output.Table && len(authors) == 0 {
+		fmt.Println("No articles with authors")
+		return
+	}
+
+	cols := []output.Column[models.AuthorStats]{
+		{Header: "Author", Value: func(a models.AuthorStats) string { return a.Author }, Flexible: true, Style: style[models.AuthorStats](output.Name)},
+		{Header: "Articles", Value: func(a models.AuthorStats) string { return strconv.FormatInt(a.Articles, 10) }, Right: true},
+	}
+	if *feedName == "" {
+		cols = append(cols, output.Column[models.AuthorStats]{Header: "Feeds", Value: func(a models.AuthorStats) string { return strconv.FormatInt(a.Feeds, 10) }, Right: true})
+	}
+	cols = append(cols, output.Column[models.AuthorStats]{Header: "Last published", Value: func(a models.AuthorStats) string { return output.Date(a.LastPublishedAt) }, Style: style[models.AuthorStats](output.When)})
+
+	err = output.Write(os.Stdout, format, authors, cols, func(a models.AuthorStats) any {
+		return client.AuthorStats{Author: a.Author, Articles: a.Articles, Feeds: a.Feeds, LastPublishedAt: a.LastPublishedAt}
+	})
+	if err != nil {
+		fmt.Printf("Error writing authors: %v\n", err)
+		os.Exit(1)
+	}
+	if format.Name == output.Table {
+		fmt.Println("\nFollow one across feeds with: rsshub articles --author NAME")
+	}
+}
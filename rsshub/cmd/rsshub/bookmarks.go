@@ -0,0 +1,253 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"rsshub/internal/config"
+	"rsshub/internal/db"
+	"rsshub/internal/models"
+	"rsshub/internal/readlater"
+)
+
+// handleStar stars or unstars the article given as the first argument.
+func handleStar(database store, starred bool) {
+	verb := "star"
+	if !starred {
+		verb = "unstar"
+	}
+	if len(os.Args) < 3 {
+		fmt.Printf("Usage: rsshub %s <article-id>\n", verb)
+		os.Exit(1)
+	}
+	id := articleArg(database, os.Args[2])
+	err := database.SetArticleStarred(id, starred)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Article %sred: %s\n", verb, models.ShortID(id))
+}
+
+// handleExportBookmarks writes the starred articles as a Netscape bookmark
+// file, which browsers, Pinboard and Linkding import, or as CSV.
+func handleExportBookmarks(database store) {
+	fs := flag.NewFlagSet("export-bookmarks", flag.ExitOnError)
+	format := fs.String("format", "netscape", "Output format: netscape or csv")
+	out := fs.String("out", "", "File to write (default: standard output)")
+	fs.Parse(os.Args[2:])
+
+	if *format != "netscape" && *format != "csv" {
+		fmt.Printf("Unknown format %q: use netscape or csv\n", *format)
+		os.Exit(1)
+	}
+
+	articles, err := database.StarredArticles()
+	if err != nil {
+		fmt.Printf("Error getting starred articles: %v\n", err)
+		os.Exit(1)
+	}
+	feeds, err := database.ListFeeds(models.FeedFilter{}, 0)
+	if err != nil {
+		fmt.Printf("Error getting feeds: %v\n", err)
+		os.Exit(1)
+	}
+	feedNames := make(map[uuid.UUID]string, len(feeds))
+	for _, f := range feeds {
+		feedNames[f.ID] = f.Name
+	}
+
+	w := io.Writer(os.Stdout)
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			fmt.Printf("Error creating %s: %v\n", *out, err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	if *format == "csv" {
+		err = writeBookmarksCSV(w, articles, feedNames)
+	} else {
+		err = writeBookmarksNetscape(w, articles, feedNames)
+	}
+	if err != nil {
+		fmt.Printf("Error writing bookmarks: %v\n", err)
+		os.Exit(1)
+	}
+	if *out != "" {
+		fmt.Printf("Exported %d starred articles to %s\n", len(articles), *out)
+	}
+}
+
+func writeBookmarksCSV(w io.Writer, articles []models.Article, feedNames map[uuid.UUID]string) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"title", "url", "feed", "published_at", "starred_at", "duration_seconds", "episode", "season", "image"})
+	for _, a := range articles {
+		cw.Write([]string{
+			a.Title,
+			a.Link,
+			feedNames[a.FeedID],
+			a.PublishedAt.UTC().Format(time.RFC3339),
+			a.StarredAt.UTC().Format(time.RFC3339),
+			optionalNumber(int64(a.Duration / time.Second)),
+			optionalNumber(int64(a.Episode)),
+			optionalNumber(int64(a.Season)),
+			a.ImageURL,
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// optionalNumber leaves unknown (zero) values empty in CSV output.
+func optionalNumber(n int64) string {
+	if n <= 0 {
+		return ""
+	}
+	return strconv.FormatInt(n, 10)
+}
+
+// writeBookmarksNetscape writes one folder per feed. Each bookmark is
+// tagged with "rsshub" and its feed name, and dated by when it was starred.
+func writeBookmarksNetscape(w io.Writer, articles []models.Article, feedNames map[uuid.UUID]string) error {
+	byFeed := map[string][]models.Article{}
+	for _, a := range articles {
+		name := feedNames[a.FeedID]
+		byFeed[name] = append(byFeed[name], a)
+	}
+	names := make([]string, 0, len(byFeed))
+	for name := range byFeed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString(`<!DOCTYPE NETSCAPE-Bookmark-file-1>
+<META HTTP-EQUIV="Content-Type" CONTENT="text/html; charset=UTF-8">
+<TITLE>Bookmarks</TITLE>
+<H1>rsshub starred articles</H1>
+<DL><p>
+`)
+	for _, name := range names {
+		fmt.Fprintf(&b, "    <DT><H3>%s</H3>\n    <DL><p>\n", html.EscapeString(name))
+		for _, a := range byFeed[name] {
+			tags := "rsshub"
+			if name != "" {
+				tags += "," + strings.ReplaceAll(name, ",", " ")
+			}
+			fmt.Fprintf(&b, "        <DT><A HREF=\"%s\" ADD_DATE=\"%d\" TAGS=\"%s\">%s</A>\n",
+				html.EscapeString(a.Link), a.StarredAt.Unix(), html.EscapeString(tags), html.EscapeString(a.Title))
+		}
+		b.WriteString("    </DL><p>\n")
+	}
+	b.WriteString("</DL><p>\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// handleSave pushes an article's link to a read-later service.
+func handleSave(cfg *config.Config, database store) {
+	if len(os.Args) < 3 {
+		fmt.Println("Usage: rsshub save <article-id> [--to wallabag|pocket|instapaper]")
+		os.Exit(1)
+	}
+	id := articleArg(database, os.Args[2])
+	fs := flag.NewFlagSet("save", flag.ExitOnError)
+	to := fs.String("to", "", "Read-later service (default: CLI_APP_READ_LATER)")
+	fs.Parse(os.Args[3:])
+
+	credentials, err := readLaterSecrets(cfg, database, *to)
+	if err != nil {
+		fmt.Printf("Error reading read-later secrets: %v\n", err)
+		os.Exit(1)
+	}
+	service, err := readlater.New(credentials, *to)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	article, err := database.GetArticle(id)
+	if err != nil {
+		fmt.Printf("Error loading article: %v\n", err)
+		os.Exit(1)
+	}
+	ctx, cancel := context.WithTimeout(cmdCtx, apiTimeout)
+	defer cancel()
+	err = service.Save(ctx, article.Link, article.Title)
+	if err != nil {
+		fmt.Printf("Error saving article: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Saved for later: %s\n", article.Title)
+}
+
+// readLaterSecrets returns the read-later settings with the credentials
+// of service, or of CLI_APP_READ_LATER's if it is empty, that are not set
+// in the environment taken from the global secrets named after their
+// variables, as stored with rsshub secrets set CLI_APP_POCKET_ACCESS_TOKEN.
+// Secrets need CLI_APP_SECRETS_KEY and the database, so --server goes
+// without them.
+func readLaterSecrets(cfg *config.Config, st store, service string) (config.ReadLater, error) {
+	rl := cfg.ReadLater
+	if service == "" {
+		service = rl.Service
+	}
+	var fields map[string]*string
+	switch service {
+	case "wallabag":
+		fields = map[string]*string{"CLI_APP_WALLABAG_CLIENT_SECRET": &rl.WallabagClientSecret, "CLI_APP_WALLABAG_PASSWORD": &rl.WallabagPassword}
+	case "pocket":
+		fields = map[string]*string{"CLI_APP_POCKET_CONSUMER_KEY": &rl.PocketConsumerKey, "CLI_APP_POCKET_ACCESS_TOKEN": &rl.PocketAccessToken}
+	case "instapaper":
+		fields = map[string]*string{"CLI_APP_INSTAPAPER_PASSWORD": &rl.InstapaperPassword}
+	}
+	for name, field := range fields {
+		if *field != "" {
+			delete(fields, name)
+		}
+	}
+	if len(fields) == 0 || cfg.SecretsKey == "" || cfg.Server != "" {
+		return rl, nil
+	}
+	key, err := secretsKey(cfg)
+	if err != nil {
+		return rl, err
+	}
+	var database *db.DB
+	if a, ok := st.(*db.Audited); ok {
+		database = a.DB
+	} else {
+		// The store is the local daemon's API, which serves no secrets.
+		database, err = db.NewDB(cfg)
+		if err != nil {
+			return rl, err
+		}
+		defer database.Close()
+		database = database.WithContext(cmdCtx)
+	}
+	for name, field := range fields {
+		value, err := database.Secret(key, "", name)
+		if errors.Is(err, db.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return rl, fmt.Errorf("%s: %w", name, err)
+		}
+		*field = string(value)
+	}
+	return rl, nil
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"rsshub/internal/config"
+	"rsshub/internal/db"
+)
+
+// handleBudget caps how much a feed may download per day.
+func handleBudget(database *db.DB) {
+	fs := flag.NewFlagSet("budget", flag.ExitOnError)
+	feedName := fs.String("feed-name", "", "Feed to cap")
+	daily := fs.String("daily", "", "Bytes the feed may download per day, e.g. 50MB; 0 removes the cap")
+	fs.Parse(os.Args[2:])
+
+	if *feedName == "" || *daily == "" {
+		fmt.Println("Usage: rsshub budget --feed-name NAME --daily 50MB (see usage with rsshub stats)")
+		os.Exit(1)
+	}
+	limit, err := config.ParseSize(*daily)
+	if err != nil {
+		fmt.Printf("Invalid --daily: %v (use e.g. 512KB, 50MB or 1GB)\n", err)
+		os.Exit(1)
+	}
+	if err := database.SetFeedByteLimit(*feedName, limit); err != nil {
+		fmt.Printf("Error setting budget: %v\n", err)
+		os.Exit(1)
+	}
+	database.Audit(db.Actor{Name: currentUser(), Source: db.SourceCLI}, "budget", *feedName, "", *daily)
+	if limit == 0 {
+		fmt.Printf("Feed %s may download as much as it needs\n", *feedName)
+		return
+	}
+	fmt.Printf("Feed %s may download %s a day; once it has, its fetches wait for the next day\n", *feedName, byteSize(limit))
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// cmdCtx is the context the command runs under, from commandContext.
+// Database queries, API requests and fetches made for the command are
+// cancelled with it.
+var cmdCtx = context.Background()
+
+// defaultTimeout bounds a command that looks up or changes a few rows, so
+// a hung database, server or feed cannot keep it waiting forever.
+const defaultTimeout = 2 * time.Minute
+
+// untimedCommands run for as long as they have work, or until Ctrl-C:
+// those that follow the daemon, work through whole feeds or exports, or
+// wait on prompts.
+var untimedCommands = map[string]bool{
+	"watch": true, "top": true, "download": true, "import": true, "reingest": true, "reparse": true,
+	"build-site": true, "export-bookmarks": true, "export-notes": true, "user": true, "secrets": true,
+}
+
+// interruptGrace is how long a command has to wind down after Ctrl-C
+// before it is ended anyway, for one stuck where no context reaches, such
+// as a prompt.
+const interruptGrace = 5 * time.Second
+
+// commandTimeout is how long command may run: the --timeout flag if
+// given, 0 meaning no limit, or else the command's default.
+func (g globalFlags) commandTimeout(command string) time.Duration {
+	if g.timeout != nil {
+		d, err := time.ParseDuration(*g.timeout)
+		if err != nil || d < 0 {
+			fmt.Printf("Error: --timeout %q is not a duration such as 30s or 5m\n", *g.timeout)
+			os.Exit(1)
+		}
+		return d
+	}
+	if untimedCommands[command] {
+		return 0
+	}
+	return defaultTimeout
+}
+
+// commandContext returns a context cancelled by Ctrl-C or SIGTERM and,
+// unless timeout is 0, once timeout has passed. After a signal the command
+// has interruptGrace to return; a second signal ends it at once. stop
+// releases the signals.
+func commandContext(timeout time.Duration) (context.Context, func()) {
+	interrupted, cancel := context.WithCancel(context.Background())
+	ctx, cancelTimeout := interrupted, context.CancelFunc(func() {})
+	if timeout > 0 {
+		ctx, cancelTimeout = context.WithTimeout(interrupted, timeout)
+	}
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		select {
+		case <-sigs:
+		case <-ctx.Done():
+			// Timed out or done: signals act as usual again.
+			signal.Stop(sigs)
+			return
+		}
+		cancel()
+		select {
+		case <-sigs:
+		case <-time.After(interruptGrace):
+		}
+		os.Exit(130)
+	}()
+	return ctx, func() {
+		cancelTimeout()
+		cancel()
+	}
+}
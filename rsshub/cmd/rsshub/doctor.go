@@ -0,0 +1,224 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+
+	"rsshub/client"
+	"rsshub/internal/aggregator"
+	"rsshub/internal/config"
+	"rsshub/internal/db"
+	"rsshub/internal/models"
+	"rsshub/internal/rss"
+)
+
+// doctor collects check results and prints each with a suggested fix.
+type doctor struct {
+	failed bool
+}
+
+func (d *doctor) ok(format string, args ...any) {
+	fmt.Printf("[ok]   "+format+"\n", args...)
+}
+
+func (d *doctor) warn(fix, format string, args ...any) {
+	fmt.Printf("[warn] "+format+"\n", args...)
+	fmt.Printf("       fix: %s\n", fix)
+}
+
+func (d *doctor) fail(fix, format string, args ...any) {
+	d.failed = true
+	fmt.Printf("[FAIL] "+format+"\n", args...)
+	fmt.Printf("       fix: %s\n", fix)
+}
+
+// handleDoctor checks the configuration, database, control socket and a
+// sample feed, printing what is wrong and how to fix it. It changes
+// nothing and exits non-zero if any check fails.
+func handleDoctor(cfg *config.Config, cfgErr error) {
+	fs := flag.NewFlagSet("doctor", flag.ExitOnError)
+	feedName := fs.String("feed", "", "feed to probe (default: most recently fetched)")
+	fs.Parse(os.Args[2:])
+
+	d := &doctor{}
+	d.checkConfig(cfg, cfgErr)
+	if cfg.Server != "" {
+		d.checkServer(cfg)
+	} else {
+		d.checkSocket()
+		if conn := d.checkDatabase(cfg); conn != nil {
+			d.probeFeed(cfg, conn, *feedName)
+			conn.Close()
+		}
+	}
+
+	if d.failed {
+		os.Exit(1)
+	}
+	fmt.Println("\nNo problems found")
+}
+
+// checkConfig reports the errors LoadConfig found, one per setting.
+func (d *doctor) checkConfig(cfg *config.Config, cfgErr error) {
+	if cfg.Profile != "" {
+		d.ok("using profile %s from %s", cfg.Profile, config.ProfilesPath())
+	}
+	if cfgErr == nil {
+		d.ok("configuration is valid (interval %s, %d workers, %s scheduling)", cfg.Interval, cfg.Workers, cfg.Scheduling)
+	} else {
+		for _, line := range strings.Split(cfgErr.Error(), "\n") {
+			d.fail("correct this variable; other commands refuse to start until it is fixed", "%s", line)
+		}
+	}
+	if cfg.HTTPAddr != "" && cfg.APIToken == "" && !loopbackAddr(cfg.HTTPAddr) {
+		d.warn("set CLI_APP_API_TOKEN, or bind CLI_APP_HTTP_ADDR to 127.0.0.1",
+			"HTTP API on %s will accept unauthenticated requests from other hosts", cfg.HTTPAddr)
+	}
+}
+
+// checkServer verifies a remote server is reachable and accepts the token.
+func (d *doctor) checkServer(cfg *config.Config) {
+	ctx, cancel := context.WithTimeout(cmdCtx, apiTimeout)
+	defer cancel()
+	_, err := newAPIClient(cfg, cfg.Server).ListFeeds(ctx, client.FeedOptions{ListOptions: client.ListOptions{Limit: 1}})
+	var apiErr *client.APIError
+	switch {
+	case err == nil:
+		d.ok("server %s is reachable and accepts the token", cfg.Server)
+	case errors.As(err, &apiErr) && apiErr.StatusCode == 401:
+		d.fail("pass the server's CLI_APP_API_TOKEN with --token", "server %s rejected the API token", cfg.Server)
+	default:
+		d.fail("check the --server URL and that the daemon runs with CLI_APP_HTTP_ADDR set",
+			"server %s is not reachable: %v", cfg.Server, err)
+	}
+}
+
+// checkSocket looks at the control socket the CLI uses to reach the daemon.
+func (d *doctor) checkSocket() {
+	info, err := os.Lstat(sockPath)
+	if errors.Is(err, os.ErrNotExist) {
+		d.ok("no daemon running (%s absent); start one with rsshub fetch", sockPath)
+		return
+	}
+	if err != nil {
+		d.fail("check permissions on "+sockPath, "cannot inspect %s: %v", sockPath, err)
+		return
+	}
+	if info.Mode()&os.ModeSocket == 0 {
+		d.fail("remove "+sockPath+" so rsshub fetch can create its socket", "%s exists but is not a socket", sockPath)
+		return
+	}
+	if st, ok := info.Sys().(*syscall.Stat_t); ok && int(st.Uid) != os.Getuid() {
+		d.warn("run rsshub as the user that started the daemon", "%s is owned by uid %d, not the current uid %d", sockPath, st.Uid, os.Getuid())
+	}
+	conn, err := net.DialTimeout("unix", sockPath, time.Second)
+	if err != nil {
+		pid, alive := aggregator.ReadPID(sockPath)
+		switch {
+		case errors.Is(err, syscall.EACCES):
+			d.fail("run rsshub as the user that started the daemon", "no permission to connect to %s", sockPath)
+		case alive:
+			d.warn(fmt.Sprintf("check whether process %d is a hung daemon", pid),
+				"%s exists and process %d from its PID file is alive, but nothing answers", sockPath, pid)
+		default:
+			d.ok("no daemon running; rsshub fetch will reclaim the stale socket %s", sockPath)
+		}
+		return
+	}
+	conn.Close()
+	if _, err := controlRequest("version"); err != nil {
+		d.fail("stop the program listening on "+sockPath+" or set CLI_APP_SOCKET_PATH",
+			"%s does not answer like an rsshub daemon: %v", sockPath, err)
+		return
+	}
+	d.ok("daemon is reachable on %s", sockPath)
+}
+
+// checkDatabase connects and verifies the schema. It returns the open
+// connection for further checks, or nil if the database is unusable.
+func (d *doctor) checkDatabase(cfg *config.Config) *sql.DB {
+	conn, err := sql.Open("postgres", cfg.DSN())
+	if err == nil {
+		ctx, cancel := context.WithTimeout(cmdCtx, 5*time.Second)
+		err = conn.PingContext(ctx)
+		cancel()
+	}
+	if err != nil {
+		d.fail("check POSTGRES_HOST, POSTGRES_PORT, POSTGRES_USER, POSTGRES_PASSWORD and POSTGRES_DBNAME, and that Postgres is running",
+			"cannot connect to postgres at %s:%s/%s: %v", cfg.PGHost, cfg.PGPort, cfg.PGDBName, err)
+		if conn != nil {
+			conn.Close()
+		}
+		return nil
+	}
+	d.ok("connected to postgres at %s:%s/%s", cfg.PGHost, cfg.PGPort, cfg.PGDBName)
+
+	issues, err := db.CheckSchema(cmdCtx, conn)
+	if err != nil {
+		d.fail("check that the database user can read information_schema", "cannot inspect schema: %v", err)
+		conn.Close()
+		return nil
+	}
+	if len(issues) == 0 {
+		d.ok("database schema is up to date")
+		return conn
+	}
+	for _, issue := range issues {
+		d.fail(fmt.Sprintf("run any rsshub command against this database (e.g. rsshub list) to upgrade it, or apply migrations/%s.up.sql", issue.Migration),
+			"database schema is missing %s", issue.Object)
+	}
+	conn.Close()
+	return nil
+}
+
+// probeFeed fetches one feed with the configured limits, without storing
+// anything.
+func (d *doctor) probeFeed(cfg *config.Config, conn *sql.DB, name string) {
+	store := (&db.DB{DB: conn}).WithContext(cmdCtx)
+	var feed models.Feed
+	if name != "" {
+		var err error
+		feed, err = store.GetFeedByName(name)
+		if err != nil {
+			d.fail("check the name with rsshub list", "cannot load feed %q: %v", name, err)
+			return
+		}
+	} else {
+		feeds, err := store.ListFeeds(models.FeedFilter{}, 1)
+		if err != nil {
+			d.fail("check that the database user can read the feeds table", "cannot list feeds: %v", err)
+			return
+		}
+		if len(feeds) == 0 {
+			d.ok("no feeds to probe; add one with rsshub add")
+			return
+		}
+		feed = feeds[0]
+	}
+
+	start := time.Now()
+	parsed, err := rss.NewFetcher(cfg).FetchAndParse(cmdCtx, feed.URL)
+	elapsed := time.Since(start).Round(time.Millisecond)
+	var netErr net.Error
+	switch {
+	case err == nil:
+		d.ok("fetched feed %q in %s (%d items)", feed.Name, elapsed, len(parsed.Channel.Item))
+	case errors.Is(err, rss.ErrBodyTooLarge):
+		d.fail(fmt.Sprintf("raise CLI_APP_MAX_BODY_SIZE (now %d bytes)", cfg.MaxBodySize),
+			"feed %q is larger than the maximum body size", feed.Name)
+	case errors.As(err, &netErr) && netErr.Timeout():
+		d.fail("raise CLI_APP_FETCH_TIMEOUT (now "+cfg.FetchTimeout.String()+") or check outbound network access",
+			"fetching feed %q timed out after %s", feed.Name, elapsed)
+	default:
+		d.fail("check the URL with curl -sI "+feed.URL+", or replace the feed",
+			"fetching feed %q failed: %v", feed.Name, err)
+	}
+}
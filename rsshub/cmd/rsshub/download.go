@@ -0,0 +1,148 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"rsshub/internal/config"
+	"rsshub/internal/db"
+	"rsshub/internal/download"
+	"rsshub/internal/models"
+	"rsshub/internal/rss"
+)
+
+// downloadHeaderTimeout bounds waiting for a server to start sending an
+// enclosure; the transfer itself may take as long as it needs.
+const downloadHeaderTimeout = 30 * time.Second
+
+// handleDownload saves the enclosures of a feed's newest articles, skipping
+// those the download history says were already fetched. Interrupted
+// downloads resume on the next run. Enclosures are fetched under the same
+// URL policy as feeds.
+func handleDownload(cfg *config.Config, database *db.DB) {
+	fs := flag.NewFlagSet("download", flag.ExitOnError)
+	feedName := fs.String("feed-name", "", "Name of the feed")
+	dest := fs.String("dest", "", "Directory to save episodes into")
+	latest := fs.Int("latest", 5, "Number of newest episodes to consider")
+	tmplText := fs.String("template", download.DefaultTemplate,
+		"Filename template; fields are .Feed .Title .Date .Published .Season .Episode .ID .Ext, and / makes directories")
+	force := fs.Bool("force", false, "Download again even if the history says it was done")
+	fs.Parse(os.Args[2:])
+
+	if *feedName == "" || *dest == "" {
+		fmt.Println("Missing required flags: --feed-name and --dest")
+		os.Exit(1)
+	}
+	if *latest < 1 {
+		fmt.Println("--latest must be at least 1")
+		os.Exit(1)
+	}
+	tmpl, err := download.ParseTemplate(*tmplText)
+	if err != nil {
+		fmt.Printf("Invalid --template: %v\n", err)
+		os.Exit(1)
+	}
+
+	feed, err := database.GetFeedByName(*feedName)
+	if err != nil {
+		fmt.Printf("Error getting feed: %v\n", err)
+		os.Exit(1)
+	}
+	articles, _, err := database.QueryArticles(db.ArticleQuery{FeedID: feed.ID, HasEnclosure: true, ShowMuted: true, Limit: *latest})
+	if err != nil {
+		fmt.Printf("Error getting articles: %v\n", err)
+		os.Exit(1)
+	}
+	done, err := database.DownloadedURLs(feed.ID)
+	if err != nil {
+		fmt.Printf("Error reading download history: %v\n", err)
+		os.Exit(1)
+	}
+	if len(articles) == 0 {
+		fmt.Printf("Feed %s has no articles with enclosures\n", feed.Name)
+		return
+	}
+
+	ctx := cmdCtx
+	fetcher := rss.NewFetcher(cfg)
+	transport := rss.NewTransport(cfg)
+	transport.ResponseHeaderTimeout = downloadHeaderTimeout
+	client := &http.Client{Transport: transport, CheckRedirect: fetcher.Client.CheckRedirect}
+
+	var fetched, skipped, failed int
+	var total int64
+	for _, a := range articles {
+		if done[a.EnclosureURL] && !*force {
+			skipped++
+			continue
+		}
+		if err := fetcher.CheckURL(a.EnclosureURL); err != nil {
+			fmt.Printf("Not downloading %q: %v\n", a.Title, err)
+			failed++
+			continue
+		}
+		ep := download.NewEpisode(feed.Name, a.Title, a.PublishedAt, a.Season, a.Episode, a.ID.String(), a.EnclosureURL, a.EnclosureType)
+		name, err := download.FileName(tmpl, ep)
+		if err != nil {
+			fmt.Printf("Error naming %q: %v\n", a.Title, err)
+			failed++
+			continue
+		}
+		path := filepath.Join(*dest, name)
+		fmt.Printf("Downloading %s\n   -> %s\n", a.Title, path)
+		n, err := download.Fetch(ctx, client, a.EnclosureURL, path)
+		if err != nil {
+			if ctx.Err() != nil {
+				fmt.Println("Interrupted; run download again to resume")
+				os.Exit(1)
+			}
+			fmt.Printf("   failed: %v\n", err)
+			failed++
+			continue
+		}
+		err = database.RecordDownload(models.Download{
+			FeedID:    feed.ID,
+			ArticleID: a.ID,
+			URL:       a.EnclosureURL,
+			Path:      path,
+			Bytes:     n,
+		})
+		if err != nil {
+			fmt.Printf("Error recording download: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("   %s\n", byteSize(n))
+		fetched++
+		total += n
+	}
+
+	fmt.Printf("\nDownloaded %d episodes (%s)", fetched, byteSize(total))
+	if skipped > 0 {
+		fmt.Printf(", %d already downloaded", skipped)
+	}
+	if failed > 0 {
+		fmt.Printf(", %d failed", failed)
+	}
+	fmt.Println()
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// byteSize formats a byte count for people, e.g. "48.2 MB".
+func byteSize(n int64) string {
+	const unit = 1000
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "kMGTPE"[exp])
+}
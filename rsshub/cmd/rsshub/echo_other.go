@@ -0,0 +1,11 @@
+//go:build !linux
+
+package main
+
+import "os"
+
+// disableEcho reports false: echo is only turned off through the Linux
+// ioctls, so elsewhere a typed secret shows; piping it in avoids that.
+func disableEcho(f *os.File) (func(), bool) {
+	return nil, false
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"rsshub/internal/config"
+	"rsshub/internal/db"
+	"rsshub/internal/models"
+	"rsshub/internal/output"
+)
+
+// healthRow is a feed's recent fetches, plus its silence if it has gone
+// quiet.
+type healthRow struct {
+	models.FeedHealth
+	Quiet *models.QuietFeed
+}
+
+// handleHealth reports how each feed's fetches have gone recently, from the
+// fetch log the daemon keeps, feeds that keep failing first. Feeds that
+// still fetch fine but have stopped posting are flagged as quiet.
+func handleHealth(cfg *config.Config, database *db.DB) {
+	fs := flag.NewFlagSet("health", flag.ExitOnError)
+	window := fs.Duration("window", 24*time.Hour, "How far back to summarise fetches")
+	failing := fs.Bool("failing", false, "Only show feeds whose last fetch failed")
+	gaps := fs.Int("quiet-gaps", cfg.QuietAfterGaps, "Call a feed quiet after this many times its usual gap between posts (0 to skip)")
+	formatFlag := fs.String("format", output.Table, `Output format: table, json, or a template such as '{{.FeedName}}\t{{.Failing}}'`)
+	fs.Parse(os.Args[2:])
+
+	if *window <= 0 {
+		fmt.Println("--window must be positive")
+		os.Exit(1)
+	}
+	format, err := output.Parse(*formatFlag)
+	if err != nil {
+		fmt.Printf("Invalid --format: %v\n", err)
+		os.Exit(1)
+	}
+	health, err := database.GetFeedHealth(*window)
+	if err != nil {
+		fmt.Printf("Error getting feed health: %v\n", err)
+		os.Exit(1)
+	}
+	quiet := map[string]*models.QuietFeed{}
+	if *gaps > 0 {
+		feeds, err := database.QuietFeeds(*gaps, cfg.QuietMinimum)
+		if err != nil {
+			fmt.Printf("Error finding quiet feeds: %v\n", err)
+			os.Exit(1)
+		}
+		for i := range feeds {
+			quiet[feeds[i].FeedName] = &feeds[i]
+		}
+	}
+
+	var rows []healthRow
+	for _, h := range health {
+		if *failing && h.Failing == 0 {
+			continue
+		}
+		rows = append(rows, healthRow{FeedHealth: h, Quiet: quiet[h.FeedName]})
+	}
+	if format.Name == output.Table {
+		fmt.Printf("# Fetch health (last %s)\n", *window)
+	}
+	err = output.Write(os.Stdout, format, rows, healthColumns, healthJSON)
+	if err != nil {
+		fmt.Printf("Error writing feed health: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+var healthColumns = []output.Column[healthRow]{
+	{Header: "Feed", Value: func(h healthRow) string { return h.FeedName }, Style: style[healthRow](output.Name)},
+	{Header: "Fetches", Value: func(h healthRow) string { return strconv.FormatInt(h.Fetches, 10) }, Right: true},
+	{Header: "Failed", Value: func(h healthRow) string { return strconv.FormatInt(h.Failures, 10) }, Right: true},
+	{Header: "Avg", Value: func(h healthRow) string { return h.AvgDuration.Round(time.Millisecond).String() }, Right: true},
+	{Header: "Bytes", Value: func(h healthRow) string { return byteSize(h.Bytes) }, Right: true},
+	{Header: "Decoded", Value: func(h healthRow) string { return byteSize(h.DecodedBytes) }, Right: true},
+	{Header: "New", Value: func(h healthRow) string { return strconv.FormatInt(h.NewItems, 10) }, Right: true},
+	{Header: "Last fetch", Value: func(h healthRow) string { return output.Date(h.LastFetch.FetchedAt) }, Style: style[healthRow](output.When)},
+	{Header: "HTTP", Value: func(h healthRow) string { return optionalNumber(int64(h.LastFetch.Status)) }, Right: true},
+	{Header: "Note", Value: healthNote, Flexible: true, Style: healthStyle},
+}
+
+// healthNote says what is wrong with a feed, if anything.
+func healthNote(h healthRow) string {
+	switch {
+	case !h.DisabledAt.IsZero():
+		return fmt.Sprintf("disabled %s after %d failures in a row: %s", output.Date(h.DisabledAt), h.Failing, h.LastFetch.Error)
+	case h.Failing > 0:
+		return fmt.Sprintf("failing %d in a row: %s", h.Failing, h.LastFetch.Error)
+	case h.Quiet != nil:
+		return fmt.Sprintf("quiet: nothing published for %s, usually every %s",
+			time.Since(h.Quiet.LastPublishedAt).Round(time.Hour), h.Quiet.UsualGap)
+	}
+	return ""
+}
+
+// healthStyle colours a feed's note by how bad things are.
+func healthStyle(h healthRow) output.Style {
+	switch {
+	case !h.DisabledAt.IsZero():
+		return output.Bad
+	case h.Failing > 0, h.Quiet != nil:
+		return output.Warn
+	}
+	return output.Plain
+}
+
+// healthJSON is one feed as health --format json prints it.
+func healthJSON(h healthRow) any {
+	type quiet struct {
+		LastPublishedAt time.Time `json:"last_published_at"`
+		UsualGapSeconds int64     `json:"usual_gap_seconds"`
+	}
+	out := struct {
+		Feed          string     `json:"feed"`
+		Fetches       int64      `json:"fetches"`
+		Failures      int64      `json:"failures"`
+		Failing       int64      `json:"failing"`
+		DisabledAt    *time.Time `json:"disabled_at,omitempty"`
+		AvgDurationMS int64      `json:"avg_duration_ms"`
+		Bytes         int64      `json:"bytes"`
+		DecodedBytes  int64      `json:"decoded_bytes"`
+		NewItems      int64      `json:"new_items"`
+		LastFetchedAt *time.Time `json:"last_fetched_at,omitempty"`
+		LastStatus    int        `json:"last_status,omitempty"`
+		LastError     string     `json:"last_error,omitempty"`
+		Quiet         *quiet     `json:"quiet,omitempty"`
+	}{
+		Feed:          h.FeedName,
+		Fetches:       h.Fetches,
+		Failures:      h.Failures,
+		Failing:       h.Failing,
+		AvgDurationMS: h.AvgDuration.Milliseconds(),
+		Bytes:         h.Bytes,
+		DecodedBytes:  h.DecodedBytes,
+		NewItems:      h.NewItems,
+		LastStatus:    h.LastFetch.Status,
+		LastError:     h.LastFetch.Error,
+	}
+	if !h.DisabledAt.IsZero() {
+		out.DisabledAt = &h.DisabledAt
+	}
+	if !h.LastFetch.FetchedAt.IsZero() {
+		out.LastFetchedAt = &h.LastFetch.FetchedAt
+	}
+	if h.Quiet != nil {
+		out.Quiet = &quiet{LastPublishedAt: h.Quiet.LastPublishedAt, UsualGapSeconds: int64(h.Quiet.UsualGap / time.Second)}
+	}
+	return out
+}
+
+// lastFetch describes a feed's most recent fetch by when it ended and how.
+func lastFetch(e models.FetchLogEntry) string {
+	if e.FetchedAt.IsZero() {
+		return "never"
+	}
+	outcome := "error"
+	if e.Status != 0 {
+		outcome = fmt.Sprintf("HTTP %d", e.Status)
+	}
+	if e.Error == "" {
+		outcome = output.Good.Paint(outcome + " ok")
+	} else {
+		outcome = output.Bad.Paint(outcome)
+	}
+	return fmt.Sprintf("%s (%s, %s)", output.When.Paint(output.Date(e.FetchedAt)), outcome, e.Duration.Round(time.Millisecond))
+}
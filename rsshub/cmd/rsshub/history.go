@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"rsshub/internal/models"
+	"rsshub/internal/output"
+)
+
+// maxDiffCells bounds the word diff table; longer texts are shown whole.
+const maxDiffCells = 4_000_000
+
+// handleHistory shows how an article changed upstream, one word diff per
+// revision from the first version seen to the current one.
+func handleHistory(database store) {
+	if len(os.Args) < 3 {
+		fmt.Println("Usage: rsshub history <article-id>")
+		os.Exit(1)
+	}
+	id := articleArg(database, os.Args[2])
+
+	article, err := database.GetArticle(id)
+	if err != nil {
+		fmt.Printf("Error loading article: %v\n", err)
+		os.Exit(1)
+	}
+	revisions, err := database.GetArticleRevisions(id)
+	if err != nil {
+		fmt.Printf("Error loading revisions: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("%s\n%s\n\n", article.Title, article.Link)
+	if len(revisions) == 0 {
+		fmt.Println("No revisions: the article has not changed since it was first fetched")
+		return
+	}
+
+	// Each revision is replaced by the next, the last by the article itself.
+	versions := append(revisions, models.ArticleRevision{
+		Title:       article.Title,
+		Description: article.Description,
+		ValidFrom:   article.UpdatedAt,
+	})
+	fmt.Printf("First seen %s\n", output.Date(versions[0].ValidFrom))
+	for i := 1; i < len(versions); i++ {
+		prev, cur := versions[i-1], versions[i]
+		fmt.Printf("\n--- revision %d, changed %s\n", i, output.Date(prev.ReplacedAt))
+		if prev.Title != cur.Title {
+			fmt.Printf("title: %s\n", diffWords(prev.Title, cur.Title))
+		}
+		if prev.Description != cur.Description {
+			fmt.Printf("description: %s\n", diffWords(prev.Description, cur.Description))
+		}
+	}
+}
+
+// diffWords renders a word-level diff in git's --word-diff=plain style:
+// [-removed-] and {+added+}.
+func diffWords(before, after string) string {
+	a, b := strings.Fields(before), strings.Fields(after)
+	if len(a)*len(b) > maxDiffCells {
+		return "[-" + before + "-] {+" + after + "+}"
+	}
+
+	// lcs[i][j] is the longest common subsequence of a[i:] and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var out []string
+	var removed, added []string
+	flush := func() {
+		if len(removed) > 0 {
+			out = append(out, "[-"+strings.Join(removed, " ")+"-]")
+			removed = nil
+		}
+		if len(added) > 0 {
+			out = append(out, "{+"+strings.Join(added, " ")+"+}")
+			added = nil
+		}
+	}
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			flush()
+			out = append(out, a[i])
+			i++
+			j++
+		case j < len(b) && (i == len(a) || lcs[i][j+1] >= lcs[i+1][j]):
+			added = append(added, b[j])
+			j++
+		default:
+			removed = append(removed, a[i])
+			i++
+		}
+	}
+	flush()
+	return strings.Join(out, " ")
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"rsshub/internal/db"
+	"rsshub/internal/importer"
+	"rsshub/internal/models"
+	"rsshub/internal/rss"
+)
+
+// handleImport subscribes to another reader's feeds and brings over its
+// starred and read articles, so migrating keeps reading state that an
+// OPML export would lose. Running it again only adds what is missing.
+func handleImport(database *db.DB) {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	from := fs.String("from", "", "Reader to import from: "+strings.Join(importer.Sources, ", "))
+	base := fs.String("url", "", "Base URL of the reader")
+	key := fs.String("api-key", "", "API key (miniflux), API password (freshrss) or password (ttrss)")
+	user := fs.String("user", "", "User name (freshrss and ttrss)")
+	limit := fs.Int("limit", 5000, "Most starred and most read articles to import, each")
+	fs.Parse(os.Args[2:])
+
+	if *from == "" || *base == "" || *key == "" {
+		fmt.Println("Missing required flags: --from, --url and --api-key")
+		os.Exit(1)
+	}
+	if *limit < 0 {
+		fmt.Println("--limit must not be negative")
+		os.Exit(1)
+	}
+	source, err := importer.New(*from, *base, *user, *key)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	ctx := cmdCtx
+
+	feeds, err := source.Feeds(ctx)
+	if err != nil {
+		fmt.Printf("Error reading subscriptions: %v\n", err)
+		os.Exit(1)
+	}
+	actor := db.Actor{Name: currentUser(), Source: db.SourceCLI}
+	feedIDs := make(map[string]uuid.UUID, len(feeds))
+	var added, existing, skipped, categorized int
+	for _, f := range feeds {
+		if f.Category != "" {
+			categorized++
+		}
+		feed := models.Feed{Name: db.FeedName(f.Title, f.URL), URL: f.URL}
+		err := database.As(actor).AddFeedNumbered(&feed)
+		if err != nil && ctx.Err() != nil {
+			importInterrupted()
+		}
+		name := feed.Name
+		var dup *db.DuplicateURLError
+		switch {
+		case errors.As(err, &dup) && dup.Deleted:
+			fmt.Printf("  skipped %s: subscribed as %s, which is deleted (restore it and import again)\n", f.URL, dup.Name)
+			skipped++
+			continue
+		case errors.As(err, &dup):
+			name = dup.Name
+			existing++
+		case err != nil:
+			fmt.Printf("  skipped %s: %v\n", f.URL, err)
+			skipped++
+			continue
+		default:
+			fmt.Printf("  added %s (%s)\n", name, f.URL)
+			added++
+		}
+		feed, err = database.GetFeedByName(name)
+		if err != nil {
+			fmt.Printf("  skipped %s: %v\n", f.URL, err)
+			skipped++
+			continue
+		}
+		feedIDs[f.URL] = feed.ID
+	}
+	fmt.Printf("Feeds: %d added, %d already subscribed, %d skipped\n", added, existing, skipped)
+	if categorized > 0 {
+		fmt.Printf("Note: rsshub has no categories; %d feeds were filed in one and are imported without it\n", categorized)
+	}
+
+	entries, err := importEntries(ctx, source, *limit)
+	if err != nil {
+		fmt.Printf("Error reading articles: %v\n", err)
+		os.Exit(1)
+	}
+	var stored, marked, orphaned, failed int
+	for _, e := range entries {
+		feedID, ok := feedIDs[e.FeedURL]
+		if !ok || e.Link == "" {
+			orphaned++
+			continue
+		}
+		published, source := e.Published, models.DateSourcePublished
+		if published.IsZero() || published.Unix() == 0 {
+			published, source = time.Now(), models.DateSourceFirstSeen
+		}
+		article := models.Article{
+			Title:       e.Title,
+			Link:        e.Link,
+			PublishedAt: published,
+			Description: e.Content,
+
+			PublishedAtSource: source,
+			FeedID:            feedID,
+			Author:            e.Author,
+			WordCount:         rss.WordCount(e.Content),
+			ContentHash:       rss.ContentHash(e.Title, e.Content),
+		}
+		isNew, err := database.ImportArticle(&article, e.Read, e.Starred)
+		if err != nil && ctx.Err() != nil {
+			importInterrupted()
+		}
+		if err != nil {
+			fmt.Printf("  failed %s: %v\n", e.Link, err)
+			failed++
+			continue
+		}
+		if isNew {
+			stored++
+		} else {
+			marked++
+		}
+	}
+	fmt.Printf("Articles: %d imported, %d already stored (marks updated), %d without a subscribed feed, %d failed\n",
+		stored, marked, orphaned, failed)
+	database.Audit(actor, "import", *from, "", fmt.Sprintf("%d feeds added, %d articles imported", added, stored))
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// importInterrupted ends an import cut short by Ctrl-C or --timeout. What was
+// imported stays; importing again skips it.
+func importInterrupted() {
+	fmt.Println("Interrupted; run import again to finish")
+	os.Exit(1)
+}
+
+// importEntries gathers the starred and the read entries, merging the two
+// marks for articles that are in both lists.
+func importEntries(ctx context.Context, source importer.Source, limit int) ([]importer.Entry, error) {
+	starred, err := source.Starred(ctx, limit)
+	if err != nil {
+		return nil, err
+	}
+	read, err := source.Read(ctx, limit)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]int, len(starred)+len(read))
+	var out []importer.Entry
+	for _, e := range append(starred, read...) {
+		key := e.FeedURL + "\x00" + e.Link
+		if i, ok := seen[key]; ok {
+			out[i].Read = out[i].Read || e.Read
+			out[i].Starred = out[i].Starred || e.Starred
+			continue
+		}
+		seen[key] = len(out)
+		out = append(out, e)
+	}
+	return out, nil
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"rsshub/internal/config"
+	"rsshub/internal/rss"
+)
+
+// handleLint fetches a feed with the daemon's fetch settings and reports
+// what is wrong with it and which items rsshub would drop. It exits with
+// status 1 if the feed has errors.
+func handleLint(cfg *config.Config) {
+	fs := flag.NewFlagSet("lint", flag.ExitOnError)
+	feedURL := fs.String("url", "", "URL of the feed to check")
+	fs.Parse(os.Args[2:])
+
+	if *feedURL == "" {
+		fmt.Println("Missing required flag: --url")
+		os.Exit(1)
+	}
+
+	body, contentType, err := rss.NewFetcher(cfg).Fetch(cmdCtx, *feedURL)
+	if err != nil {
+		fmt.Printf("Error fetching %s: %v\n", *feedURL, err)
+		os.Exit(1)
+	}
+	report := rss.Lint(body, contentType, cfg.MaxItems)
+
+	errs := 0
+	for _, f := range report.Findings {
+		where := "feed"
+		if f.Item > 0 {
+			where = fmt.Sprintf("item %d", f.Item)
+			if f.Title != "" {
+				where += fmt.Sprintf(" %q", truncate(f.Title, 40))
+			}
+		}
+		fmt.Printf("%-8s %s: %s\n", f.Severity, where, f.Message)
+		if f.Severity == rss.SeverityError {
+			errs++
+		}
+	}
+	if len(report.Findings) > 0 {
+		fmt.Println()
+	}
+	fmt.Printf("%d items, %d would be stored, %d findings (%d errors)\n",
+		report.Items, report.Stored, len(report.Findings), errs)
+	if errs > 0 {
+		os.Exit(1)
+	}
+}
+
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
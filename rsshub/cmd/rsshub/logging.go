@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"rsshub/internal/config"
+	"rsshub/internal/logfile"
+)
+
+// startLogFile sends everything the daemon prints to the configured log
+// file as well as, unless disabled, the original stdout. Output is
+// redirected at the os.Stdout level so existing fmt.Printf logging needs no
+// changes. The returned function flushes and closes the file.
+func startLogFile(cfg *config.Config) func() {
+	if cfg.LogFile == "" {
+		return func() {}
+	}
+	w, err := logfile.Open(cfg.LogFile, cfg.LogMaxSize, cfg.LogMaxAge, cfg.LogKeep)
+	if err != nil {
+		fmt.Printf("Error opening log file: %v\n", err)
+		os.Exit(1)
+	}
+	r, pw, err := os.Pipe()
+	if err != nil {
+		fmt.Printf("Error redirecting output: %v\n", err)
+		os.Exit(1)
+	}
+	stdout := os.Stdout
+	var out io.Writer = w
+	if cfg.LogStdout {
+		out = teeWriter{stdout, w}
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		drain(r, out)
+	}()
+	os.Stdout = pw
+	return func() {
+		os.Stdout = stdout
+		pw.Close()
+		<-done
+		if err := w.Close(); err != nil {
+			fmt.Printf("Error closing log file: %v\n", err)
+		}
+	}
+}
+
+// drain copies r to out until r is closed. Unlike io.Copy it carries on
+// past write errors, reporting them on stderr: if it stopped, the pipe
+// would fill and every later log line would block the daemon.
+func drain(r io.Reader, out io.Writer) {
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			if _, werr := out.Write(buf[:n]); werr != nil {
+				fmt.Fprintf(os.Stderr, "Error writing log file: %v\n", werr)
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// teeWriter writes to stdout and the log file. Unlike io.MultiWriter it
+// keeps writing the file when stdout goes away, e.g. a closed terminal.
+type teeWriter struct {
+	stdout io.Writer
+	file   io.Writer
+}
+
+func (t teeWriter) Write(p []byte) (int, error) {
+	t.stdout.Write(p)
+	return t.file.Write(p)
+}
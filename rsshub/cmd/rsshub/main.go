@@ -1,7 +1,10 @@
 package main
 
 import (
+	"bufio"
 	"context"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	_ "github.com/lib/pq"
@@ -9,15 +12,33 @@ import (
 	"os"
 	"os/signal"
 	"rsshub/internal/aggregator"
+	"rsshub/internal/api"
 	"rsshub/internal/config"
 	"rsshub/internal/db"
+	"rsshub/internal/errreport"
+	"rsshub/internal/geo"
+	"rsshub/internal/logging"
 	"rsshub/internal/models"
+	"rsshub/internal/output"
+	"rsshub/internal/rss"
+	"rsshub/internal/version"
+	"strconv"
+	"strings"
 	"syscall"
+	"time"
 )
 
-const sockPath = "/tmp/rsshub.sock"
+// sockPath is the daemon's control socket, from CLI_APP_SOCKET_PATH or
+// --socket, or else the per-database default.
+var sockPath string
 
 func main() {
+	flags := parseGlobalFlags()
+	flags.applyProfile()
+	cfg, cfgErr := config.LoadConfig()
+	flags.apply(cfg)
+	sockPath = cfg.SocketPath
+
 	if len(os.Args) < 2 {
 		printHelp()
 		return
@@ -25,7 +46,117 @@ func main() {
 
 	command := os.Args[1]
 
-	cfg := config.LoadConfig()
+	// The daemon shuts down on signals itself, taking as long as its grace.
+	if command != "fetch" {
+		var stop func()
+		cmdCtx, stop = commandContext(flags.commandTimeout(command))
+		defer stop()
+	}
+
+	// doctor reports configuration errors itself alongside its other checks.
+	if cfgErr != nil && command != "doctor" && command != "version" && command != "--help" {
+		fmt.Printf("Invalid configuration:\n%v\n", cfgErr)
+		os.Exit(1)
+	}
+
+	switch command {
+	case "add", "list", "delete", "restore", "enable", "merge", "quirks", "articles", "archived", "history", "star", "unstar", "save", "export-bookmarks", "export-notes", "build-site", "audit", "random", "resurface", "authors", "note", "notes", "open", "mark-read":
+		st, closeStore := openStore(cfg)
+		defer closeStore()
+		switch command {
+		case "add":
+			handleAdd(st)
+		case "list":
+			handleList(st)
+		case "delete":
+			handleDelete(cfg, st)
+		case "restore":
+			handleRestore(st)
+		case "enable":
+			handleEnable(st)
+		case "merge":
+			handleMerge(st)
+		case "quirks":
+			handleQuirks(st)
+		case "articles":
+			handleArticles(st)
+		case "history":
+			handleHistory(st)
+		case "archived":
+			handleArchived(st)
+		case "open":
+			handleOpen(st)
+		case "mark-read":
+			handleMarkRead(st)
+		case "star":
+			handleStar(st, true)
+		case "unstar":
+			handleStar(st, false)
+		case "save":
+			handleSave(cfg, st)
+		case "export-notes":
+			handleExportNotes(st)
+		case "build-site":
+			handleBuildSite(st)
+		case "export-bookmarks":
+			handleExportBookmarks(st)
+		case "audit":
+			handleAudit(st)
+		case "random":
+			handleRandom(st)
+		case "resurface":
+			handleResurface(st)
+		case "authors":
+			handleAuthors(st)
+		case "note":
+			handleNote(st)
+		case "notes":
+			handleNotes(st)
+		}
+		return
+	case "set-interval":
+		handleSetInterval(cfg)
+		return
+	case "set-workers":
+		handleSetWorkers(cfg)
+		return
+	case "pause":
+		handlePause(cfg)
+		return
+	case "resume":
+		handleResume(cfg)
+		return
+	case "doctor":
+		handleDoctor(cfg, cfgErr)
+		return
+	case "lint":
+		handleLint(cfg)
+		return
+	case "preview":
+		handlePreview(cfg)
+		return
+	case "version":
+		handleVersion(cfg)
+		return
+	case "status":
+		sendControl(cfg, "status")
+		return
+	case "top":
+		handleTop(cfg)
+		return
+	case "ticks":
+		handleTicks(cfg)
+		return
+	case "fetch", "stats", "today", "health", "watch", "user", "download", "import", "mute", "unmute", "reingest", "budget", "reparse", "secrets":
+		if command == "secrets" && len(os.Args) > 2 && os.Args[2] == "new-key" {
+			handleNewSecretsKey()
+			return
+		}
+		if cfg.Server != "" {
+			fmt.Printf("Error: %s needs direct database access and is not available with --server\n", command)
+			os.Exit(1)
+		}
+	}
 
 	database, err := db.NewDB(cfg)
 	if err != nil {
@@ -33,22 +164,37 @@ func main() {
 		os.Exit(1)
 	}
 	defer database.Close()
+	database = database.WithContext(cmdCtx)
 
 	switch command {
 	case "fetch":
 		handleFetch(cfg, database)
-	case "add":
-		handleAdd(database)
-	case "list":
-		handleList(database)
-	case "delete":
-		handleDelete(database)
-	case "articles":
-		handleArticles(database)
-	case "set-interval":
-		handleSetInterval()
-	case "set-workers":
-		handleSetWorkers()
+	case "stats":
+		handleStats(cfg, database)
+	case "today":
+		handleToday(database)
+	case "health":
+		handleHealth(cfg, database)
+	case "watch":
+		handleWatch(cfg)
+	case "user":
+		handleUser(database)
+	case "download":
+		handleDownload(cfg, database)
+	case "import":
+		handleImport(database)
+	case "mute":
+		handleMute(database)
+	case "unmute":
+		handleUnmute(database)
+	case "reingest":
+		handleReingest(cfg, database)
+	case "reparse":
+		handleReparse(cfg, database)
+	case "budget":
+		handleBudget(database)
+	case "secrets":
+		handleSecrets(cfg, database)
 	case "--help":
 		printHelp()
 	default:
@@ -59,28 +205,65 @@ func main() {
 }
 
 func handleFetch(cfg *config.Config, database *db.DB) {
-	// Check if already running
-	_, err := net.Dial("unix", sockPath)
-	if err == nil {
-		fmt.Println("Background process is already running")
-		return
+	stopLog := startLogFile(cfg)
+	defer stopLog()
+
+	reporter, err := errreport.New(cfg)
+	if err != nil {
+		fmt.Printf("Error setting up error reporting: %v\n", err)
+		os.Exit(1)
 	}
-	// Clean up stale socket if exists
-	os.Remove(sockPath)
+	defer reporter.Recover("fetch")
 
-	agg := aggregator.NewAggregator(database.DB, cfg.Interval, cfg.Workers, sockPath)
+	agg := aggregator.NewAggregator(database.DB, cfg, sockPath)
+	agg.SetReporter(reporter)
 
 	err = agg.Start(context.Background())
+	var running *aggregator.RunningError
+	if errors.As(err, &running) {
+		if running.PID == 0 {
+			fmt.Println("Background process is already running")
+			return
+		}
+		fmt.Printf("Background process is already running (pid %d)\n", running.PID)
+		return
+	}
 	if err != nil {
 		fmt.Printf("Error starting aggregator: %v\n", err)
 		os.Exit(1)
 	}
-	fmt.Printf("The background process for fetching feeds has started (interval = %s, workers = %d)\n", cfg.Interval, cfg.Workers)
+	fmt.Printf("The background process for fetching feeds has started (interval = %s, workers = %d, scheduling = %s)\n", cfg.Interval, cfg.Workers, cfg.Scheduling)
+
+	var server *api.Server
+	if cfg.HTTPAddr != "" {
+		server = api.NewServer(database, cfg, agg)
+		server.SetReporter(reporter)
+		err = server.Start(context.Background())
+		if err != nil {
+			fmt.Printf("Error starting HTTP API: %v\n", err)
+			agg.Stop()
+			os.Exit(1)
+		}
+		agg.SetAPIAddr(cfg.HTTPAddr + cfg.BasePath)
+		fmt.Printf("HTTP API listening on %s%s\n", cfg.HTTPAddr, cfg.BasePath)
+		if cfg.APIToken == "" && !loopbackAddr(cfg.HTTPAddr) {
+			fmt.Println("Warning: HTTP API is reachable from other hosts without authentication; set CLI_APP_API_TOKEN")
+		}
+	}
 
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 	<-sigChan
+	fmt.Printf("Shutting down: waiting up to %s for in-flight fetches\n", cfg.ShutdownGrace)
 
+	if server != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		err = server.Stop(ctx)
+		cancel()
+		if err != nil {
+			fmt.Printf("Error stopping HTTP API: %v\n", err)
+		}
+	}
 	err = agg.Stop()
 	if err != nil {
 		fmt.Printf("Error stopping aggregator: %v\n", err)
@@ -88,7 +271,7 @@ func handleFetch(cfg *config.Config, database *db.DB) {
 	fmt.Println("Graceful shutdown: aggregator stopped")
 }
 
-func handleAdd(database *db.DB) {
+func handleAdd(database store) {
 	fs := flag.NewFlagSet("add", flag.ExitOnError)
 	name := fs.String("name", "", "Name of the feed")
 	url := fs.String("url", "", "URL of the feed")
@@ -112,26 +295,367 @@ func handleAdd(database *db.DB) {
 	fmt.Printf("Feed added: %s (%s)\n", *name, *url)
 }
 
-func handleList(database *db.DB) {
+func handleList(database store) {
 	fs := flag.NewFlagSet("list", flag.ExitOnError)
 	num := fs.Int("num", 0, "Number of feeds to show (default: all)")
+	wide := fs.Bool("wide", false, "Also show the last fetch, its HTTP status, failures and unread counts")
+	order := fs.String("sort", models.FeedSortAdded, "Order by name, added, updated or articles")
+	filters := fs.String("filter", "", "Only feeds that are errored or disabled (comma-separated, all must hold)")
+	match := fs.String("match", "", "Only feeds whose name or URL contains this, ignoring case")
+	formatFlag := fs.String("format", "text", `Output format: text, table, json, or a template such as '{{.Name}}\t{{.URL}}'`)
+	porcelain := fs.Bool("porcelain", false, "Stable tab-separated output for scripts: name, url, created_at, article_count, last_published_at, disabled_at")
 	fs.Parse(os.Args[2:])
 
-	feeds, err := database.ListFeeds(*num)
+	format, err := output.Parse(*formatFlag, "text")
+	if err != nil {
+		fmt.Printf("Invalid --format: %v\n", err)
+		os.Exit(1)
+	}
+	f := models.FeedFilter{Match: *match, Sort: *order, Wide: *wide}
+	switch *order {
+	case models.FeedSortAdded, models.FeedSortName, models.FeedSortUpdated, models.FeedSortArticles:
+	default:
+		fmt.Printf("Unknown --sort %q: use name, added, updated or articles\n", *order)
+		os.Exit(1)
+	}
+	for _, name := range strings.Split(*filters, ",") {
+		switch name = strings.TrimSpace(name); {
+		case name == "":
+		case name == "errored":
+			f.Errored = true
+		case name == "disabled":
+			f.Disabled = true
+		case strings.HasPrefix(name, "tag="):
+			fmt.Println("Feeds have no tags to filter on yet; use --match to filter by name or URL")
+			os.Exit(1)
+		default:
+			fmt.Printf("Unknown --filter %q: use errored or disabled\n", name)
+			os.Exit(1)
+		}
+	}
+
+	if *porcelain && *formatFlag != "text" {
+		fmt.Println("--porcelain and --format cannot be used together")
+		os.Exit(1)
+	}
+
+	feeds, err := database.ListFeeds(f, *num)
 	if err != nil {
 		fmt.Printf("Error listing feeds: %v\n", err)
 		os.Exit(1)
 	}
+	if *porcelain {
+		if err := output.WritePorcelain(os.Stdout, feeds, feedPorcelain); err != nil {
+			fmt.Printf("Error writing feeds: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if format.Name != "text" {
+		err = output.Write(os.Stdout, format, feeds, feedColumns(*wide), func(f models.Feed) any {
+			out := api.FeedJSON(f)
+			if *wide {
+				out.Status = api.FeedStatusJSON(f)
+			}
+			return out
+		})
+		if err != nil {
+			fmt.Printf("Error writing feeds: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
 
 	fmt.Println("# Available RSS Feeds")
 	for i, feed := range feeds {
-		fmt.Printf("%d. Name: %s\n   URL: %s\n   Added: %s\n\n", i+1, feed.Name, feed.URL, feed.CreatedAt.Format("2006-01-02 15:04"))
+		fmt.Printf("%d. Name: %s\n   URL: %s\n   Added: %s\n   Articles: %d (last published %s)\n",
+			i+1, output.Name.Paint(feed.Name), feed.URL, output.When.Paint(output.Date(feed.CreatedAt)), feed.ArticleCount,
+			output.When.Paint(output.Date(feed.LastPublishedAt)))
+		if *wide {
+			fmt.Printf("   Last fetch: %s\n   Unread: %s\n", lastFetch(feed.LastFetch), unreadStyle(feed.UnreadCount).Paint(strconv.FormatInt(feed.UnreadCount, 10)))
+			if feed.Failures > 0 && feed.DisabledAt.IsZero() {
+				fmt.Printf("   Failing: %s\n", output.Warn.Paint(fmt.Sprintf("%d in a row: %s", feed.Failures, feed.LastFetch.Error)))
+			}
+		}
+		if !feed.DisabledAt.IsZero() {
+			fmt.Printf("   Disabled: %s after repeated failures: %s (rsshub enable --name %s)\n",
+				output.When.Paint(output.Date(feed.DisabledAt)), output.Bad.Paint(feed.DisabledReason), feed.Name)
+		}
+		fmt.Println()
+	}
+}
+
+// feedColumns lays out feeds as a table, with their fetch status if wide.
+func feedColumns(wide bool) []output.Column[models.Feed] {
+	cols := []output.Column[models.Feed]{
+		{Header: "Name", Value: func(f models.Feed) string { return f.Name }, Style: style[models.Feed](output.Name)},
+		{Header: "URL", Value: func(f models.Feed) string { return f.URL }, Flexible: true},
+		{Header: "Added", Value: func(f models.Feed) string { return output.Date(f.CreatedAt) }, Style: style[models.Feed](output.When)},
+		{Header: "Articles", Value: func(f models.Feed) string { return strconv.FormatInt(f.ArticleCount, 10) }, Right: true},
+		{Header: "Last published", Value: func(f models.Feed) string { return output.Date(f.LastPublishedAt) }, Style: style[models.Feed](output.When)},
+	}
+	if !wide {
+		return cols
+	}
+	return append(cols,
+		output.Column[models.Feed]{Header: "Last fetch", Value: func(f models.Feed) string { return output.Date(f.LastFetch.FetchedAt) }, Style: style[models.Feed](output.When)},
+		output.Column[models.Feed]{Header: "HTTP", Value: func(f models.Feed) string { return optionalNumber(int64(f.LastFetch.Status)) }, Right: true},
+		output.Column[models.Feed]{Header: "Unread", Value: func(f models.Feed) string { return strconv.FormatInt(f.UnreadCount, 10) }, Right: true,
+			Style: func(f models.Feed) output.Style { return unreadStyle(f.UnreadCount) }},
+		output.Column[models.Feed]{Header: "State", Value: feedState, Flexible: true, Style: feedStateStyle},
+	)
+}
+
+// style colours every cell of a column alike.
+func style[T any](s output.Style) func(T) output.Style {
+	return func(T) output.Style { return s }
+}
+
+// unreadStyle highlights unread counts, but only those worth reading.
+func unreadStyle(n int64) output.Style {
+	if n == 0 {
+		return output.Plain
+	}
+	return output.Unread
+}
+
+// feedStateStyle colours a feed's state by how bad it is.
+func feedStateStyle(f models.Feed) output.Style {
+	switch {
+	case !f.DisabledAt.IsZero():
+		return output.Bad
+	case f.Failures > 0:
+		return output.Warn
+	case f.LastFetch.FetchedAt.IsZero():
+		return output.Plain
+	}
+	return output.Good
+}
+
+// feedState sums up whether a feed is fetching fine, failing or disabled.
+func feedState(f models.Feed) string {
+	switch {
+	case !f.DisabledAt.IsZero():
+		return "disabled: " + f.DisabledReason
+	case f.Failures > 0:
+		return fmt.Sprintf("failing %d in a row: %s", f.Failures, f.LastFetch.Error)
+	case f.LastFetch.FetchedAt.IsZero():
+		return "not fetched"
+	}
+	return "ok"
+}
+
+func handleWatch(cfg *config.Config) {
+	fs := flag.NewFlagSet("watch", flag.ExitOnError)
+	feedName := fs.String("feed-name", "", "Only show articles from this feed")
+	fs.Parse(os.Args[2:])
+
+	events, err := db.ListenArticles(cmdCtx, cfg.DSN(), logging.New(cfg))
+	if err != nil {
+		fmt.Printf("Error listening for articles: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println("Watching for new articles (Ctrl-C to stop)...")
+	for ev := range events {
+		if *feedName != "" && ev.FeedName != *feedName {
+			continue
+		}
+		fmt.Printf("[%s] %s: %s\n   %s\n", output.When.Paint(ev.PublishedAt.Format("2006-01-02 15:04")), output.Name.Paint(ev.FeedName), ev.Title, ev.Link)
+	}
+}
+
+func handleStats(cfg *config.Config, database *db.DB) {
+	fs := flag.NewFlagSet("stats", flag.ExitOnError)
+	days := fs.Int("days", 7, "Number of days of daily counts and bandwidth to show")
+	rebuild := fs.Bool("rebuild", false, "Recompute the aggregates from the articles table first")
+	fetchWindow := fs.Duration("fetch-window", 24*time.Hour, "How far back to summarise each worker's fetches (0 to skip)")
+	formatFlag := fs.String("format", output.Table, `Output format: table, json, or a template over each feed such as '{{.FeedName}}\t{{.ArticleCount}}'`)
+	fs.Parse(os.Args[2:])
+
+	format, err := output.Parse(*formatFlag)
+	if err != nil {
+		fmt.Printf("Invalid --format: %v\n", err)
+		os.Exit(1)
+	}
+	if *rebuild {
+		err := database.RebuildStats()
+		if err != nil {
+			fmt.Printf("Error rebuilding stats: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	stats, err := database.GetFeedStats()
+	if err != nil {
+		fmt.Printf("Error getting stats: %v\n", err)
+		os.Exit(1)
+	}
+	if format.IsTemplate() {
+		err = output.Write(os.Stdout, format, stats, nil, nil)
+		if err != nil {
+			fmt.Printf("Error writing stats: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	var counts []models.DailyCount
+	if *days > 0 {
+		counts, err = database.GetDailyCounts(*days)
+		if err != nil {
+			fmt.Printf("Error getting daily counts: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	var bandwidth []models.FeedBandwidth
+	if *days > 0 {
+		bandwidth, err = database.GetBandwidth(*days)
+		if err != nil {
+			fmt.Printf("Error getting bandwidth: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	var workers []models.WorkerStats
+	if *fetchWindow > 0 {
+		workers, err = database.GetWorkerStats(*fetchWindow)
+		if err != nil {
+			fmt.Printf("Error getting worker stats: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if format.Name == output.JSON {
+		err = output.WriteJSON(os.Stdout, statsJSON(stats, counts, bandwidth, cfg.DailyByteLimit, workers))
+		if err != nil {
+			fmt.Printf("Error writing stats: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	var total int64
+	for _, s := range stats {
+		total += s.ArticleCount
+	}
+	fmt.Println("# Articles per feed")
+	output.Write(os.Stdout, format, stats, []output.Column[models.FeedStats]{
+		{Header: "Feed", Value: func(s models.FeedStats) string { return s.FeedName }, Flexible: true},
+		{Header: "Articles", Value: func(s models.FeedStats) string { return strconv.FormatInt(s.ArticleCount, 10) }, Right: true},
+		{Header: "Last published", Value: func(s models.FeedStats) string { return output.Date(s.LastPublishedAt) }},
+	}, nil)
+	fmt.Printf("\nTotal: %d articles in %d feeds\n", total, len(stats))
+
+	if *days > 0 {
+		fmt.Printf("\n# Articles published per day (last %d days)\n", *days)
+		output.Write(os.Stdout, format, counts, []output.Column[models.DailyCount]{
+			{Header: "Day", Value: func(c models.DailyCount) string { return c.Day.Format("2006-01-02") }},
+			{Header: "Articles", Value: func(c models.DailyCount) string { return strconv.FormatInt(c.Count, 10) }, Right: true},
+		}, nil)
+	}
+
+	if *days > 0 {
+		var today int64
+		for _, b := range bandwidth {
+			today += b.TodayBytes
+		}
+		fmt.Printf("\n# Bytes downloaded per feed (today and last %d days)\n", *days)
+		output.Write(os.Stdout, format, bandwidth, []output.Column[models.FeedBandwidth]{
+			{Header: "Feed", Value: func(b models.FeedBandwidth) string { return b.FeedName }, Flexible: true},
+			{Header: "Today", Value: func(b models.FeedBandwidth) string { return byteSize(b.TodayBytes) }, Right: true},
+			{Header: "Fetches", Value: func(b models.FeedBandwidth) string { return strconv.FormatInt(b.TodayFetches, 10) }, Right: true},
+			{Header: "Daily cap", Value: func(b models.FeedBandwidth) string { return dailyCap(b.DailyLimit) }, Right: true},
+			{Header: fmt.Sprintf("%d days", *days), Value: func(b models.FeedBandwidth) string { return byteSize(b.PeriodBytes) }, Right: true},
+		}, nil)
+		if cfg.DailyByteLimit > 0 {
+			fmt.Printf("\nToday: %s of the %s allowed a day\n", byteSize(today), byteSize(cfg.DailyByteLimit))
+		} else {
+			fmt.Printf("\nToday: %s\n", byteSize(today))
+		}
+	}
+
+	if *fetchWindow > 0 {
+		fmt.Printf("\n# Fetches per worker (last %s)\n", *fetchWindow)
+		output.Write(os.Stdout, format, workers, []output.Column[models.WorkerStats]{
+			{Header: "Instance", Value: func(w models.WorkerStats) string { return w.Instance }, Flexible: true},
+			{Header: "Worker", Value: func(w models.WorkerStats) string { return strconv.Itoa(w.Worker) }, Right: true},
+			{Header: "Fetches", Value: func(w models.WorkerStats) string { return strconv.FormatInt(w.Fetches, 10) }, Right: true},
+			{Header: "Failed", Value: func(w models.WorkerStats) string { return strconv.FormatInt(w.Failures, 10) }, Right: true},
+			{Header: "Avg", Value: func(w models.WorkerStats) string { return w.AvgDuration.Round(time.Millisecond).String() }, Right: true},
+			{Header: "Bytes", Value: func(w models.WorkerStats) string { return byteSize(w.Bytes) }, Right: true},
+			{Header: "New", Value: func(w models.WorkerStats) string { return strconv.FormatInt(w.NewItems, 10) }, Right: true},
+		}, nil)
 	}
 }
 
-func handleDelete(database *db.DB) {
+// dailyCap describes a daily byte cap, 0 meaning none.
+func dailyCap(limit int64) string {
+	if limit <= 0 {
+		return "no cap"
+	}
+	return byteSize(limit)
+}
+
+// statsJSON is what stats --format json prints.
+func statsJSON(stats []models.FeedStats, counts []models.DailyCount, bandwidth []models.FeedBandwidth, dailyLimit int64, workers []models.WorkerStats) any {
+	type feed struct {
+		Name            string     `json:"name"`
+		ArticleCount    int64      `json:"article_count"`
+		LastPublishedAt *time.Time `json:"last_published_at,omitempty"`
+	}
+	type day struct {
+		Day   string `json:"day"`
+		Count int64  `json:"count"`
+	}
+	type usage struct {
+		Name         string `json:"name"`
+		TodayBytes   int64  `json:"today_bytes"`
+		TodayFetches int64  `json:"today_fetches"`
+		PeriodBytes  int64  `json:"period_bytes"`
+		DailyLimit   int64  `json:"daily_limit,omitempty"`
+	}
+	type worker struct {
+		Instance  string `json:"instance"`
+		Worker    int    `json:"worker"`
+		Fetches   int64  `json:"fetches"`
+		Failures  int64  `json:"failures"`
+		AvgMillis int64  `json:"avg_duration_ms"`
+		Bytes     int64  `json:"bytes"`
+		NewItems  int64  `json:"new_items"`
+	}
+	out := struct {
+		Feeds          []feed   `json:"feeds"`
+		Days           []day    `json:"days"`
+		Bandwidth      []usage  `json:"bandwidth"`
+		DailyByteLimit int64    `json:"daily_byte_limit,omitempty"`
+		Workers        []worker `json:"workers"`
+	}{Feeds: []feed{}, Days: []day{}, Bandwidth: []usage{}, DailyByteLimit: dailyLimit, Workers: []worker{}}
+	for _, s := range stats {
+		f := feed{Name: s.FeedName, ArticleCount: s.ArticleCount}
+		if !s.LastPublishedAt.IsZero() {
+			f.LastPublishedAt = &s.LastPublishedAt
+		}
+		out.Feeds = append(out.Feeds, f)
+	}
+	for _, c := range counts {
+		out.Days = append(out.Days, day{Day: c.Day.Format("2006-01-02"), Count: c.Count})
+	}
+	for _, b := range bandwidth {
+		out.Bandwidth = append(out.Bandwidth, usage{Name: b.FeedName, TodayBytes: b.TodayBytes, TodayFetches: b.TodayFetches,
+			PeriodBytes: b.PeriodBytes, DailyLimit: b.DailyLimit})
+	}
+	for _, w := range workers {
+		out.Workers = append(out.Workers, worker{Instance: w.Instance, Worker: w.Worker, Fetches: w.Fetches, Failures: w.Failures,
+			AvgMillis: w.AvgDuration.Milliseconds(), Bytes: w.Bytes, NewItems: w.NewItems})
+	}
+	return out
+}
+
+func handleDelete(cfg *config.Config, database store) {
 	fs := flag.NewFlagSet("delete", flag.ExitOnError)
 	name := fs.String("name", "", "Name of the feed to delete")
+	purge := fs.Bool("purge", false, "Permanently remove the feed and its articles instead of a restorable delete")
+	dryRun := fs.Bool("dry-run", false, "Only show what would be affected")
+	yes := fs.Bool("yes", false, "Do not ask for confirmation")
+	keep := fs.Bool("keep-articles", cfg.KeepArticlesOnPurge, "With --purge, archive the feed's articles instead of deleting them")
 	fs.Parse(os.Args[2:])
 
 	if *name == "" {
@@ -139,106 +663,604 @@ func handleDelete(database *db.DB) {
 		os.Exit(1)
 	}
 
-	err := database.DeleteFeed(*name)
+	im, err := database.FeedImpact(*name)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	if im.Deleted && !*purge {
+		fmt.Printf("Feed %s is already deleted (restore it with rsshub restore, or remove it for good with --purge)\n", *name)
+		os.Exit(1)
+	}
+	fmt.Printf("Feed %s has %d articles (%d read, %d starred) and %d stored revisions.\n",
+		*name, im.Articles, im.Read, im.Starred, im.Revisions)
+	if *purge && *keep {
+		fmt.Println("Purging removes the feed for good; its articles are archived (see rsshub archived).")
+	} else if *purge {
+		fmt.Println("Purging removes all of them permanently.")
+	} else {
+		fmt.Println("Deleting hides them until the feed is restored.")
+	}
+	if *dryRun {
+		return
+	}
+	if !*yes && !confirm("Proceed?") {
+		fmt.Println("Nothing changed")
+		os.Exit(1)
+	}
+
+	if *purge && *keep {
+		archived, err := database.PurgeFeedKeepArticles(*name)
+		if err != nil {
+			fmt.Printf("Error purging feed: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Feed purged, %d articles archived: %s\n", archived, *name)
+		return
+	}
+	if *purge {
+		err := database.PurgeFeed(*name)
+		if err != nil {
+			fmt.Printf("Error purging feed: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Feed purged with all its articles: %s\n", *name)
+		return
+	}
+
+	err = database.DeleteFeed(*name)
 	if err != nil {
 		fmt.Printf("Error deleting feed: %v\n", err)
 		os.Exit(1)
 	}
-	fmt.Printf("Feed deleted: %s\n", *name)
+	fmt.Printf("Feed deleted: %s (undo with: rsshub restore --name %s)\n", *name, *name)
+}
+
+// confirm asks a yes/no question on the terminal. Without a terminal to
+// ask on it answers no, so scripts must pass --yes.
+func confirm(question string) bool {
+	info, err := os.Stdin.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		fmt.Println("Refusing to continue without confirmation: pass --yes")
+		return false
+	}
+	fmt.Printf("%s [y/N] ", question)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+func handleMerge(database store) {
+	fs := flag.NewFlagSet("merge", flag.ExitOnError)
+	from := fs.String("from", "", "Feed to merge and remove")
+	into := fs.String("into", "", "Feed to keep")
+	yes := fs.Bool("yes", false, "Do not ask for confirmation")
+	fs.Parse(os.Args[2:])
+
+	if *from == "" || *into == "" {
+		fmt.Println("Missing required flags: --from and --into")
+		os.Exit(1)
+	}
+	if *from == *into {
+		fmt.Println("--from and --into must name different feeds")
+		os.Exit(1)
+	}
+
+	im, err := database.FeedImpact(*from)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Feed %s has %d articles; those not already in %s move there, then %s is removed.\n",
+		*from, im.Articles, *into, *from)
+	if !*yes && !confirm("Proceed?") {
+		fmt.Println("Nothing changed")
+		os.Exit(1)
+	}
+
+	res, err := database.MergeFeeds(*from, *into)
+	if err != nil {
+		fmt.Printf("Error merging feeds: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Merged %s into %s: %d articles moved, %d duplicates dropped\n", *from, *into, res.Moved, res.Duplicates)
+}
+
+func handleRestore(database store) {
+	fs := flag.NewFlagSet("restore", flag.ExitOnError)
+	name := fs.String("name", "", "Name of the deleted feed to restore")
+	fs.Parse(os.Args[2:])
+
+	if *name == "" {
+		fmt.Println("Missing required flag: --name")
+		os.Exit(1)
+	}
+
+	err := database.RestoreFeed(*name)
+	if err != nil {
+		fmt.Printf("Error restoring feed: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Feed restored: %s\n", *name)
 }
 
-func handleArticles(database *db.DB) {
+func handleEnable(database store) {
+	fs := flag.NewFlagSet("enable", flag.ExitOnError)
+	name := fs.String("name", "", "Name of the disabled feed to fetch again")
+	fs.Parse(os.Args[2:])
+
+	if *name == "" {
+		fmt.Println("Missing required flag: --name")
+		os.Exit(1)
+	}
+
+	err := database.EnableFeed(*name)
+	if err != nil {
+		fmt.Printf("Error enabling feed: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Feed enabled: %s\n", *name)
+}
+
+func handleArticles(database store) {
 	fs := flag.NewFlagSet("articles", flag.ExitOnError)
 	feedName := fs.String("feed-name", "", "Name of the feed")
+	author := fs.String("author", "", "Only articles by this author (case-insensitive)")
+	near := fs.String("near", "", "Only articles located near this point, as lat,lon")
+	radius := fs.String("radius", "50km", "Distance from --near, in km, mi or m")
 	num := fs.Int("num", 3, "Number of articles to show")
+	formatFlag := fs.String("format", "text", `Output format: text, table, json with the REST API's fields, or a template such as '{{.Title}}\t{{.Link}}'`)
+	showIDs := fs.Bool("ids", false, "Show short article IDs, as used by open, star, note, mark-read and history")
+	maxReadTime := fs.Duration("max-read-time", 0, "Only articles estimated to take at most this long to read, e.g. 5m")
+	showMuted := fs.Bool("show-muted", false, "Include articles hidden by mute rules")
+	porcelain := fs.Bool("porcelain", false, "Stable tab-separated output for scripts: id, feed_id, published_at, title, link, author, read_at, starred_at")
+	stream := fs.Bool("stream", false, "Write every matching article (or --num) as it is read, in the --porcelain fields, for pipelines")
 	fs.Parse(os.Args[2:])
 
-	if *feedName == "" {
-		fmt.Println("Missing required flag: --feed-name")
+	if *feedName == "" && *author == "" && *near == "" {
+		fmt.Println("Missing required flag: --feed-name, --author or --near")
+		os.Exit(1)
+	}
+	if *porcelain && *formatFlag != "text" {
+		fmt.Println("--porcelain and --format cannot be used together")
+		os.Exit(1)
+	}
+	if *stream && (*porcelain || *formatFlag != "text" || *showIDs) {
+		fmt.Println("--stream cannot be used with --porcelain, --format or --ids")
 		os.Exit(1)
 	}
+	format, err := output.Parse(*formatFlag, "text")
+	if err != nil {
+		fmt.Printf("Invalid --format: %v\n", err)
+		os.Exit(1)
+	}
+
+	filter := models.ArticleFilter{FeedName: *feedName, Author: *author, MaxReadTime: *maxReadTime, ShowMuted: *showMuted}
+	if *near != "" {
+		p, err := geo.ParsePoint(*near)
+		if err != nil {
+			fmt.Printf("Invalid --near: %v\n", err)
+			os.Exit(1)
+		}
+		km, err := geo.ParseDistance(*radius)
+		if err != nil {
+			fmt.Printf("Invalid --radius: %v\n", err)
+			os.Exit(1)
+		}
+		filter.Near, filter.RadiusKM = &p, km
+	}
+
+	if *stream {
+		streamArticles(database, filter, fs, *num)
+		return
+	}
 
-	articles, err := database.GetArticles(*feedName, *num)
+	articles, err := database.GetArticles(filter, *num)
 	if err != nil {
 		fmt.Printf("Error getting articles: %v\n", err)
 		os.Exit(1)
 	}
+	if *porcelain {
+		if err := output.WritePorcelain(os.Stdout, articles, articlePorcelain); err != nil {
+			fmt.Printf("Error writing articles: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if format.Name != "text" {
+		err = output.Write(os.Stdout, format, articles, articleColumns(*showIDs), func(a models.Article) any {
+			return api.ArticleJSON(a)
+		})
+		if err != nil {
+			fmt.Printf("Error writing articles: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	var heading []string
+	if *feedName != "" {
+		heading = append(heading, "Feed: "+*feedName)
+	}
+	if *author != "" {
+		heading = append(heading, "author: "+*author)
+	}
+	if *near != "" {
+		heading = append(heading, fmt.Sprintf("within %s of %s", *radius, *near))
+	}
+	if *maxReadTime > 0 {
+		heading = append(heading, fmt.Sprintf("read in %s or less", *maxReadTime))
+	}
+	heading[0] = strings.ToUpper(heading[0][:1]) + heading[0][1:]
+	fmt.Printf("%s\n\n", strings.Join(heading, ", "))
+	printArticles(articles, *showIDs)
+}
 
-	fmt.Printf("Feed: %s\n\n", *feedName)
+// printArticles lists articles for people, numbered, with whatever each
+// has beyond a title and link, and their IDs if ids.
+func printArticles(articles []models.Article, ids bool) {
 	for i, art := range articles {
-		fmt.Printf("%d. [%s] %s\n   %s\n\n", i+1, art.PublishedAt.Format("2006-01-02"), art.Title, art.Link)
+		fmt.Printf("%d. %s[%s] %s\n   %s\n", i+1, unreadMarker(art), output.When.Paint(art.PublishedAt.Format("2006-01-02")), art.Title, art.Link)
+		if art.Author != "" {
+			fmt.Printf("   by %s\n", art.Author)
+		}
+		if art.WordCount > 0 {
+			fmt.Printf("   %s\n", readingTime(art))
+		}
+		if c := commentsLink(art); c != "" {
+			fmt.Printf("   comments: %s\n", c)
+		}
+		if art.Location != nil {
+			fmt.Printf("   location: %g,%g\n", art.Location.Lat, art.Location.Lon)
+		}
+		if ep := episodeLabel(art); ep != "" {
+			fmt.Printf("   %s\n", ep)
+		}
+		if art.VideoID != "" {
+			fmt.Printf("   video: %s\n", art.VideoID)
+		}
+		if ids {
+			fmt.Printf("   id: %s\n", art.ID)
+		}
+		fmt.Println()
 	}
 }
 
-func handleSetInterval() {
-	if len(os.Args) < 3 {
-		fmt.Println("Usage: rsshub set-interval <duration> (e.g., 2m)")
+// streamArticles writes the articles filter selects to stdout as they are
+// read, one porcelain line each. Only an explicit --num limits them.
+func streamArticles(database store, filter models.ArticleFilter, fs *flag.FlagSet, num int) {
+	limit := 0
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "num" {
+			limit = num
+		}
+	})
+	w := bufio.NewWriter(os.Stdout)
+	err := database.StreamArticles(filter, limit, func(a models.Article) error {
+		return output.WritePorcelainRow(w, a, articlePorcelain)
+	})
+	if ferr := w.Flush(); err == nil {
+		err = ferr
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error streaming articles: %v\n", err)
 		os.Exit(1)
 	}
-	durStr := os.Args[2]
+}
+
+// readingTime describes an article's length, e.g. "1240 words, 6 min read".
+func readingTime(a models.Article) string {
+	return fmt.Sprintf("%d words, %s read", a.WordCount, readingMinutes(a))
+}
+
+// readingMinutes is an article's estimated reading time, or empty if its
+// words were not counted.
+func readingMinutes(a models.Article) string {
+	if a.WordCount == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d min", rss.ReadingTime(a.WordCount)/time.Minute)
+}
+
+// unreadMark is "* " for articles not yet read, to pick them out of a
+// listing, and blank spaces for read ones.
+func unreadMark(a models.Article) string {
+	if a.ReadAt.IsZero() {
+		return "* "
+	}
+	return "  "
+}
+
+// unreadMarker is unreadMark coloured for the terminal.
+func unreadMarker(a models.Article) string {
+	return output.Unread.Paint(unreadMark(a))
+}
+
+// articleShortID is the ID a listing shows for a: its shortest unique
+// prefix, or its first eight digits from a server that does not say.
+func articleShortID(a models.Article) string {
+	if a.ShortID != "" {
+		return a.ShortID
+	}
+	return models.ShortID(a.ID)
+}
+
+// articleColumns lays out articles as a table, led by their short IDs if
+// ids.
+func articleColumns(ids bool) []output.Column[models.Article] {
+	var cols []output.Column[models.Article]
+	if ids {
+		cols = append(cols, output.Column[models.Article]{Header: "ID", Value: articleShortID})
+	}
+	return append(cols,
+		output.Column[models.Article]{Header: "Published", Value: func(a models.Article) string { return output.Date(a.PublishedAt) }, Style: style[models.Article](output.When)},
+		output.Column[models.Article]{Header: "", Value: func(a models.Article) string { return strings.TrimSpace(unreadMark(a)) }, Style: style[models.Article](output.Unread)},
+		output.Column[models.Article]{Header: "Title", Value: func(a models.Article) string { return a.Title }, Flexible: true},
+		output.Column[models.Article]{Header: "Author", Value: func(a models.Article) string { return a.Author }},
+		output.Column[models.Article]{Header: "Read", Value: func(a models.Article) string { return readingMinutes(a) }, Right: true},
+		output.Column[models.Article]{Header: "Link", Value: func(a models.Article) string { return a.Link }, Flexible: true},
+	)
+}
+
+func handleArchived(database store) {
+	fs := flag.NewFlagSet("archived", flag.ExitOnError)
+	feedName := fs.String("feed-name", "", "Only articles of this former feed")
+	num := fs.Int("num", 10, "Number of articles to show")
+	fs.Parse(os.Args[2:])
 
-	conn, err := net.Dial("unix", sockPath)
+	articles, err := database.ArchivedArticles(*feedName, *num)
 	if err != nil {
-		fmt.Println("Background process is not running")
+		fmt.Printf("Error getting archived articles: %v\n", err)
 		os.Exit(1)
 	}
-	defer conn.Close()
+	if len(articles) == 0 {
+		fmt.Println("No archived articles")
+		return
+	}
+	for i, art := range articles {
+		fmt.Printf("%d. [%s] %s (%s)\n   %s\n\n", i+1, output.When.Paint(art.PublishedAt.Format("2006-01-02")), art.Title, output.Name.Paint(art.FeedName), art.Link)
+	}
+}
+
+func handleAudit(database store) {
+	fs := flag.NewFlagSet("audit", flag.ExitOnError)
+	target := fs.String("target", "", "Only show operations on this feed")
+	limit := fs.Int("limit", 50, "Number of entries to show")
+	fs.Parse(os.Args[2:])
 
-	_, err = conn.Write([]byte("set-interval " + durStr + "\n"))
+	entries, err := database.ListAudit(*target, *limit)
 	if err != nil {
-		fmt.Printf("Error sending command: %v\n", err)
+		fmt.Printf("Error reading audit log: %v\n", err)
 		os.Exit(1)
 	}
+	if len(entries) == 0 {
+		fmt.Println("No audited operations")
+		return
+	}
 
-	buf := make([]byte, 1024)
-	n, err := conn.Read(buf)
-	if err != nil {
-		fmt.Printf("Error reading response: %v\n", err)
+	fmt.Printf("%-19s  %-24s  %-4s  %-14s  %-20s  %s\n", "TIME", "ACTOR", "VIA", "ACTION", "TARGET", "CHANGE")
+	for _, e := range entries {
+		change := ""
+		switch {
+		case e.OldValue != "" && e.NewValue != "":
+			change = e.OldValue + " -> " + e.NewValue
+		case e.NewValue != "":
+			change = e.NewValue
+		case e.OldValue != "":
+			change = "was " + e.OldValue
+		}
+		fmt.Printf("%-19s  %-24s  %-4s  %-14s  %-20s  %s\n",
+			e.At.Local().Format("2006-01-02 15:04:05"), e.Actor, e.Source, e.Action, e.Target, change)
+	}
+}
+
+func handleSetInterval(cfg *config.Config) {
+	if len(os.Args) < 3 {
+		fmt.Println("Usage: rsshub set-interval <duration> (e.g., 2m)")
 		os.Exit(1)
 	}
-	fmt.Print(string(buf[:n]))
+	sendControl(cfg, "set-interval "+os.Args[2])
 }
 
-func handleSetWorkers() {
+func handleSetWorkers(cfg *config.Config) {
 	if len(os.Args) < 3 {
 		fmt.Println("Usage: rsshub set-workers <count> (e.g., 5)")
 		os.Exit(1)
 	}
-	countStr := os.Args[2]
+	sendControl(cfg, "set-workers "+os.Args[2])
+}
+
+func handlePause(cfg *config.Config) {
+	sendControl(cfg, "pause")
+}
+
+func handleResume(cfg *config.Config) {
+	sendControl(cfg, "resume")
+}
 
-	conn, err := net.Dial("unix", sockPath)
+// handleVersion prints this binary's build and, if one is reachable, the
+// daemon's (locally or, with --server, the remote server's).
+func handleVersion(cfg *config.Config) {
+	fmt.Println(version.Get())
+	var daemon string
+	if cfg.Server != "" {
+		ctx, cancel := context.WithTimeout(cmdCtx, apiTimeout)
+		defer cancel()
+		info, err := newAPIClient(cfg, cfg.Server).Version(ctx)
+		if err != nil {
+			fmt.Printf("server: %v\n", err)
+			return
+		}
+		daemon = fmt.Sprintf("rsshub %s (commit %s, built %s, %s)", info.Version, info.Commit, info.Date, info.GoVersion)
+	} else {
+		reply, err := controlRequest("version")
+		if err != nil {
+			return
+		}
+		daemon = strings.TrimSpace(reply)
+	}
+	fmt.Printf("daemon: %s\n", daemon)
+}
+
+// commentsLink returns an article's discussion URL, or "" if it has none
+// or it is the same as the article link, as in feeds that link nowhere else.
+func commentsLink(a models.Article) string {
+	if a.CommentsURL == a.Link {
+		return ""
+	}
+	return a.CommentsURL
+}
+
+// episodeLabel describes a podcast episode as e.g. "S2 E14 · 1:02:03",
+// or returns "" for articles without episode metadata.
+func episodeLabel(a models.Article) string {
+	var parts []string
+	switch {
+	case a.Season > 0 && a.Episode > 0:
+		parts = append(parts, fmt.Sprintf("S%d E%d", a.Season, a.Episode))
+	case a.Episode > 0:
+		parts = append(parts, fmt.Sprintf("episode %d", a.Episode))
+	case a.Season > 0:
+		parts = append(parts, fmt.Sprintf("season %d", a.Season))
+	}
+	if a.Duration > 0 {
+		parts = append(parts, clock(a.Duration))
+	}
+	return strings.Join(parts, " · ")
+}
+
+// loopbackAddr reports whether a listen address only accepts local
+// connections.
+func loopbackAddr(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
 	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// errNotRunning is returned by controlRequest when no daemon is listening.
+var errNotRunning = errors.New("background process is not running")
+
+// sendControl delivers a single command to the running background process
+// and prints its reply. Locally that goes over the control socket; in
+// remote mode it goes through the server's HTTP API.
+func sendControl(cfg *config.Config, command string) {
+	var reply string
+	var err error
+	if cfg.Server != "" {
+		reply, err = remoteControl(cfg, command)
+	} else {
+		reply, err = controlRequest(command)
+	}
+	if err == errNotRunning {
 		fmt.Println("Background process is not running")
 		os.Exit(1)
 	}
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Print(reply)
+}
+
+// controlRequest sends one command over the control socket and returns
+// the daemon's reply. A rejected command comes back as an
+// *aggregator.ControlError. Cancelling the command closes the socket, so
+// a daemon slow to answer does not hold it up.
+func controlRequest(command string) (string, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(cmdCtx, "unix", sockPath)
+	if err != nil {
+		return "", errNotRunning
+	}
 	defer conn.Close()
+	stop := context.AfterFunc(cmdCtx, func() { conn.Close() })
+	defer stop()
 
-	_, err = conn.Write([]byte("set-workers " + countStr + "\n"))
+	_, err = conn.Write([]byte(command + "\n"))
 	if err != nil {
-		fmt.Printf("Error sending command: %v\n", err)
-		os.Exit(1)
+		return "", fmt.Errorf("sending command: %w", err)
 	}
 
-	buf := make([]byte, 1024)
-	n, err := conn.Read(buf)
+	// Each command is answered with one JSON line.
+	var resp aggregator.ControlResponse
+	err = json.NewDecoder(conn).Decode(&resp)
+	if err != nil && cmdCtx.Err() != nil {
+		return "", cmdCtx.Err()
+	}
 	if err != nil {
-		fmt.Printf("Error reading response: %v\n", err)
-		os.Exit(1)
+		return "", fmt.Errorf("reading response: %w", err)
 	}
-	fmt.Print(string(buf[:n]))
+	if !resp.OK {
+		return "", &aggregator.ControlError{Code: resp.Code, Message: resp.Error}
+	}
+	return resp.Reply, nil
 }
 
 func printHelp() {
 	fmt.Println(`Usage:
-  rsshub COMMAND [OPTIONS]
+  rsshub [--profile NAME] [--server URL --token TOKEN] [--socket PATH] [--timeout DURATION] [--no-color] COMMAND [OPTIONS]
+
+  Global Options:
+     --profile       use the settings of a profile in the profiles file (env CLI_APP_PROFILE), read from ~/.config/rsshub/profiles or CLI_APP_PROFILES_FILE
+     --server        manage the rsshub deployment at URL over its HTTP API (env CLI_APP_SERVER)
+     --token         API token for the server (env CLI_APP_API_TOKEN)
+     --socket        control socket of the local daemon (env CLI_APP_SOCKET_PATH; default one per database under $XDG_RUNTIME_DIR)
+     --timeout       give up on the command after DURATION, 0 for never (default 2m; none for watch, top, download, import, reingest, reparse, build-site, exports, user and secrets); Ctrl-C cancels any command
+     --no-color      never colour output (also NO_COLOR; colour is only used on a terminal)
 
   Common Commands:
      add             add new RSS feed
+     import          subscribe to the feeds of a miniflux, freshrss or ttrss account and bring over its starred and read articles
      set-interval    set RSS fetch interval
      set-workers     set number of workers
-     list            list available RSS feeds
-     delete          delete RSS feed
-     articles        show latest articles
-     fetch           starts the background process that periodically fetches and processes RSS feeds using a worker pool
-`)
+     pause           stop scheduling new fetches (in-flight fetches finish)
+     resume          resume scheduling fetches after a pause
+     list            list available RSS feeds (--wide adds fetch status; --sort, --filter errored|disabled and --match narrow it down; --format table|json|template, or --porcelain for scripts)
+     delete          delete RSS feed after confirming its impact (restorable; --purge removes it for good, --dry-run only reports)
+     restore         restore a deleted RSS feed
+     enable          fetch a feed again after it was disabled for failing too many times in a row
+     merge           move one feed's articles into another, skipping duplicates, and remove it
+     quirks          show or set a feed's parser overrides (date layout, TLS checks, CA and pins, plain text, title prefix)
+     budget          cap how much a feed may download per day (--feed-name, --daily 50MB); usage is in stats
+     secrets         keep credentials encrypted in the database under CLI_APP_SECRETS_KEY (list, set|delete <name> [--feed-name], new-key, rotate-key)
+     reingest        fetch a feed again and fix its stored articles from the last --since 30d after a quirk or parser change
+     reparse         like reingest, but from the items kept by CLI_APP_STORE_RAW_ITEMS, for feeds that are gone
+     audit           show who added, deleted or reconfigured what and when
+     articles        show latest articles with their reading times (--max-read-time 5m for short ones, --show-muted to include muted ones; --format table|json|template, --porcelain for scripts, --stream for pipelines)
+     archived        show articles kept from feeds purged with --keep-articles
+     authors         count articles per author (--feed-name for one feed); follow one with articles --author
+     random          show a random article from the archive (--unread, --starred, --older-than, --feed-name)
+     resurface       bring back a few old unread or starred articles (run it now and then, e.g. from cron)
+     mute            hide articles mentioning a keyword from listings for a while (--keyword elon --for 7d); without --keyword, list what is muted
+     unmute          lift a mute rule before it expires (--id)
+     history         show how an article's title and description changed upstream
+     open            open an article's link in the browser and mark it read; articles are named by the short IDs of articles --ids, or any unique prefix
+     mark-read       mark articles read
+     star            star an article (unstar removes the star)
+     note            add a note to an article, optionally about a --quote'd passage; without text, show its notes
+     notes           search notes (--search) or list the latest ones; --delete removes one
+     save            send an article to a read-later service (wallabag, pocket, instapaper)
+     export-bookmarks  export starred articles as Netscape bookmarks HTML or CSV
+     export-notes    write starred (or --feed-name/--match) articles, with your notes on them, as Markdown notes for a vault
+     build-site      render recent articles into a static HTML site (index plus a page per feed)
+     download        save a feed's newest podcast episodes to a directory, resuming partial files
+     stats           show article counts per feed and per day, bytes downloaded per feed, and each worker's recent fetches (--format table|json|template)
+     today           show what was published today (or --date YYYY-MM-DD) by feed, under a calendar of the last month's daily counts
+     health          show each feed's recent fetches, durations and failures, and feeds that have gone quiet (--format table|json|template)
+     watch           stream newly fetched articles as they arrive
+     status          show the running daemon's version and settings, and how its last ticks went
+     ticks           show feeds due, fetches, items and errors per scheduler tick of the running daemon (--last 10, --json)
+     top             live view of the daemon's workers, queue, throughput and recent errors
+     version         show the build version of this binary and of the running daemon
+     user            manage users who sign in to the HTTP API (add, passwd, delete, list) and the feeds they share publicly at /u/<name>/subscriptions (share, unshare, shared)
+     preview         fetch a feed and show its newest items without subscribing
+     lint            fetch a feed and report spec problems and items rsshub would drop
+     doctor          check configuration, database, daemon socket and a sample feed
+     fetch           starts the background process that periodically fetches and processes RSS feeds using a worker pool`)
 }
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"rsshub/internal/db"
+)
+
+// longDuration is a flag.Value for periods that may be given in days, as
+// in 7d or 1d12h, besides anything time.ParseDuration takes.
+type longDuration time.Duration
+
+func (d *longDuration) String() string {
+	return age(time.Duration(*d))
+}
+
+func (d *longDuration) Set(s string) error {
+	var total time.Duration
+	if days, rest, ok := strings.Cut(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return errors.New("want a duration such as 7d, 36h or 1d12h")
+		}
+		total = time.Duration(n) * 24 * time.Hour
+		s = rest
+	}
+	if s != "" {
+		v, err := time.ParseDuration(s)
+		if err != nil {
+			return errors.New("want a duration such as 7d, 36h or 1d12h")
+		}
+		total += v
+	}
+	*d = longDuration(total)
+	return nil
+}
+
+// handleMute hides articles mentioning a keyword from listings for a
+// while; without --keyword it lists the active rules.
+func handleMute(database *db.DB) {
+	fs := flag.NewFlagSet("mute", flag.ExitOnError)
+	keyword := fs.String("keyword", "", "Hide articles whose title or description mentions this word or phrase")
+	period := longDuration(7 * 24 * time.Hour)
+	fs.Var(&period, "for", "How long to mute it, e.g. 7d or 12h")
+	fs.Parse(os.Args[2:])
+
+	if *keyword == "" {
+		listMuteRules(database)
+		return
+	}
+	if period <= 0 {
+		fmt.Println("--for must be positive")
+		os.Exit(1)
+	}
+	actor := db.Actor{Name: currentUser(), Source: db.SourceCLI}
+	rule, err := database.MuteKeyword(*keyword, time.Duration(period), actor.Name)
+	if err != nil {
+		fmt.Printf("Error muting %q: %v\n", *keyword, err)
+		os.Exit(1)
+	}
+	database.Audit(actor, "mute", rule.Keyword, "", rule.ExpiresAt.Format(time.DateTime))
+	fmt.Printf("Muted %q for %s, until %s (rule %d; lift it early with rsshub unmute --id %d)\n",
+		rule.Keyword, age(time.Duration(period)), rule.ExpiresAt.Format("2006-01-02 15:04"), rule.ID, rule.ID)
+}
+
+func listMuteRules(database *db.DB) {
+	rules, err := database.MuteRules()
+	if err != nil {
+		fmt.Printf("Error listing mute rules: %v\n", err)
+		os.Exit(1)
+	}
+	if len(rules) == 0 {
+		fmt.Println("Nothing is muted (add a rule with rsshub mute --keyword WORD --for 7d)")
+		return
+	}
+	fmt.Println("# Muted keywords")
+	for _, r := range rules {
+		fmt.Printf("%4d  %-30s until %s, by %s\n", r.ID, r.Keyword, r.ExpiresAt.Format("2006-01-02 15:04"), r.CreatedBy)
+	}
+}
+
+// handleUnmute lifts a mute rule before it expires.
+func handleUnmute(database *db.DB) {
+	fs := flag.NewFlagSet("unmute", flag.ExitOnError)
+	id := fs.Int64("id", 0, "Rule to lift, as shown by rsshub mute")
+	fs.Parse(os.Args[2:])
+
+	if *id <= 0 {
+		fmt.Println("Missing required flag: --id")
+		os.Exit(1)
+	}
+	if err := database.Unmute(*id); err != nil {
+		fmt.Printf("Error lifting mute rule: %v\n", err)
+		os.Exit(1)
+	}
+	database.Audit(db.Actor{Name: currentUser(), Source: db.SourceCLI}, "unmute", strconv.FormatInt(*id, 10), "", "")
+	fmt.Printf("Mute rule %d lifted\n", *id)
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/google/uuid"
+	"rsshub/internal/models"
+)
+
+// defaultNoteTemplate writes YAML front matter that Obsidian and most
+// static-site tools understand, followed by the article summary.
+const defaultNoteTemplate = `---
+title: {{yaml .Title}}
+source: {{yaml .Link}}
+{{- if .Comments}}
+comments: {{yaml .Comments}}
+{{- end}}
+feed: {{yaml .Feed}}
+published: {{date .Published}}
+{{- if not .Starred.IsZero}}
+starred: {{date .Starred}}
+{{- end}}
+{{- if .Season}}
+season: {{.Season}}
+{{- end}}
+{{- if .Episode}}
+episode: {{.Episode}}
+{{- end}}
+{{- if .Duration}}
+duration: {{clock .Duration}}
+{{- end}}
+{{- if .Image}}
+image: {{yaml .Image}}
+{{- end}}
+id: {{.ID}}
+tags: [rsshub]
+---
+
+# {{.Title}}
+
+{{.Summary}}
+
+[Read the original]({{.Link}}){{if .Comments}} · [Discussion]({{.Comments}}){{end}}
+{{- if .Notes}}
+
+## Notes
+{{range .Notes}}
+{{- if .Quote}}
+{{blockquote .Quote}}
+{{end}}
+{{.Text}}
+{{end}}
+{{- end}}
+`
+
+// note is the data passed to export-notes templates.
+type note struct {
+	ID        uuid.UUID
+	Title     string
+	Link      string
+	Feed      string
+	Published time.Time
+	Starred   time.Time
+	// Comments is the item's discussion page, e.g. on Hacker News.
+	Comments string
+	// Description is the article summary as published, usually HTML.
+	Description string
+	// Summary is Description with the markup stripped.
+	Summary string
+	// Podcast episode metadata; zero when the item had none.
+	Season   int
+	Episode  int
+	Duration time.Duration
+	Image    string
+	// Notes are the reader's own notes on the article, oldest first.
+	Notes []models.ArticleNote
+}
+
+var noteFuncs = template.FuncMap{
+	"yaml":  yamlString,
+	"date":  func(t time.Time) string { return t.UTC().Format(time.RFC3339) },
+	"slug":  slugify,
+	"clock": clock,
+	"blockquote": func(s string) string {
+		return "> " + strings.ReplaceAll(strings.TrimSpace(s), "\n", "\n> ")
+	},
+}
+
+// handleExportNotes writes one Markdown note per starred article, or per
+// article of a feed matching --match, into a notes vault directory. Notes
+// that already exist are left alone so edits made in the vault survive.
+func handleExportNotes(database store) {
+	fs := flag.NewFlagSet("export-notes", flag.ExitOnError)
+	dir := fs.String("dir", "", "Directory to write notes into")
+	tmplPath := fs.String("template", "", "Go text/template for each note (default: front matter and summary)")
+	feedName := fs.String("feed-name", "", "Export this feed's articles instead of starred ones")
+	num := fs.Int("num", 50, "Number of feed articles to consider with --feed-name")
+	match := fs.String("match", "", "Only export articles whose title or summary contains this text")
+	overwrite := fs.Bool("overwrite", false, "Replace notes that already exist")
+	fs.Parse(os.Args[2:])
+
+	if *dir == "" {
+		fmt.Println("Missing required flag: --dir")
+		os.Exit(1)
+	}
+
+	text := defaultNoteTemplate
+	if *tmplPath != "" {
+		b, err := os.ReadFile(*tmplPath)
+		if err != nil {
+			fmt.Printf("Error reading template: %v\n", err)
+			os.Exit(1)
+		}
+		text = string(b)
+	}
+	tmpl, err := template.New("note").Funcs(noteFuncs).Parse(text)
+	if err != nil {
+		fmt.Printf("Error parsing template: %v\n", err)
+		os.Exit(1)
+	}
+
+	var articles []models.Article
+	if *feedName != "" {
+		articles, err = database.GetArticles(models.ArticleFilter{FeedName: *feedName}, *num)
+	} else {
+		articles, err = database.StarredArticles()
+	}
+	if err != nil {
+		fmt.Printf("Error getting articles: %v\n", err)
+		os.Exit(1)
+	}
+	feeds, err := database.ListFeeds(models.FeedFilter{}, 0)
+	if err != nil {
+		fmt.Printf("Error getting feeds: %v\n", err)
+		os.Exit(1)
+	}
+	feedNames := make(map[uuid.UUID]string, len(feeds))
+	for _, f := range feeds {
+		feedNames[f.ID] = f.Name
+	}
+
+	err = os.MkdirAll(*dir, 0o755)
+	if err != nil {
+		fmt.Printf("Error creating %s: %v\n", *dir, err)
+		os.Exit(1)
+	}
+
+	needle := strings.ToLower(*match)
+	var written, skipped int
+	for _, a := range articles {
+		n := note{
+			ID:          a.ID,
+			Title:       a.Title,
+			Link:        a.Link,
+			Feed:        feedNames[a.FeedID],
+			Published:   a.PublishedAt,
+			Starred:     a.StarredAt,
+			Comments:    commentsLink(a),
+			Description: a.Description,
+			Summary:     stripHTML(a.Description),
+			Season:      a.Season,
+			Episode:     a.Episode,
+			Duration:    a.Duration,
+			Image:       a.ImageURL,
+		}
+		if needle != "" && !strings.Contains(strings.ToLower(n.Title+" "+n.Summary), needle) {
+			continue
+		}
+		n.Notes, err = database.ArticleNotes(a.ID)
+		if err != nil {
+			fmt.Printf("Error loading notes for %s: %v\n", a.ID, err)
+			os.Exit(1)
+		}
+
+		path := filepath.Join(*dir, noteFileName(n))
+		flags := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+		if *overwrite {
+			flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+		}
+		f, err := os.OpenFile(path, flags, 0o644)
+		if errors.Is(err, os.ErrExist) {
+			skipped++
+			continue
+		}
+		if err != nil {
+			fmt.Printf("Error creating %s: %v\n", path, err)
+			os.Exit(1)
+		}
+		err = tmpl.Execute(f, n)
+		f.Close()
+		if err != nil {
+			fmt.Printf("Error writing %s: %v\n", path, err)
+			os.Exit(1)
+		}
+		written++
+	}
+	fmt.Printf("Wrote %d notes to %s", written, *dir)
+	if skipped > 0 {
+		fmt.Printf(" (%d already existed)", skipped)
+	}
+	fmt.Println()
+}
+
+// clock formats an episode duration as H:MM:SS, or M:SS under an hour.
+func clock(d time.Duration) string {
+	s := int64(d / time.Second)
+	if s >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", s/3600, s/60%60, s%60)
+	}
+	return fmt.Sprintf("%d:%02d", s/60, s%60)
+}
+
+// noteFileName is the publication date plus a slug of the title, which
+// sorts notes chronologically and stays stable across exports.
+func noteFileName(n note) string {
+	slug := slugify(n.Title)
+	if slug == "" {
+		slug = n.ID.String()
+	}
+	return n.Published.UTC().Format("2006-01-02") + "-" + slug + ".md"
+}
+
+var (
+	nonSlug = regexp.MustCompile(`[^\p{L}\p{N}]+`)
+	tags    = regexp.MustCompile(`<[^>]*>`)
+	blanks  = regexp.MustCompile(`\n{3,}`)
+)
+
+// maxSlug keeps file names well under common filesystem limits.
+const maxSlug = 80
+
+func slugify(s string) string {
+	s = strings.Trim(nonSlug.ReplaceAllString(strings.ToLower(s), "-"), "-")
+	if r := []rune(s); len(r) > maxSlug {
+		s = strings.TrimRight(string(r[:maxSlug]), "-")
+	}
+	return s
+}
+
+// stripHTML reduces an HTML summary to plain text, keeping paragraph breaks.
+func stripHTML(s string) string {
+	r := strings.NewReplacer("<br>", "\n", "<br/>", "\n", "<br />", "\n", "</p>", "\n\n", "</li>", "\n")
+	s = tags.ReplaceAllString(r.Replace(s), "")
+	s = html.UnescapeString(s)
+	return strings.TrimSpace(blanks.ReplaceAllString(s, "\n\n"))
+}
+
+// yamlString quotes s as a YAML double-quoted scalar.
+func yamlString(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", "", "\t", `\t`)
+	return `"` + r.Replace(s) + `"`
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+
+	"github.com/google/uuid"
+	"rsshub/internal/db"
+	"rsshub/internal/models"
+)
+
+// articleArg resolves an article given on the command line by its ID or
+// a prefix of one, as listings show it, exiting if it names none or
+// several.
+func articleArg(database store, ref string) uuid.UUID {
+	id, err := database.ResolveArticleID(ref)
+	if errors.Is(err, db.ErrInvalidID) || errors.Is(err, db.ErrAmbiguous) || errors.Is(err, db.ErrNotFound) {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Printf("Error looking up article %s: %v\n", ref, err)
+		os.Exit(1)
+	}
+	return id
+}
+
+// handleOpen opens an article's link in the default browser and marks
+// the article read.
+func handleOpen(database store) {
+	if len(os.Args) < 3 {
+		fmt.Println("Usage: rsshub open <article-id>")
+		os.Exit(1)
+	}
+	id := articleArg(database, os.Args[2])
+	article, err := database.GetArticle(id)
+	if err != nil {
+		fmt.Printf("Error loading article: %v\n", err)
+		os.Exit(1)
+	}
+	if article.Link == "" {
+		fmt.Printf("Article %s has no link\n", models.ShortID(id))
+		os.Exit(1)
+	}
+	if err := openBrowser(article.Link); err != nil {
+		fmt.Printf("Error opening %s: %v\n", article.Link, err)
+		os.Exit(1)
+	}
+	err = database.MarkArticleRead(id)
+	if err != nil {
+		fmt.Printf("Error marking article read: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Opened %s\n", article.Link)
+}
+
+// handleMarkRead marks the articles given as arguments read.
+func handleMarkRead(database store) {
+	if len(os.Args) < 3 {
+		fmt.Println("Usage: rsshub mark-read <article-id>...")
+		os.Exit(1)
+	}
+	for _, ref := range os.Args[2:] {
+		id := articleArg(database, ref)
+		err := database.MarkArticleRead(id)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Article marked read: %s\n", models.ShortID(id))
+	}
+}
+
+// openBrowser hands url to the desktop's opener.
+func openBrowser(url string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+	return cmd.Start()
+}
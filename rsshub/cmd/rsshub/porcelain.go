@@ -0,0 +1,40 @@
+package main
+
+import (
+	"strconv"
+
+	"rsshub/internal/models"
+	"rsshub/internal/output"
+)
+
+// Porcelain output is for scripts: one record per line, fields separated
+// by tabs, no header and no colour. Within a field, backslash, tab,
+// newline and carriage return are escaped as \\, \t, \n and \r. Times are
+// RFC 3339 in UTC and empty when unknown. The fields below, and their
+// order, are a contract: they never change between versions.
+
+// feedPorcelain are the fields of list --porcelain:
+//
+//	name  url  created_at  article_count  last_published_at  disabled_at
+var feedPorcelain = []func(models.Feed) string{
+	func(f models.Feed) string { return f.Name },
+	func(f models.Feed) string { return f.URL },
+	func(f models.Feed) string { return output.PorcelainTime(f.CreatedAt) },
+	func(f models.Feed) string { return strconv.FormatInt(f.ArticleCount, 10) },
+	func(f models.Feed) string { return output.PorcelainTime(f.LastPublishedAt) },
+	func(f models.Feed) string { return output.PorcelainTime(f.DisabledAt) },
+}
+
+// articlePorcelain are the fields of articles --porcelain:
+//
+//	id  feed_id  published_at  title  link  author  read_at  starred_at
+var articlePorcelain = []func(models.Article) string{
+	func(a models.Article) string { return a.ID.String() },
+	func(a models.Article) string { return a.FeedID.String() },
+	func(a models.Article) string { return output.PorcelainTime(a.PublishedAt) },
+	func(a models.Article) string { return a.Title },
+	func(a models.Article) string { return a.Link },
+	func(a models.Article) string { return a.Author },
+	func(a models.Article) string { return output.PorcelainTime(a.ReadAt) },
+	func(a models.Article) string { return output.PorcelainTime(a.StarredAt) },
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"rsshub/internal/config"
+	"rsshub/internal/models"
+	"rsshub/internal/rss"
+)
+
+// previewSummaryLen caps the summary line printed under each item.
+const previewSummaryLen = 160
+
+// handlePreview fetches a feed and prints its newest items without
+// touching the database, to judge a feed before adding it.
+func handlePreview(cfg *config.Config) {
+	fs := flag.NewFlagSet("preview", flag.ExitOnError)
+	feedURL := fs.String("url", "", "URL of the feed to preview")
+	num := fs.Int("num", 5, "Number of items to show")
+	fs.Parse(os.Args[2:])
+
+	if *feedURL == "" {
+		fmt.Println("Missing required flag: --url")
+		os.Exit(1)
+	}
+
+	feed, err := rss.NewFetcher(cfg).FetchAndParse(cmdCtx, *feedURL)
+	if err != nil {
+		fmt.Printf("Error fetching %s: %v\n", *feedURL, err)
+		os.Exit(1)
+	}
+
+	type dated struct {
+		item models.RSSItem
+		at   time.Time
+	}
+	items := make([]dated, 0, len(feed.Channel.Item))
+	undated := 0
+	for _, item := range feed.Channel.Item {
+		at, err := rss.ParsePubDate(rss.ItemDate(item))
+		if err != nil {
+			undated++
+		}
+		items = append(items, dated{item, at})
+	}
+	// Undated items sort last; rsshub would date them when it first sees
+	// them, or by the response's Last-Modified.
+	sort.SliceStable(items, func(i, j int) bool { return items[i].at.After(items[j].at) })
+
+	fmt.Printf("Feed: %s\n", feed.Channel.Title)
+	if feed.Channel.Description != "" {
+		fmt.Printf("      %s\n", truncate(feed.Channel.Description, previewSummaryLen))
+	}
+	fmt.Printf("%d items", len(items))
+	if undated > 0 {
+		fmt.Printf(", %d without a usable date (run lint for details)", undated)
+	}
+	fmt.Print("\n\n")
+
+	for i, d := range items {
+		if i >= *num {
+			break
+		}
+		date := "undated   "
+		if !d.at.IsZero() {
+			date = d.at.Format("2006-01-02")
+		}
+		fmt.Printf("%d. [%s] %s\n   %s\n", i+1, date, d.item.Title, d.item.Link)
+		if author := rss.ItemAuthor(d.item); author != "" {
+			fmt.Printf("   by %s\n", author)
+		}
+		// The description is the excerpt where a feed has both; fall back
+		// to the body for feeds that only send content:encoded.
+		excerpt := d.item.Description
+		if strings.TrimSpace(excerpt) == "" {
+			excerpt = rss.ItemBody(d.item)
+		}
+		if summary := strings.Join(strings.Fields(stripHTML(excerpt)), " "); summary != "" {
+			fmt.Printf("   %s\n", truncate(summary, previewSummaryLen))
+		}
+		fmt.Println()
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"rsshub/internal/api"
+	"rsshub/internal/models"
+	"rsshub/internal/output"
+)
+
+// handleRandom picks articles from the whole archive at random, for
+// rediscovering what was missed or forgotten.
+func handleRandom(database store) {
+	fs := flag.NewFlagSet("random", flag.ExitOnError)
+	feedName := fs.String("feed-name", "", "Only articles of this feed")
+	unread := fs.Bool("unread", false, "Only unread articles")
+	starred := fs.Bool("starred", false, "Only starred articles (with --unread, unread or starred ones)")
+	olderThan := fs.Duration("older-than", 0, "Only articles published at least this long ago, e.g. 720h")
+	tag := fs.String("tag", "", "Only articles of feeds with this tag")
+	num := fs.Int("num", 1, "Number of articles to pick")
+	formatFlag := fs.String("format", "text", `Output format: text, table, json, or a template such as '{{.Title}}\t{{.Link}}'`)
+	showIDs := fs.Bool("ids", false, "Show article IDs")
+	fs.Parse(os.Args[2:])
+
+	if *tag != "" {
+		fmt.Println("Feeds have no tags to pick from yet; use --feed-name to pick from one feed")
+		os.Exit(1)
+	}
+	f := models.RandomFilter{FeedName: *feedName, Unread: *unread, Starred: *starred, OlderThan: *olderThan}
+	showPicked(database, f, *num, *formatFlag, *showIDs, "# Picked at random")
+}
+
+// handleResurface brings back a few old articles that were starred or
+// never read. Run it now and then, e.g. from cron, for a different few
+// each time.
+func handleResurface(database store) {
+	fs := flag.NewFlagSet("resurface", flag.ExitOnError)
+	feedName := fs.String("feed-name", "", "Only articles of this feed")
+	olderThan := fs.Duration("older-than", 30*24*time.Hour, "Only articles published at least this long ago")
+	num := fs.Int("num", 3, "Number of articles to bring back")
+	formatFlag := fs.String("format", "text", `Output format: text, table, json, or a template such as '{{.Title}}\t{{.Link}}'`)
+	showIDs := fs.Bool("ids", false, "Show article IDs")
+	fs.Parse(os.Args[2:])
+
+	if *olderThan <= 0 {
+		fmt.Println("--older-than must be positive")
+		os.Exit(1)
+	}
+	f := models.RandomFilter{FeedName: *feedName, Unread: true, Starred: true, OlderThan: *olderThan}
+	heading := fmt.Sprintf("# From the archive: unread or starred, published over %s ago", age(*olderThan))
+	showPicked(database, f, *num, *formatFlag, *showIDs, heading)
+}
+
+// showPicked picks num articles f selects and prints them in the format
+// asked for, under heading when printed as text.
+func showPicked(database store, f models.RandomFilter, num int, formatFlag string, ids bool, heading string) {
+	if num < 1 {
+		fmt.Println("--num must be at least 1")
+		os.Exit(1)
+	}
+	format, err := output.Parse(formatFlag, "text")
+	if err != nil {
+		fmt.Printf("Invalid --format: %v\n", err)
+		os.Exit(1)
+	}
+	articles, err := database.RandomArticles(f, num)
+	if err != nil {
+		fmt.Printf("Error picking articles: %v\n", err)
+		os.Exit(1)
+	}
+	if format.Name != "text" {
+		err = output.Write(os.Stdout, format, articles, articleColumns(ids), func(a models.Article) any {
+			return api.ArticleJSON(a)
+		})
+		if err != nil {
+			fmt.Printf("Error writing articles: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if len(articles) == 0 {
+		fmt.Println("No articles to pick from")
+		return
+	}
+	fmt.Printf("%s\n\n", heading)
+	printArticles(articles, ids)
+}
+
+// age describes a duration in days when it is a whole number of them.
+func age(d time.Duration) string {
+	const day = 24 * time.Hour
+	switch {
+	case d == day:
+		return "1 day"
+	case d%day == 0:
+		return fmt.Sprintf("%d days", d/day)
+	}
+	return d.String()
+}
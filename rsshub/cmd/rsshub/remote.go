@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"rsshub/client"
+	"rsshub/internal/config"
+	"rsshub/internal/output"
+)
+
+// globalFlags are the options given ahead of the command. Each one given
+// overrides its environment variable.
+type globalFlags struct {
+	profile *string
+	server  *string
+	token   *string
+	socket  *string
+	timeout *string
+}
+
+// parseGlobalFlags consumes --profile, --server, --token, --socket and
+// --timeout (as "--flag value" or "--flag=value") and --no-color ahead of
+// the command, and strips them from os.Args so every command sees its own
+// arguments at os.Args[2:]. They are parsed before the configuration is loaded because
+// --profile decides what it is loaded from; apply then sets the rest.
+func parseGlobalFlags() globalFlags {
+	var g globalFlags
+	args := os.Args[1:]
+	for len(args) > 0 && strings.HasPrefix(args[0], "--") {
+		name, value, hasValue := strings.Cut(strings.TrimPrefix(args[0], "--"), "=")
+		var target **string
+		switch name {
+		case "no-color":
+			output.DisableColor()
+			args = args[1:]
+			continue
+		case "profile":
+			target = &g.profile
+		case "server":
+			target = &g.server
+		case "token":
+			target = &g.token
+		case "socket":
+			target = &g.socket
+		case "timeout":
+			target = &g.timeout
+		default:
+			// Not a global flag, e.g. --help.
+			os.Args = append(os.Args[:1], args...)
+			return g
+		}
+		args = args[1:]
+		if !hasValue {
+			if len(args) == 0 {
+				fmt.Printf("Error: --%s requires a value\n", name)
+				os.Exit(1)
+			}
+			value, args = args[0], args[1:]
+		}
+		*target = &value
+	}
+	os.Args = append(os.Args[:1], args...)
+	return g
+}
+
+// applyProfile applies the settings of the profile given with --profile,
+// or else CLI_APP_PROFILE, before the configuration is loaded.
+func (g globalFlags) applyProfile() {
+	name := os.Getenv("CLI_APP_PROFILE")
+	if g.profile != nil {
+		name = *g.profile
+	}
+	if name == "" {
+		return
+	}
+	if err := config.ApplyProfile(name); err != nil {
+		fmt.Printf("Error: profile %s: %v\n", name, err)
+		os.Exit(1)
+	}
+}
+
+// apply overrides the loaded configuration with the flags given.
+func (g globalFlags) apply(cfg *config.Config) {
+	if g.server != nil {
+		cfg.Server = *g.server
+	}
+	if g.token != nil {
+		cfg.APIToken = *g.token
+	}
+	if g.socket != nil {
+		cfg.SocketPath = *g.socket
+	}
+	if cfg.Server != "" {
+		if err := config.CheckServerURL(cfg.Server); err != nil {
+			fmt.Printf("Error: --server %v\n", err)
+			os.Exit(1)
+		}
+	}
+}
+
+// newAPIClient returns a client for the API at base that authenticates
+// with the configured token.
+func newAPIClient(cfg *config.Config, base string) *client.Client {
+	c := client.New(strings.TrimRight(base, "/"))
+	c.Token = cfg.APIToken
+	c.Actor = currentUser()
+	return c
+}
+
+// remoteControl sends a control command to the remote server's API.
+func remoteControl(cfg *config.Config, command string) (string, error) {
+	ctx, cancel := context.WithTimeout(cmdCtx, apiTimeout)
+	defer cancel()
+	return newAPIClient(cfg, cfg.Server).Control(ctx, command)
+}
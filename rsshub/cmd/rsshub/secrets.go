@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"rsshub/internal/config"
+	"rsshub/internal/db"
+	"rsshub/internal/output"
+	"rsshub/internal/secretbox"
+)
+
+// handleSecrets manages the credentials kept encrypted in the database
+// under CLI_APP_SECRETS_KEY.
+func handleSecrets(cfg *config.Config, database *db.DB) {
+	usage := "Usage: rsshub secrets list | set|delete <name> [--feed-name NAME] | new-key | rotate-key"
+	if len(os.Args) < 3 {
+		fmt.Println(usage)
+		os.Exit(1)
+	}
+	actor := db.Actor{Name: currentUser(), Source: db.SourceCLI}
+
+	var err error
+	switch sub := os.Args[2]; sub {
+	case "list":
+		err = listSecrets(cfg, database)
+	case "set", "delete":
+		if len(os.Args) < 4 || strings.HasPrefix(os.Args[3], "-") {
+			fmt.Println(usage)
+			os.Exit(1)
+		}
+		name := os.Args[3]
+		fs := flag.NewFlagSet("secrets "+sub, flag.ExitOnError)
+		feedName := fs.String("feed-name", "", "Feed the secret belongs to; none for a global secret")
+		fs.Parse(os.Args[4:])
+		target := name
+		if *feedName != "" {
+			target = *feedName + "/" + name
+		}
+		if sub == "delete" {
+			err = database.DeleteSecret(*feedName, name)
+			if err == nil {
+				database.Audit(actor, "secret-delete", target, "", "")
+				fmt.Printf("Secret deleted: %s\n", target)
+			}
+			break
+		}
+		var key *secretbox.Key
+		key, err = secretsKey(cfg)
+		if err != nil {
+			break
+		}
+		var value string
+		value, err = readSecretLine("Value: ")
+		if err == nil {
+			err = database.SetSecret(key, *feedName, name, []byte(value))
+		}
+		if err == nil {
+			database.Audit(actor, "secret-set", target, "", "key "+key.ID())
+			fmt.Printf("Secret stored: %s\n", target)
+		}
+	case "rotate-key":
+		err = rotateSecretsKey(cfg, database, actor)
+	default:
+		fmt.Println(usage)
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// handleNewSecretsKey prints a fresh key for CLI_APP_SECRETS_KEY. It
+// needs no database.
+func handleNewSecretsKey() {
+	key, err := secretbox.NewKey()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(key)
+}
+
+// secretsKey parses CLI_APP_SECRETS_KEY, which LoadConfig has checked.
+func secretsKey(cfg *config.Config) (*secretbox.Key, error) {
+	if cfg.SecretsKey == "" {
+		return nil, errors.New("CLI_APP_SECRETS_KEY is not set; generate a key with rsshub secrets new-key")
+	}
+	return secretbox.ParseKey(cfg.SecretsKey)
+}
+
+func listSecrets(cfg *config.Config, database *db.DB) error {
+	secrets, err := database.ListSecrets()
+	if err != nil {
+		return err
+	}
+	if len(secrets) == 0 {
+		fmt.Println("No secrets")
+		return nil
+	}
+	current := ""
+	if key, err := secretsKey(cfg); err == nil {
+		current = key.ID()
+	}
+	fmt.Printf("%-24s  %-24s  %-10s  %s\n", "NAME", "FEED", "KEY", "UPDATED")
+	stale := 0
+	for _, s := range secrets {
+		keyID := s.KeyID
+		if keyID != current {
+			keyID += "*"
+			stale++
+		}
+		fmt.Printf("%-24s  %-24s  %-10s  %s\n", truncate(s.Name, 24), truncate(s.FeedName, 24), keyID, output.Date(s.UpdatedAt))
+	}
+	if stale > 0 {
+		fmt.Printf("\n* %d secrets are not encrypted with CLI_APP_SECRETS_KEY and cannot be read with it\n", stale)
+	}
+	return nil
+}
+
+// rotateSecretsKey re-encrypts every secret from CLI_APP_SECRETS_KEY to a
+// new key read from standard input, which then replaces it.
+func rotateSecretsKey(cfg *config.Config, database *db.DB, actor db.Actor) error {
+	oldKey, err := secretsKey(cfg)
+	if err != nil {
+		return err
+	}
+	line, err := readSecretLine("New key: ")
+	if err != nil {
+		return err
+	}
+	newKey, err := secretbox.ParseKey(line)
+	if err != nil {
+		return fmt.Errorf("new key: %w", err)
+	}
+	if newKey.ID() == oldKey.ID() {
+		return errors.New("the new key is the current one")
+	}
+	n, err := database.RotateSecretsKey(oldKey, newKey)
+	if err != nil {
+		return err
+	}
+	database.Audit(actor, "secrets-rotate-key", "", oldKey.ID(), newKey.ID())
+	fmt.Printf("Re-encrypted %d secrets with key %s; set CLI_APP_SECRETS_KEY to the new key and restart the daemon\n", n, newKey.ID())
+	return nil
+}
+
+// readSecretLine reads the first line of standard input, so it can be
+// piped in by scripts, prompting on standard error. Typed at a terminal,
+// the line is not echoed.
+func readSecretLine(prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	if restore, ok := disableEcho(os.Stdin); ok {
+		defer fmt.Fprintln(os.Stderr)
+		defer restore()
+	}
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("reading %s: %w", strings.ToLower(strings.TrimSuffix(prompt, ": ")), err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
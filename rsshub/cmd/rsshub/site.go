@@ -0,0 +1,206 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"rsshub/internal/models"
+)
+
+// siteFeed is one feed's page in a generated site.
+type siteFeed struct {
+	Name     string
+	URL      string
+	Page     string
+	Articles []siteArticle
+}
+
+type siteArticle struct {
+	Title     string
+	Link      string
+	Feed      string
+	FeedPage  string
+	Published time.Time
+	Summary   string
+	Comments  string
+}
+
+type sitePage struct {
+	Title    string
+	Heading  string
+	Root     string
+	Built    time.Time
+	Feeds    []*siteFeed
+	Feed     *siteFeed
+	Articles []siteArticle
+}
+
+// siteSummaryLen caps the plain-text summary shown under each article.
+const siteSummaryLen = 300
+
+var siteTemplate = template.Must(template.New("page").Funcs(template.FuncMap{
+	"date": func(t time.Time) string { return t.UTC().Format("2006-01-02") },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{if .Heading}}{{.Heading}} · {{end}}{{.Title}}</title>
+<link rel="stylesheet" href="{{.Root}}style.css">
+</head>
+<body>
+<header><a href="{{.Root}}index.html">{{.Title}}</a></header>
+<main>
+{{- if .Feed}}
+<h1>{{.Feed.Name}}</h1>
+<p class="source"><a href="{{.Feed.URL}}">{{.Feed.URL}}</a></p>
+{{- end}}
+{{- range .Articles}}
+<article>
+<h2><a href="{{.Link}}">{{.Title}}</a></h2>
+<p class="meta">{{date .Published}}{{if not $.Feed}} · <a href="{{$.Root}}{{.FeedPage}}">{{.Feed}}</a>{{end}}{{if .Comments}} · <a href="{{.Comments}}">comments</a>{{end}}</p>
+{{- if .Summary}}
+<p>{{.Summary}}</p>
+{{- end}}
+</article>
+{{- else}}
+<p>No articles yet.</p>
+{{- end}}
+</main>
+<nav>
+<h2>Feeds</h2>
+<ul>
+{{- range .Feeds}}
+<li><a href="{{$.Root}}{{.Page}}">{{.Name}}</a> ({{len .Articles}})</li>
+{{- end}}
+</ul>
+</nav>
+<footer>Generated by rsshub on {{date .Built}}</footer>
+</body>
+</html>
+`))
+
+const siteCSS = `body{font-family:system-ui,sans-serif;max-width:60rem;margin:0 auto;padding:1rem;display:grid;grid-template-columns:1fr 14rem;gap:2rem;color:#222}
+header,footer{grid-column:1/-1}
+header a{font-size:1.5rem;font-weight:bold;text-decoration:none;color:inherit}
+article{margin-bottom:1.5rem}
+article h2{font-size:1.1rem;margin:0}
+.meta,.source,footer{color:#666;font-size:.85rem}
+nav ul{list-style:none;padding:0}
+@media (max-width:40rem){body{grid-template-columns:1fr}}
+`
+
+// handleBuildSite renders recent articles into a static site: an index of
+// the newest articles across feeds and one page per feed, suitable for
+// GitHub Pages or any static host.
+func handleBuildSite(database store) {
+	fs := flag.NewFlagSet("build-site", flag.ExitOnError)
+	out := fs.String("out", "public", "Directory to write the site into")
+	title := fs.String("title", "rsshub", "Site title")
+	num := fs.Int("num", 20, "Number of articles per feed page")
+	indexNum := fs.Int("index-num", 50, "Number of articles on the index page")
+	fs.Parse(os.Args[2:])
+
+	feeds, err := database.ListFeeds(models.FeedFilter{}, 0)
+	if err != nil {
+		fmt.Printf("Error getting feeds: %v\n", err)
+		os.Exit(1)
+	}
+	sort.Slice(feeds, func(i, j int) bool { return strings.ToLower(feeds[i].Name) < strings.ToLower(feeds[j].Name) })
+
+	var site []*siteFeed
+	var all []siteArticle
+	used := map[string]bool{}
+	for _, f := range feeds {
+		articles, err := database.GetArticles(models.ArticleFilter{FeedName: f.Name}, *num)
+		if err != nil {
+			fmt.Printf("Error getting articles for %s: %v\n", f.Name, err)
+			os.Exit(1)
+		}
+		page := sitePageName(f)
+		if used[page] {
+			page = f.ID.String()
+		}
+		used[page] = true
+		sf := &siteFeed{Name: f.Name, URL: f.URL, Page: "feeds/" + page + ".html"}
+		for _, a := range articles {
+			sa := siteArticleFrom(a, sf)
+			sf.Articles = append(sf.Articles, sa)
+			all = append(all, sa)
+		}
+		site = append(site, sf)
+	}
+	sort.SliceStable(all, func(i, j int) bool { return all[i].Published.After(all[j].Published) })
+	if len(all) > *indexNum {
+		all = all[:*indexNum]
+	}
+
+	err = os.MkdirAll(filepath.Join(*out, "feeds"), 0o755)
+	if err != nil {
+		fmt.Printf("Error creating %s: %v\n", *out, err)
+		os.Exit(1)
+	}
+	built := time.Now()
+	pages := map[string]sitePage{
+		"index.html": {Title: *title, Built: built, Feeds: site, Articles: all},
+	}
+	for _, sf := range site {
+		pages[sf.Page] = sitePage{Title: *title, Heading: sf.Name, Root: "../", Built: built, Feeds: site, Feed: sf, Articles: sf.Articles}
+	}
+	for name, page := range pages {
+		err = writeSitePage(filepath.Join(*out, name), page)
+		if err != nil {
+			fmt.Printf("Error writing %s: %v\n", name, err)
+			os.Exit(1)
+		}
+	}
+	err = os.WriteFile(filepath.Join(*out, "style.css"), []byte(siteCSS), 0o644)
+	if err != nil {
+		fmt.Printf("Error writing style.css: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Built %d pages for %d feeds in %s\n", len(pages), len(site), *out)
+}
+
+// sitePageName keeps feed page URLs readable, falling back to the ID for
+// names with no usable characters. Names that slug alike also use the ID.
+func sitePageName(f models.Feed) string {
+	if slug := slugify(f.Name); slug != "" {
+		return slug
+	}
+	return f.ID.String()
+}
+
+func siteArticleFrom(a models.Article, sf *siteFeed) siteArticle {
+	summary := strings.Join(strings.Fields(stripHTML(a.Description)), " ")
+	if r := []rune(summary); len(r) > siteSummaryLen {
+		summary = strings.TrimSpace(string(r[:siteSummaryLen])) + "…"
+	}
+	return siteArticle{
+		Title:     a.Title,
+		Link:      a.Link,
+		Feed:      sf.Name,
+		FeedPage:  sf.Page,
+		Published: a.PublishedAt,
+		Summary:   summary,
+		Comments:  commentsLink(a),
+	}
+}
+
+func writeSitePage(path string, page sitePage) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = siteTemplate.Execute(f, page)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,618 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/user"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"rsshub/client"
+	"rsshub/internal/config"
+	"rsshub/internal/db"
+	"rsshub/internal/models"
+)
+
+// store is what the feed and article commands need. It is served by the
+// running daemon's HTTP API when available, so the database can be locked
+// down to the daemon's credentials, and by the database directly otherwise.
+type store interface {
+	AddFeed(feed *models.Feed) error
+	ListFeeds(f models.FeedFilter, limit int) ([]models.Feed, error)
+	DeleteFeed(name string) error
+	PurgeFeed(name string) error
+	PurgeFeedKeepArticles(name string) (int64, error)
+	ArchivedArticles(feedName string, limit int) ([]models.ArchivedArticle, error)
+	RestoreFeed(name string) error
+	EnableFeed(name string) error
+	FeedImpact(name string) (models.FeedImpact, error)
+	MergeFeeds(from, into string) (models.MergeResult, error)
+	GetFeedQuirks(name string) (models.FeedQuirks, error)
+	SetFeedQuirks(name string, q models.FeedQuirks) error
+	GetArticles(f models.ArticleFilter, limit int) ([]models.Article, error)
+	StreamArticles(f models.ArticleFilter, limit int, fn func(models.Article) error) error
+	RandomArticles(f models.RandomFilter, n int) ([]models.Article, error)
+	GetAuthorStats(feedName string, limit int) ([]models.AuthorStats, error)
+	ListAudit(target string, limit int) ([]models.AuditEntry, error)
+	ResolveArticleID(ref string) (uuid.UUID, error)
+	GetArticle(id uuid.UUID) (models.Article, error)
+	GetArticleRevisions(id uuid.UUID) ([]models.ArticleRevision, error)
+	SetArticleStarred(id uuid.UUID, starred bool) error
+	MarkArticleRead(id uuid.UUID) error
+	StarredArticles() ([]models.Article, error)
+	AddNote(articleID uuid.UUID, quote, text string) (models.ArticleNote, error)
+	DeleteNote(id int64) error
+	ArticleNotes(articleID uuid.UUID) ([]models.ArticleNote, error)
+	SearchNotes(query string, limit int) ([]models.ArticleNote, error)
+}
+
+const apiTimeout = 30 * time.Second
+
+// openStore uses the remote server in remote mode. Otherwise it picks the
+// local daemon's API if it is running and exposes one, falling back to a
+// direct database connection.
+func openStore(cfg *config.Config) (store, func()) {
+	if cfg.Server != "" {
+		return &apiStore{c: newAPIClient(cfg, cfg.Server)}, func() {}
+	}
+	if base := daemonAPIURL(); base != "" {
+		return &apiStore{c: newAPIClient(cfg, base)}, func() {}
+	}
+	database, err := db.NewDB(cfg)
+	if err != nil {
+		fmt.Printf("Error connecting to database: %v\n", err)
+		os.Exit(1)
+	}
+	return database.WithContext(cmdCtx).As(db.Actor{Name: currentUser(), Source: db.SourceCLI}), func() { database.Close() }
+}
+
+// daemonAPIURL asks the local daemon where its HTTP API listens. It returns
+// "" if the daemon is not running or serves no API.
+func daemonAPIURL() string {
+	reply, err := controlRequest("api-addr")
+	if err != nil {
+		return ""
+	}
+	addr := strings.TrimSpace(reply)
+	if addr == "" || addr == "none" {
+		return ""
+	}
+	hostPort, basePath, _ := strings.Cut(addr, "/")
+	host, port, err := net.SplitHostPort(hostPort)
+	if err != nil {
+		return ""
+	}
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "127.0.0.1"
+	}
+	if basePath != "" {
+		basePath = "/" + basePath
+	}
+	return "http://" + net.JoinHostPort(host, port) + basePath
+}
+
+// apiStore implements store over the REST API.
+type apiStore struct {
+	c *client.Client
+}
+
+func (s *apiStore) AddFeed(feed *models.Feed) error {
+	ctx, cancel := context.WithTimeout(cmdCtx, apiTimeout)
+	defer cancel()
+	_, err := s.c.AddFeed(ctx, client.NewFeed{Name: feed.Name, URL: feed.URL})
+	return err
+}
+
+// ListFeeds filters on the server. The API only lists feeds newest first,
+// so other orders fetch every matching feed and sort them here.
+func (s *apiStore) ListFeeds(f models.FeedFilter, limit int) ([]models.Feed, error) {
+	ctx, cancel := context.WithTimeout(cmdCtx, apiTimeout)
+	defer cancel()
+	sorted := f.Sort == "" || f.Sort == models.FeedSortAdded
+	var feeds []models.Feed
+	opts := client.FeedOptions{Match: f.Match, Errored: f.Errored, Disabled: f.Disabled, Wide: f.Wide}
+	for {
+		page, err := s.c.ListFeeds(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, feed := range page.Feeds {
+			feeds = append(feeds, feedFromAPI(feed))
+			if sorted && limit > 0 && len(feeds) == limit {
+				return feeds, nil
+			}
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		opts.Cursor = page.NextCursor
+	}
+	if !sorted {
+		sortFeeds(feeds, f.Sort)
+	}
+	if limit > 0 && len(feeds) > limit {
+		feeds = feeds[:limit]
+	}
+	return feeds, nil
+}
+
+// sortFeeds orders feeds as the database does for the same sort.
+func sortFeeds(feeds []models.Feed, order string) {
+	sort.SliceStable(feeds, func(i, j int) bool {
+		a, b := feeds[i], feeds[j]
+		switch order {
+		case models.FeedSortUpdated:
+			if !a.UpdatedAt.Equal(b.UpdatedAt) {
+				return a.UpdatedAt.After(b.UpdatedAt)
+			}
+		case models.FeedSortArticles:
+			if a.ArticleCount != b.ArticleCount {
+				return a.ArticleCount > b.ArticleCount
+			}
+		}
+		return a.Name < b.Name
+	})
+}
+
+func (s *apiStore) DeleteFeed(name string) error {
+	ctx, cancel := context.WithTimeout(cmdCtx, apiTimeout)
+	defer cancel()
+	return s.c.DeleteFeed(ctx, name)
+}
+
+func (s *apiStore) PurgeFeed(name string) error {
+	ctx, cancel := context.WithTimeout(cmdCtx, apiTimeout)
+	defer cancel()
+	return s.c.PurgeFeed(ctx, name)
+}
+
+func (s *apiStore) PurgeFeedKeepArticles(name string) (int64, error) {
+	ctx, cancel := context.WithTimeout(cmdCtx, apiTimeout)
+	defer cancel()
+	return s.c.PurgeFeedKeepArticles(ctx, name)
+}
+
+func (s *apiStore) ArchivedArticles(feedName string, limit int) ([]models.ArchivedArticle, error) {
+	ctx, cancel := context.WithTimeout(cmdCtx, apiTimeout)
+	defer cancel()
+	var articles []models.ArchivedArticle
+	opts := client.ListOptions{}
+	for {
+		opts.Limit = limit - len(articles)
+		page, err := s.c.ArchivedArticles(ctx, feedName, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range page.Articles {
+			articles = append(articles, models.ArchivedArticle{
+				Article:    articleFromAPI(a.Article),
+				FeedName:   a.FeedName,
+				FeedURL:    a.FeedURL,
+				ArchivedAt: a.ArchivedAt,
+			})
+		}
+		if page.NextCursor == "" || len(articles) >= limit {
+			return articles, nil
+		}
+		opts.Cursor = page.NextCursor
+	}
+}
+
+func (s *apiStore) RestoreFeed(name string) error {
+	ctx, cancel := context.WithTimeout(cmdCtx, apiTimeout)
+	defer cancel()
+	_, err := s.c.RestoreFeed(ctx, name)
+	return err
+}
+
+func (s *apiStore) EnableFeed(name string) error {
+	ctx, cancel := context.WithTimeout(cmdCtx, apiTimeout)
+	defer cancel()
+	_, err := s.c.EnableFeed(ctx, name)
+	return err
+}
+
+func (s *apiStore) FeedImpact(name string) (models.FeedImpact, error) {
+	ctx, cancel := context.WithTimeout(cmdCtx, apiTimeout)
+	defer cancel()
+	im, err := s.c.FeedImpact(ctx, name)
+	if err != nil {
+		return models.FeedImpact{}, err
+	}
+	return models.FeedImpact{
+		Deleted:   im.Deleted,
+		Articles:  im.Articles,
+		Read:      im.Read,
+		Starred:   im.Starred,
+		Revisions: im.Revisions,
+	}, nil
+}
+
+func (s *apiStore) GetFeedQuirks(name string) (models.FeedQuirks, error) {
+	ctx, cancel := context.WithTimeout(cmdCtx, apiTimeout)
+	defer cancel()
+	q, err := s.c.FeedQuirks(ctx, name)
+	if err != nil {
+		return models.FeedQuirks{}, err
+	}
+	return models.FeedQuirks{
+		DateLayout:       q.DateLayout,
+		InsecureTLS:      q.InsecureTLS,
+		CABundle:         q.CABundle,
+		CertPins:         q.CertPins,
+		PlainDescription: q.PlainDescription,
+		StripTitlePrefix: q.StripTitlePrefix,
+	}, nil
+}
+
+func (s *apiStore) SetFeedQuirks(name string, q models.FeedQuirks) error {
+	ctx, cancel := context.WithTimeout(cmdCtx, apiTimeout)
+	defer cancel()
+	_, err := s.c.SetFeedQuirks(ctx, name, client.FeedQuirks{
+		DateLayout:       q.DateLayout,
+		InsecureTLS:      q.InsecureTLS,
+		CABundle:         q.CABundle,
+		CertPins:         q.CertPins,
+		PlainDescription: q.PlainDescription,
+		StripTitlePrefix: q.StripTitlePrefix,
+	})
+	return err
+}
+
+func (s *apiStore) MergeFeeds(from, into string) (models.MergeResult, error) {
+	ctx, cancel := context.WithTimeout(cmdCtx, apiTimeout)
+	defer cancel()
+	res, err := s.c.MergeFeed(ctx, from, into)
+	if err != nil {
+		return models.MergeResult{}, err
+	}
+	return models.MergeResult{Moved: res.Moved, Duplicates: res.Duplicates}, nil
+}
+
+func (s *apiStore) GetArticles(f models.ArticleFilter, limit int) ([]models.Article, error) {
+	ctx, cancel := context.WithTimeout(cmdCtx, apiTimeout)
+	defer cancel()
+	var articles []models.Article
+	opts := client.ArticleOptions{Feed: f.FeedName, Author: f.Author, MaxReadTime: f.MaxReadTime, ShowMuted: f.ShowMuted}
+	if f.Near != nil {
+		opts.Near = fmt.Sprintf("%g,%g", f.Near.Lat, f.Near.Lon)
+		opts.Radius = fmt.Sprintf("%gkm", f.RadiusKM)
+	}
+	for {
+		opts.Limit = limit - len(articles)
+		page, err := s.c.ListArticles(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range page.Articles {
+			articles = append(articles, articleFromAPI(a))
+		}
+		if page.NextCursor == "" || len(articles) >= limit {
+			return articles, nil
+		}
+		opts.Cursor = page.NextCursor
+	}
+}
+
+// StreamArticles hands over each page of articles as it arrives, so only
+// one page is held at a time; a limit of 0 means all of them.
+func (s *apiStore) StreamArticles(f models.ArticleFilter, limit int, fn func(models.Article) error) error {
+	opts := client.ArticleOptions{Feed: f.FeedName, Author: f.Author, MaxReadTime: f.MaxReadTime, ShowMuted: f.ShowMuted}
+	if f.Near != nil {
+		opts.Near = fmt.Sprintf("%g,%g", f.Near.Lat, f.Near.Lon)
+		opts.Radius = fmt.Sprintf("%gkm", f.RadiusKM)
+	}
+	for seen := 0; ; {
+		if limit > 0 {
+			opts.Limit = limit - seen
+		}
+		ctx, cancel := context.WithTimeout(cmdCtx, apiTimeout)
+		page, err := s.c.ListArticles(ctx, opts)
+		cancel()
+		if err != nil {
+			return err
+		}
+		for _, a := range page.Articles {
+			if err := fn(articleFromAPI(a)); err != nil {
+				return err
+			}
+			seen++
+		}
+		if page.NextCursor == "" || (limit > 0 && seen >= limit) {
+			return nil
+		}
+		opts.Cursor = page.NextCursor
+	}
+}
+
+func (s *apiStore) RandomArticles(f models.RandomFilter, n int) ([]models.Article, error) {
+	ctx, cancel := context.WithTimeout(cmdCtx, apiTimeout)
+	defer cancel()
+	page, err := s.c.RandomArticles(ctx, client.RandomOptions{
+		Limit:     n,
+		Feed:      f.FeedName,
+		Unread:    f.Unread,
+		Starred:   f.Starred,
+		OlderThan: f.OlderThan,
+	})
+	if err != nil {
+		return nil, err
+	}
+	articles := make([]models.Article, len(page.Articles))
+	for i, a := range page.Articles {
+		articles[i] = articleFromAPI(a)
+	}
+	return articles, nil
+}
+
+func (s *apiStore) GetAuthorStats(feedName string, limit int) ([]models.AuthorStats, error) {
+	ctx, cancel := context.WithTimeout(cmdCtx, apiTimeout)
+	defer cancel()
+	list, err := s.c.ListAuthors(ctx, feedName, limit)
+	if err != nil {
+		return nil, err
+	}
+	stats := make([]models.AuthorStats, len(list.Authors))
+	for i, a := range list.Authors {
+		stats[i] = models.AuthorStats{
+			Author:          a.Author,
+			Articles:        a.Articles,
+			Feeds:           a.Feeds,
+			LastPublishedAt: a.LastPublishedAt,
+		}
+	}
+	return stats, nil
+}
+
+func (s *apiStore) ListAudit(target string, limit int) ([]models.AuditEntry, error) {
+	ctx, cancel := context.WithTimeout(cmdCtx, apiTimeout)
+	defer cancel()
+	var entries []models.AuditEntry
+	opts := client.ListOptions{}
+	for {
+		opts.Limit = limit - len(entries)
+		page, err := s.c.ListAudit(ctx, target, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range page.Entries {
+			entries = append(entries, models.AuditEntry{
+				ID:       e.ID,
+				At:       e.At,
+				Actor:    e.Actor,
+				Source:   e.Source,
+				Action:   e.Action,
+				Target:   e.Target,
+				OldValue: e.OldValue,
+				NewValue: e.NewValue,
+			})
+		}
+		if page.NextCursor == "" || len(entries) >= limit {
+			return entries, nil
+		}
+		opts.Cursor = page.NextCursor
+	}
+}
+
+// ResolveArticleID has the server resolve a prefix of an article ID, as
+// its article routes accept one.
+func (s *apiStore) ResolveArticleID(ref string) (uuid.UUID, error) {
+	if id, err := uuid.Parse(ref); err == nil {
+		return id, nil
+	}
+	ctx, cancel := context.WithTimeout(cmdCtx, apiTimeout)
+	defer cancel()
+	a, err := s.c.GetArticle(ctx, ref)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return uuid.Parse(a.ID)
+}
+
+func (s *apiStore) GetArticle(id uuid.UUID) (models.Article, error) {
+	ctx, cancel := context.WithTimeout(cmdCtx, apiTimeout)
+	defer cancel()
+	a, err := s.c.GetArticle(ctx, id.String())
+	if err != nil {
+		return models.Article{}, err
+	}
+	return articleFromAPI(*a), nil
+}
+
+func (s *apiStore) GetArticleRevisions(id uuid.UUID) ([]models.ArticleRevision, error) {
+	ctx, cancel := context.WithTimeout(cmdCtx, apiTimeout)
+	defer cancel()
+	list, err := s.c.ArticleRevisions(ctx, id.String())
+	if err != nil {
+		return nil, err
+	}
+	revisions := make([]models.ArticleRevision, 0, len(list.Revisions))
+	for _, r := range list.Revisions {
+		revisions = append(revisions, models.ArticleRevision{
+			ArticleID:   id,
+			Title:       r.Title,
+			Description: r.Description,
+			ValidFrom:   r.ValidFrom,
+			ReplacedAt:  r.ReplacedAt,
+		})
+	}
+	return revisions, nil
+}
+
+func (s *apiStore) SetArticleStarred(id uuid.UUID, starred bool) error {
+	ctx, cancel := context.WithTimeout(cmdCtx, apiTimeout)
+	defer cancel()
+	return s.c.SetStarred(ctx, id.String(), starred)
+}
+
+func (s *apiStore) MarkArticleRead(id uuid.UUID) error {
+	ctx, cancel := context.WithTimeout(cmdCtx, apiTimeout)
+	defer cancel()
+	return s.c.MarkRead(ctx, id.String())
+}
+
+func (s *apiStore) StarredArticles() ([]models.Article, error) {
+	ctx, cancel := context.WithTimeout(cmdCtx, apiTimeout)
+	defer cancel()
+	var articles []models.Article
+	opts := client.ArticleOptions{Starred: true}
+	for {
+		page, err := s.c.ListArticles(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range page.Articles {
+			articles = append(articles, articleFromAPI(a))
+		}
+		if page.NextCursor == "" {
+			return articles, nil
+		}
+		opts.Cursor = page.NextCursor
+	}
+}
+
+func (s *apiStore) AddNote(articleID uuid.UUID, quote, text string) (models.ArticleNote, error) {
+	ctx, cancel := context.WithTimeout(cmdCtx, apiTimeout)
+	defer cancel()
+	n, err := s.c.AddNote(ctx, articleID.String(), client.NewNote{Quote: quote, Text: text})
+	if err != nil {
+		return models.ArticleNote{}, err
+	}
+	return noteFromAPI(*n), nil
+}
+
+func (s *apiStore) DeleteNote(id int64) error {
+	ctx, cancel := context.WithTimeout(cmdCtx, apiTimeout)
+	defer cancel()
+	return s.c.DeleteNote(ctx, id)
+}
+
+func (s *apiStore) ArticleNotes(articleID uuid.UUID) ([]models.ArticleNote, error) {
+	ctx, cancel := context.WithTimeout(cmdCtx, apiTimeout)
+	defer cancel()
+	list, err := s.c.ArticleNotes(ctx, articleID.String())
+	if err != nil {
+		return nil, err
+	}
+	return notesFromAPI(list.Notes), nil
+}
+
+func (s *apiStore) SearchNotes(query string, limit int) ([]models.ArticleNote, error) {
+	ctx, cancel := context.WithTimeout(cmdCtx, apiTimeout)
+	defer cancel()
+	list, err := s.c.SearchNotes(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	return notesFromAPI(list.Notes), nil
+}
+
+func noteFromAPI(n client.Note) models.ArticleNote {
+	note := models.ArticleNote{
+		ID:           n.ID,
+		Quote:        n.Quote,
+		Text:         n.Text,
+		CreatedBy:    n.CreatedBy,
+		CreatedAt:    n.CreatedAt,
+		ArticleTitle: n.ArticleTitle,
+		ArticleLink:  n.ArticleLink,
+	}
+	note.ArticleID, _ = uuid.Parse(n.ArticleID)
+	return note
+}
+
+func notesFromAPI(list []client.Note) []models.ArticleNote {
+	notes := make([]models.ArticleNote, len(list))
+	for i, n := range list {
+		notes[i] = noteFromAPI(n)
+	}
+	return notes
+}
+
+// currentUser names the local user for the audit log.
+func currentUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return "unknown"
+}
+
+func feedFromAPI(f client.Feed) models.Feed {
+	feed := models.Feed{
+		Name:         f.Name,
+		URL:          f.URL,
+		CreatedAt:    f.CreatedAt,
+		ArticleCount: f.ArticleCount,
+	}
+	feed.ID, _ = uuid.Parse(f.ID)
+	if f.UpdatedAt != nil {
+		feed.UpdatedAt = *f.UpdatedAt
+	}
+	if f.LastPublishedAt != nil {
+		feed.LastPublishedAt = *f.LastPublishedAt
+	}
+	if f.DisabledAt != nil {
+		feed.DisabledAt, feed.DisabledReason = *f.DisabledAt, f.DisabledReason
+	}
+	if st := f.Status; st != nil {
+		if st.LastFetchedAt != nil {
+			feed.LastFetch.FetchedAt = *st.LastFetchedAt
+		}
+		feed.LastFetch.Status, feed.LastFetch.Error = st.LastStatus, st.LastError
+		feed.Failures, feed.UnreadCount = st.Failures, st.UnreadCount
+	}
+	return feed
+}
+
+func articleFromAPI(a client.Article) models.Article {
+	article := models.Article{
+		Title:             a.Title,
+		Link:              a.Link,
+		Description:       a.Description,
+		PublishedAt:       a.PublishedAt,
+		Author:            a.Author,
+		PublishedAtSource: a.PublishedAtSource,
+		GUID:              a.GUID,
+		CommentsURL:       a.Comments,
+		WordCount:         a.WordCount,
+	}
+	if a.Location != nil {
+		article.Location = &models.GeoPoint{Lat: a.Location.Lat, Lon: a.Location.Lon}
+	}
+	article.ThumbnailURL = a.Thumbnail
+	if a.Video != nil {
+		article.VideoID = a.Video.ID
+		article.Views = a.Video.Views
+	}
+	if a.Podcast != nil {
+		article.Duration = time.Duration(a.Podcast.DurationSeconds) * time.Second
+		article.Episode = a.Podcast.Episode
+		article.Season = a.Podcast.Season
+		article.ImageURL = a.Podcast.Image
+	}
+	if a.Enclosure != nil {
+		article.EnclosureURL = a.Enclosure.URL
+		article.EnclosureType = a.Enclosure.Type
+		article.EnclosureLength = a.Enclosure.Length
+	}
+	article.ID, _ = uuid.Parse(a.ID)
+	article.ShortID = a.ShortID
+	article.FeedID, _ = uuid.Parse(a.FeedID)
+	if a.UpdatedAt != nil {
+		article.UpdatedAt = *a.UpdatedAt
+	}
+	if a.ReadAt != nil {
+		article.ReadAt = *a.ReadAt
+	}
+	if a.StarredAt != nil {
+		article.StarredAt = *a.StarredAt
+	}
+	return article
+}
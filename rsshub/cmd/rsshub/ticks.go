@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"rsshub/internal/aggregator"
+	"rsshub/internal/config"
+)
+
+// handleTicks shows what the running daemon did on its last scheduler
+// ticks, for telling at a glance whether ingestion is healthy.
+func handleTicks(cfg *config.Config) {
+	fs := flag.NewFlagSet("ticks", flag.ExitOnError)
+	last := fs.Int("last", 10, "Number of ticks to show")
+	asJSON := fs.Bool("json", false, "Print the summaries as JSON")
+	fs.Parse(os.Args[2:])
+
+	if *last < 1 {
+		fmt.Println("--last must be at least 1")
+		os.Exit(1)
+	}
+	command := "ticks " + strconv.Itoa(*last)
+	var reply string
+	var err error
+	if cfg.Server != "" {
+		reply, err = remoteControl(cfg, command)
+	} else {
+		reply, err = controlRequest(command)
+	}
+	if err == errNotRunning {
+		fmt.Println("Background process is not running")
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	var ticks []aggregator.TickSummary
+	if err := json.Unmarshal([]byte(reply), &ticks); err != nil {
+		// Daemons that predate the ticks command reply with an error.
+		fmt.Printf("Daemon does not report ticks: %s\n", strings.TrimSpace(reply))
+		os.Exit(1)
+	}
+	if *asJSON {
+		fmt.Print(reply)
+		return
+	}
+	if len(ticks) == 0 {
+		fmt.Println("No ticks yet")
+		return
+	}
+	fmt.Printf("%-8s %5s %7s %7s %7s %5s %6s\n", "TICK", "DUE", "QUEUED", "FETCHED", "ITEMS", "NEW", "ERRORS")
+	for i, t := range ticks {
+		fmt.Printf("%-8s %5d %7d %7d %7d %5d %6d", t.At.Local().Format("15:04:05"), t.Due, t.Queued, t.Attempted, t.Items, t.NewItems, t.Errors)
+		if i == 0 {
+			fmt.Print("  (in progress)")
+		}
+		if t.Error != "" {
+			fmt.Printf("  scheduling failed: %s", truncate(t.Error, 60))
+		}
+		fmt.Println()
+	}
+	oldest := ticks[len(ticks)-1].At
+	fmt.Printf("\n%d ticks over the last %s\n", len(ticks), time.Since(oldest).Round(time.Second))
+}
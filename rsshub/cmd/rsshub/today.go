@@ -0,0 +1,109 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"rsshub/internal/db"
+	"rsshub/internal/models"
+	"rsshub/internal/output"
+)
+
+// handleToday shows what was published on one day, feed by feed, under a
+// calendar of how much was published each day before it. Days are UTC
+// dates, as in stats.
+func handleToday(database *db.DB) {
+	fs := flag.NewFlagSet("today", flag.ExitOnError)
+	date := fs.String("date", "", "Day to show, as YYYY-MM-DD (default: today)")
+	days := fs.Int("days", 30, "Days of counts in the calendar (0 to skip it)")
+	fs.Parse(os.Args[2:])
+
+	day := time.Now().UTC().Truncate(24 * time.Hour)
+	if *date != "" {
+		var err error
+		day, err = time.Parse("2006-01-02", *date)
+		if err != nil {
+			fmt.Printf("Invalid --date %q: use YYYY-MM-DD\n", *date)
+			os.Exit(1)
+		}
+	}
+	if *days < 0 {
+		fmt.Println("--days cannot be negative")
+		os.Exit(1)
+	}
+
+	if *days > 0 {
+		counts, err := database.GetDailyCounts(*days)
+		if err != nil {
+			fmt.Printf("Error getting daily counts: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("# Articles per day (last %d days)\n", *days)
+		printCalendar(counts, day)
+		fmt.Println()
+	}
+
+	feeds, err := database.ArticlesPublishedOn(day)
+	if err != nil {
+		fmt.Printf("Error getting articles: %v\n", err)
+		os.Exit(1)
+	}
+	total := 0
+	for _, f := range feeds {
+		total += len(f.Articles)
+	}
+	fmt.Printf("# %s: %d articles from %d feeds\n", day.Format("Monday 2006-01-02"), total, len(feeds))
+	for _, f := range feeds {
+		printFeedDay(f)
+	}
+}
+
+// printFeedDay lists one feed's articles of the day with their times.
+func printFeedDay(f models.FeedArticles) {
+	fmt.Printf("\n%s (%d)\n", output.Name.Paint(f.FeedName), len(f.Articles))
+	for _, a := range f.Articles {
+		fmt.Printf("  %s%s  %s\n     %s\n", unreadMarker(a), output.When.Paint(a.PublishedAt.UTC().Format("15:04")), a.Title, a.Link)
+	}
+}
+
+// printCalendar lays counts out a week to a line, Monday first, each line
+// led by the date of its Monday. Days without articles show as a dot;
+// marked, the day being shown, is highlighted.
+func printCalendar(counts []models.DailyCount, marked time.Time) {
+	if len(counts) == 0 {
+		return
+	}
+	const cell = 5
+	fmt.Printf("%5s", "")
+	for _, d := range []string{"Mon", "Tue", "Wed", "Thu", "Fri", "Sat", "Sun"} {
+		fmt.Printf("%*s", cell, d)
+	}
+	fmt.Println()
+
+	// Pad the first week back to its Monday.
+	first := counts[0].Day
+	pad := (int(first.Weekday()) + 6) % 7
+	var line strings.Builder
+	line.WriteString(first.AddDate(0, 0, -pad).Format("01-02"))
+	line.WriteString(strings.Repeat(" ", pad*cell))
+	for i, c := range counts {
+		if i > 0 && c.Day.Weekday() == time.Monday {
+			fmt.Println(line.String())
+			line.Reset()
+			line.WriteString(c.Day.Format("01-02"))
+		}
+		v := "."
+		if c.Count > 0 {
+			v = fmt.Sprint(c.Count)
+		}
+		v = fmt.Sprintf("%*s", cell, v)
+		if c.Day.Format("2006-01-02") == marked.Format("2006-01-02") {
+			v = output.Unread.Paint(v)
+		}
+		line.WriteString(v)
+	}
+	fmt.Println(line.String())
+}
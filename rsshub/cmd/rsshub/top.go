@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"rsshub/internal/aggregator"
+	"rsshub/internal/config"
+)
+
+// handleTop redraws the daemon's activity every --interval until
+// interrupted. Items per second is the change in the daemon's item count
+// between two polls.
+func handleTop(cfg *config.Config) {
+	fs := flag.NewFlagSet("top", flag.ExitOnError)
+	interval := fs.Duration("interval", 2*time.Second, "How often to refresh")
+	numErrors := fs.Int("errors", 5, "Number of recent errors to show")
+	fs.Parse(os.Args[2:])
+
+	if *interval < 100*time.Millisecond {
+		fmt.Println("--interval must be at least 100ms")
+		os.Exit(1)
+	}
+
+	var prev *aggregator.Activity
+	for {
+		cur, err := fetchActivity(cfg)
+		if err == errNotRunning {
+			fmt.Println("Background process is not running")
+			os.Exit(1)
+		}
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Print("\033[H\033[2J")
+		printActivity(cur, prev, *numErrors)
+		prev = cur
+
+		select {
+		case <-cmdCtx.Done():
+			return
+		case <-time.After(*interval):
+		}
+	}
+}
+
+func fetchActivity(cfg *config.Config) (*aggregator.Activity, error) {
+	var reply string
+	var err error
+	if cfg.Server != "" {
+		reply, err = remoteControl(cfg, "activity")
+	} else {
+		reply, err = controlRequest("activity")
+	}
+	if err != nil {
+		return nil, err
+	}
+	var act aggregator.Activity
+	if err := json.Unmarshal([]byte(reply), &act); err != nil {
+		// Daemons that predate the activity command reply with text.
+		return nil, fmt.Errorf("daemon does not report activity: %s", strings.TrimSpace(reply))
+	}
+	return &act, nil
+}
+
+func printActivity(cur, prev *aggregator.Activity, numErrors int) {
+	state := "running"
+	if cur.Paused {
+		state = "paused"
+	}
+	rate := "-"
+	if prev != nil {
+		if secs := cur.At.Sub(prev.At).Seconds(); secs > 0 {
+			rate = fmt.Sprintf("%.1f", float64(cur.Items-prev.Items)/secs)
+		}
+	}
+	fmt.Printf("rsshub top - %s   state: %s\n", cur.At.Local().Format("15:04:05"), state)
+	fmt.Printf("workers: %d busy / %d   queue: %d / %d   items/sec: %s\n",
+		len(cur.Busy), cur.Workers, cur.Queued, cur.QueueCap, rate)
+	if !cur.SaturatedSince.IsZero() {
+		fmt.Printf("queue full for %s: feeds are waiting for a later tick\n", cur.At.Sub(cur.SaturatedSince).Round(time.Second))
+	}
+	fmt.Printf("since start: %d feeds fetched, %d items, %d skipped as already queued, %d dropped on a full queue\n\n",
+		cur.Feeds, cur.Items, cur.Merged, cur.Dropped)
+
+	fmt.Printf("%-6s %-24s %-8s %s\n", "WORKER", "FEED", "TIME", "URL")
+	for _, w := range cur.Busy {
+		fmt.Printf("%-6d %-24s %-8s %s\n", w.Worker, truncate(w.Feed, 24),
+			cur.At.Sub(w.Since).Round(time.Second), truncate(w.URL, 60))
+	}
+	if len(cur.Busy) == 0 {
+		fmt.Println("(all workers idle)")
+	}
+
+	if numErrors <= 0 {
+		return
+	}
+	fmt.Println("\nRecent errors:")
+	errs := cur.Errors
+	if len(errs) > numErrors {
+		errs = errs[:numErrors]
+	}
+	for _, e := range errs {
+		fmt.Printf("%s %-24s %s\n", e.At.Local().Format("15:04:05"), truncate(e.Feed, 24), truncate(e.Error, 80))
+	}
+	if len(errs) == 0 {
+		fmt.Println("(none)")
+	}
+}
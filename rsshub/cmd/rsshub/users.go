@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"rsshub/internal/auth"
+	"rsshub/internal/db"
+	"rsshub/internal/output"
+)
+
+// handleUser manages the users who can sign in to the HTTP API, and the
+// feeds each shares on their public profile.
+func handleUser(database *db.DB) {
+	usage := "Usage: rsshub user add|passwd|delete|shared <name> | rsshub user share|unshare <name> <feed>... | rsshub user list"
+	if len(os.Args) < 3 {
+		fmt.Println(usage)
+		os.Exit(1)
+	}
+	sub := os.Args[2]
+	if sub == "list" {
+		listUsers(database)
+		return
+	}
+	if len(os.Args) < 4 {
+		fmt.Println(usage)
+		os.Exit(1)
+	}
+	name := os.Args[3]
+	actor := db.Actor{Name: currentUser(), Source: db.SourceCLI}
+
+	var err error
+	switch sub {
+	case "share", "unshare":
+		if len(os.Args) < 5 {
+			fmt.Println(usage)
+			os.Exit(1)
+		}
+		err = shareFeeds(database, actor, sub, name, os.Args[4:])
+	case "shared":
+		err = listShared(database, name)
+	case "add":
+		var hash string
+		hash, err = readPasswordHash()
+		if err == nil {
+			_, err = database.CreateUser(name, hash, "")
+		}
+		if err == nil {
+			database.Audit(actor, "user-add", name, "", "password")
+			fmt.Printf("User added: %s\n", name)
+		}
+	case "passwd":
+		var hash string
+		hash, err = readPasswordHash()
+		if err == nil {
+			err = database.SetPassword(name, hash)
+		}
+		if err == nil {
+			database.Audit(actor, "user-passwd", name, "", "")
+			fmt.Printf("Password changed for %s; their sessions were signed out\n", name)
+		}
+	case "delete":
+		err = database.DeleteUser(name)
+		if err == nil {
+			database.Audit(actor, "user-delete", name, "", "")
+			fmt.Printf("User deleted: %s\n", name)
+		}
+	default:
+		fmt.Println(usage)
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// shareFeeds adds feeds to, or with unshare takes them off, a user's
+// public subscriptions at /u/<name>/subscriptions.
+func shareFeeds(database *db.DB, actor db.Actor, sub, name string, feeds []string) error {
+	u, err := database.GetUserByName(name)
+	if err != nil {
+		return err
+	}
+	for _, feed := range feeds {
+		if sub == "share" {
+			err = database.ShareFeed(u.ID, feed)
+		} else {
+			err = database.UnshareFeed(u.ID, feed)
+		}
+		if err != nil {
+			return err
+		}
+		database.Audit(actor, sub+"-feed", feed, "", u.Name)
+		if sub == "share" {
+			fmt.Printf("Shared %s on %s's profile\n", feed, u.Name)
+		} else {
+			fmt.Printf("Stopped sharing %s on %s's profile\n", feed, u.Name)
+		}
+	}
+	return nil
+}
+
+func listShared(database *db.DB, name string) error {
+	u, err := database.GetUserByName(name)
+	if err != nil {
+		return err
+	}
+	feeds, err := database.SharedFeeds(u.ID)
+	if err != nil {
+		return err
+	}
+	if len(feeds) == 0 {
+		fmt.Printf("%s shares no feeds\n", u.Name)
+		return nil
+	}
+	fmt.Printf("Public at /u/%s/subscriptions (and .opml) on the HTTP API:\n", u.Name)
+	for _, f := range feeds {
+		fmt.Printf("  %s  %s\n", f.Name, f.URL)
+	}
+	return nil
+}
+
+func listUsers(database *db.DB) {
+	users, err := database.ListUsers()
+	if err != nil {
+		fmt.Printf("Error listing users: %v\n", err)
+		os.Exit(1)
+	}
+	if len(users) == 0 {
+		fmt.Println("No users")
+		return
+	}
+	fmt.Printf("%-24s  %-10s  %s\n", "NAME", "SIGN-IN", "CREATED")
+	for _, u := range users {
+		var methods []string
+		if u.HasPassword {
+			methods = append(methods, "password")
+		}
+		if u.OIDCSubject != "" {
+			methods = append(methods, "sso")
+		}
+		fmt.Printf("%-24s  %-10s  %s\n", u.Name, strings.Join(methods, ","), output.Date(u.CreatedAt))
+	}
+}
+
+// readPasswordHash reads a password from the first line of standard input,
+// so it can be piped in by scripts, and hashes it.
+func readPasswordHash() (string, error) {
+	fmt.Fprint(os.Stderr, "Password: ")
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("reading password: %w", err)
+	}
+	return auth.HashPassword(strings.TrimRight(line, "\r\n"))
+}
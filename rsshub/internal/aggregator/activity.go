@@ -0,0 +1,132 @@
+package aggregator
+
+import (
+	"encoding/json"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"rsshub/internal/models"
+)
+
+// recentErrors bounds how many fetch errors Activity reports.
+const recentErrors = 20
+
+// Activity is a snapshot of what the daemon is doing, returned as JSON by
+// the "activity" control command for `rsshub top`.
+type Activity struct {
+	At       time.Time      `json:"at"`
+	Paused   bool           `json:"paused"`
+	Workers  int            `json:"workers"`
+	Queued   int            `json:"queued"`
+	QueueCap int            `json:"queue_cap"`
+	Feeds    int64          `json:"feeds"`
+	Items    int64          `json:"items"`
+	Busy     []WorkerStatus `json:"busy"`
+	Errors   []FetchError   `json:"errors"`
+
+	// Merged counts feeds not queued because they already were, Dropped
+	// those left for a later tick because the queue was full, since the
+	// daemon started. SaturatedSince is set while the queue is full.
+	Merged         int64     `json:"merged"`
+	Dropped        int64     `json:"dropped"`
+	SaturatedSince time.Time `json:"saturated_since,omitzero"`
+}
+
+// WorkerStatus is a worker in the middle of fetching a feed.
+type WorkerStatus struct {
+	Worker int       `json:"worker"`
+	Feed   string    `json:"feed"`
+	URL    string    `json:"url"`
+	Since  time.Time `json:"since"`
+}
+
+// FetchError is a feed that failed to fetch or store, newest first.
+type FetchError struct {
+	At    time.Time `json:"at"`
+	Feed  string    `json:"feed"`
+	Error string    `json:"error"`
+}
+
+// activity tracks worker progress for Activity. Counters are totals since
+// the daemon started; rates are left to the reader.
+type activity struct {
+	nextWorker atomic.Int64
+	feeds      atomic.Int64
+	items      atomic.Int64
+
+	mu     sync.Mutex
+	busy   map[int]WorkerStatus
+	errors []FetchError
+}
+
+func (t *activity) newWorker() int {
+	return int(t.nextWorker.Add(1))
+}
+
+func (t *activity) start(worker int, feed models.Feed) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.busy == nil {
+		t.busy = make(map[int]WorkerStatus)
+	}
+	t.busy[worker] = WorkerStatus{Worker: worker, Feed: feed.Name, URL: feed.URL, Since: time.Now()}
+}
+
+func (t *activity) finish(worker int) {
+	t.feeds.Add(1)
+	t.mu.Lock()
+	delete(t.busy, worker)
+	t.mu.Unlock()
+}
+
+// inFlight counts the workers busy with a feed.
+func (t *activity) inFlight() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.busy)
+}
+
+func (t *activity) fail(feed models.Feed, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.errors = append([]FetchError{{At: time.Now(), Feed: feed.Name, Error: err.Error()}}, t.errors...)
+	if len(t.errors) > recentErrors {
+		t.errors = t.errors[:recentErrors]
+	}
+}
+
+// Activity reports the workers' current feeds, queue depth, totals and
+// recent errors.
+func (a *Aggregator) Activity() Activity {
+	out := Activity{
+		At:       time.Now(),
+		Paused:   a.paused.Load(),
+		Workers:  a.workerCount(),
+		Queued:   len(a.queue.jobs),
+		QueueCap: cap(a.queue.jobs),
+		Feeds:    a.activity.feeds.Load(),
+		Items:    a.activity.items.Load(),
+
+		Merged:         a.queue.merged.Load(),
+		Dropped:        a.queue.dropped.Load(),
+		SaturatedSince: a.queue.saturatedSince(),
+	}
+	a.activity.mu.Lock()
+	for _, w := range a.activity.busy {
+		out.Busy = append(out.Busy, w)
+	}
+	out.Errors = append([]FetchError(nil), a.activity.errors...)
+	a.activity.mu.Unlock()
+	sort.Slice(out.Busy, func(i, j int) bool { return out.Busy[i].Worker < out.Busy[j].Worker })
+	return out
+}
+
+func (a *Aggregator) activityJSON() string {
+	b, err := json.Marshal(a.Activity())
+	if err != nil {
+		return "Error: " + err.Error() + "\n"
+	}
+	return string(b) + "\n"
+}
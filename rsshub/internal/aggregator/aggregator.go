@@ -3,145 +3,499 @@ package aggregator
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"net"
 	"os"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"rsshub/internal/config"
 	"rsshub/internal/db"
+	"rsshub/internal/errreport"
+	"rsshub/internal/logging"
 	"rsshub/internal/models"
 	"rsshub/internal/rss"
+	"rsshub/internal/version"
 )
 
+// ErrQueueFull is returned when a feed cannot be queued without blocking.
+var ErrQueueFull = errors.New("worker queue is full, try again later")
+
+// ErrStopping is returned when a feed is refreshed or the workers resized
+// during shutdown.
+var ErrStopping = errors.New("aggregator is shutting down")
+
+// schedulerLockKey identifies the advisory lock that elects which of several
+// running instances schedules feeds.
+const schedulerLockKey int64 = 0x727373687562
+
+// claimLease bounds how long a feed claimed in claim mode stays reserved if
+// the claiming instance dies before releasing it.
+const claimLease = 15 * time.Minute
+
+// Feeds yielding at least bulkThreshold items are stored with COPY rather
+// than row by row, in batches of up to bulkBatchSize articles. Smaller
+// fetches are batched together when write batching is on.
+const (
+	bulkThreshold = 100
+	bulkBatchSize = 1000
+)
+
+// maintenanceInterval spaces out partition creation and retention runs.
+const maintenanceInterval = time.Hour
+
 type Aggregator struct {
-	db        *sql.DB
-	interval  time.Duration
-	workers   int
-	sockPath  string
-	ticker    *time.Ticker
-	jobs      chan models.Feed
-	ctx       context.Context
-	cancel    context.CancelFunc
-	wg        sync.WaitGroup
-	listener  net.Listener
-	doneChans []chan struct{}
-}
-
-func NewAggregator(db *sql.DB, interval time.Duration, workers int, sockPath string) *Aggregator {
+	db         *sql.DB
+	interval   time.Duration
+	workers    int
+	scheduling string
+	instanceID string
+	hosts      *hostLimiter
+	fetcher    *rss.Fetcher
+	sockPath   string
+	grace      time.Duration
+	ticker     *time.Ticker
+	queue      *feedQueue
+	ctx        context.Context
+	cancel     context.CancelFunc
+	wg         sync.WaitGroup
+	scheduler  sync.WaitGroup
+	stopping   chan struct{}
+	stopOnce   sync.Once
+	listener   net.Listener
+	pidFile    *os.File
+	doneChans  []chan struct{}
+	workersMu  sync.Mutex // guards workers, doneChans and catchUp
+	paused     atomic.Bool
+	apiAddr    atomic.Value // string
+	leader     *db.Leader
+	isLeader   bool
+	startedAt  time.Time
+	log        logging.Logger
+	reporter   *errreport.Reporter
+	reportAt   int
+	disableAt  int
+	quiet      quietPolicy
+	activity   activity
+	ticks      *tickLog
+	pauses     pauseSchedule
+	catchUp    catchUp
+	poll       pollPolicy
+
+	partitioned       bool
+	retentionMonths   int
+	fetchLogRetention time.Duration
+	dedupWindow       time.Duration
+	batcher           *writeBatcher // nil without write batching
+	dailyByteLimit    int64
+	pipeline          pipeline
+	lastMaintenance   time.Time
+}
+
+func NewAggregator(conn *sql.DB, cfg *config.Config, sockPath string) *Aggregator {
+	hostname, _ := os.Hostname()
+	log := logging.New(cfg)
 	return &Aggregator{
-		db:        db,
-		interval:  interval,
-		workers:   workers,
-		sockPath:  sockPath,
-		doneChans: []chan struct{}{},
+		db:         conn,
+		interval:   cfg.Interval,
+		workers:    cfg.Workers,
+		scheduling: cfg.Scheduling,
+		instanceID: fmt.Sprintf("%s-%d", hostname, os.Getpid()),
+		hosts:      newHostLimiter(cfg.MaxPerHost),
+		fetcher:    rss.NewFetcher(cfg),
+		log:        log,
+		reportAt:   cfg.ErrorReportAfter,
+		disableAt:  cfg.DisableAfterFailures,
+		quiet:      quietPolicy{gaps: cfg.QuietAfterGaps, minimum: cfg.QuietMinimum},
+		ticks:      newTickLog(cfg.TickHistory),
+		pauses:     pauseSchedule{hours: cfg.PauseHours, days: cfg.PauseDays},
+		catchUp:    newCatchUp(cfg),
+		poll: pollPolicy{
+			maxDelay:    cfg.MaxScheduleDelay,
+			adaptive:    cfg.AdaptivePolling,
+			minInterval: cfg.PollMinInterval,
+			maxInterval: cfg.PollMaxInterval,
+		},
+
+		partitioned:       cfg.PartitionArticles,
+		retentionMonths:   cfg.RetentionMonths,
+		fetchLogRetention: cfg.FetchLogRetention,
+		dedupWindow:       cfg.DedupWindow,
+		batcher:           newWriteBatcher(&db.DB{DB: conn, Log: log}, log, cfg.DedupWindow, cfg.WriteBatchSize, cfg.WriteBatchDelay),
+		dailyByteLimit:    cfg.DailyByteLimit,
+		pipeline:          newPipeline(cfg),
+		sockPath:          sockPath,
+		grace:             cfg.ShutdownGrace,
+		stopping:          make(chan struct{}),
+		doneChans:         []chan struct{}{},
 	}
 }
 
 func (a *Aggregator) Start(parentCtx context.Context) error {
+	if err := a.claimSocket(); err != nil {
+		return err
+	}
+	var err error
+	a.listener, err = net.Listen("unix", a.sockPath)
+	if err != nil {
+		a.releaseSocket()
+		return err
+	}
+
 	a.ctx, a.cancel = context.WithCancel(parentCtx)
+	a.startedAt = time.Now()
 	a.ticker = time.NewTicker(a.interval)
-	a.jobs = make(chan models.Feed, a.workers)
+	a.queue = newFeedQueue(a.workers)
+	a.leader = db.NewLeader(a.db, schedulerLockKey)
 
+	if a.batcher != nil {
+		go a.batcher.run()
+	}
+	a.workersMu.Lock()
 	for i := 0; i < a.workers; i++ {
 		done := make(chan struct{})
 		a.doneChans = append(a.doneChans, done)
 		a.wg.Add(1)
-		go a.worker(done)
+		go a.worker(a.activity.newWorker(), done)
 	}
+	a.workersMu.Unlock()
 
+	a.scheduler.Add(1)
 	go func() {
+		defer a.scheduler.Done()
+		defer a.reporter.Recover("scheduler")
+		defer func() {
+			if err := a.leader.Release(context.Background()); err != nil {
+				a.log.Errorf("Error releasing scheduler lock: %v", err)
+			}
+		}()
+		// resume fires when the pause hours under way end, to catch up
+		// at once rather than on the next tick; hurry brings the next
+		// tick forward while catching up.
+		var resume, hurry <-chan time.Time
+		if !a.pauses.covers(time.Now()) && a.startCatchUp() {
+			hurry = time.After(0)
+		}
 		for {
 			select {
+			case <-a.stopping:
+				return
 			case <-a.ctx.Done():
 				return
+			case <-resume:
+				resume = nil
+				a.log.Infof("Pause hours over: fetching resumes")
+				if !a.paused.Load() {
+					a.startCatchUp()
+					a.tick()
+				}
+			case <-hurry:
+				hurry = nil
+				if !a.paused.Load() && !a.pauses.covers(time.Now()) {
+					a.tick()
+				}
 			case <-a.ticker.C:
-				database := &db.DB{DB: a.db}
-				feeds, err := database.GetOutdatedFeeds(a.workers)
-				if err != nil {
-					fmt.Printf("Error getting outdated feeds: %v\n", err)
+				if a.paused.Load() {
+					a.log.Debugf("Ticker tick: paused, skipping")
 					continue
 				}
-				fmt.Printf("Ticker tick: Processing %d outdated feeds\n", len(feeds)) // Debug
-				for _, feed := range feeds {
-					a.jobs <- feed
+				if until, ok := a.pauses.until(time.Now()); ok {
+					if resume == nil {
+						a.log.Infof("Pause hours: no feeds are scheduled until %s", until.Format(time.DateTime))
+						resume = time.After(time.Until(until))
+					}
+					continue
 				}
+				a.tick()
+			}
+			if a.catchingUp() && hurry == nil {
+				hurry = time.After(catchUpTick)
 			}
 		}
 	}()
 
-	var err error
-	a.listener, err = net.Listen("unix", a.sockPath)
-	if err != nil {
-		return err
-	}
 	go a.controlLoop()
 
 	return nil
 }
 
+// tick runs maintenance when it is due and hands the feeds due for a
+// fetch to the workers.
+func (a *Aggregator) tick() {
+	a.ticks.begin(time.Now())
+	a.maintain()
+	feeds, err := a.nextFeeds()
+	if err != nil {
+		a.log.Errorf("Error getting outdated feeds: %v", err)
+		a.ticks.scheduled(0, 0, err)
+		return
+	}
+	a.log.Debugf("Ticker tick: Processing %d outdated feeds", len(feeds))
+	a.ticks.scheduled(len(feeds), a.enqueue(feeds), nil)
+	if a.catchingUp() && len(feeds) < a.workerCount() {
+		a.endCatchUp()
+	}
+}
+
+// enqueue offers a tick's feeds to the workers without waiting for room,
+// and returns how many were queued. Feeds dropped because the queue is
+// full stay due for a later tick; in claim mode their claims are released
+// so any instance can take them.
+func (a *Aggregator) enqueue(feeds []models.Feed) int {
+	var n [3]int
+	for _, feed := range feeds {
+		outcome := a.queue.offer(feed)
+		n[outcome]++
+		if outcome == dropped && a.scheduling == config.SchedulingClaim {
+			err := (&db.DB{DB: a.db, Log: a.log}).ReleaseFeedClaim(feed.ID, a.instanceID)
+			if err != nil {
+				a.log.Errorf("Error releasing claim on feed %s: %v", feed.URL, err)
+			}
+		}
+	}
+	if n[merged] > 0 {
+		a.log.Debugf("Ticker tick: %d feeds already queued or being fetched", n[merged])
+	}
+	if n[dropped] > 0 {
+		a.log.Infof("Worker queue full (%d/%d): %d of %d feeds left for a later tick; consider more workers",
+			len(a.queue.jobs), cap(a.queue.jobs), n[dropped], len(feeds))
+	}
+	return n[queued]
+}
+
+// maintain runs the periodic upkeep at most once per maintenanceInterval.
+func (a *Aggregator) maintain() {
+	if time.Since(a.lastMaintenance) < maintenanceInterval {
+		return
+	}
+	a.lastMaintenance = time.Now()
+	database := &db.DB{DB: a.db, Log: a.log}
+	if a.partitioned {
+		a.maintainPartitions(database)
+	}
+	a.pruneFetchLog(database)
+	a.reportQuietFeeds(database)
+}
+
+// quietPolicy says when a feed that stopped posting is worth reporting.
+type quietPolicy struct {
+	gaps    int
+	minimum time.Duration
+}
+
+// reportQuietFeeds logs and reports feeds that have gone quiet for far
+// longer than usual, which often means their URL broke in a way that still
+// serves a valid but empty or frozen feed. Each silence is reported once,
+// by whichever instance gets there first.
+func (a *Aggregator) reportQuietFeeds(database *db.DB) {
+	if a.quiet.gaps <= 0 {
+		return
+	}
+	feeds, err := database.QuietFeeds(a.quiet.gaps, a.quiet.minimum)
+	if err != nil {
+		a.log.Errorf("Error finding quiet feeds: %v", err)
+		return
+	}
+	for _, f := range feeds {
+		first, err := database.MarkQuietReported(f.FeedID)
+		if err != nil {
+			a.log.Errorf("Error marking feed %s as reported quiet: %v", f.FeedName, err)
+			continue
+		}
+		if !first {
+			continue
+		}
+		msg := fmt.Sprintf("Feed %s has gone quiet: nothing published for %s, usually every %s",
+			f.FeedName, time.Since(f.LastPublishedAt).Round(time.Hour), f.UsualGap)
+		a.log.Infof("%s", msg)
+		a.reporter.Error(errreport.Event{
+			Level:   "warning",
+			Message: msg,
+			Tags:    map[string]string{"feed": f.FeedName, "action": "quiet"},
+			Extra:   map[string]any{"last_published_at": f.LastPublishedAt, "usual_gap_seconds": int64(f.UsualGap / time.Second)},
+		})
+	}
+}
+
+// pruneFetchLog drops fetch_log entries and daily bandwidth usage older
+// than the configured retention.
+func (a *Aggregator) pruneFetchLog(database *db.DB) {
+	if a.fetchLogRetention <= 0 {
+		return
+	}
+	n, err := database.PruneFetchLog(a.fetchLogRetention)
+	if err != nil {
+		a.log.Errorf("Error pruning fetch log: %v", err)
+		return
+	}
+	if n > 0 {
+		a.log.Debugf("Pruned %d fetch log entries older than %s", n, a.fetchLogRetention)
+	}
+	_, err = database.PruneBandwidth(a.fetchLogRetention)
+	if err != nil {
+		a.log.Errorf("Error pruning bandwidth usage: %v", err)
+	}
+}
+
+// maintainPartitions keeps future monthly article partitions created and,
+// when retention is configured, drops partitions that have aged out.
+func (a *Aggregator) maintainPartitions(database *db.DB) {
+	err := database.EnsureArticlePartitions(db.PartitionMonthsAhead)
+	if err != nil {
+		a.log.Errorf("Error creating article partitions: %v", err)
+	}
+	if a.retentionMonths <= 0 {
+		return
+	}
+	cutoff := time.Now().UTC().AddDate(0, -a.retentionMonths, 0)
+	dropped, err := database.DropArticlePartitionsBefore(cutoff)
+	if err != nil {
+		a.log.Errorf("Error dropping expired article partitions: %v", err)
+	}
+	for _, name := range dropped {
+		a.log.Infof("Dropped expired article partition %s", name)
+	}
+	// Kept markup ages out with the partitions, which hold whole months.
+	n, err := database.PruneRawItemsBefore(time.Date(cutoff.Year(), cutoff.Month(), 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		a.log.Errorf("Error pruning raw items: %v", err)
+	} else if n > 0 {
+		a.log.Debugf("Pruned %d raw items of expired articles", n)
+	}
+	if len(dropped) > 0 {
+		err = database.RebuildStats()
+		if err != nil {
+			a.log.Errorf("Error rebuilding feed stats: %v", err)
+		}
+	}
+}
+
+// nextFeeds picks the feeds to process on this tick. In claim mode every
+// instance claims its own batch; otherwise only the elected leader schedules.
+// Feeds still queued or being fetched are passed over, so the batch goes to
+// the next feeds due rather than to the same slow ones again, and no host
+// gets more of the batch than CLI_APP_MAX_PER_HOST leaves it room for.
+func (a *Aggregator) nextFeeds() ([]models.Feed, error) {
+	database := &db.DB{DB: a.db, Log: a.log}
+	if a.scheduling == config.SchedulingClaim {
+		return database.ClaimOutdatedFeeds(a.instanceID, claimLease, a.workerCount(), a.hosts.limit, a.feedOrder(), a.queue.inFlight())
+	}
+	if !a.campaign() {
+		return nil, nil
+	}
+	return database.GetOutdatedFeeds(a.workerCount(), a.hosts.limit, a.feedOrder(), a.queue.inFlight())
+}
+
+// campaign tries to become (or stay) the scheduling leader and reports
+// whether this instance may enqueue feeds on this tick. Followers retry on
+// every tick, so a new leader takes over within one interval of a failure.
+func (a *Aggregator) campaign() bool {
+	leader, err := a.leader.Acquire(a.ctx)
+	if err != nil {
+		a.log.Errorf("Error acquiring scheduler lock: %v", err)
+	}
+	if leader && !a.isLeader {
+		a.log.Infof("Acquired scheduler lock: this instance is now scheduling feeds")
+	}
+	if !leader {
+		a.log.Debugf("Ticker tick: another instance holds the scheduler lock, standing by")
+	}
+	a.isLeader = leader
+	return leader
+}
+
+// Stop shuts the aggregator down: it stops scheduling and taking control
+// commands, lets the workers finish the fetches in flight for up to the
+// grace period, then cancels whatever is still running. Feeds still queued
+// are left due for the next start. Calling Stop more than once is safe.
 func (a *Aggregator) Stop() error {
-	a.cancel()
+	a.stopOnce.Do(a.shutdown)
+	return nil
+}
+
+func (a *Aggregator) shutdown() {
+	close(a.stopping)
 	a.ticker.Stop()
-	close(a.jobs)
-	for _, done := range a.doneChans {
-		close(done)
+	if a.listener != nil {
+		a.listener.Close()
+	}
+	a.scheduler.Wait()
+
+	idle := make(chan struct{})
+	go func() {
+		a.wg.Wait()
+		close(idle)
+	}()
+	timer := time.NewTimer(a.grace)
+	defer timer.Stop()
+	select {
+	case <-idle:
+	case <-timer.C:
+		a.log.Infof("Shutdown grace period of %s elapsed with %d fetches in flight; cancelling them", a.grace, a.activity.inFlight())
+		a.cancel()
+		<-idle
+	}
+	a.cancel()
+	if a.batcher != nil {
+		a.batcher.close()
 	}
-	a.wg.Wait()
-	a.listener.Close()
+	a.dropQueued()
 	os.Remove(a.sockPath)
-	return nil
+	a.releaseSocket()
 }
 
-func (a *Aggregator) worker(done chan struct{}) {
-	defer a.wg.Done()
-	database := &db.DB{DB: a.db}
+// dropQueued empties the queue once the workers have exited. In claim mode
+// the queued feeds' claims are released so any instance can take them.
+func (a *Aggregator) dropQueued() {
+	database := &db.DB{DB: a.db, Log: a.log}
 	for {
 		select {
-		case feed := <-a.jobs:
-			fmt.Printf("Worker fetching feed: %s (%s)\n", feed.Name, feed.URL) // Debug log
-			rssFeed, err := rss.FetchAndParse(feed.URL)
-			if err != nil {
-				fmt.Printf("Error fetching/parsing feed %s: %v\n", feed.URL, err)
+		case feed := <-a.queue.jobs:
+			a.queue.done(feed.ID)
+			if a.scheduling != config.SchedulingClaim {
 				continue
 			}
-			itemCount := len(rssFeed.Channel.Item)
-			fmt.Printf("Parsed %d items from feed %s\n", itemCount, feed.Name) // Debug
-			for _, item := range rssFeed.Channel.Item {
-				pubDate, err := parsePubDate(item.PubDate)
-				if err != nil {
-					fmt.Printf("Error parsing pubDate '%s' for item %s: %v\n", item.PubDate, item.Link, err)
-					continue
-				}
-				article := models.Article{
-					Title:       item.Title,
-					Link:        item.Link,
-					Description: item.Description,
-					PublishedAt: pubDate,
-					FeedID:      feed.ID,
-				}
-				exists, err := database.ArticleExists(feed.ID, article.Link)
-				if err != nil {
-					fmt.Printf("Error checking if article exists: %v\n", err)
-					continue
-				}
-				if exists {
-					fmt.Printf("Article already exists: %s\n", article.Link) // Debug
-					continue
-				}
-				err = database.InsertArticle(&article)
+			err := database.ReleaseFeedClaim(feed.ID, a.instanceID)
+			if err != nil {
+				a.log.Errorf("Error releasing claim on feed %s: %v", feed.URL, err)
+			}
+		default:
+			return
+		}
+	}
+}
+
+// worker processes queued feeds until the aggregator stops or done, which
+// only Resize closes, retires it.
+func (a *Aggregator) worker(id int, done chan struct{}) {
+	defer a.wg.Done()
+	defer a.reporter.Recover("worker")
+	database := &db.DB{DB: a.db, Log: a.log}
+	for {
+		// Once stopping, take no new feed even if one is queued.
+		select {
+		case <-a.stopping:
+			return
+		default:
+		}
+		select {
+		case <-a.stopping:
+			return
+		case feed := <-a.queue.jobs:
+			a.activity.start(id, feed)
+			a.processFeed(database, id, feed)
+			a.activity.finish(id)
+			a.queue.done(feed.ID)
+			if a.scheduling == config.SchedulingClaim {
+				err := database.ReleaseFeedClaim(feed.ID, a.instanceID)
 				if err != nil {
-					fmt.Printf("Error inserting article %s: %v\n", article.Link, err)
-				} else {
-					fmt.Printf("Inserted article: %s\n", article.Title) // Debug
+					a.log.Errorf("Error releasing claim on feed %s: %v", feed.URL, err)
 				}
 			}
-			err = database.UpdateFeedUpdatedAt(feed.ID)
-			if err != nil {
-				fmt.Printf("Error updating feed %s: %v\n", feed.URL, err)
-			}
 		case <-done:
 			return
 		case <-a.ctx.Done():
@@ -150,28 +504,319 @@ func (a *Aggregator) worker(done chan struct{}) {
 	}
 }
 
-// Helper for robust pubDate parsing
-func parsePubDate(s string) (time.Time, error) {
-	formats := []string{
-		time.RFC1123,  // e.g., "Tue, 20 Aug 2024 10:20:30 GMT"
-		time.RFC1123Z, // e.g., "Tue, 20 Aug 2024 10:20:30 -0000"
-		time.RFC822,   // Similar, but with 2-digit year
-		time.RFC822Z,
-		"2006-01-02T15:04:05Z", // ISO 8601 variant
-		"2006-01-02T15:04:05-07:00",
+func (a *Aggregator) processFeed(database *db.DB, worker int, feed models.Feed) {
+	log := a.log.Fetch(feed)
+	// nextFeeds already caps each host's share of a batch, so this only
+	// trips where the database and hostOf disagree on a URL's host (an
+	// IPv6 literal, say). A worker does
+	// not wait on a busy host: the feed stays due and a later tick offers
+	// it again, while the worker moves on to other hosts.
+	release, ok := a.hosts.tryAcquire(feed.URL)
+	if !ok {
+		log.Debugf("Skipping feed %s for now: its host is at CLI_APP_MAX_PER_HOST fetches", feed.Name)
+		return
+	}
+	defer release()
+
+	if reason := a.overBudget(database, feed); reason != "" {
+		log.Infof("Not fetching feed %s until tomorrow: %s", feed.Name, reason)
+		err := database.DeferFeedUntilTomorrow(feed.ID)
+		if err != nil {
+			log.Errorf("Error deferring feed %s: %v", feed.Name, err)
+		}
+		return
+	}
+	log.Debugf("Worker fetching feed: %s (%s)", feed.Name, feed.URL)
+	quirks, err := database.FeedQuirksByID(feed.ID)
+	if err != nil {
+		log.Errorf("Error loading quirks for feed %s: %v", feed.Name, err)
+	}
+	validators, err := database.FeedValidators(feed.ID)
+	if err != nil {
+		log.Errorf("Error loading validators for feed %s: %v", feed.Name, err)
+	}
+	started := time.Now()
+	entry := models.FetchLogEntry{FeedID: feed.ID, Instance: a.instanceID, Worker: worker}
+	fetcher, err := a.fetcher.TLS(quirks)
+	if err != nil {
+		err = fmt.Errorf("TLS quirks: %w", err)
+		log.Errorf("Error fetching feed %s: %v", feed.URL, err)
+		a.activity.fail(feed, err)
+		a.feedFailed(database, log, feed, err)
+		entry.Error = err.Error()
+		a.recordFetch(database, log, entry, started)
+		return
+	}
+	itemCount := 0
+	stored := true
+	var pending []models.Article
+	parsed, resp, err := fetcher.Stream(a.ctx, feed.URL, validators, func(item models.RSSItem) error {
+		itemCount++
+		article := toArticle(log, feed, quirks, item)
+		if !a.pipeline.run(candidate{log: log, feed: feed, quirks: quirks, article: &article}) {
+			return nil
+		}
+		pending = append(pending, article)
+		if len(pending) >= bulkBatchSize {
+			n, ok := a.storeArticles(database, log, pending)
+			entry.NewItems, stored = entry.NewItems+n, stored && ok
+			pending = pending[:0]
+		}
+		return nil
+	})
+	entry.Status, entry.Bytes, entry.DecodedBytes, entry.Items = resp.Status, resp.Bytes, resp.DecodedBytes, itemCount
+	if err != nil && a.ctx.Err() != nil {
+		// Cut short by shutdown: the batches already stored are whole, the
+		// rest is fetched again next time, and the feed is not at fault.
+		log.Infof("Fetch of feed %s interrupted by shutdown after %d items", feed.Name, itemCount)
+		entry.Error = "interrupted by shutdown"
+		a.recordFetch(database, log, entry, started)
+		return
+	}
+	unchanged := errors.Is(err, rss.ErrNotModified)
+	if err != nil && !unchanged {
+		log.Errorf("Error fetching/parsing feed %s: %v", feed.URL, err)
+		a.activity.fail(feed, err)
+		a.feedFailed(database, log, feed, err)
+		entry.Error = err.Error()
+		a.recordFetch(database, log, entry, started)
+		return
+	}
+	err = database.ResetFeedFailures(feed.ID)
+	if err != nil {
+		log.Errorf("Error resetting failures of feed %s: %v", feed.URL, err)
+	}
+	var schedule models.FeedSchedule
+	if unchanged {
+		// Nothing to parse or store; keep the hints the feed last declared.
+		log.Debugf("Feed %s unchanged since its last fetch (status %d)", feed.Name, resp.Status)
+		schedule, err = database.FeedSchedule(feed.ID)
+		if err != nil {
+			log.Errorf("Error loading schedule of feed %s: %v", feed.Name, err)
+		}
+	} else {
+		log.Debugf("Parsed %d items from feed %s", itemCount, feed.Name)
+		a.activity.items.Add(int64(itemCount))
+		n, ok := a.storeArticles(database, log, pending)
+		entry.NewItems, stored = entry.NewItems+n, stored && ok
+		schedule = rss.ChannelSchedule(parsed)
+		// Articles that failed to store are retried on the next fetch,
+		// so only remember this version once all of them made it.
+		if stored {
+			err = database.SetFeedValidators(feed.ID, resp.Validators)
+			if err != nil {
+				log.Errorf("Error saving validators of feed %s: %v", feed.Name, err)
+			}
+		}
+	}
+	// A 304 renews the freshness of the stored response, so its
+	// Cache-Control or Expires replaces the one stored either way.
+	schedule.MaxAge = resp.MaxAge
+	a.recordFetch(database, log, entry, started)
+	err = database.UpdateFeedUpdatedAt(feed.ID)
+	if err != nil {
+		log.Errorf("Error updating feed %s: %v", feed.URL, err)
+	}
+	a.schedule(database, log, feed, schedule)
+}
+
+// overBudget reports why feed must not be fetched again today, or "" if
+// neither its own daily cap nor the global one is used up. A fetch that
+// starts under a cap can end over it, by up to CLI_APP_MAX_BODY_SIZE.
+func (a *Aggregator) overBudget(database *db.DB, feed models.Feed) string {
+	feedBytes, feedLimit, totalBytes, err := database.DailyUsage(feed.ID)
+	if err != nil {
+		a.log.Errorf("Error loading bandwidth usage of feed %s: %v", feed.Name, err)
+		return ""
+	}
+	if feedLimit > 0 && feedBytes >= feedLimit {
+		return fmt.Sprintf("it downloaded %d of its %d bytes a day", feedBytes, feedLimit)
+	}
+	if a.dailyByteLimit > 0 && totalBytes >= a.dailyByteLimit {
+		return fmt.Sprintf("all feeds downloaded %d of the %d bytes a day allowed by CLI_APP_DAILY_BYTE_LIMIT", totalBytes, a.dailyByteLimit)
+	}
+	return ""
+}
+
+// recordFetch stamps entry with the time since started, counts it in the
+// current tick's summary and appends it to fetch_log. A failure to record
+// is logged and otherwise ignored.
+func (a *Aggregator) recordFetch(database *db.DB, log logging.Logger, entry models.FetchLogEntry, started time.Time) {
+	entry.Duration = time.Since(started)
+	a.ticks.fetched(entry)
+	err := database.RecordFetch(entry)
+	if err != nil {
+		log.Errorf("Error recording fetch of feed %s: %v", entry.FeedID, err)
+	}
+}
+
+// feedFailed counts a failed fetch against the feed. The failure that
+// makes reportAt in a row is sent to the error reporter, and the one that
+// makes disableAt disables the feed; the count survives restarts and only
+// a success or enabling the feed resets it.
+func (a *Aggregator) feedFailed(database *db.DB, log logging.Logger, feed models.Feed, err error) {
+	n, dbErr := database.RecordFeedFailure(feed.ID)
+	if dbErr != nil {
+		log.Errorf("Error counting failure of feed %s: %v", feed.URL, dbErr)
+		return
+	}
+	if n == a.reportAt {
+		a.reporter.Error(errreport.Event{
+			Message: fmt.Sprintf("Feed %s failed %d fetches in a row: %v", feed.Name, n, err),
+			Tags:    map[string]string{"feed": feed.Name, "fetch_id": log.FetchID()},
+			Extra:   map[string]any{"url": feed.URL, "error": err.Error(), "consecutive_failures": n},
+		})
+	}
+	if a.disableAt > 0 && n >= a.disableAt {
+		a.disableFeed(database, log, feed, n, err)
+	}
+}
+
+// disableFeed stops scheduling a feed that failed n fetches in a row and
+// tells the error reporter, with err as the reason.
+func (a *Aggregator) disableFeed(database *db.DB, log logging.Logger, feed models.Feed, n int, err error) {
+	disabled, dbErr := database.DisableFeed(feed.ID, err.Error())
+	if dbErr != nil {
+		log.Errorf("Error disabling feed %s: %v", feed.URL, dbErr)
+		return
+	}
+	if !disabled {
+		return
+	}
+	log.Errorf("Disabled feed %s after %d failed fetches in a row (enable it with rsshub enable --name %s): %v", feed.Name, n, feed.Name, err)
+	database.Audit(db.Actor{Name: a.instanceID, Source: db.SourceDaemon}, "disable-feed", feed.Name, "", err.Error())
+	a.reporter.Error(errreport.Event{
+		Message: fmt.Sprintf("Feed %s disabled after %d failed fetches in a row: %v", feed.Name, n, err),
+		Tags:    map[string]string{"feed": feed.Name, "fetch_id": log.FetchID(), "action": "disabled"},
+		Extra:   map[string]any{"url": feed.URL, "error": err.Error(), "consecutive_failures": n},
+	})
+}
+
+// toArticle is the parse step: it turns a fetched item into an article of
+// feed, as published, for the pipeline to process. Items without a usable
+// date of their own are dated by rss.ItemPublished's fallbacks.
+func toArticle(log logging.Logger, feed models.Feed, quirks models.FeedQuirks, item models.RSSItem) models.Article {
+	pubDate, source, unparsed := rss.ItemPublished(item, quirks.DateLayout, time.Now())
+	if unparsed != "" {
+		log.Errorf("Error parsing date '%s' for item %s; dated by %s instead", unparsed, item.Link, source)
+	}
+	return models.Article{
+		Title:       item.Title,
+		Link:        item.Link,
+		Description: rss.ItemBody(item),
+		Author:      rss.ItemAuthor(item),
+		PublishedAt: pubDate,
+		FeedID:      feed.ID,
+
+		PublishedAtSource: source,
+
+		GUID:            strings.TrimSpace(item.GUID),
+		CommentsURL:     strings.TrimSpace(item.Comments),
+		EnclosureURL:    strings.TrimSpace(item.Enclosure.URL),
+		EnclosureType:   strings.TrimSpace(item.Enclosure.Type),
+		EnclosureLength: rss.EnclosureLength(item.Enclosure),
+		Location:        rss.ItemLocation(item),
+
+		Duration: rss.ItemDuration(item.ITunesDuration),
+		Episode:  rss.ItemNumber(item.ITunesEpisode),
+		Season:   rss.ItemNumber(item.ITunesSeason),
+		ImageURL: strings.TrimSpace(item.ITunesImage.Href),
+
+		VideoID:      strings.TrimSpace(item.VideoID),
+		ThumbnailURL: rss.ItemThumbnail(item),
+		Views:        rss.ItemViews(item),
+
+		Raw: string(item.Raw),
+	}
+}
+
+// storeArticles inserts new articles and revises known ones, returning
+// how many were new and whether every article was stored without error.
+// Their markup is kept too when the fetcher keeps it.
+func (a *Aggregator) storeArticles(database *db.DB, log logging.Logger, articles []models.Article) (int, bool) {
+	if a.fetcher.KeepRaw {
+		err := database.SaveRawItems(articles)
+		if err != nil {
+			log.Errorf("Error saving raw items: %v", err)
+		}
+	}
+	if len(articles) >= bulkThreshold {
+		inserted, revised, err := database.BulkInsertArticles(articles, a.dedupWindow)
+		if err != nil {
+			log.Errorf("Error bulk inserting %d articles: %v", len(articles), err)
+			return 0, false
+		}
+		log.Debugf("Bulk inserted %d of %d articles, revised %d", inserted, len(articles), revised)
+		return int(inserted), true
+	}
+	if a.batcher != nil {
+		return a.batcher.store(log, articles)
+	}
+	inserted, ok := 0, true
+	for i := range articles {
+		added, err := a.storeArticle(database, log, &articles[i])
+		if added {
+			inserted++
+		}
+		ok = ok && err == nil
+	}
+	return inserted, ok
+}
+
+func (a *Aggregator) storeArticle(database *db.DB, log logging.Logger, article *models.Article) (bool, error) {
+	exists, err := database.ArticleExists(article.FeedID, article.Link, a.dedupWindow)
+	if err != nil {
+		log.Errorf("Error checking if article exists: %v", err)
+		return false, err
 	}
-	for _, f := range formats {
-		t, err := time.Parse(f, s)
-		if err == nil {
-			return t, nil
+	if exists {
+		revised, err := database.ReviseArticle(article)
+		if err != nil {
+			log.Errorf("Error revising article %s: %v", article.Link, err)
+		} else if revised {
+			log.Debugf("Revised article: %s", article.Title)
+		} else {
+			log.Debugf("Article already exists: %s", article.Link)
 		}
+		return false, err
 	}
-	return time.Time{}, fmt.Errorf("no matching format for pubDate: %s", s)
+	err = database.InsertArticle(article)
+	if errors.Is(err, db.ErrExists) {
+		log.Debugf("Article already exists, before the dedup window: %s", article.Link)
+		return false, nil
+	}
+	if err != nil {
+		log.Errorf("Error inserting article %s: %v", article.Link, err)
+		return false, err
+	}
+	log.Debugf("Inserted article: %s", article.Title)
+	return true, nil
 }
 
+// Resize starts or retires workers until newWorkers are running. Retired
+// workers finish the feed they are fetching first. It fails if
+// newWorkers is out of range or the aggregator is stopping.
 func (a *Aggregator) Resize(newWorkers int) error {
-	if newWorkers < 1 {
-		return fmt.Errorf("workers must be at least 1")
+	_, err := a.resize(newWorkers)
+	return err
+}
+
+// resize is Resize, returning the number of workers there were before.
+func (a *Aggregator) resize(newWorkers int) (int, error) {
+	a.workersMu.Lock()
+	defer a.workersMu.Unlock()
+	return a.resizeLocked(newWorkers)
+}
+
+// resizeLocked is resize for a caller holding workersMu.
+func (a *Aggregator) resizeLocked(newWorkers int) (int, error) {
+	if newWorkers < 1 || newWorkers > config.MaxWorkers {
+		return 0, fmt.Errorf("workers must be between 1 and %d", config.MaxWorkers)
+	}
+	select {
+	case <-a.stopping:
+		return 0, ErrStopping
+	default:
 	}
 	oldWorkers := a.workers
 	a.workers = newWorkers
@@ -180,7 +825,7 @@ func (a *Aggregator) Resize(newWorkers int) error {
 			done := make(chan struct{})
 			a.doneChans = append(a.doneChans, done)
 			a.wg.Add(1)
-			go a.worker(done)
+			go a.worker(a.activity.newWorker(), done)
 		}
 	} else if newWorkers < oldWorkers {
 		for i := newWorkers; i < oldWorkers; i++ {
@@ -188,55 +833,145 @@ func (a *Aggregator) Resize(newWorkers int) error {
 		}
 		a.doneChans = a.doneChans[:newWorkers]
 	}
-	fmt.Printf("Resized workers from %d to %d\n", oldWorkers, newWorkers) // Debug
-	return nil
+	a.log.Debugf("Resized workers from %d to %d", oldWorkers, newWorkers)
+	return oldWorkers, nil
 }
 
-func (a *Aggregator) controlLoop() {
-	for {
-		conn, err := a.listener.Accept()
-		if err != nil {
-			continue // Allow graceful shutdown
-		}
-		go a.handleControl(conn)
-	}
+// workerCount returns how many workers are running.
+func (a *Aggregator) workerCount() int {
+	a.workersMu.Lock()
+	defer a.workersMu.Unlock()
+	return a.workers
 }
 
-func (a *Aggregator) handleControl(conn net.Conn) {
-	defer conn.Close()
-	buf := make([]byte, 1024)
-	n, err := conn.Read(buf)
+// Refresh queues the named feed for immediate processing, outside the
+// regular schedule, unless it is queued or being fetched already. It fails
+// rather than block when the queue is full.
+func (a *Aggregator) Refresh(name string) error {
+	database := &db.DB{DB: a.db, Log: a.log}
+	feed, err := database.GetFeedByName(name)
 	if err != nil {
-		return
+		return err
 	}
-	cmd := strings.TrimSpace(string(buf[:n]))
-	parts := strings.Split(cmd, " ")
-	if len(parts) < 2 {
-		return
+	if err := a.ctx.Err(); err != nil {
+		return err
+	}
+	select {
+	case <-a.stopping:
+		return ErrStopping
+	default:
+	}
+	if a.queue.offer(feed) == dropped {
+		return ErrQueueFull
+	}
+	return nil
+}
+
+// SetReporter sends panics and feeds that keep failing to r. It must be
+// called before Start.
+func (a *Aggregator) SetReporter(r *errreport.Reporter) {
+	a.reporter = r
+}
+
+// SetAPIAddr records the address the HTTP API listens on, followed by its
+// base path if any, so local CLI commands can discover it over the control
+// socket.
+func (a *Aggregator) SetAPIAddr(addr string) {
+	a.apiAddr.Store(addr)
+}
+
+// Pause stops the scheduler from enqueuing new feeds. Jobs already handed
+// to workers are left to finish. It reports false if already paused.
+func (a *Aggregator) Pause() bool {
+	return a.paused.CompareAndSwap(false, true)
+}
+
+// Resume lets the scheduler enqueue feeds again on the next tick.
+// It reports false if the aggregator was not paused.
+func (a *Aggregator) Resume() bool {
+	return a.paused.CompareAndSwap(true, false)
+}
+
+// Control runs one control command, as sent by the CLI over the socket or
+// the HTTP API, and returns the reply text, or a *ControlError. Commands
+// that change the daemon's state are recorded in the audit log under actor.
+func (a *Aggregator) Control(cmd string, actor db.Actor) (string, error) {
+	database := &db.DB{DB: a.db, Log: a.log}
+	parts := strings.Fields(cmd)
+	if len(parts) == 0 {
+		return "", &ControlError{Code: CodeUnknownCommand, Message: "empty command"}
 	}
 	switch parts[0] {
+	case "version":
+		return version.Get().String() + "\n", nil
+	case "status":
+		state := "running"
+		if until, ok := a.pauses.until(time.Now()); ok {
+			state = "pause hours until " + until.Format(time.DateTime)
+		}
+		if a.catchingUp() {
+			state = "catching up"
+		}
+		if a.paused.Load() {
+			state = "paused"
+		}
+		return fmt.Sprintf("%s\nstate: %s (up %s)\ninstance: %s\nscheduling: %s\ninterval: %s\nworkers: %d\n",
+			version.Get(), state, time.Since(a.startedAt).Round(time.Second), a.instanceID, a.scheduling, a.interval, a.workerCount()) + a.tickStatus(), nil
+	case "activity":
+		return a.activityJSON(), nil
+	case "ticks":
+		return a.ticksJSON(parts[1:])
+	case "api-addr":
+		addr, _ := a.apiAddr.Load().(string)
+		if addr == "" {
+			addr = "none"
+		}
+		return addr + "\n", nil
+	case "pause":
+		if !a.Pause() {
+			return "Fetching is already paused\n", nil
+		}
+		database.Audit(actor, "pause", "", "running", "paused")
+		return "Fetching paused: no new feeds will be scheduled until resumed\n", nil
+	case "resume":
+		if !a.Resume() {
+			return "Fetching is not paused\n", nil
+		}
+		database.Audit(actor, "resume", "", "paused", "running")
+		return "Fetching resumed\n", nil
 	case "set-interval":
+		if len(parts) < 2 {
+			return "", &ControlError{Code: CodeUsage, Message: "missing duration"}
+		}
 		dur, err := time.ParseDuration(parts[1])
 		if err != nil {
-			conn.Write([]byte("Invalid duration\n"))
-			return
+			return "", &ControlError{Code: CodeUsage, Message: "invalid duration " + parts[1]}
+		}
+		if dur < config.MinInterval {
+			return "", &ControlError{Code: CodeUsage, Message: fmt.Sprintf("interval must be at least %s", config.MinInterval)}
 		}
 		old := a.interval
 		a.interval = dur
 		a.ticker.Reset(dur)
-		conn.Write([]byte(fmt.Sprintf("Interval of fetching feeds changed from %s to %s\n", old, dur)))
+		database.Audit(actor, "set-interval", "", old.String(), dur.String())
+		return fmt.Sprintf("Interval of fetching feeds changed from %s to %s\n", old, dur), nil
 	case "set-workers":
+		if len(parts) < 2 {
+			return "", &ControlError{Code: CodeUsage, Message: "missing count"}
+		}
 		count, err := strconv.Atoi(parts[1])
 		if err != nil {
-			conn.Write([]byte("Invalid count\n"))
-			return
+			return "", &ControlError{Code: CodeUsage, Message: "invalid count " + parts[1]}
+		}
+		old, err := a.resize(count)
+		if errors.Is(err, ErrStopping) {
+			return "", &ControlError{Code: CodeStopping, Message: err.Error()}
 		}
-		old := a.workers
-		err = a.Resize(count)
 		if err != nil {
-			conn.Write([]byte(fmt.Sprintf("Error resizing workers: %v\n", err)))
-			return
+			return "", &ControlError{Code: CodeUsage, Message: err.Error()}
 		}
-		conn.Write([]byte(fmt.Sprintf("Number of workers changed from %d to %d\n", old, count)))
+		database.Audit(actor, "set-workers", "", strconv.Itoa(old), strconv.Itoa(count))
+		return fmt.Sprintf("Number of workers changed from %d to %d\n", old, count), nil
 	}
+	return "", &ControlError{Code: CodeUnknownCommand, Message: "unknown command " + parts[0]}
 }
@@ -0,0 +1,130 @@
+package aggregator
+
+import (
+	"time"
+
+	"rsshub/internal/db"
+	"rsshub/internal/logging"
+	"rsshub/internal/models"
+)
+
+// writeBatcher stores the articles of fetches with too few for a bulk
+// insert of their own together, across workers: one COPY and transaction
+// per batch rather than a few statements per article. A batch is stored
+// once it holds size articles, or delay after the first fetch joined it.
+// Each fetch waits for its batch, so what it counts as stored is.
+type writeBatcher struct {
+	db     *db.DB
+	log    logging.Logger
+	window time.Duration
+	size   int
+	delay  time.Duration
+
+	requests chan *batchRequest
+	done     chan struct{}
+}
+
+// batchRequest is one fetch's articles, waiting to be stored.
+type batchRequest struct {
+	log      logging.Logger
+	articles []models.Article
+	result   chan batchResult
+}
+
+type batchResult struct {
+	inserted int
+	ok       bool
+}
+
+// newWriteBatcher returns nil when size is 0, for no batching.
+func newWriteBatcher(database *db.DB, log logging.Logger, window time.Duration, size int, delay time.Duration) *writeBatcher {
+	if size <= 0 {
+		return nil
+	}
+	return &writeBatcher{
+		db:       database,
+		log:      log,
+		window:   window,
+		size:     size,
+		delay:    delay,
+		requests: make(chan *batchRequest),
+		done:     make(chan struct{}),
+	}
+}
+
+func (b *writeBatcher) run() {
+	defer close(b.done)
+	var batch []*batchRequest
+	var n int
+	var timer <-chan time.Time
+	for {
+		select {
+		case req, ok := <-b.requests:
+			if !ok {
+				b.flush(batch)
+				return
+			}
+			batch = append(batch, req)
+			n += len(req.articles)
+			if timer == nil {
+				timer = time.After(b.delay)
+			}
+			if n < b.size {
+				continue
+			}
+		case <-timer:
+		}
+		b.flush(batch)
+		batch, n, timer = nil, 0, nil
+	}
+}
+
+// store adds a fetch's articles to the next batch and returns, once it is
+// stored, how many of them were new and whether all of them were stored.
+func (b *writeBatcher) store(log logging.Logger, articles []models.Article) (int, bool) {
+	if len(articles) == 0 {
+		return 0, true
+	}
+	req := &batchRequest{log: log, articles: articles, result: make(chan batchResult, 1)}
+	b.requests <- req
+	r := <-req.result
+	return r.inserted, r.ok
+}
+
+// close stores what is left once no worker can call store any more.
+func (b *writeBatcher) close() {
+	close(b.requests)
+	<-b.done
+}
+
+// flush stores a batch. If it fails as a whole, say over one article the
+// database rejects, each fetch's articles are stored on their own so only
+// the feed at fault fails.
+func (b *writeBatcher) flush(batch []*batchRequest) {
+	if len(batch) == 0 {
+		return
+	}
+	var articles []models.Article
+	for _, req := range batch {
+		articles = append(articles, req.articles...)
+	}
+	inserted, revised, err := b.db.BulkInsertArticlesByFeed(articles, b.window)
+	if err == nil {
+		var total int64
+		for _, req := range batch {
+			n := inserted[req.articles[0].FeedID]
+			total += n
+			req.result <- batchResult{inserted: int(n), ok: true}
+		}
+		b.log.Debugf("Stored a batch of %d articles from %d fetches: %d new, %d revised", len(articles), len(batch), total, revised)
+		return
+	}
+	b.log.Errorf("Error storing a batch of %d articles from %d fetches, storing each fetch's separately: %v", len(articles), len(batch), err)
+	for _, req := range batch {
+		n, _, err := b.db.BulkInsertArticles(req.articles, b.window)
+		if err != nil {
+			req.log.Errorf("Error inserting %d articles: %v", len(req.articles), err)
+		}
+		req.result <- batchResult{inserted: int(n), ok: err == nil}
+	}
+}
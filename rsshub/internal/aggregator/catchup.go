@@ -0,0 +1,101 @@
+package aggregator
+
+import (
+	"time"
+
+	"rsshub/internal/config"
+	"rsshub/internal/db"
+)
+
+// catchUpTick spaces the scheduler's ticks while it catches up, so the
+// backlog is worked off as fast as the workers go rather than a batch per
+// interval.
+const catchUpTick = 5 * time.Second
+
+// catchUp is the scheduler's state while it works off the backlog of
+// overdue feeds left by downtime or pause hours. Its state is guarded by
+// the Aggregator's workersMu, as the worker count it changes is.
+type catchUp struct {
+	// after is how stale the backlog must be to catch up, 0 never; workers
+	// is how many workers to catch up with.
+	after   time.Duration
+	workers int
+
+	active bool
+	// restore is the worker count to go back to, unless it was changed
+	// while catching up.
+	restore int
+	boosted int
+}
+
+func newCatchUp(cfg *config.Config) catchUp {
+	workers := cfg.CatchUpWorkers
+	if workers == 0 {
+		workers = min(2*cfg.Workers, config.MaxWorkers)
+	}
+	return catchUp{after: cfg.CatchUpAfter, workers: workers}
+}
+
+// startCatchUp looks at the feeds due now and, if there are more than one
+// tick can pick and the stalest went unfetched for longer than the
+// policy allows, starts catching up. It reports whether it did.
+func (a *Aggregator) startCatchUp() bool {
+	if a.catchUp.after <= 0 || a.catchingUp() {
+		return false
+	}
+	if a.scheduling != config.SchedulingClaim && !a.campaign() {
+		return false
+	}
+	database := &db.DB{DB: a.db, Log: a.log}
+	n, oldest, err := database.DueBacklog()
+	if err != nil {
+		a.log.Errorf("Error measuring the backlog of due feeds: %v", err)
+		return false
+	}
+	a.workersMu.Lock()
+	defer a.workersMu.Unlock()
+	if n <= a.workers || oldest.IsZero() || time.Since(oldest) < a.catchUp.after {
+		return false
+	}
+	a.catchUp.active = true
+	a.catchUp.restore, a.catchUp.boosted = a.workers, a.workers
+	if a.catchUp.workers > a.workers {
+		if _, err := a.resizeLocked(a.catchUp.workers); err != nil {
+			a.log.Errorf("Error adding workers to catch up: %v", err)
+		} else {
+			a.catchUp.boosted = a.catchUp.workers
+		}
+	}
+	a.log.Infof("Catching up: %d feeds due, the stalest last fetched %s ago; fetching with %d workers, most active feeds first",
+		n, time.Since(oldest).Round(time.Minute), a.workers)
+	return true
+}
+
+// endCatchUp returns to the usual worker count once a tick finds fewer
+// feeds due than it could pick.
+func (a *Aggregator) endCatchUp() {
+	a.workersMu.Lock()
+	defer a.workersMu.Unlock()
+	a.catchUp.active = false
+	if a.workers == a.catchUp.boosted && a.catchUp.boosted != a.catchUp.restore {
+		if _, err := a.resizeLocked(a.catchUp.restore); err != nil {
+			a.log.Errorf("Error removing catch-up workers: %v", err)
+		}
+	}
+	a.log.Infof("Caught up: back to %d workers", a.workers)
+}
+
+// feedOrder is the order the scheduler picks due feeds in.
+func (a *Aggregator) feedOrder() db.FeedOrder {
+	if a.catchingUp() {
+		return db.ActiveFirst
+	}
+	return db.OldestFirst
+}
+
+// catchingUp reports whether the scheduler is catching up.
+func (a *Aggregator) catchingUp() bool {
+	a.workersMu.Lock()
+	defer a.workersMu.Unlock()
+	return a.catchUp.active
+}
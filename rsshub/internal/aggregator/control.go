@@ -0,0 +1,102 @@
+package aggregator
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net"
+	"strings"
+	"time"
+
+	"rsshub/internal/db"
+)
+
+// The control socket speaks a line protocol: the client writes one command
+// per line and the daemon answers each with one ControlResponse encoded as
+// a single JSON line, in order, until either side closes the connection.
+// Blank lines are ignored.
+const (
+	// maxControlLine bounds a command line, newline included.
+	maxControlLine = 4096
+	// controlIdleTimeout closes connections that send nothing for this long.
+	controlIdleTimeout = time.Minute
+)
+
+// Control error codes, reported in ControlResponse.Code.
+const (
+	CodeUnknownCommand = "unknown_command"
+	CodeUsage          = "usage"
+	CodeStopping       = "stopping"
+	CodeLineTooLong    = "line_too_long"
+)
+
+// ControlResponse answers one control command on the socket. OK is false
+// when the command failed, with Code saying why and Error describing it.
+type ControlResponse struct {
+	OK    bool   `json:"ok"`
+	Reply string `json:"reply,omitempty"`
+	Code  string `json:"code,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// ControlError is a control command's failure.
+type ControlError struct {
+	Code    string
+	Message string
+}
+
+func (e *ControlError) Error() string {
+	return e.Message
+}
+
+// controlResponse wraps the outcome of Control for the socket.
+func controlResponse(reply string, err error) ControlResponse {
+	if err == nil {
+		return ControlResponse{OK: true, Reply: reply}
+	}
+	var ce *ControlError
+	if errors.As(err, &ce) {
+		return ControlResponse{Code: ce.Code, Error: ce.Message}
+	}
+	return ControlResponse{Code: CodeUsage, Error: err.Error()}
+}
+
+func (a *Aggregator) controlLoop() {
+	for {
+		conn, err := a.listener.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			return
+		}
+		if err != nil {
+			continue
+		}
+		go a.handleControl(conn)
+	}
+}
+
+func (a *Aggregator) handleControl(conn net.Conn) {
+	defer a.reporter.Recover("control")
+	defer conn.Close()
+	actor := db.Actor{Name: peerName(conn), Source: db.SourceCLI}
+	r := bufio.NewReaderSize(conn, maxControlLine)
+	enc := json.NewEncoder(conn)
+	for {
+		conn.SetReadDeadline(time.Now().Add(controlIdleTimeout))
+		line, err := r.ReadSlice('\n')
+		if errors.Is(err, bufio.ErrBufferFull) {
+			// The rest of the line cannot be told apart from the next
+			// command, so give up on the connection.
+			enc.Encode(ControlResponse{Code: CodeLineTooLong, Error: "command longer than 4096 bytes"})
+			return
+		}
+		// A final command may arrive without its newline before EOF.
+		if cmd := strings.TrimSpace(string(line)); cmd != "" {
+			if enc.Encode(controlResponse(a.Control(cmd, actor))) != nil {
+				return
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package aggregator
+
+import (
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// hostLimiter caps how many workers may fetch from the same hostname at
+// once, so subscribing to many feeds from one site doesn't hammer it.
+// Hosts are counted only while fetches from them run.
+type hostLimiter struct {
+	mu      sync.Mutex
+	limit   int
+	running map[string]int
+}
+
+func newHostLimiter(limit int) *hostLimiter {
+	if limit < 1 {
+		limit = 1
+	}
+	return &hostLimiter{limit: limit, running: map[string]int{}}
+}
+
+// tryAcquire takes a slot for rawURL's host without waiting, and reports
+// false if all of them are taken. The returned func releases the slot.
+func (h *hostLimiter) tryAcquire(rawURL string) (func(), bool) {
+	host := hostOf(rawURL)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.running[host] >= h.limit {
+		return nil, false
+	}
+	h.running[host]++
+	return func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		h.running[host]--
+		if h.running[host] == 0 {
+			delete(h.running, host)
+		}
+	}, true
+}
+
+func hostOf(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Hostname() == "" {
+		return rawURL
+	}
+	return strings.ToLower(u.Hostname())
+}
@@ -0,0 +1,61 @@
+package aggregator
+
+import (
+	"slices"
+	"time"
+
+	"rsshub/internal/config"
+)
+
+// pauseSchedule holds the daily hours and the days, in local time, on
+// which the scheduler picks no feeds.
+type pauseSchedule struct {
+	hours []config.Window
+	days  []time.Weekday
+}
+
+// covers reports whether t falls in a pause.
+func (p pauseSchedule) covers(t time.Time) bool {
+	if slices.Contains(p.days, t.Weekday()) {
+		return true
+	}
+	return slices.ContainsFunc(p.hours, func(w config.Window) bool { return w.Contains(t) })
+}
+
+// until returns when the pause covering t ends, following on through
+// pauses that adjoin it, and reports false if t is not in one.
+func (p pauseSchedule) until(t time.Time) (time.Time, bool) {
+	if !p.covers(t) {
+		return time.Time{}, false
+	}
+	// Pauses start and end at midnight or at a window's edge; a week of
+	// those is as far as a pause can run, since some day is left free.
+	for range 8 * (1 + 2*len(p.hours)) {
+		t = p.nextEdge(t)
+		if !p.covers(t) {
+			return t, true
+		}
+	}
+	return t, true
+}
+
+// nextEdge returns the first midnight or window edge after t.
+func (p pauseSchedule) nextEdge(t time.Time) time.Time {
+	var next time.Time
+	y, m, d := t.Date()
+	for day := range 2 {
+		at := func(clock time.Duration) time.Time {
+			return time.Date(y, m, d+day, 0, int(clock/time.Minute), 0, 0, t.Location())
+		}
+		edges := []time.Time{at(0)}
+		for _, w := range p.hours {
+			edges = append(edges, at(w.From), at(w.To))
+		}
+		for _, e := range edges {
+			if e.After(t) && (next.IsZero() || e.Before(next)) {
+				next = e
+			}
+		}
+	}
+	return next
+}
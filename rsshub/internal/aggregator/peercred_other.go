@@ -0,0 +1,11 @@
+//go:build !linux
+
+package aggregator
+
+import "net"
+
+// peerName names the local user on the other end of a control socket
+// connection. Peer credentials are only read on Linux.
+func peerName(conn net.Conn) string {
+	return "unknown"
+}
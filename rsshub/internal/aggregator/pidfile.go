@@ -0,0 +1,108 @@
+package aggregator
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RunningError is returned by Start when another daemon already serves
+// the control socket.
+type RunningError struct {
+	PID int
+}
+
+func (e *RunningError) Error() string {
+	if e.PID == 0 {
+		return "background process is already running"
+	}
+	return fmt.Sprintf("background process is already running (pid %d)", e.PID)
+}
+
+// PIDPath is the PID file kept next to the control socket at sockPath. The
+// running daemon holds a lock on it, so a crash releases it on its own.
+func PIDPath(sockPath string) string {
+	return sockPath + ".pid"
+}
+
+// ReadPID returns the PID recorded next to sockPath and whether that
+// process is still alive; it returns 0 if there is no readable PID file.
+func ReadPID(sockPath string) (pid int, alive bool) {
+	b, err := os.ReadFile(PIDPath(sockPath))
+	if err != nil {
+		return 0, false
+	}
+	pid, err = strconv.Atoi(strings.TrimSpace(string(b)))
+	if err != nil || pid <= 0 {
+		return 0, false
+	}
+	return pid, processAlive(pid)
+}
+
+// claimSocket makes this process the owner of the control socket: it
+// locks the PID file, failing with a *RunningError if another daemon holds
+// it, then clears whatever a crashed daemon left at the socket path.
+func (a *Aggregator) claimSocket() error {
+	f, err := os.OpenFile(PIDPath(a.sockPath), os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return err
+	}
+	if err := lockFile(f); err != nil {
+		f.Close()
+		pid, _ := ReadPID(a.sockPath)
+		return &RunningError{PID: pid}
+	}
+	if err := a.reclaimSocket(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Truncate(0); err == nil {
+		_, err = f.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0)
+	}
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("writing %s: %w", f.Name(), err)
+	}
+	a.pidFile = f
+	return nil
+}
+
+// reclaimSocket removes a stale socket at a.sockPath. With the PID file
+// locked no rsshub daemon can be listening there, so a peer that answers
+// is some other program, or a daemon from before PID files, and is left
+// alone.
+func (a *Aggregator) reclaimSocket() error {
+	info, err := os.Lstat(a.sockPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("%s exists and is not a socket", a.sockPath)
+	}
+	conn, err := net.DialTimeout("unix", a.sockPath, time.Second)
+	if err == nil {
+		conn.Close()
+		return fmt.Errorf("another program is listening on %s", a.sockPath)
+	}
+	if err := os.Remove(a.sockPath); err != nil {
+		return err
+	}
+	a.log.Infof("Removed stale control socket %s left by a stopped daemon", a.sockPath)
+	return nil
+}
+
+// releaseSocket removes the PID file and drops its lock.
+func (a *Aggregator) releaseSocket() {
+	if a.pidFile == nil {
+		return
+	}
+	os.Remove(a.pidFile.Name())
+	a.pidFile.Close()
+}
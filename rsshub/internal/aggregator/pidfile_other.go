@@ -0,0 +1,20 @@
+//go:build !unix
+
+package aggregator
+
+import "os"
+
+// lockFile is a no-op where advisory locks are unavailable; the socket
+// check in reclaimSocket still catches a live daemon.
+func lockFile(f *os.File) error {
+	return nil
+}
+
+func processAlive(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	p.Release()
+	return true
+}
@@ -0,0 +1,105 @@
+package aggregator
+
+import (
+	"html"
+	"strings"
+
+	"rsshub/internal/config"
+	"rsshub/internal/logging"
+	"rsshub/internal/models"
+	"rsshub/internal/rss"
+)
+
+// candidate is a parsed article on its way through the pipeline, with
+// the feed it came from.
+type candidate struct {
+	log     logging.Logger
+	feed    models.Feed
+	quirks  models.FeedQuirks
+	article *models.Article
+}
+
+// A step processes one item between parsing and storing. It returns false
+// to drop the item.
+type step func(c candidate) bool
+
+var steps = map[string]step{
+	config.StepNormalize: normalize,
+	config.StepFilter:    filter,
+	config.StepEnrich:    enrich,
+}
+
+// pipeline holds the configured steps: the default ones and those of
+// feeds with their own.
+type pipeline struct {
+	defaults []step
+	feeds    map[string][]step
+}
+
+func newPipeline(cfg *config.Config) pipeline {
+	p := pipeline{defaults: stepsNamed(cfg.Pipeline), feeds: map[string][]step{}}
+	for name, names := range cfg.FeedPipelines {
+		p.feeds[name] = stepsNamed(names)
+	}
+	return p
+}
+
+func stepsNamed(names []string) []step {
+	out := make([]step, 0, len(names))
+	for _, n := range names {
+		out = append(out, steps[n])
+	}
+	return out
+}
+
+// run passes a candidate through the steps configured for its feed and
+// reports whether it should be stored.
+func (p pipeline) run(c candidate) bool {
+	s, ok := p.feeds[c.feed.Name]
+	if !ok {
+		s = p.defaults
+	}
+	for _, fn := range s {
+		if !fn(c) {
+			return false
+		}
+	}
+	return true
+}
+
+// normalize applies the feed's quirks to the title and description.
+func normalize(c candidate) bool {
+	a := c.article
+	if c.quirks.StripTitlePrefix != "" {
+		a.Title = strings.TrimSpace(strings.TrimPrefix(a.Title, c.quirks.StripTitlePrefix))
+	}
+	if c.quirks.PlainDescription {
+		// Stored descriptions are HTML; escape text that only looks like it.
+		a.Description = html.EscapeString(a.Description)
+	}
+	return true
+}
+
+// filter drops items rsshub cannot keep track of or show: those without
+// a link, by which articles are identified, and those without any text.
+func filter(c candidate) bool {
+	a := c.article
+	if strings.TrimSpace(a.Link) == "" {
+		c.log.Debugf("Dropping item %q of feed %s: no link", a.Title, c.feed.Name)
+		return false
+	}
+	if strings.TrimSpace(a.Title) == "" && rss.WordCount(a.Description) == 0 {
+		c.log.Debugf("Dropping item %s of feed %s: no title or description", a.Link, c.feed.Name)
+		return false
+	}
+	return true
+}
+
+// enrich derives what is computed from the text: its length and the hash
+// changes are detected by.
+func enrich(c candidate) bool {
+	a := c.article
+	a.WordCount = rss.WordCount(a.Description)
+	a.ContentHash = rss.ContentHash(a.Title, a.Description)
+	return true
+}
@@ -0,0 +1,88 @@
+package aggregator
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"rsshub/internal/models"
+)
+
+// Outcomes of offering a feed to the queue.
+const (
+	queued = iota
+	merged
+	dropped
+)
+
+// feedQueue hands feeds from the scheduler to the workers without ever
+// blocking the scheduler. A feed that is already queued or being fetched
+// is not queued again: the pending fetch will pick up whatever is new. A
+// feed offered while the queue is full is dropped; it stays due, so a
+// later tick offers it again.
+type feedQueue struct {
+	jobs chan models.Feed
+
+	mu      sync.Mutex
+	pending map[uuid.UUID]bool // queued or being fetched
+
+	merged    atomic.Int64
+	dropped   atomic.Int64
+	fullSince atomic.Int64 // unix nanoseconds; 0 while there is room
+}
+
+func newFeedQueue(size int) *feedQueue {
+	return &feedQueue{jobs: make(chan models.Feed, size), pending: make(map[uuid.UUID]bool)}
+}
+
+// offer queues feed if it is not pending already and there is room.
+func (q *feedQueue) offer(feed models.Feed) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.pending[feed.ID] {
+		q.merged.Add(1)
+		return merged
+	}
+	select {
+	case q.jobs <- feed:
+		q.pending[feed.ID] = true
+		q.fullSince.Store(0)
+		return queued
+	default:
+		q.dropped.Add(1)
+		q.fullSince.CompareAndSwap(0, time.Now().UnixNano())
+		return dropped
+	}
+}
+
+// done marks a feed taken from the queue as fetched, so it can be queued
+// again.
+func (q *feedQueue) done(id uuid.UUID) {
+	q.mu.Lock()
+	delete(q.pending, id)
+	q.mu.Unlock()
+}
+
+// inFlight returns the feeds queued or being fetched, which the scheduler
+// leaves out when picking due feeds so a slow feed does not take a slot on
+// every tick while the feeds after it wait.
+func (q *feedQueue) inFlight() []uuid.UUID {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	ids := make([]uuid.UUID, 0, len(q.pending))
+	for id := range q.pending {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// saturatedSince is when offers started being dropped for lack of room,
+// or zero if the last offer that needed room found it.
+func (q *feedQueue) saturatedSince() time.Time {
+	n := q.fullSince.Load()
+	if n == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, n)
+}
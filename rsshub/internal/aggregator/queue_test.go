@@ -0,0 +1,107 @@
+package aggregator
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"rsshub/internal/models"
+)
+
+// TestFeedQueue runs offers against a small queue, with workers taking
+// feeds from it and finishing them, and checks what each offer did and
+// what the queue counted.
+func TestFeedQueue(t *testing.T) {
+	// Each step offers, takes (as a worker starting a fetch) or finishes
+	// (as a worker done fetching) one of the feeds by index.
+	type step struct {
+		op   string
+		feed int
+		want int // outcome of an offer
+	}
+	tests := []struct {
+		name          string
+		size          int
+		steps         []step
+		wantMerged    int64
+		wantDropped   int64
+		wantSaturated bool
+	}{
+		{
+			name: "merge of a queued feed",
+			size: 2,
+			steps: []step{
+				{op: "offer", feed: 0, want: queued},
+				{op: "offer", feed: 0, want: merged},
+				{op: "offer", feed: 1, want: queued},
+			},
+			wantMerged: 1,
+		},
+		{
+			name: "merge of a feed being fetched",
+			size: 1,
+			steps: []step{
+				{op: "offer", feed: 0, want: queued},
+				{op: "take", feed: 0},
+				{op: "offer", feed: 0, want: merged},
+				{op: "done", feed: 0},
+				{op: "offer", feed: 0, want: queued},
+			},
+			wantMerged: 1,
+		},
+		{
+			name: "drop when full",
+			size: 1,
+			steps: []step{
+				{op: "offer", feed: 0, want: queued},
+				{op: "offer", feed: 1, want: dropped},
+				{op: "offer", feed: 2, want: dropped},
+			},
+			wantDropped:   2,
+			wantSaturated: true,
+		},
+		{
+			name: "saturation cleared once there is room",
+			size: 1,
+			steps: []step{
+				{op: "offer", feed: 0, want: queued},
+				{op: "offer", feed: 1, want: dropped},
+				{op: "take", feed: 0},
+				{op: "offer", feed: 1, want: queued},
+			},
+			wantDropped: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := newFeedQueue(tt.size)
+			feeds := []models.Feed{{ID: uuid.New()}, {ID: uuid.New()}, {ID: uuid.New()}}
+			for i, s := range tt.steps {
+				feed := feeds[s.feed]
+				switch s.op {
+				case "offer":
+					if got := q.offer(feed); got != s.want {
+						t.Fatalf("step %d: offer of feed %d = %d, want %d", i, s.feed, got, s.want)
+					}
+					if s.want == dropped && q.saturatedSince().IsZero() {
+						t.Fatalf("step %d: saturatedSince not set after a drop", i)
+					}
+				case "take":
+					if got := <-q.jobs; got.ID != feed.ID {
+						t.Fatalf("step %d: took %s, want feed %d", i, got.ID, s.feed)
+					}
+				case "done":
+					q.done(feed.ID)
+				}
+			}
+			if got := q.merged.Load(); got != tt.wantMerged {
+				t.Errorf("merged = %d, want %d", got, tt.wantMerged)
+			}
+			if got := q.dropped.Load(); got != tt.wantDropped {
+				t.Errorf("dropped = %d, want %d", got, tt.wantDropped)
+			}
+			if got := !q.saturatedSince().IsZero(); got != tt.wantSaturated {
+				t.Errorf("saturated = %v, want %v", got, tt.wantSaturated)
+			}
+		})
+	}
+}
@@ -0,0 +1,188 @@
+package aggregator
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"rsshub/internal/config"
+	"rsshub/internal/db"
+	"rsshub/internal/logging"
+	"rsshub/internal/models"
+	"rsshub/internal/rss"
+)
+
+// ReingestResult counts what Reingest did with a feed's items.
+type ReingestResult struct {
+	Items     int // items in the feed
+	Dropped   int // items the feed's pipeline filtered out
+	Older     int // items published before the period, left alone
+	Inserted  int // items that had not been stored
+	Revised   int // stored articles whose title or description changed
+	Updated   int // stored articles whose date or other metadata changed
+	Unchanged int
+	// Missing counts articles stored for the period that the feed no
+	// longer carries. They are reported, not deleted.
+	Missing int
+	// Unparsable counts kept items the parser now rejects, for Reparse.
+	Unparsable int
+}
+
+// Reingest fetches feed afresh, ignoring its validators, and reconciles
+// the items published within since with its stored articles under the
+// feed's current quirks: new items are stored, changed ones revised, and
+// dates or other metadata parsed differently are corrected. It is meant
+// for after a quirk or parser fix, and looks through all stored articles
+// whatever CLI_APP_DEDUP_WINDOW says.
+func Reingest(ctx context.Context, database *db.DB, cfg *config.Config, feed models.Feed, since time.Duration) (ReingestResult, error) {
+	var res ReingestResult
+	quirks, err := database.FeedQuirksByID(feed.ID)
+	if err != nil {
+		return res, fmt.Errorf("loading quirks: %w", err)
+	}
+	fetcher, err := rss.NewFetcher(cfg).TLS(quirks)
+	if err != nil {
+		return res, fmt.Errorf("TLS quirks: %w", err)
+	}
+	from := time.Now().Add(-since)
+	log := logging.Logger{}
+	pipe := newPipeline(cfg)
+	seen := make(map[string]bool)
+	var kept []models.Article
+	_, resp, err := fetcher.Stream(ctx, feed.URL, models.FeedValidators{}, func(item models.RSSItem) error {
+		res.Items++
+		article := toArticle(log, feed, quirks, item)
+		if !pipe.run(candidate{log: log, feed: feed, quirks: quirks, article: &article}) {
+			res.Dropped++
+			return nil
+		}
+		if article.PublishedAt.Before(from) {
+			res.Older++
+			return nil
+		}
+		seen[article.Link] = true
+		if article.Raw != "" {
+			kept = append(kept, article)
+		}
+		return reconcile(database, &article, &res)
+	})
+	if err != nil {
+		return res, err
+	}
+	if err := database.SaveRawItems(kept); err != nil {
+		return res, fmt.Errorf("saving raw items: %w", err)
+	}
+	if err := refreshIfUpdated(database, feed, res); err != nil {
+		return res, err
+	}
+	err = database.SetFeedValidators(feed.ID, resp.Validators)
+	if err != nil {
+		return res, fmt.Errorf("saving validators: %w", err)
+	}
+
+	links, err := database.ArticleLinksSince(feed.ID, from)
+	if err != nil {
+		return res, fmt.Errorf("listing stored articles: %w", err)
+	}
+	for _, link := range links {
+		if !seen[link] {
+			res.Missing++
+		}
+	}
+	return res, nil
+}
+
+// Reparse runs the markup kept for a feed's items published within since
+// back through the current parser and the feed's pipeline, and reconciles
+// the result with its stored articles as Reingest does, without fetching
+// anything. It needs CLI_APP_STORE_RAW_ITEMS to have been on when the
+// items were fetched. Items that fall back to a fetch-time date are dated
+// now if their article is gone and stored again.
+func Reparse(database *db.DB, cfg *config.Config, feed models.Feed, since time.Duration) (ReingestResult, error) {
+	var res ReingestResult
+	quirks, err := database.FeedQuirksByID(feed.ID)
+	if err != nil {
+		return res, fmt.Errorf("loading quirks: %w", err)
+	}
+	raws, err := database.RawItems(feed.ID, time.Now().Add(-since))
+	if err != nil {
+		return res, fmt.Errorf("loading raw items: %w", err)
+	}
+	log := logging.Logger{}
+	pipe := newPipeline(cfg)
+	for _, raw := range raws {
+		_, err := rss.Parse(bytes.NewReader(raw), 0, func(item models.RSSItem) error {
+			res.Items++
+			article := toArticle(log, feed, quirks, item)
+			if !pipe.run(candidate{log: log, feed: feed, quirks: quirks, article: &article}) {
+				res.Dropped++
+				return nil
+			}
+			return reconcile(database, &article, &res)
+		})
+		var storeErr *storeError
+		if errors.As(err, &storeErr) {
+			return res, storeErr.err
+		}
+		if err != nil {
+			res.Unparsable++
+		}
+	}
+	return res, refreshIfUpdated(database, feed, res)
+}
+
+// storeError marks a database failure from within a parse callback, to
+// tell it apart from the parser's own errors.
+type storeError struct{ err error }
+
+func (e *storeError) Error() string { return e.err.Error() }
+
+// reconcile stores article if it is new and reconciles it with its stored
+// copy otherwise, whatever CLI_APP_DEDUP_WINDOW says, counting the outcome
+// in res.
+func reconcile(database *db.DB, article *models.Article, res *ReingestResult) error {
+	exists, err := database.ArticleExists(article.FeedID, article.Link, 0)
+	if err != nil {
+		return &storeError{err}
+	}
+	if !exists {
+		err = database.InsertArticle(article)
+		switch {
+		case errors.Is(err, db.ErrExists):
+			res.Unchanged++
+		case err == nil:
+			res.Inserted++
+		default:
+			return &storeError{fmt.Errorf("storing %s: %w", article.Link, err)}
+		}
+		return nil
+	}
+	revised, updated, err := database.ReconcileArticle(article)
+	if err != nil {
+		return &storeError{fmt.Errorf("updating %s: %w", article.Link, err)}
+	}
+	if revised {
+		res.Revised++
+	}
+	if updated {
+		res.Updated++
+	}
+	if !revised && !updated {
+		res.Unchanged++
+	}
+	return nil
+}
+
+// refreshIfUpdated recomputes feed's aggregates when dates may have moved,
+// which the per-day counts follow.
+func refreshIfUpdated(database *db.DB, feed models.Feed, res ReingestResult) error {
+	if res.Updated == 0 {
+		return nil
+	}
+	if err := database.RefreshFeedStats(feed.ID); err != nil {
+		return fmt.Errorf("refreshing stats: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package aggregator
+
+import (
+	"slices"
+	"time"
+
+	"rsshub/internal/db"
+	"rsshub/internal/logging"
+	"rsshub/internal/models"
+)
+
+// pollPolicy bounds how far a feed's schedule may space out its fetches.
+type pollPolicy struct {
+	// maxDelay caps how long published hints can postpone a fetch.
+	maxDelay time.Duration
+	// adaptive polls feeds about twice per observed cadence, kept within
+	// minInterval and maxInterval.
+	adaptive    bool
+	minInterval time.Duration
+	maxInterval time.Duration
+}
+
+// schedule records a feed's polling hints and cadence after a successful
+// fetch and defers its next fetch accordingly.
+func (a *Aggregator) schedule(database *db.DB, log logging.Logger, feed models.Feed, s models.FeedSchedule) {
+	if a.poll.adaptive {
+		cadence, err := database.FeedCadence(feed.ID)
+		if err != nil {
+			log.Errorf("Error measuring cadence of feed %s: %v", feed.Name, err)
+		}
+		s.Cadence = cadence
+	}
+	now := time.Now()
+	next := nextFetch(now, s, a.poll)
+	if next.After(now) {
+		log.Debugf("Feed %s is next due at %s (ttl %s, declared update interval %s, max-age %s, cadence %s)",
+			feed.Name, next.UTC().Format(time.RFC3339), s.TTL, s.UpdateInterval, s.MaxAge, s.Cadence.Round(time.Minute))
+	}
+	err := database.SetFeedSchedule(feed.ID, s, next.Sub(now))
+	if err != nil {
+		log.Errorf("Error saving schedule of feed %s: %v", feed.Name, err)
+	}
+}
+
+// nextFetch returns when a feed fetched at now may be fetched again. The
+// adaptive interval comes first; the feed's TTL, declared update interval
+// or the freshness lifetime its server gave can lengthen it, and the UTC
+// hours and days it asks to skip push it further, but these published
+// hints never add more than p.maxDelay, and 0 ignores them.
+func nextFetch(now time.Time, s models.FeedSchedule, p pollPolicy) time.Time {
+	wait := p.interval(s.Cadence)
+	if p.maxDelay <= 0 {
+		return now.Add(wait)
+	}
+	limit := now.Add(wait + p.maxDelay)
+	next := now.Add(max(wait, s.TTL, s.UpdateInterval, s.MaxAge))
+	for next.Before(limit) && skipped(next, s) {
+		next = next.Truncate(time.Hour).Add(time.Hour)
+	}
+	if next.After(limit) {
+		return limit
+	}
+	return next
+}
+
+// interval is how long to wait between fetches of a feed that posts every
+// cadence on average: half of that, within the policy's bounds, so a new
+// post waits at most about half a gap. Without adaptive polling or a known
+// cadence it is 0, making the feed due on every tick as before.
+func (p pollPolicy) interval(cadence time.Duration) time.Duration {
+	if !p.adaptive || cadence <= 0 {
+		return 0
+	}
+	return min(max(cadence/2, p.minInterval), p.maxInterval)
+}
+
+// skipped reports whether t falls in one of a feed's skipHours or skipDays.
+func skipped(t time.Time, s models.FeedSchedule) bool {
+	t = t.UTC()
+	return slices.Contains(s.SkipHours, t.Hour()) || slices.Contains(s.SkipDays, t.Weekday())
+}
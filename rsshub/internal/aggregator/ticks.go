@@ -0,0 +1,161 @@
+package aggregator
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"rsshub/internal/models"
+)
+
+// TickSummary is what the daemon did between one tick of its scheduler and
+// the next: how many feeds were due, and how the fetches that finished in
+// the meantime went. Fetches are counted by when they finish, so a slow
+// one lands in the tick after the one that scheduled it.
+type TickSummary struct {
+	At time.Time `json:"at"`
+	// Due counts the feeds picked at the tick, Queued those handed to the
+	// workers; the rest were already queued or left for a later tick.
+	Due    int `json:"due"`
+	Queued int `json:"queued"`
+	// Attempted counts finished fetches, Errors the failed ones among them.
+	Attempted int   `json:"attempted"`
+	Items     int64 `json:"items"`
+	NewItems  int64 `json:"new_items"`
+	Errors    int   `json:"errors"`
+	// Error is set when the tick could not pick the due feeds.
+	Error string `json:"error,omitempty"`
+}
+
+// tickLog keeps the summaries of the last ticks, oldest first; the last
+// one is the tick in progress.
+type tickLog struct {
+	mu    sync.Mutex
+	size  int
+	ticks []TickSummary
+}
+
+func newTickLog(size int) *tickLog {
+	return &tickLog{size: size}
+}
+
+// begin starts the summary of a new tick, forgetting the oldest once
+// more than size are kept.
+func (l *tickLog) begin(at time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.ticks = append(l.ticks, TickSummary{At: at})
+	if len(l.ticks) > l.size {
+		l.ticks = append(l.ticks[:0], l.ticks[len(l.ticks)-l.size:]...)
+	}
+}
+
+// scheduled records the feeds the current tick picked and queued, or why
+// it could not pick any.
+func (l *tickLog) scheduled(due, queued int, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.ticks) == 0 {
+		return
+	}
+	t := &l.ticks[len(l.ticks)-1]
+	t.Due, t.Queued = due, queued
+	if err != nil {
+		t.Error = err.Error()
+	}
+}
+
+// fetched counts a finished fetch against the current tick. Fetches
+// finished before the first tick, such as those of rsshub refresh, are
+// not counted.
+func (l *tickLog) fetched(entry models.FetchLogEntry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.ticks) == 0 {
+		return
+	}
+	t := &l.ticks[len(l.ticks)-1]
+	t.Attempted++
+	t.Items += int64(entry.Items)
+	t.NewItems += int64(entry.NewItems)
+	if entry.Error != "" {
+		t.Errors++
+	}
+}
+
+// last returns up to n summaries, newest first; n <= 0 returns all.
+func (l *tickLog) last(n int) []TickSummary {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if n <= 0 || n > len(l.ticks) {
+		n = len(l.ticks)
+	}
+	out := make([]TickSummary, 0, n)
+	for i := len(l.ticks) - 1; i >= len(l.ticks)-n; i-- {
+		out = append(out, l.ticks[i])
+	}
+	return out
+}
+
+// Ticks returns the summaries of up to the last n scheduler ticks, newest
+// first, the tick in progress included; n <= 0 returns all that are kept.
+func (a *Aggregator) Ticks(n int) []TickSummary {
+	return a.ticks.last(n)
+}
+
+// ticksJSON answers the "ticks [n]" control command.
+func (a *Aggregator) ticksJSON(args []string) (string, error) {
+	n := 0
+	if len(args) > 0 {
+		var err error
+		n, err = strconv.Atoi(args[0])
+		if err != nil || n < 1 {
+			return "", &ControlError{Code: CodeUsage, Message: "invalid count " + args[0]}
+		}
+	}
+	b, err := json.Marshal(a.Ticks(n))
+	if err != nil {
+		return "", err
+	}
+	return string(b) + "\n", nil
+}
+
+// tickStatus sums up the kept ticks for the "status" control command:
+// the last finished tick, and totals over all of them.
+func (a *Aggregator) tickStatus() string {
+	ticks := a.Ticks(0)
+	// The newest tick is still collecting fetches.
+	if len(ticks) < 2 {
+		return "ticks: none finished yet\n"
+	}
+	var b strings.Builder
+	t := ticks[1]
+	fmt.Fprintf(&b, "last tick: %s ago: %d feeds due, %d fetched, %d items, %d new, %d errors",
+		time.Since(t.At).Round(time.Second), t.Due, t.Attempted, t.Items, t.NewItems, t.Errors)
+	if t.Error != "" {
+		fmt.Fprintf(&b, " (scheduling failed: %s)", t.Error)
+	}
+	b.WriteString("\n")
+	var sum TickSummary
+	failed := 0
+	for _, t := range ticks[1:] {
+		sum.Due += t.Due
+		sum.Attempted += t.Attempted
+		sum.Items += t.Items
+		sum.NewItems += t.NewItems
+		sum.Errors += t.Errors
+		if t.Error != "" {
+			failed++
+		}
+	}
+	fmt.Fprintf(&b, "last %d ticks: %d feeds due, %d fetched, %d items, %d new, %d errors",
+		len(ticks)-1, sum.Due, sum.Attempted, sum.Items, sum.NewItems, sum.Errors)
+	if failed > 0 {
+		fmt.Fprintf(&b, ", %d ticks failed to schedule", failed)
+	}
+	b.WriteString("\n")
+	return b.String()
+}
@@ -0,0 +1,149 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"rsshub/internal/auth"
+	"rsshub/internal/config"
+	"rsshub/internal/db"
+	"rsshub/internal/errreport"
+	"rsshub/internal/graphql"
+	"rsshub/internal/logging"
+	"rsshub/internal/rss"
+)
+
+// Controller is the part of the fetch daemon the API can drive.
+type Controller interface {
+	Refresh(feedName string) error
+	// Control runs a control command such as "pause" or "set-workers 5"
+	// on behalf of actor and returns the reply text, or an
+	// *aggregator.ControlError if the command was rejected.
+	Control(command string, actor db.Actor) (string, error)
+}
+
+// Server exposes rsshub over HTTP alongside the fetch daemon.
+type Server struct {
+	db     *db.DB
+	ctrl   Controller
+	dsn    string
+	token  string
+	srv    *http.Server
+	events *hub
+	schema *graphql.Schema
+	cancel context.CancelFunc
+
+	ipLimit    *limiter
+	tokenLimit *limiter
+
+	basePath string
+	origins  []string
+	proxies  []*net.IPNet
+
+	requireLogin bool
+	sessionTTL   time.Duration
+	oidc         *auth.OIDC
+
+	keepArticles bool
+
+	fetcher *rss.Fetcher
+
+	reporter *errreport.Reporter
+	log      logging.Logger
+}
+
+func NewServer(database *db.DB, cfg *config.Config, ctrl Controller) *Server {
+	s := &Server{
+		db:     database.WithReadCache(cfg.ReadCacheSize, cfg.ReadCacheTTL),
+		ctrl:   ctrl,
+		dsn:    cfg.DSN(),
+		token:  cfg.APIToken,
+		events: newHub(),
+
+		ipLimit:    newLimiter(cfg.APIRateLimit, cfg.APIRateBurst),
+		tokenLimit: newLimiter(cfg.APITokenRateLimit, cfg.APIRateBurst),
+
+		basePath: cfg.BasePath,
+		origins:  cfg.CORSOrigins,
+		proxies:  parseProxies(cfg.TrustedProxies),
+
+		requireLogin: cfg.RequireLogin,
+		sessionTTL:   cfg.SessionTTL,
+
+		keepArticles: cfg.KeepArticlesOnPurge,
+
+		fetcher: rss.NewFetcher(cfg),
+		log:     database.Log,
+	}
+	if cfg.OIDCIssuer != "" {
+		s.oidc = auth.NewOIDC(cfg.OIDCIssuer, cfg.OIDCClientID, cfg.OIDCClientSecret, cfg.OIDCRedirectURL)
+	}
+	s.schema = s.newSchema()
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/events", s.handleEvents)
+	mux.HandleFunc("GET /api/ws", s.handleWebSocket)
+	mux.HandleFunc("GET /api/graphql", s.handleGraphQL)
+	mux.HandleFunc("POST /api/graphql", s.handleGraphQL)
+	mux.HandleFunc("GET /api/graphql/schema", s.handleGraphQLSchema)
+	s.routeREST(mux)
+	s.routeAuth(mux)
+	s.routeShare(mux)
+	s.routeSubscribe(mux)
+	s.routeNotes(mux)
+	s.srv = &http.Server{
+		Addr:              cfg.HTTPAddr,
+		Handler:           s.reportPanics(s.forwarded(s.withBasePath(s.cors(s.rateLimit(s.authenticate(mux)))))),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	return s
+}
+
+// SetReporter sends panics in request handlers to r.
+func (s *Server) SetReporter(r *errreport.Reporter) {
+	s.reporter = r
+}
+
+// reportPanics reports a handler's panic before net/http recovers it and
+// drops the connection.
+func (s *Server) reportPanics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer s.reporter.Recover(r.Method + " " + r.URL.Path)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Start begins relaying article notifications and serving HTTP in the
+// background.
+func (s *Server) Start(parentCtx context.Context) error {
+	ctx, cancel := context.WithCancel(parentCtx)
+	s.cancel = cancel
+
+	events, err := db.ListenArticles(ctx, s.dsn, s.log)
+	if err != nil {
+		cancel()
+		return err
+	}
+	go s.events.run(events, s.db.InvalidateReadCache)
+
+	ln, err := net.Listen("tcp", s.srv.Addr)
+	if err != nil {
+		cancel()
+		return err
+	}
+	go func() {
+		err := s.srv.Serve(ln)
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.log.Errorf("HTTP server error: %v", err)
+		}
+	}()
+	return nil
+}
+
+func (s *Server) Stop(ctx context.Context) error {
+	s.cancel()
+	s.events.close()
+	return s.srv.Shutdown(ctx)
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+
+	"rsshub/internal/db"
+)
+
+// actor identifies the caller for the audit log: the signed-in user, or
+// else the name the client reports in X-Rsshub-Actor, if any, at its
+// remote address.
+func actor(r *http.Request) db.Actor {
+	host := clientIP(r)
+	name := host
+	if u, ok := requestUser(r); ok {
+		name = u.Name + "@" + host
+	} else if claimed := strings.TrimSpace(r.Header.Get("X-Rsshub-Actor")); claimed != "" {
+		name = claimed + "@" + host
+	}
+	return db.Actor{Name: name, Source: db.SourceAPI}
+}
+
+// clientIP is the address the request came from.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// authenticate admits requests carrying the configured API token or a
+// signed-in user's session cookie. Clients send the token as a bearer
+// token; browsers using EventSource or WebSocket, which cannot set
+// headers, may pass it as the access_token query parameter. The OpenAPI
+// document, the sign-in endpoints and public profiles are always
+// reachable, and with no token, OIDC or CLI_APP_REQUIRE_LOGIN the API is
+// open.
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if u, ok := s.sessionUser(r); ok {
+			if !s.sameOrigin(r) {
+				writeError(w, http.StatusForbidden, errors.New("cross-origin request refused"))
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), userKey{}, u)))
+			return
+		}
+		if !s.loginRequired() || publicPaths[r.URL.Path] || strings.HasPrefix(r.URL.Path, profilePrefix) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if s.token == "" || subtle.ConstantTimeCompare([]byte(requestToken(r)), []byte(s.token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="rsshub"`)
+			writeError(w, http.StatusUnauthorized, errors.New("sign in or pass a valid API token"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,123 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"rsshub/internal/models"
+)
+
+// sseKeepAlive is how often an idle event stream gets a comment line, so
+// proxies don't time the connection out.
+const sseKeepAlive = 30 * time.Second
+
+// hub fans article events out to every connected subscriber. Slow
+// subscribers miss events rather than stall the others.
+type hub struct {
+	mu     sync.Mutex
+	subs   map[chan models.ArticleEvent]struct{}
+	closed bool
+}
+
+func newHub() *hub {
+	return &hub{subs: map[chan models.ArticleEvent]struct{}{}}
+}
+
+// run passes on events until the channel closes, calling stored for each
+// first: a new article makes cached listings stale.
+func (h *hub) run(events <-chan models.ArticleEvent, stored func()) {
+	for ev := range events {
+		stored()
+		h.mu.Lock()
+		for sub := range h.subs {
+			select {
+			case sub <- ev:
+			default:
+			}
+		}
+		h.mu.Unlock()
+	}
+}
+
+// subscribe returns a channel of events that is closed when the hub shuts
+// down. A nil channel means the hub is already closed.
+func (h *hub) subscribe() chan models.ArticleEvent {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return nil
+	}
+	sub := make(chan models.ArticleEvent, 16)
+	h.subs[sub] = struct{}{}
+	return sub
+}
+
+func (h *hub) unsubscribe(sub chan models.ArticleEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.subs[sub]; ok {
+		delete(h.subs, sub)
+		close(sub)
+	}
+}
+
+func (h *hub) close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for sub := range h.subs {
+		delete(h.subs, sub)
+		close(sub)
+	}
+}
+
+// handleEvents streams new articles as server-sent events. The optional
+// "feed" query parameter restricts the stream to one feed by name.
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	sub := s.events.subscribe()
+	if sub == nil {
+		http.Error(w, "server shutting down", http.StatusServiceUnavailable)
+		return
+	}
+	defer s.events.unsubscribe(sub)
+	feed := r.URL.Query().Get("feed")
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(sseKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			flusher.Flush()
+		case ev, ok := <-sub:
+			if !ok {
+				return
+			}
+			if feed != "" && ev.FeedName != feed {
+				continue
+			}
+			data, err := json.Marshal(ev)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "id: %s\nevent: article\ndata: %s\n\n", ev.ID, data)
+			flusher.Flush()
+		}
+	}
+}
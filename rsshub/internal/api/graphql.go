@@ -0,0 +1,342 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"rsshub/internal/db"
+	"rsshub/internal/geo"
+	"rsshub/internal/graphql"
+	"rsshub/internal/models"
+	"rsshub/internal/rss"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// newSchema builds the GraphQL schema over feeds, articles and read state.
+func (s *Server) newSchema() *graphql.Schema {
+	pageInfo := &graphql.Object{
+		Name: "PageInfo",
+		Fields: []*graphql.Field{
+			{Name: "hasNextPage", Type: graphql.Boolean},
+			{Name: "endCursor", Type: graphql.String},
+		},
+	}
+	feed := &graphql.Object{Name: "Feed"}
+	article := &graphql.Object{Name: "Article"}
+	articleConnection := connection("Article", article, pageInfo)
+	feedConnection := connection("Feed", feed, pageInfo)
+	pageArgs := []graphql.Arg{
+		{Name: "first", Type: graphql.Int, Default: int64(defaultPageSize)},
+		{Name: "after", Type: graphql.String},
+	}
+	articleArgs := append([]graphql.Arg{{Name: "unread", Type: graphql.Boolean, Default: false}, {Name: "author", Type: graphql.String},
+		{Name: "near", Type: graphql.String}, {Name: "radius", Type: graphql.String}}, pageArgs...)
+
+	feed.Fields = []*graphql.Field{
+		{Name: "id", Type: graphql.ID, Resolve: func(p graphql.Params) (any, error) {
+			return p.Source.(models.Feed).ID.String(), nil
+		}},
+		{Name: "name", Type: graphql.String, Resolve: func(p graphql.Params) (any, error) {
+			return p.Source.(models.Feed).Name, nil
+		}},
+		{Name: "url", Type: graphql.String, Resolve: func(p graphql.Params) (any, error) {
+			return p.Source.(models.Feed).URL, nil
+		}},
+		{Name: "createdAt", Type: graphql.Time, Resolve: func(p graphql.Params) (any, error) {
+			return timeValue(p.Source.(models.Feed).CreatedAt), nil
+		}},
+		{Name: "updatedAt", Type: graphql.Time, Resolve: func(p graphql.Params) (any, error) {
+			return timeValue(p.Source.(models.Feed).UpdatedAt), nil
+		}},
+		{Name: "articleCount", Type: graphql.Int, Resolve: func(p graphql.Params) (any, error) {
+			return p.Source.(models.Feed).ArticleCount, nil
+		}},
+		{Name: "lastPublishedAt", Type: graphql.Time, Resolve: func(p graphql.Params) (any, error) {
+			return timeValue(p.Source.(models.Feed).LastPublishedAt), nil
+		}},
+		{Name: "disabledAt", Type: graphql.Time, Resolve: func(p graphql.Params) (any, error) {
+			return timeValue(p.Source.(models.Feed).DisabledAt), nil
+		}},
+		{Name: "disabledReason", Type: graphql.String, Resolve: func(p graphql.Params) (any, error) {
+			return p.Source.(models.Feed).DisabledReason, nil
+		}},
+		{Name: "articles", Type: articleConnection, Args: articleArgs, Resolve: func(p graphql.Params) (any, error) {
+			return s.articlePage(db.ArticleQuery{FeedID: p.Source.(models.Feed).ID}, p.Args)
+		}},
+	}
+	article.Fields = []*graphql.Field{
+		{Name: "id", Type: graphql.ID, Resolve: func(p graphql.Params) (any, error) {
+			return p.Source.(models.Article).ID.String(), nil
+		}},
+		{Name: "title", Type: graphql.String, Resolve: func(p graphql.Params) (any, error) {
+			return p.Source.(models.Article).Title, nil
+		}},
+		{Name: "link", Type: graphql.String, Resolve: func(p graphql.Params) (any, error) {
+			return p.Source.(models.Article).Link, nil
+		}},
+		{Name: "description", Type: graphql.String, Resolve: func(p graphql.Params) (any, error) {
+			return p.Source.(models.Article).Description, nil
+		}},
+		{Name: "author", Type: graphql.String, Resolve: func(p graphql.Params) (any, error) {
+			return p.Source.(models.Article).Author, nil
+		}},
+		{Name: "guid", Type: graphql.String, Resolve: func(p graphql.Params) (any, error) {
+			return p.Source.(models.Article).GUID, nil
+		}},
+		{Name: "commentsUrl", Type: graphql.String, Resolve: func(p graphql.Params) (any, error) {
+			return p.Source.(models.Article).CommentsURL, nil
+		}},
+		{Name: "enclosureUrl", Type: graphql.String, Resolve: func(p graphql.Params) (any, error) {
+			return p.Source.(models.Article).EnclosureURL, nil
+		}},
+		{Name: "enclosureType", Type: graphql.String, Resolve: func(p graphql.Params) (any, error) {
+			return p.Source.(models.Article).EnclosureType, nil
+		}},
+		{Name: "latitude", Type: graphql.Float, Resolve: func(p graphql.Params) (any, error) {
+			if l := p.Source.(models.Article).Location; l != nil {
+				return l.Lat, nil
+			}
+			return nil, nil
+		}},
+		{Name: "longitude", Type: graphql.Float, Resolve: func(p graphql.Params) (any, error) {
+			if l := p.Source.(models.Article).Location; l != nil {
+				return l.Lon, nil
+			}
+			return nil, nil
+		}},
+		{Name: "durationSeconds", Type: graphql.Int, Resolve: func(p graphql.Params) (any, error) {
+			return positiveInt(int(p.Source.(models.Article).Duration / time.Second)), nil
+		}},
+		{Name: "episode", Type: graphql.Int, Resolve: func(p graphql.Params) (any, error) {
+			return positiveInt(p.Source.(models.Article).Episode), nil
+		}},
+		{Name: "season", Type: graphql.Int, Resolve: func(p graphql.Params) (any, error) {
+			return positiveInt(p.Source.(models.Article).Season), nil
+		}},
+		{Name: "imageUrl", Type: graphql.String, Resolve: func(p graphql.Params) (any, error) {
+			return p.Source.(models.Article).ImageURL, nil
+		}},
+		{Name: "videoId", Type: graphql.String, Resolve: func(p graphql.Params) (any, error) {
+			return p.Source.(models.Article).VideoID, nil
+		}},
+		{Name: "thumbnailUrl", Type: graphql.String, Resolve: func(p graphql.Params) (any, error) {
+			return p.Source.(models.Article).ThumbnailURL, nil
+		}},
+		{Name: "wordCount", Type: graphql.Int, Resolve: func(p graphql.Params) (any, error) {
+			return positiveInt(p.Source.(models.Article).WordCount), nil
+		}},
+		{Name: "readingMinutes", Type: graphql.Int, Resolve: func(p graphql.Params) (any, error) {
+			return positiveInt(int(rss.ReadingTime(p.Source.(models.Article).WordCount) / time.Minute)), nil
+		}},
+		{Name: "publishedAt", Type: graphql.Time, Resolve: func(p graphql.Params) (any, error) {
+			return timeValue(p.Source.(models.Article).PublishedAt), nil
+		}},
+		{Name: "read", Type: graphql.Boolean, Resolve: func(p graphql.Params) (any, error) {
+			return !p.Source.(models.Article).ReadAt.IsZero(), nil
+		}},
+		{Name: "readAt", Type: graphql.Time, Resolve: func(p graphql.Params) (any, error) {
+			return timeValue(p.Source.(models.Article).ReadAt), nil
+		}},
+		{Name: "starred", Type: graphql.Boolean, Resolve: func(p graphql.Params) (any, error) {
+			return !p.Source.(models.Article).StarredAt.IsZero(), nil
+		}},
+		{Name: "starredAt", Type: graphql.Time, Resolve: func(p graphql.Params) (any, error) {
+			return timeValue(p.Source.(models.Article).StarredAt), nil
+		}},
+		{Name: "feed", Type: feed, Resolve: func(p graphql.Params) (any, error) {
+			return s.db.GetFeedByID(p.Source.(models.Article).FeedID)
+		}},
+	}
+
+	query := &graphql.Object{
+		Name: "Query",
+		Fields: []*graphql.Field{
+			{Name: "feeds", Type: feedConnection, Args: pageArgs, Resolve: func(p graphql.Params) (any, error) {
+				after, limit, err := pageParams(p.Args)
+				if err != nil {
+					return nil, err
+				}
+				feeds, more, err := s.db.QueryFeeds(models.FeedFilter{}, after, limit)
+				if err != nil {
+					return nil, err
+				}
+				edges := make([]any, len(feeds))
+				for i, f := range feeds {
+					edges[i] = map[string]any{"cursor": db.Cursor{Time: f.CreatedAt, ID: f.ID}.String(), "node": f}
+				}
+				return connectionValue(edges, more), nil
+			}},
+			{Name: "feed", Type: feed, Args: []graphql.Arg{{Name: "name", Type: graphql.String, Required: true}},
+				Resolve: func(p graphql.Params) (any, error) {
+					return s.db.GetFeedByName(p.Args["name"].(string))
+				}},
+			{Name: "articles", Type: articleConnection,
+				Args: append([]graphql.Arg{{Name: "feed", Type: graphql.String}}, articleArgs...),
+				Resolve: func(p graphql.Params) (any, error) {
+					feedName, _ := p.Args["feed"].(string)
+					return s.articlePage(db.ArticleQuery{FeedName: feedName}, p.Args)
+				}},
+			{Name: "article", Type: article, Args: []graphql.Arg{{Name: "id", Type: graphql.ID, Required: true}},
+				Resolve: func(p graphql.Params) (any, error) {
+					id, err := uuid.Parse(p.Args["id"].(string))
+					if err != nil {
+						return nil, err
+					}
+					return s.db.GetArticle(id)
+				}},
+		},
+	}
+	mutation := &graphql.Object{
+		Name: "Mutation",
+		Fields: []*graphql.Field{
+			{Name: "markRead", Type: article, Args: []graphql.Arg{{Name: "id", Type: graphql.ID, Required: true}},
+				Resolve: func(p graphql.Params) (any, error) {
+					id, err := uuid.Parse(p.Args["id"].(string))
+					if err != nil {
+						return nil, err
+					}
+					err = s.db.MarkArticleRead(id)
+					if err != nil {
+						return nil, err
+					}
+					return s.db.GetArticle(id)
+				}},
+		},
+	}
+	return &graphql.Schema{Query: query, Mutation: mutation}
+}
+
+func connection(name string, node, pageInfo *graphql.Object) *graphql.Object {
+	edge := &graphql.Object{
+		Name: name + "Edge",
+		Fields: []*graphql.Field{
+			{Name: "cursor", Type: graphql.String},
+			{Name: "node", Type: node},
+		},
+	}
+	return &graphql.Object{
+		Name: name + "Connection",
+		Fields: []*graphql.Field{
+			{Name: "edges", Type: &graphql.List{Of: edge}},
+			{Name: "pageInfo", Type: pageInfo},
+		},
+	}
+}
+
+func connectionValue(edges []any, more bool) map[string]any {
+	var end any
+	if len(edges) > 0 {
+		end = edges[len(edges)-1].(map[string]any)["cursor"]
+	}
+	return map[string]any{
+		"edges":    edges,
+		"pageInfo": map[string]any{"hasNextPage": more, "endCursor": end},
+	}
+}
+
+func (s *Server) articlePage(q db.ArticleQuery, args map[string]any) (any, error) {
+	after, limit, err := pageParams(args)
+	if err != nil {
+		return nil, err
+	}
+	q.After, q.Limit = after, limit
+	q.UnreadOnly, _ = args["unread"].(bool)
+	q.Author, _ = args["author"].(string)
+	near, _ := args["near"].(string)
+	radius, _ := args["radius"].(string)
+	if near != "" || radius != "" {
+		if near == "" || radius == "" {
+			return nil, errors.New("near and radius must be given together")
+		}
+		p, err := geo.ParsePoint(near)
+		if err != nil {
+			return nil, err
+		}
+		if q.RadiusKM, err = geo.ParseDistance(radius); err != nil {
+			return nil, err
+		}
+		q.Near = &p
+	}
+	articles, more, err := s.db.QueryArticles(q)
+	if err != nil {
+		return nil, err
+	}
+	edges := make([]any, len(articles))
+	for i, a := range articles {
+		edges[i] = map[string]any{"cursor": db.Cursor{Time: a.PublishedAt, ID: a.ID}.String(), "node": a}
+	}
+	return connectionValue(edges, more), nil
+}
+
+func pageParams(args map[string]any) (*db.Cursor, int, error) {
+	limit, _ := args["first"].(int)
+	if limit <= 0 || limit > maxPageSize {
+		limit = maxPageSize
+	}
+	after, _ := args["after"].(string)
+	if after == "" {
+		return nil, limit, nil
+	}
+	c, err := db.ParseCursor(after)
+	if err != nil {
+		return nil, 0, err
+	}
+	return &c, limit, nil
+}
+
+func timeValue(t time.Time) any {
+	if t.IsZero() {
+		return nil
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// positiveInt resolves unknown (zero) numbers to null.
+func positiveInt(n int) any {
+	if n <= 0 {
+		return nil
+	}
+	return n
+}
+
+// handleGraphQL serves GraphQL over HTTP: POST with a JSON body, or GET
+// with query, operationName and variables parameters. Mutations need POST,
+// so a link or an image tag cannot make a signed-in browser run one.
+func (s *Server) handleGraphQL(w http.ResponseWriter, r *http.Request) {
+	var req graphql.Request
+	if r.Method == http.MethodGet {
+		req.Query = r.URL.Query().Get("query")
+		req.OperationName = r.URL.Query().Get("operationName")
+		if req.IsMutation() {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "mutations must be sent with POST", http.StatusMethodNotAllowed)
+			return
+		}
+		if vars := r.URL.Query().Get("variables"); vars != "" {
+			if err := json.Unmarshal([]byte(vars), &req.Variables); err != nil {
+				http.Error(w, "invalid variables: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+	} else {
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+			http.Error(w, "invalid request body: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	resp := s.schema.Execute(r.Context(), req)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (s *Server) handleGraphQLSchema(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write([]byte(s.schema.SDL()))
+}
@@ -0,0 +1,109 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"rsshub/client"
+	"rsshub/internal/models"
+)
+
+func (s *Server) routeNotes(mux *http.ServeMux) {
+	mux.HandleFunc("GET /api/articles/{id}/notes", s.handleArticleNotes)
+	mux.HandleFunc("POST /api/articles/{id}/notes", s.handleAddNote)
+	mux.HandleFunc("GET /api/notes", s.handleSearchNotes)
+	mux.HandleFunc("DELETE /api/notes/{id}", s.handleDeleteNote)
+}
+
+// NoteJSON converts a note to its REST representation.
+func NoteJSON(n models.ArticleNote) client.Note {
+	return client.Note{
+		ID:           n.ID,
+		ArticleID:    n.ArticleID.String(),
+		Quote:        n.Quote,
+		Text:         n.Text,
+		CreatedBy:    n.CreatedBy,
+		CreatedAt:    n.CreatedAt,
+		ArticleTitle: n.ArticleTitle,
+		ArticleLink:  n.ArticleLink,
+	}
+}
+
+func noteList(notes []models.ArticleNote) client.NoteList {
+	out := client.NoteList{Notes: make([]client.Note, len(notes))}
+	for i, n := range notes {
+		out.Notes[i] = NoteJSON(n)
+	}
+	return out
+}
+
+func (s *Server) handleArticleNotes(w http.ResponseWriter, r *http.Request) {
+	id, ok := s.articleID(w, r)
+	if !ok {
+		return
+	}
+	_, err := s.db.GetArticle(id)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	notes, err := s.db.ArticleNotes(id)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, noteList(notes))
+}
+
+func (s *Server) handleAddNote(w http.ResponseWriter, r *http.Request) {
+	id, ok := s.articleID(w, r)
+	if !ok {
+		return
+	}
+	var req client.NewNote
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if req.Text == "" {
+		writeError(w, http.StatusBadRequest, errors.New("text is required"))
+		return
+	}
+	note, err := s.db.As(actor(r)).AddNote(id, req.Quote, req.Text)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, NoteJSON(note))
+}
+
+// handleSearchNotes finds notes containing q, or lists the latest ones.
+func (s *Server) handleSearchNotes(w http.ResponseWriter, r *http.Request) {
+	limit, err := pageLimit(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	notes, err := s.db.SearchNotes(r.URL.Query().Get("q"), limit)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, noteList(notes))
+}
+
+func (s *Server) handleDeleteNote(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	err = s.db.DeleteNote(id)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
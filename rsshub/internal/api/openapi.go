@@ -0,0 +1,9 @@
+package api
+
+import _ "embed"
+
+// openAPISpec documents the REST API. The client package implements it;
+// keep both in step when endpoints change.
+//
+//go:embed openapi.json
+var openAPISpec []byte
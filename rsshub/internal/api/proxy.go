@@ -0,0 +1,137 @@
+package api
+
+import (
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// corsMaxAge is how long browsers may cache a preflight response.
+const corsMaxAge = 10 * time.Minute
+
+// parseProxies turns the configured trusted proxies, single addresses or
+// CIDR ranges, into networks. Entries are validated by config.
+func parseProxies(entries []string) []*net.IPNet {
+	var nets []*net.IPNet
+	for _, e := range entries {
+		if !strings.Contains(e, "/") {
+			if ip := net.ParseIP(e); ip.To4() != nil {
+				e += "/32"
+			} else {
+				e += "/128"
+			}
+		}
+		if _, n, err := net.ParseCIDR(e); err == nil {
+			nets = append(nets, n)
+		}
+	}
+	return nets
+}
+
+func (s *Server) trusted(host string) bool {
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, n := range s.proxies {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// forwarded replaces the remote address of requests relayed by a trusted
+// proxy with the client address from X-Forwarded-For, so that rate limits
+// and the audit log see the real caller. The list is walked from the right
+// and the first address not belonging to a trusted proxy wins, since
+// anything further left was supplied by the client. X-Real-IP is used
+// when a proxy sends only that.
+func (s *Server) forwarded(next http.Handler) http.Handler {
+	if len(s.proxies) == 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.trusted(clientIP(r)) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		var hops []string
+		for _, h := range r.Header.Values("X-Forwarded-For") {
+			for _, hop := range strings.Split(h, ",") {
+				hops = append(hops, strings.TrimSpace(hop))
+			}
+		}
+		client := ""
+		for i := len(hops) - 1; i >= 0; i-- {
+			if net.ParseIP(hops[i]) == nil {
+				break
+			}
+			client = hops[i]
+			if !s.trusted(client) {
+				break
+			}
+		}
+		if client == "" {
+			client = strings.TrimSpace(r.Header.Get("X-Real-IP"))
+		}
+		if net.ParseIP(client) != nil {
+			r2 := r.Clone(r.Context())
+			r2.RemoteAddr = net.JoinHostPort(client, "0")
+			if proto := r.Header.Get("X-Forwarded-Proto"); proto == "https" || proto == "http" {
+				r2.URL.Scheme = proto
+			}
+			r = r2
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// cors lets browser clients on the configured origins call the API. "*"
+// allows any origin. Access-Control-Allow-Credentials is never sent, so
+// pages on those origins authenticate with bearer tokens: they cannot read
+// responses to requests carrying the web UI's session cookie. The cookie
+// is SameSite=Lax, and sameOrigin refuses cookie-authenticated writes from
+// origins other than this server and those listed here. Preflight
+// requests are answered here, before rate limiting and authentication.
+func (s *Server) cors(next http.Handler) http.Handler {
+	if len(s.origins) == 0 {
+		return next
+	}
+	anyOrigin := slices.Contains(s.origins, "*")
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Add("Vary", "Origin")
+		if !anyOrigin && !slices.Contains(s.origins, origin) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		h := w.Header()
+		h.Set("Access-Control-Allow-Origin", origin)
+		h.Set("Access-Control-Expose-Headers", "Link, Retry-After")
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			h.Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE")
+			h.Set("Access-Control-Allow-Headers", "Authorization, Content-Type, X-Rsshub-Actor")
+			h.Set("Access-Control-Max-Age", strconv.Itoa(int(corsMaxAge.Seconds())))
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// withBasePath serves the API under the configured path prefix, for
+// proxies that mount rsshub at e.g. /rsshub/ without rewriting paths.
+func (s *Server) withBasePath(next http.Handler) http.Handler {
+	if s.basePath == "" {
+		return next
+	}
+	return http.StripPrefix(s.basePath, next)
+}
@@ -0,0 +1,108 @@
+package api
+
+import (
+	"crypto/subtle"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// bucketIdle is how long an unused client bucket is kept. A bucket idle
+// this long has refilled anyway, so dropping it loses nothing.
+const bucketIdle = 10 * time.Minute
+
+// limiter is a token bucket per client key: each key may make burst
+// requests at once and then perMinute requests a minute.
+type limiter struct {
+	rate  float64 // tokens per second
+	burst float64
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens float64
+	seen   time.Time
+}
+
+func newLimiter(perMinute, burst int) *limiter {
+	if perMinute <= 0 {
+		return nil
+	}
+	return &limiter{
+		rate:    float64(perMinute) / 60,
+		burst:   float64(max(burst, 1)),
+		buckets: map[string]*bucket{},
+	}
+}
+
+// allow takes a token from key's bucket. When the bucket is empty it
+// reports how long until the next token.
+func (l *limiter) allow(key string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastSweep) > bucketIdle {
+		for k, b := range l.buckets {
+			if now.Sub(b.seen) > bucketIdle {
+				delete(l.buckets, k)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.burst, seen: now}
+		l.buckets[key] = b
+	}
+	b.tokens = min(l.burst, b.tokens+now.Sub(b.seen).Seconds()*l.rate)
+	b.seen = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	wait := time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+	return false, wait
+}
+
+// rateLimit throttles requests before they are authenticated. Callers
+// presenting the configured API token share the token's limit wherever
+// they connect from; everyone else, including callers guessing tokens, is
+// limited per IP address. Throttled requests get 429 with Retry-After.
+func (s *Server) rateLimit(next http.Handler) http.Handler {
+	if s.ipLimit == nil && s.tokenLimit == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		l, key := s.ipLimit, "ip:"+clientIP(r)
+		if s.token != "" && subtle.ConstantTimeCompare([]byte(requestToken(r)), []byte(s.token)) == 1 {
+			l, key = s.tokenLimit, "token"
+		}
+		if l != nil {
+			ok, wait := l.allow(key, time.Now())
+			if !ok {
+				secs := int(math.Ceil(wait.Seconds()))
+				w.Header().Set("Retry-After", strconv.Itoa(max(secs, 1)))
+				writeError(w, http.StatusTooManyRequests, errors.New("rate limit exceeded"))
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requestToken returns the bearer token or access_token query parameter.
+func requestToken(r *http.Request) string {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		token = r.URL.Query().Get("access_token")
+	}
+	return token
+}
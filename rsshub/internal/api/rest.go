@@ -0,0 +1,710 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"rsshub/client"
+	"rsshub/internal/aggregator"
+	"rsshub/internal/db"
+	"rsshub/internal/geo"
+	"rsshub/internal/models"
+	"rsshub/internal/rss"
+	"rsshub/internal/version"
+)
+
+func (s *Server) routeREST(mux *http.ServeMux) {
+	mux.HandleFunc("GET /openapi.json", s.handleOpenAPI)
+	mux.HandleFunc("GET /api/feeds", s.handleListFeeds)
+	mux.HandleFunc("POST /api/feeds", s.handleAddFeed)
+	mux.HandleFunc("GET /api/feeds/{name}", s.handleGetFeed)
+	mux.HandleFunc("DELETE /api/feeds/{name}", s.handleDeleteFeed)
+	mux.HandleFunc("POST /api/feeds/{name}/refresh", s.handleRefreshFeed)
+	mux.HandleFunc("POST /api/feeds/{name}/restore", s.handleRestoreFeed)
+	mux.HandleFunc("POST /api/feeds/{name}/enable", s.handleEnableFeed)
+	mux.HandleFunc("GET /api/feeds/{name}/impact", s.handleFeedImpact)
+	mux.HandleFunc("POST /api/feeds/{name}/merge", s.handleMergeFeed)
+	mux.HandleFunc("GET /api/feeds/{name}/quirks", s.handleGetQuirks)
+	mux.HandleFunc("PUT /api/feeds/{name}/quirks", s.handleSetQuirks)
+	mux.HandleFunc("GET /api/articles", s.handleListArticles)
+	mux.HandleFunc("GET /api/archived-articles", s.handleListArchived)
+	mux.HandleFunc("GET /api/articles/random", s.handleRandomArticles)
+	mux.HandleFunc("GET /api/articles/{id}", s.handleGetArticle)
+	mux.HandleFunc("GET /api/articles/{id}/revisions", s.handleArticleRevisions)
+	mux.HandleFunc("POST /api/articles/{id}/read", s.handleMarkRead)
+	mux.HandleFunc("PUT /api/articles/{id}/star", s.handleStar)
+	mux.HandleFunc("DELETE /api/articles/{id}/star", s.handleStar)
+	mux.HandleFunc("POST /api/control", s.handleControl)
+	mux.HandleFunc("GET /api/version", s.handleVersion)
+	mux.HandleFunc("GET /api/audit", s.handleListAudit)
+	mux.HandleFunc("GET /api/authors", s.handleListAuthors)
+}
+
+func (s *Server) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(openAPISpec)
+}
+
+func (s *Server) handleListFeeds(w http.ResponseWriter, r *http.Request) {
+	after, limit, err := listParams(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	q := r.URL.Query()
+	f := models.FeedFilter{Match: q.Get("match")}
+	f.Errored, _ = strconv.ParseBool(q.Get("errored"))
+	f.Disabled, _ = strconv.ParseBool(q.Get("disabled"))
+	f.Wide, _ = strconv.ParseBool(q.Get("wide"))
+	feeds, more, err := s.db.QueryFeeds(f, after, limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	out := client.FeedList{Feeds: make([]client.Feed, len(feeds))}
+	for i, feed := range feeds {
+		out.Feeds[i] = FeedJSON(feed)
+		if f.Wide {
+			out.Feeds[i].Status = FeedStatusJSON(feed)
+		}
+	}
+	if more {
+		last := feeds[len(feeds)-1]
+		out.NextCursor = db.Cursor{Time: last.CreatedAt, ID: last.ID}.String()
+		out.Next = s.nextLink(w, r, out.NextCursor)
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (s *Server) handleAddFeed(w http.ResponseWriter, r *http.Request) {
+	var req client.NewFeed
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if req.Name == "" || req.URL == "" {
+		writeError(w, http.StatusBadRequest, errors.New("name and url are required"))
+		return
+	}
+	if err := s.fetcher.CheckURL(req.URL); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	err := s.db.As(actor(r)).AddFeed(&models.Feed{Name: req.Name, URL: req.URL})
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	feed, err := s.db.GetFeedByName(req.Name)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, FeedJSON(feed))
+}
+
+func (s *Server) handleGetFeed(w http.ResponseWriter, r *http.Request) {
+	feed, err := s.db.GetFeedByName(r.PathValue("name"))
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, FeedJSON(feed))
+}
+
+func (s *Server) handleDeleteFeed(w http.ResponseWriter, r *http.Request) {
+	purge, _ := strconv.ParseBool(r.URL.Query().Get("purge"))
+	keep := s.keepArticles
+	if v := r.URL.Query().Get("keep_articles"); v != "" {
+		keep, _ = strconv.ParseBool(v)
+	}
+	var err error
+	if purge && keep {
+		archived, err := s.db.As(actor(r)).PurgeFeedKeepArticles(r.PathValue("name"))
+		if err != nil {
+			writeDBError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, client.PurgeResult{Archived: archived})
+		return
+	} else if purge {
+		err = s.db.As(actor(r)).PurgeFeed(r.PathValue("name"))
+	} else {
+		err = s.db.As(actor(r)).DeleteFeed(r.PathValue("name"))
+	}
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleRestoreFeed(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	err := s.db.As(actor(r)).RestoreFeed(name)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	feed, err := s.db.GetFeedByName(name)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, FeedJSON(feed))
+}
+
+func (s *Server) handleEnableFeed(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	err := s.db.As(actor(r)).EnableFeed(name)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	feed, err := s.db.GetFeedByName(name)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, FeedJSON(feed))
+}
+
+func (s *Server) handleFeedImpact(w http.ResponseWriter, r *http.Request) {
+	im, err := s.db.FeedImpact(r.PathValue("name"))
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, client.FeedImpact{
+		Deleted:   im.Deleted,
+		Articles:  im.Articles,
+		Read:      im.Read,
+		Starred:   im.Starred,
+		Revisions: im.Revisions,
+	})
+}
+
+func (s *Server) handleGetQuirks(w http.ResponseWriter, r *http.Request) {
+	q, err := s.db.GetFeedQuirks(r.PathValue("name"))
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, quirksJSON(q))
+}
+
+func (s *Server) handleSetQuirks(w http.ResponseWriter, r *http.Request) {
+	var req client.FeedQuirks
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<12)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	q := models.FeedQuirks{
+		DateLayout:       req.DateLayout,
+		InsecureTLS:      req.InsecureTLS,
+		CABundle:         req.CABundle,
+		CertPins:         req.CertPins,
+		PlainDescription: req.PlainDescription,
+		StripTitlePrefix: req.StripTitlePrefix,
+	}
+	if err := rss.CheckTLSQuirks(q); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := s.db.As(actor(r)).SetFeedQuirks(r.PathValue("name"), q); err != nil {
+		writeDBError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, quirksJSON(q))
+}
+
+func quirksJSON(q models.FeedQuirks) client.FeedQuirks {
+	return client.FeedQuirks{
+		DateLayout:       q.DateLayout,
+		InsecureTLS:      q.InsecureTLS,
+		CABundle:         q.CABundle,
+		CertPins:         q.CertPins,
+		PlainDescription: q.PlainDescription,
+		StripTitlePrefix: q.StripTitlePrefix,
+	}
+}
+
+func (s *Server) handleMergeFeed(w http.ResponseWriter, r *http.Request) {
+	var req client.MergeRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<12)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if req.Into == "" {
+		writeError(w, http.StatusBadRequest, errors.New("into is required"))
+		return
+	}
+	if req.Into == r.PathValue("name") {
+		writeError(w, http.StatusBadRequest, errors.New("cannot merge a feed into itself"))
+		return
+	}
+	res, err := s.db.As(actor(r)).MergeFeeds(r.PathValue("name"), req.Into)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, client.MergeResult{Moved: res.Moved, Duplicates: res.Duplicates})
+}
+
+func (s *Server) handleRefreshFeed(w http.ResponseWriter, r *http.Request) {
+	err := s.ctrl.Refresh(r.PathValue("name"))
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (s *Server) handleListArticles(w http.ResponseWriter, r *http.Request) {
+	after, limit, err := listParams(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	unread, _ := strconv.ParseBool(r.URL.Query().Get("unread"))
+	starred, _ := strconv.ParseBool(r.URL.Query().Get("starred"))
+	showMuted, _ := strconv.ParseBool(r.URL.Query().Get("show_muted"))
+	near, radius, err := nearParams(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	var maxReadTime time.Duration
+	if v := r.URL.Query().Get("max_read_time"); v != "" {
+		maxReadTime, err = time.ParseDuration(v)
+		if err != nil || maxReadTime <= 0 {
+			writeError(w, http.StatusBadRequest, errors.New("max_read_time must be a positive duration such as 5m"))
+			return
+		}
+	}
+	articles, more, err := s.db.QueryArticles(db.ArticleQuery{
+		FeedName:    r.URL.Query().Get("feed"),
+		Author:      r.URL.Query().Get("author"),
+		Near:        near,
+		RadiusKM:    radius,
+		UnreadOnly:  unread,
+		StarredOnly: starred,
+		MaxReadTime: maxReadTime,
+		ShowMuted:   showMuted,
+		After:       after,
+		Limit:       limit,
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	out := client.ArticleList{Articles: make([]client.Article, len(articles))}
+	for i, a := range articles {
+		out.Articles[i] = ArticleJSON(a)
+	}
+	if more {
+		last := articles[len(articles)-1]
+		out.NextCursor = db.Cursor{Time: last.PublishedAt, ID: last.ID}.String()
+		out.Next = s.nextLink(w, r, out.NextCursor)
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (s *Server) handleRandomArticles(w http.ResponseWriter, r *http.Request) {
+	limit, err := pageLimit(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	q := r.URL.Query()
+	f := models.RandomFilter{FeedName: q.Get("feed")}
+	f.Unread, _ = strconv.ParseBool(q.Get("unread"))
+	f.Starred, _ = strconv.ParseBool(q.Get("starred"))
+	if v := q.Get("older_than"); v != "" {
+		f.OlderThan, err = time.ParseDuration(v)
+		if err != nil || f.OlderThan < 0 {
+			writeError(w, http.StatusBadRequest, errors.New("older_than must be a duration such as 720h"))
+			return
+		}
+	}
+	articles, err := s.db.RandomArticles(f, limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	out := client.ArticleList{Articles: make([]client.Article, len(articles))}
+	for i, a := range articles {
+		out.Articles[i] = ArticleJSON(a)
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (s *Server) handleListArchived(w http.ResponseWriter, r *http.Request) {
+	after, limit, err := listParams(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	articles, more, err := s.db.QueryArchivedArticles(r.URL.Query().Get("feed"), after, limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	out := client.ArchivedArticleList{Articles: make([]client.ArchivedArticle, len(articles))}
+	for i, a := range articles {
+		out.Articles[i] = client.ArchivedArticle{
+			Article:    ArticleJSON(a.Article),
+			FeedName:   a.FeedName,
+			FeedURL:    a.FeedURL,
+			ArchivedAt: a.ArchivedAt,
+		}
+	}
+	if more {
+		last := articles[len(articles)-1]
+		out.NextCursor = db.Cursor{Time: last.PublishedAt, ID: last.ID}.String()
+		out.Next = s.nextLink(w, r, out.NextCursor)
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// articleID resolves the article ID in the path, which may be a prefix
+// of one as the CLI accepts, writing the error response if it cannot.
+func (s *Server) articleID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	id, err := s.db.ResolveArticleID(r.PathValue("id"))
+	if err != nil {
+		writeDBError(w, err)
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func (s *Server) handleGetArticle(w http.ResponseWriter, r *http.Request) {
+	id, ok := s.articleID(w, r)
+	if !ok {
+		return
+	}
+	article, err := s.db.GetArticle(id)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, ArticleJSON(article))
+}
+
+// handleStar stars an article on PUT and unstars it on DELETE.
+func (s *Server) handleStar(w http.ResponseWriter, r *http.Request) {
+	id, ok := s.articleID(w, r)
+	if !ok {
+		return
+	}
+	err := s.db.SetArticleStarred(id, r.Method == http.MethodPut)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleArticleRevisions(w http.ResponseWriter, r *http.Request) {
+	id, ok := s.articleID(w, r)
+	if !ok {
+		return
+	}
+	_, err := s.db.GetArticle(id)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	revisions, err := s.db.GetArticleRevisions(id)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	out := client.RevisionList{Revisions: []client.Revision{}}
+	for _, rev := range revisions {
+		out.Revisions = append(out.Revisions, client.Revision{
+			Title:       rev.Title,
+			Description: rev.Description,
+			ValidFrom:   rev.ValidFrom,
+			ReplacedAt:  rev.ReplacedAt,
+		})
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (s *Server) handleMarkRead(w http.ResponseWriter, r *http.Request) {
+	id, ok := s.articleID(w, r)
+	if !ok {
+		return
+	}
+	err := s.db.MarkArticleRead(id)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleControl(w http.ResponseWriter, r *http.Request) {
+	var req client.ControlRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<12)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if req.Command == "" {
+		writeError(w, http.StatusBadRequest, errors.New("command is required"))
+		return
+	}
+	reply, err := s.ctrl.Control(req.Command, actor(r))
+	var ce *aggregator.ControlError
+	switch {
+	case errors.As(err, &ce) && ce.Code == aggregator.CodeStopping:
+		writeError(w, http.StatusServiceUnavailable, err)
+	case err != nil:
+		writeError(w, http.StatusBadRequest, err)
+	default:
+		writeJSON(w, http.StatusOK, client.ControlReply{Reply: reply})
+	}
+}
+
+func (s *Server) handleListAuthors(w http.ResponseWriter, r *http.Request) {
+	limit, err := pageLimit(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	authors, err := s.db.GetAuthorStats(r.URL.Query().Get("feed"), limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	out := client.AuthorList{Authors: make([]client.AuthorStats, len(authors))}
+	for i, a := range authors {
+		out.Authors[i] = client.AuthorStats{
+			Author:          a.Author,
+			Articles:        a.Articles,
+			Feeds:           a.Feeds,
+			LastPublishedAt: a.LastPublishedAt,
+		}
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (s *Server) handleListAudit(w http.ResponseWriter, r *http.Request) {
+	limit, err := pageLimit(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	var after *db.SeqCursor
+	if v := r.URL.Query().Get("cursor"); v != "" {
+		c, err := db.ParseSeqCursor(v)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		after = &c
+	}
+	entries, more, err := s.db.QueryAudit(r.URL.Query().Get("target"), after, limit)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	out := client.AuditList{Entries: []client.AuditEntry{}}
+	for _, e := range entries {
+		out.Entries = append(out.Entries, client.AuditEntry{
+			ID:       e.ID,
+			At:       e.At,
+			Actor:    e.Actor,
+			Source:   e.Source,
+			Action:   e.Action,
+			Target:   e.Target,
+			OldValue: e.OldValue,
+			NewValue: e.NewValue,
+		})
+	}
+	if more {
+		out.NextCursor = db.SeqCursor(entries[len(entries)-1].ID).String()
+		out.Next = s.nextLink(w, r, out.NextCursor)
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (s *Server) handleVersion(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, version.Get())
+}
+
+// listParams reads the limit and cursor query parameters shared by list
+// endpoints.
+func listParams(r *http.Request) (*db.Cursor, int, error) {
+	limit, err := pageLimit(r)
+	if err != nil {
+		return nil, 0, err
+	}
+	cursor := r.URL.Query().Get("cursor")
+	if cursor == "" {
+		return nil, limit, nil
+	}
+	c, err := db.ParseCursor(cursor)
+	if err != nil {
+		return nil, 0, err
+	}
+	return &c, limit, nil
+}
+
+// nearParams parses the near and radius parameters of a location filter.
+func nearParams(r *http.Request) (*models.GeoPoint, float64, error) {
+	near, radius := r.URL.Query().Get("near"), r.URL.Query().Get("radius")
+	if near == "" && radius == "" {
+		return nil, 0, nil
+	}
+	if near == "" || radius == "" {
+		return nil, 0, errors.New("near and radius must be given together")
+	}
+	p, err := geo.ParsePoint(near)
+	if err != nil {
+		return nil, 0, err
+	}
+	km, err := geo.ParseDistance(radius)
+	if err != nil {
+		return nil, 0, err
+	}
+	return &p, km, nil
+}
+
+func pageLimit(r *http.Request) (int, error) {
+	limit := defaultPageSize
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return 0, errors.New("limit must be a positive integer")
+		}
+		limit = min(n, maxPageSize)
+	}
+	return limit, nil
+}
+
+// nextLink returns the request's URL with its cursor replaced, keeping the
+// filters and limit, and advertises it in a Link header as well.
+func (s *Server) nextLink(w http.ResponseWriter, r *http.Request, cursor string) string {
+	q := r.URL.Query()
+	q.Set("cursor", cursor)
+	q.Del("access_token")
+	next := s.basePath + r.URL.Path + "?" + q.Encode()
+	w.Header().Add("Link", "<"+next+`>; rel="next"`)
+	return next
+}
+
+// FeedJSON is a feed as the REST API returns it, also used by the CLI for
+// --format json.
+func FeedJSON(f models.Feed) client.Feed {
+	return client.Feed{
+		ID:              f.ID.String(),
+		Name:            f.Name,
+		URL:             f.URL,
+		CreatedAt:       f.CreatedAt,
+		UpdatedAt:       optionalTime(f.UpdatedAt),
+		ArticleCount:    f.ArticleCount,
+		LastPublishedAt: optionalTime(f.LastPublishedAt),
+		DisabledAt:      optionalTime(f.DisabledAt),
+		DisabledReason:  f.DisabledReason,
+	}
+}
+
+// FeedStatusJSON is the fetch status a wide feed listing adds.
+func FeedStatusJSON(f models.Feed) *client.FeedStatus {
+	return &client.FeedStatus{
+		LastFetchedAt: optionalTime(f.LastFetch.FetchedAt),
+		LastStatus:    f.LastFetch.Status,
+		LastError:     f.LastFetch.Error,
+		Failures:      f.Failures,
+		UnreadCount:   f.UnreadCount,
+	}
+}
+
+// ArticleJSON is an article as the REST API returns it. The CLI uses it
+// for --format json so scripts see the same fields either way.
+func ArticleJSON(a models.Article) client.Article {
+	var location *client.Location
+	if a.Location != nil {
+		location = &client.Location{Lat: a.Location.Lat, Lon: a.Location.Lon}
+	}
+	var enclosure *client.Enclosure
+	if a.EnclosureURL != "" {
+		enclosure = &client.Enclosure{URL: a.EnclosureURL, Type: a.EnclosureType, Length: a.EnclosureLength}
+	}
+	var podcast *client.Podcast
+	if a.Duration > 0 || a.Episode > 0 || a.Season > 0 || a.ImageURL != "" {
+		podcast = &client.Podcast{
+			DurationSeconds: int64(a.Duration / time.Second),
+			Episode:         a.Episode,
+			Season:          a.Season,
+			Image:           a.ImageURL,
+		}
+	}
+	var video *client.Video
+	if a.VideoID != "" {
+		video = &client.Video{ID: a.VideoID, Views: a.Views}
+	}
+	return client.Article{
+		ID:                a.ID.String(),
+		ShortID:           a.ShortID,
+		FeedID:            a.FeedID.String(),
+		Title:             a.Title,
+		Link:              a.Link,
+		Description:       a.Description,
+		Author:            a.Author,
+		PublishedAt:       a.PublishedAt,
+		PublishedAtSource: a.PublishedAtSource,
+		UpdatedAt:         optionalTime(a.UpdatedAt),
+		ReadAt:            optionalTime(a.ReadAt),
+		StarredAt:         optionalTime(a.StarredAt),
+		GUID:              a.GUID,
+		Comments:          a.CommentsURL,
+		Enclosure:         enclosure,
+		Location:          location,
+		Podcast:           podcast,
+		Thumbnail:         a.ThumbnailURL,
+		Video:             video,
+
+		WordCount:          a.WordCount,
+		ReadingTimeSeconds: int64(rss.ReadingTime(a.WordCount) / time.Second),
+	}
+}
+
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, client.ErrorBody{Error: err.Error()})
+}
+
+// writeDBError maps storage errors to HTTP statuses.
+func writeDBError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, db.ErrNotFound):
+		writeError(w, http.StatusNotFound, err)
+	case errors.Is(err, db.ErrInvalidID), errors.Is(err, db.ErrAmbiguous):
+		writeError(w, http.StatusBadRequest, err)
+	case errors.Is(err, db.ErrExists):
+		writeError(w, http.StatusConflict, err)
+	case errors.Is(err, aggregator.ErrQueueFull):
+		writeError(w, http.StatusServiceUnavailable, err)
+	default:
+		writeError(w, http.StatusInternalServerError, err)
+	}
+}
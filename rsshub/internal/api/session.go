@@ -0,0 +1,296 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"rsshub/client"
+	"rsshub/internal/auth"
+	"rsshub/internal/db"
+	"rsshub/internal/models"
+)
+
+const (
+	sessionCookie = "rsshub_session"
+	oidcCookie    = "rsshub_oidc"
+	// oidcLoginTTL is how long a user has to finish signing in at the
+	// identity provider.
+	oidcLoginTTL = 10 * time.Minute
+)
+
+type userKey struct{}
+
+// requestUser returns the user signed in with a session cookie, if any.
+func requestUser(r *http.Request) (models.User, bool) {
+	u, ok := r.Context().Value(userKey{}).(models.User)
+	return u, ok
+}
+
+func (s *Server) routeAuth(mux *http.ServeMux) {
+	mux.HandleFunc("POST /auth/login", s.handleLogin)
+	mux.HandleFunc("POST /auth/logout", s.handleLogout)
+	mux.HandleFunc("GET /auth/oidc/login", s.handleOIDCLogin)
+	mux.HandleFunc("GET /auth/oidc/callback", s.handleOIDCCallback)
+	mux.HandleFunc("GET /api/me", s.handleMe)
+}
+
+// publicPaths are reachable without signing in.
+var publicPaths = map[string]bool{
+	"/openapi.json":       true,
+	"/auth/login":         true,
+	"/auth/logout":        true,
+	"/auth/oidc/login":    true,
+	"/auth/oidc/callback": true,
+}
+
+// loginRequired reports whether the API is protected. An API token, OIDC
+// or CLI_APP_REQUIRE_LOGIN each turn protection on.
+func (s *Server) loginRequired() bool {
+	return s.token != "" || s.oidc != nil || s.requireLogin
+}
+
+// sessionUser looks up the session cookie.
+func (s *Server) sessionUser(r *http.Request) (models.User, bool) {
+	c, err := r.Cookie(sessionCookie)
+	if err != nil || c.Value == "" {
+		return models.User{}, false
+	}
+	u, err := s.db.SessionUser(c.Value)
+	if err != nil {
+		if !errors.Is(err, db.ErrNotFound) {
+			s.log.Errorf("Error looking up session: %v", err)
+		}
+		return models.User{}, false
+	}
+	return u, true
+}
+
+// sameOrigin guards cookie-authenticated requests that change state
+// against cross-site request forgery: browsers send Origin with such
+// requests, and it must be this server or an allowed CORS origin.
+func (s *Server) sameOrigin(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err == nil && u.Host == r.Host {
+		return true
+	}
+	for _, o := range s.origins {
+		if o == origin {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) cookiePath(suffix string) string {
+	return s.basePath + suffix
+}
+
+func secureRequest(r *http.Request) bool {
+	return r.TLS != nil || r.URL.Scheme == "https"
+}
+
+func (s *Server) setSession(w http.ResponseWriter, r *http.Request, u models.User) error {
+	token, err := s.db.CreateSession(u.ID, s.sessionTTL)
+	if err != nil {
+		return err
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    token,
+		Path:     s.cookiePath("/"),
+		MaxAge:   int(s.sessionTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   secureRequest(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+	return nil
+}
+
+// handleLogin signs a user in with a password, from a JSON body or an HTML
+// form. Forms may name a local page to return to.
+func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
+	var req client.Login
+	form := !strings.HasPrefix(r.Header.Get("Content-Type"), "application/json")
+	if form {
+		req.Username, req.Password = r.PostFormValue("username"), r.PostFormValue("password")
+	} else if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<12)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	u, err := s.db.GetUserByName(req.Username)
+	var hash string
+	if err == nil {
+		hash, err = s.db.PasswordHash(u.ID)
+	}
+	if err != nil && !errors.Is(err, db.ErrNotFound) {
+		writeDBError(w, err)
+		return
+	}
+	ok := false
+	if hash == "" {
+		auth.CheckMissingUser(req.Password)
+	} else {
+		ok, err = auth.CheckPassword(hash, req.Password)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+	if !ok {
+		writeError(w, http.StatusUnauthorized, errors.New("invalid user name or password"))
+		return
+	}
+
+	err = s.setSession(w, r, u)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	if form {
+		http.Redirect(w, r, s.returnPath(r.PostFormValue("return")), http.StatusSeeOther)
+		return
+	}
+	writeJSON(w, http.StatusOK, userJSON(u))
+}
+
+func (s *Server) handleLogout(w http.ResponseWriter, r *http.Request) {
+	if c, err := r.Cookie(sessionCookie); err == nil {
+		err = s.db.DeleteSession(c.Value)
+		if err != nil {
+			writeDBError(w, err)
+			return
+		}
+	}
+	http.SetCookie(w, &http.Cookie{Name: sessionCookie, Path: s.cookiePath("/"), MaxAge: -1})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleMe(w http.ResponseWriter, r *http.Request) {
+	u, ok := requestUser(r)
+	if !ok {
+		writeError(w, http.StatusUnauthorized, errors.New("not signed in"))
+		return
+	}
+	writeJSON(w, http.StatusOK, userJSON(u))
+}
+
+// handleOIDCLogin sends the browser to the identity provider. The state
+// and PKCE verifier travel in a short-lived cookie scoped to the callback.
+func (s *Server) handleOIDCLogin(w http.ResponseWriter, r *http.Request) {
+	if s.oidc == nil {
+		writeError(w, http.StatusNotFound, errors.New("single sign-on is not configured"))
+		return
+	}
+	authURL, state, verifier, err := s.oidc.Start(r.Context())
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcCookie,
+		Value:    state + "." + verifier + "." + url.QueryEscape(s.returnPath(r.URL.Query().Get("return"))),
+		Path:     s.cookiePath("/auth/oidc"),
+		MaxAge:   int(oidcLoginTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   secureRequest(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// handleOIDCCallback finishes single sign-on. A user signing in for the
+// first time is created under their provider user name; if a local user
+// already has that name the sign-in is refused rather than merged, since
+// providers may let people pick their own names.
+func (s *Server) handleOIDCCallback(w http.ResponseWriter, r *http.Request) {
+	if s.oidc == nil {
+		writeError(w, http.StatusNotFound, errors.New("single sign-on is not configured"))
+		return
+	}
+	q := r.URL.Query()
+	if e := q.Get("error"); e != "" {
+		writeError(w, http.StatusUnauthorized, errors.New("sign-in failed: "+e+" "+q.Get("error_description")))
+		return
+	}
+	c, err := r.Cookie(oidcCookie)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("sign-in expired; start again"))
+		return
+	}
+	http.SetCookie(w, &http.Cookie{Name: oidcCookie, Path: s.cookiePath("/auth/oidc"), MaxAge: -1})
+	parts := strings.SplitN(c.Value, ".", 3)
+	if len(parts) != 3 || parts[0] == "" || parts[0] != q.Get("state") {
+		writeError(w, http.StatusBadRequest, errors.New("sign-in state mismatch; start again"))
+		return
+	}
+	returnTo, _ := url.QueryUnescape(parts[2])
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+	id, err := s.oidc.Finish(ctx, q.Get("code"), parts[1])
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+
+	u, err := s.db.GetUserByOIDCSubject(id.Subject)
+	if errors.Is(err, db.ErrNotFound) {
+		u, err = s.db.CreateUser(id.Name(), "", id.Subject)
+		if errors.Is(err, db.ErrExists) {
+			writeError(w, http.StatusConflict, errors.New("a local user named "+id.Name()+" already exists"))
+			return
+		}
+		if err == nil {
+			s.db.Audit(db.Actor{Name: u.Name + "@" + clientIP(r), Source: db.SourceAPI}, "user-add", u.Name, "", "oidc")
+		}
+	}
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	err = s.setSession(w, r, u)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	http.Redirect(w, r, s.returnPath(returnTo), http.StatusFound)
+}
+
+// returnPath accepts only paths on this server as post-login destinations,
+// so the login endpoints cannot be used as open redirects. Control
+// characters are refused outright: browsers drop tabs and newlines from
+// URLs, which would turn "/\t/evil.example" into "//evil.example".
+func (s *Server) returnPath(p string) string {
+	if !strings.HasPrefix(p, "/") || strings.HasPrefix(p, "//") || strings.Contains(p, `\`) ||
+		strings.ContainsFunc(p, func(r rune) bool { return r < 0x20 || r == 0x7f }) {
+		return s.basePath + "/api/me"
+	}
+	if u, err := url.Parse(p); err != nil || u.Scheme != "" || u.Host != "" {
+		return s.basePath + "/api/me"
+	}
+	return p
+}
+
+func userJSON(u models.User) client.User {
+	return client.User{
+		ID:        u.ID.String(),
+		Name:      u.Name,
+		CreatedAt: u.CreatedAt,
+		Password:  u.HasPassword,
+		SSO:       u.OIDCSubject != "",
+	}
+}
@@ -0,0 +1,162 @@
+package api
+
+import (
+	"encoding/xml"
+	"errors"
+	"html/template"
+	"net/http"
+	"strings"
+	"time"
+
+	"rsshub/client"
+	"rsshub/internal/db"
+	"rsshub/internal/models"
+)
+
+// profilePrefix starts the public profile pages, which are reachable
+// without signing in.
+const profilePrefix = "/u/"
+
+func (s *Server) routeShare(mux *http.ServeMux) {
+	mux.HandleFunc("GET /api/me/shared", s.handleListShared)
+	mux.HandleFunc("PUT /api/me/shared/{name}", s.handleShare)
+	mux.HandleFunc("DELETE /api/me/shared/{name}", s.handleShare)
+	mux.HandleFunc("GET /u/{user}/subscriptions", s.handleSubscriptionsPage)
+	mux.HandleFunc("GET /u/{user}/subscriptions.opml", s.handleSubscriptionsOPML)
+}
+
+func (s *Server) handleListShared(w http.ResponseWriter, r *http.Request) {
+	u, ok := requestUser(r)
+	if !ok {
+		writeError(w, http.StatusUnauthorized, errors.New("not signed in"))
+		return
+	}
+	feeds, err := s.db.SharedFeeds(u.ID)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	out := client.FeedList{Feeds: make([]client.Feed, len(feeds))}
+	for i, feed := range feeds {
+		out.Feeds[i] = FeedJSON(feed)
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// handleShare adds a feed to, or with DELETE takes it off, the signed-in
+// user's public subscriptions.
+func (s *Server) handleShare(w http.ResponseWriter, r *http.Request) {
+	u, ok := requestUser(r)
+	if !ok {
+		writeError(w, http.StatusUnauthorized, errors.New("not signed in"))
+		return
+	}
+	name := r.PathValue("name")
+	action := "share-feed"
+	var err error
+	if r.Method == http.MethodDelete {
+		action = "unshare-feed"
+		err = s.db.UnshareFeed(u.ID, name)
+	} else {
+		err = s.db.ShareFeed(u.ID, name)
+	}
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	s.db.Audit(actor(r), action, name, "", u.Name)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// sharedFeeds looks up the feeds the user named in the path has shared.
+// Users who share nothing get the same 404 as unknown ones, so the pages
+// do not reveal who has an account.
+func (s *Server) sharedFeeds(w http.ResponseWriter, r *http.Request) (string, []models.Feed, bool) {
+	name := r.PathValue("user")
+	u, err := s.db.GetUserByName(name)
+	var feeds []models.Feed
+	if err == nil {
+		feeds, err = s.db.SharedFeeds(u.ID)
+	}
+	if errors.Is(err, db.ErrNotFound) || (err == nil && len(feeds) == 0) {
+		http.NotFound(w, r)
+		return "", nil, false
+	}
+	if err != nil {
+		http.Error(w, "could not load subscriptions", http.StatusInternalServerError)
+		return "", nil, false
+	}
+	return u.Name, feeds, true
+}
+
+var subscriptionsPage = template.Must(template.New("subscriptions").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.User}}'s subscriptions</title>
+<link rel="alternate" type="text/x-opml" title="{{.User}}'s subscriptions" href="subscriptions.opml">
+<style>
+body { font-family: system-ui, sans-serif; max-width: 40rem; margin: 2rem auto; padding: 0 1rem; line-height: 1.5; }
+li { margin: .25rem 0; }
+.url { color: #666; font-size: .9em; word-break: break-all; }
+</style>
+</head>
+<body>
+<h1>{{.User}}'s subscriptions</h1>
+<p>{{len .Feeds}} feed{{if ne (len .Feeds) 1}}s{{end}} &middot; <a href="subscriptions.opml">Download as OPML</a> to import them into any feed reader.</p>
+<ul>
+{{range .Feeds}}<li><a href="{{.URL}}">{{.Name}}</a><br><span class="url">{{.URL}}</span></li>
+{{end}}</ul>
+</body>
+</html>
+`))
+
+func (s *Server) handleSubscriptionsPage(w http.ResponseWriter, r *http.Request) {
+	user, feeds, ok := s.sharedFeeds(w, r)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	subscriptionsPage.Execute(w, struct {
+		User  string
+		Feeds []models.Feed
+	}{user, feeds})
+}
+
+// opml is an OPML 2.0 subscription list.
+type opml struct {
+	XMLName xml.Name      `xml:"opml"`
+	Version string        `xml:"version,attr"`
+	Title   string        `xml:"head>title"`
+	Created string        `xml:"head>dateCreated"`
+	Body    []opmlOutline `xml:"body>outline"`
+}
+
+type opmlOutline struct {
+	Type   string `xml:"type,attr"`
+	Text   string `xml:"text,attr"`
+	Title  string `xml:"title,attr"`
+	XMLURL string `xml:"xmlUrl,attr"`
+}
+
+func (s *Server) handleSubscriptionsOPML(w http.ResponseWriter, r *http.Request) {
+	user, feeds, ok := s.sharedFeeds(w, r)
+	if !ok {
+		return
+	}
+	doc := opml{
+		Version: "2.0",
+		Title:   user + "'s subscriptions",
+		Created: time.Now().UTC().Format(time.RFC1123Z),
+	}
+	for _, f := range feeds {
+		doc.Body = append(doc.Body, opmlOutline{Type: "rss", Text: f.Name, Title: f.Name, XMLURL: f.URL})
+	}
+	w.Header().Set("Content-Type", "text/x-opml; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+strings.ReplaceAll(user, `"`, "")+`-subscriptions.opml"`)
+	w.Write([]byte(xml.Header))
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	enc.Encode(doc)
+}
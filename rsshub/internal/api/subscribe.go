@@ -0,0 +1,141 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"rsshub/client"
+	"rsshub/internal/db"
+	"rsshub/internal/models"
+)
+
+// routeSubscribe serves the endpoints behind a "subscribe with rsshub"
+// browser extension or bookmarklet: check tells what feeds the page being
+// read has and which are subscribed, subscribe adds one in a single call.
+func (s *Server) routeSubscribe(mux *http.ServeMux) {
+	mux.HandleFunc("GET /api/check", s.handleCheck)
+	mux.HandleFunc("POST /api/subscribe", s.handleSubscribe)
+}
+
+// pageURL reads the url parameter, which must be an http or https URL
+// the fetch policy allows.
+func (s *Server) pageURL(r *http.Request) (string, error) {
+	raw := r.URL.Query().Get("url")
+	if raw == "" {
+		return "", errors.New("url is required")
+	}
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("url %q is not an http or https URL", raw)
+	}
+	return raw, s.fetcher.CheckURL(raw)
+}
+
+// checkPage finds the feeds for page and looks each up among the
+// subscriptions. A page URL that is itself subscribed is answered without
+// fetching it.
+func (s *Server) checkPage(ctx context.Context, page string) (client.FeedCheck, error) {
+	check := client.FeedCheck{URL: page, Feeds: []client.FeedCandidate{}}
+	dup, err := s.db.SubscribedURL(page)
+	if err != nil {
+		return check, err
+	}
+	if dup != nil {
+		check.Feeds = append(check.Feeds, client.FeedCandidate{URL: page, SubscribedAs: dup.Name, Deleted: dup.Deleted})
+		return check, nil
+	}
+	links, err := s.fetcher.Discover(ctx, page)
+	if err != nil {
+		return check, &discoveryError{err}
+	}
+	for _, l := range links {
+		c := client.FeedCandidate{URL: l.URL, Title: l.Title}
+		dup, err := s.db.SubscribedURL(l.URL)
+		if err != nil {
+			return check, err
+		}
+		if dup != nil {
+			c.SubscribedAs, c.Deleted = dup.Name, dup.Deleted
+		}
+		check.Feeds = append(check.Feeds, c)
+	}
+	return check, nil
+}
+
+// discoveryError is a failure to fetch the page being checked, which is
+// the remote site's fault rather than rsshub's.
+type discoveryError struct{ err error }
+
+func (e *discoveryError) Error() string {
+	return "fetching page: " + e.err.Error()
+}
+
+func writeCheckError(w http.ResponseWriter, err error) {
+	var de *discoveryError
+	if errors.As(err, &de) {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	writeDBError(w, err)
+}
+
+func (s *Server) handleCheck(w http.ResponseWriter, r *http.Request) {
+	page, err := s.pageURL(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	check, err := s.checkPage(r.Context(), page)
+	if err != nil {
+		writeCheckError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, check)
+}
+
+// handleSubscribe subscribes to the first feed found for the url
+// parameter, which may be the feed itself. The name parameter names the
+// feed; without it the name comes from the feed's title, numbered if
+// taken. A feed that is already subscribed is a 409.
+func (s *Server) handleSubscribe(w http.ResponseWriter, r *http.Request) {
+	page, err := s.pageURL(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	check, err := s.checkPage(r.Context(), page)
+	if err != nil {
+		writeCheckError(w, err)
+		return
+	}
+	if len(check.Feeds) == 0 {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no feed found at %s", page))
+		return
+	}
+	found := check.Feeds[0]
+	if found.SubscribedAs != "" {
+		writeDBError(w, &db.DuplicateURLError{Name: found.SubscribedAs, Deleted: found.Deleted})
+		return
+	}
+	feed := models.Feed{Name: r.URL.Query().Get("name"), URL: found.URL}
+	audited := s.db.As(actor(r))
+	if feed.Name != "" {
+		err = audited.AddFeed(&feed)
+	} else {
+		feed.Name = db.FeedName(found.Title, found.URL)
+		err = audited.AddFeedNumbered(&feed)
+	}
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	added, err := s.db.GetFeedByName(feed.Name)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, FeedJSON(added))
+}
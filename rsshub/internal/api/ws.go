@@ -0,0 +1,135 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"rsshub/internal/models"
+)
+
+const (
+	wsWriteTimeout = 10 * time.Second
+	wsPingInterval = 30 * time.Second
+	wsReadLimit    = 4096
+)
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+}
+
+// wsRequest is a control operation sent by a WebSocket client. ID is echoed
+// back in the matching result so clients can pair them up.
+type wsRequest struct {
+	ID        string `json:"id"`
+	Op        string `json:"op"`
+	Feed      string `json:"feed,omitempty"`
+	ArticleID string `json:"article_id,omitempty"`
+}
+
+// wsMessage is anything the server sends: either a live article event or
+// the result of a client request.
+type wsMessage struct {
+	Type    string               `json:"type"`
+	ID      string               `json:"id,omitempty"`
+	OK      bool                 `json:"ok,omitempty"`
+	Error   string               `json:"error,omitempty"`
+	Article *models.ArticleEvent `json:"article,omitempty"`
+}
+
+// handleWebSocket carries live article events and control operations
+// ("refresh" a feed, "mark-read" an article) over a single connection. The
+// optional "feed" query parameter restricts events to one feed by name.
+func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return // Upgrade already replied with an error
+	}
+	defer conn.Close()
+
+	sub := s.events.subscribe()
+	if sub == nil {
+		conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down"))
+		return
+	}
+	defer s.events.unsubscribe(sub)
+	feed := r.URL.Query().Get("feed")
+
+	var writeMu sync.Mutex
+	send := func(msg wsMessage) error {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+		return conn.WriteJSON(msg)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		conn.SetReadLimit(wsReadLimit)
+		for {
+			var req wsRequest
+			err := conn.ReadJSON(&req)
+			if err != nil {
+				return
+			}
+			result := wsMessage{Type: "result", ID: req.ID, OK: true}
+			err = s.runOp(req)
+			if err != nil {
+				result.OK = false
+				result.Error = err.Error()
+			}
+			if send(result) != nil {
+				return
+			}
+		}
+	}()
+
+	ping := time.NewTicker(wsPingInterval)
+	defer ping.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ping.C:
+			writeMu.Lock()
+			err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteTimeout))
+			writeMu.Unlock()
+			if err != nil {
+				return
+			}
+		case ev, ok := <-sub:
+			if !ok {
+				return
+			}
+			if feed != "" && ev.FeedName != feed {
+				continue
+			}
+			if send(wsMessage{Type: "article", Article: &ev}) != nil {
+				return
+			}
+		}
+	}
+}
+
+func (s *Server) runOp(req wsRequest) error {
+	switch req.Op {
+	case "refresh":
+		if req.Feed == "" {
+			return fmt.Errorf("refresh requires feed")
+		}
+		return s.ctrl.Refresh(req.Feed)
+	case "mark-read":
+		id, err := uuid.Parse(req.ArticleID)
+		if err != nil {
+			return fmt.Errorf("invalid article_id: %v", err)
+		}
+		return s.db.MarkArticleRead(id)
+	default:
+		return fmt.Errorf("unknown op %q", req.Op)
+	}
+}
@@ -0,0 +1,83 @@
+// Package auth hashes user passwords and signs users in through OpenID
+// Connect.
+package auth
+
+import (
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// passwordIterations follows the OWASP recommendation for PBKDF2-SHA256.
+// The count is stored with each hash, so it can be raised later without
+// invalidating existing passwords.
+const passwordIterations = 600_000
+
+// MinPasswordLength is the shortest password accepted for new users.
+const MinPasswordLength = 8
+
+var ErrBadHash = errors.New("unrecognized password hash")
+
+// HashPassword returns a salted PBKDF2-SHA256 hash in the form
+// pbkdf2-sha256$<iterations>$<salt>$<key>.
+func HashPassword(password string) (string, error) {
+	if len(password) < MinPasswordLength {
+		return "", fmt.Errorf("password must be at least %d characters", MinPasswordLength)
+	}
+	salt := make([]byte, 16)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return "", err
+	}
+	key, err := pbkdf2.Key(sha256.New, password, salt, passwordIterations, sha256.Size)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("pbkdf2-sha256$%d$%s$%s", passwordIterations,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// CheckPassword reports whether password matches hash.
+func CheckPassword(hash, password string) (bool, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 4 || parts[0] != "pbkdf2-sha256" {
+		return false, ErrBadHash
+	}
+	iterations, err := strconv.Atoi(parts[1])
+	if err != nil || iterations < 1 {
+		return false, ErrBadHash
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return false, ErrBadHash
+	}
+	want, err := base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return false, ErrBadHash
+	}
+	got, err := pbkdf2.Key(sha256.New, password, salt, iterations, len(want))
+	if err != nil {
+		return false, err
+	}
+	return subtle.ConstantTimeCompare(got, want) == 1, nil
+}
+
+// dummyHash is checked against when a user does not exist, so that a
+// failed login takes as long whether or not the name is known. It is made
+// on first use since hashing is deliberately slow.
+var dummyHash = sync.OnceValue(func() string {
+	h, _ := HashPassword("rsshub-no-such-user")
+	return h
+})
+
+// CheckMissingUser spends the time a password check would.
+func CheckMissingUser(password string) {
+	CheckPassword(dummyHash(), password)
+}
@@ -1,43 +1,705 @@
 package config
 
 import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/url"
 	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
+
+	"rsshub/internal/resolver"
+	"rsshub/internal/secretbox"
+)
+
+// Scheduling modes for running several fetch instances against one database.
+const (
+	// SchedulingLeader elects one instance to schedule all feeds.
+	SchedulingLeader = "leader"
+	// SchedulingClaim lets every instance claim its own batch of feeds.
+	SchedulingClaim = "claim"
 )
 
+// Steps a parsed item can go through before it is stored. Parsing comes
+// first and storing, which also notifies listeners, last; the steps in
+// between are configured with CLI_APP_PIPELINE.
+const (
+	// StepNormalize applies the feed's title and description quirks.
+	StepNormalize = "normalize"
+	// StepFilter drops items without a link or without any text.
+	StepFilter = "filter"
+	// StepEnrich counts words and hashes the text for change detection.
+	StepEnrich = "enrich"
+)
+
+// PipelineSteps are the known pipeline steps.
+var PipelineSteps = []string{StepNormalize, StepFilter, StepEnrich}
+
 type Config struct {
 	Interval   time.Duration
 	Workers    int
+	Scheduling string
+	MaxPerHost int
+
+	// ShutdownGrace is how long stopping waits for in-flight fetches to
+	// finish before cancelling them.
+	ShutdownGrace time.Duration
+
+	// TickHistory is how many scheduler ticks the daemon keeps summaries
+	// of, for rsshub status and rsshub ticks.
+	TickHistory int
+
+	// PauseHours and PauseDays are the daily windows and the days, in
+	// local time, during which the daemon schedules no fetches, e.g. to
+	// let a NAS sleep at night.
+	PauseHours []Window
+	PauseDays  []time.Weekday
+
+	// CatchUpAfter is how long the stalest due feed must have gone
+	// unfetched, at startup or when pause hours end, for the daemon to
+	// catch up with CatchUpWorkers workers, most active feeds first; 0
+	// never catches up. CatchUpWorkers of 0 means twice Workers.
+	CatchUpAfter   time.Duration
+	CatchUpWorkers int
+
+	FetchTimeout time.Duration
+	MaxRedirects int
+	MaxBodySize  int64
+	MaxItems     int
+
+	// DNSServer is the DNS server feeds' hosts are looked up with, see
+	// resolver.ParseServer; empty uses the system's. DNSCacheMaxTTL caps
+	// how long an answer is reused, within its own TTL; 0 turns the cache
+	// off.
+	DNSServer      string
+	DNSCacheMaxTTL time.Duration
+
+	// FetchSchemes and FetchHosts, when set, are the only URL schemes and
+	// hosts (with their subdomains) fetches may reach, redirects included.
+	// BlockPrivateAddresses refuses loopback, private, link-local and
+	// other non-public addresses, for a multi-user or exposed service
+	// where a feed URL must not reach the internal network.
+	FetchSchemes          []string
+	FetchHosts            []string
+	BlockPrivateAddresses bool
+
+	// DailyByteLimit caps the bytes downloaded from all feeds per day;
+	// once reached, fetches wait for the next day. 0 means no cap. Feeds
+	// can also have caps of their own, set with rsshub budget.
+	DailyByteLimit int64
+
+	// MaxScheduleDelay caps how long a feed's <ttl>, <skipHours> and
+	// <skipDays> can postpone its next fetch; 0 ignores them.
+	MaxScheduleDelay time.Duration
+
+	// AdaptivePolling polls each feed about twice per its average time
+	// between posts, kept within PollMinInterval and PollMaxInterval.
+	AdaptivePolling bool
+	PollMinInterval time.Duration
+	PollMaxInterval time.Duration
+
+	PartitionArticles bool
+	RetentionMonths   int
+
+	// DedupWindow is how far back, by publication date, a fetched item is
+	// looked up among its feed's articles to tell whether it is new; 0
+	// looks through the whole history. Items whose stored copy is older
+	// are skipped when it has the same date and stored again otherwise.
+	DedupWindow time.Duration
+
+	// WriteBatchSize is how many articles, gathered across workers from
+	// fetches with few new items, are stored in one transaction;
+	// WriteBatchDelay is how long a fetch waits for a batch to fill
+	// before it is stored anyway. 0 stores each fetch's articles on their
+	// own.
+	WriteBatchSize  int
+	WriteBatchDelay time.Duration
+
+	// Pipeline is the steps every parsed item goes through before it is
+	// stored, in order. FeedPipelines replaces it for the feeds it names.
+	Pipeline      []string
+	FeedPipelines map[string][]string
+
+	// StoreRawItems keeps each fetched item's markup next to its article,
+	// for rsshub reparse to run through a later parser or pipeline.
+	StoreRawItems bool
+
+	// FetchLogRetention is how long each fetch's duration, status and
+	// counts stay in fetch_log, and each feed's daily bandwidth in
+	// feed_bandwidth; 0 keeps them forever.
+	FetchLogRetention time.Duration
+
+	// KeepArticlesOnPurge archives a purged feed's articles instead of
+	// deleting them, unless a purge says otherwise.
+	KeepArticlesOnPurge bool
+
+	// LogFormat is "text" or "json"; JSON lines from a fetch carry a
+	// correlation ID shared by all of that fetch's lines.
+	//
+	// LogFile, when set, receives the daemon's log alongside stdout (or
+	// instead of it, with LogStdout off). The file rotates at LogMaxSize
+	// bytes or LogMaxAge, either 0 for no limit, keeping LogKeep old files.
+	LogFile    string
+	LogStdout  bool
+	LogFormat  string
+	LogMaxSize int64
+	LogMaxAge  time.Duration
+	LogKeep    int
+
+	HTTPAddr string
+	APIToken string
+
+	// API rate limits in requests per minute, 0 for none. Callers with the
+	// API token are limited together; everyone else per IP address.
+	APIRateLimit      int
+	APITokenRateLimit int
+	APIRateBurst      int
+
+	// BasePath is the path prefix the API is served under, e.g. "/rsshub"
+	// behind a reverse proxy. CORSOrigins lists the browser origins allowed
+	// to call the API ("*" for any). TrustedProxies are addresses or CIDR
+	// ranges whose X-Forwarded-For headers are believed.
+	BasePath       string
+	CORSOrigins    []string
+	TrustedProxies []string
+
+	// RequireLogin protects the API with user sign-in even without an API
+	// token. It is implied by an API token or an OIDC issuer. Sessions
+	// last SessionTTL.
+	RequireLogin bool
+	SessionTTL   time.Duration
+
+	// OIDC single sign-on, enabled by setting the issuer.
+	OIDCIssuer       string
+	OIDCClientID     string
+	OIDCClientSecret string
+	OIDCRedirectURL  string
+
+	// SentryDSN enables reporting panics and feeds that keep failing to
+	// Sentry. A feed is reported once it has failed ErrorReportAfter
+	// fetches in a row.
+	SentryDSN         string
+	SentryEnvironment string
+	ErrorReportAfter  int
+
+	// DisableAfterFailures stops fetching a feed once it has failed that
+	// many fetches in a row, reporting its last error; 0 never does.
+	DisableAfterFailures int
+
+	// A feed has gone quiet when it has not posted for QuietAfterGaps
+	// times its usual time between posts, and at least QuietMinimum.
+	// Quiet feeds are reported once per silence; 0 gaps never does.
+	QuietAfterGaps int
+	QuietMinimum   time.Duration
+
+	// Profile names the profile whose settings were applied, see
+	// ApplyProfile; empty when none was.
+	Profile string
+
+	// Server is the base URL of a remote rsshub API. When set, the CLI
+	// manages that deployment instead of a local database and daemon.
+	Server string
+
+	// SocketPath is the daemon's control socket. By default each database
+	// gets its own under XDG_RUNTIME_DIR, or the temp directory, so daemons
+	// for different databases on one machine do not collide.
+	SocketPath string
+
 	PGHost     string
 	PGPort     string
 	PGUser     string
 	PGPassword string
 	PGDBName   string
+
+	// ReadDSN optionally points list and report queries at a read replica.
+	ReadDSN string
+
+	// ReadCacheSize is how many feed and article listings the HTTP API
+	// keeps in memory, each for up to ReadCacheTTL; 0 disables the cache.
+	ReadCacheSize int
+	ReadCacheTTL  time.Duration
+
+	// SecretsKey is the base64 key the credentials stored in the database
+	// are encrypted with; see rsshub secrets.
+	SecretsKey string
+
+	ReadLater ReadLater
 }
 
-func LoadConfig() *Config {
-	intervalStr := os.Getenv("CLI_APP_TIMER_INTERVAL")
-	if intervalStr == "" {
-		intervalStr = "3m"
-	}
-	interval, _ := time.ParseDuration(intervalStr)
+// ReadLater holds credentials for read-later services. Service is the one
+// used when a command does not name one.
+type ReadLater struct {
+	Service string
 
-	workersStr := os.Getenv("CLI_APP_WORKERS_COUNT")
-	if workersStr == "" {
-		workersStr = "3"
-	}
-	workers, _ := strconv.Atoi(workersStr)
+	WallabagURL          string
+	WallabagClientID     string
+	WallabagClientSecret string
+	WallabagUsername     string
+	WallabagPassword     string
+
+	PocketConsumerKey string
+	PocketAccessToken string
+
+	InstapaperUsername string
+	InstapaperPassword string
+}
+
+// Log formats for the daemon's output.
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+// Bounds applied to the fetch schedule, both at startup and when changed
+// on a running daemon.
+const (
+	MinInterval = 10 * time.Second
+	MaxWorkers  = 100
+)
+
+// MaxTickHistory bounds how many tick summaries the daemon keeps in memory.
+const MaxTickHistory = 10000
+
+// MaxWriteBatchSize bounds a write batch, as the aggregator bounds a large
+// feed's bulk inserts.
+const MaxWriteBatchSize = 1000
+
+// LoadConfig reads the configuration from the environment. Every malformed
+// or out-of-range value is reported in the returned error, one per line;
+// the Config is still returned with defaults in place of the bad values so
+// diagnostics can inspect the rest.
+func LoadConfig() (*Config, error) {
+	l := &loader{}
+	cfg := &Config{
+		Interval:   l.duration("CLI_APP_TIMER_INTERVAL", "3m"),
+		Workers:    l.int("CLI_APP_WORKERS_COUNT", "3"),
+		Scheduling: getEnv("CLI_APP_SCHEDULING_MODE", SchedulingLeader),
+		MaxPerHost: l.int("CLI_APP_MAX_PER_HOST", "1"),
+
+		ShutdownGrace: l.duration("CLI_APP_SHUTDOWN_GRACE", "30s"),
+		TickHistory:   l.int("CLI_APP_TICK_HISTORY", "60"),
+		PauseHours:    l.windows("CLI_APP_PAUSE_HOURS"),
+		PauseDays:     l.weekdays("CLI_APP_PAUSE_DAYS"),
+
+		CatchUpAfter:   l.duration("CLI_APP_CATCH_UP_AFTER", "1h"),
+		CatchUpWorkers: l.int("CLI_APP_CATCH_UP_WORKERS", "0"),
+
+		FetchTimeout: l.duration("CLI_APP_FETCH_TIMEOUT", "30s"),
+		MaxRedirects: l.int("CLI_APP_MAX_REDIRECTS", "5"),
+		MaxBodySize:  l.size("CLI_APP_MAX_BODY_SIZE", "10MB"),
+		MaxItems:     l.int("CLI_APP_MAX_ITEMS_PER_FEED", "0"),
+
+		DNSServer:      getEnv("CLI_APP_DNS_SERVER", ""),
+		DNSCacheMaxTTL: l.duration("CLI_APP_DNS_CACHE_MAX_TTL", "5m"),
+
+		FetchSchemes:          list(strings.ToLower(getEnv("CLI_APP_FETCH_SCHEMES", "http,https"))),
+		FetchHosts:            list(strings.ToLower(os.Getenv("CLI_APP_FETCH_HOSTS"))),
+		BlockPrivateAddresses: l.bool("CLI_APP_BLOCK_PRIVATE_ADDRESSES", "false"),
+
+		DailyByteLimit: l.size("CLI_APP_DAILY_BYTE_LIMIT", "0"),
+
+		Pipeline:      l.pipeline("CLI_APP_PIPELINE", getEnv("CLI_APP_PIPELINE", "normalize,enrich")),
+		FeedPipelines: l.feedPipelines("CLI_APP_FEED_PIPELINES"),
+		StoreRawItems: l.bool("CLI_APP_STORE_RAW_ITEMS", "false"),
+
+		MaxScheduleDelay: l.duration("CLI_APP_MAX_SCHEDULE_DELAY", "24h"),
+		AdaptivePolling:  l.bool("CLI_APP_ADAPTIVE_POLLING", "true"),
+		PollMinInterval:  l.duration("CLI_APP_POLL_MIN_INTERVAL", "0s"),
+		PollMaxInterval:  l.duration("CLI_APP_POLL_MAX_INTERVAL", "6h"),
+
+		PartitionArticles: l.bool("CLI_APP_PARTITION_ARTICLES", "false"),
+		RetentionMonths:   l.int("CLI_APP_RETENTION_MONTHS", "0"),
+		DedupWindow:       l.duration("CLI_APP_DEDUP_WINDOW", "0s"),
+		WriteBatchSize:    l.int("CLI_APP_WRITE_BATCH_SIZE", "500"),
+		WriteBatchDelay:   l.duration("CLI_APP_WRITE_BATCH_DELAY", "250ms"),
+		FetchLogRetention: l.duration("CLI_APP_FETCH_LOG_RETENTION", "720h"),
+
+		KeepArticlesOnPurge: l.bool("CLI_APP_KEEP_ARTICLES_ON_PURGE", "false"),
+
+		LogFile:    os.Getenv("CLI_APP_LOG_FILE"),
+		LogStdout:  l.bool("CLI_APP_LOG_STDOUT", "true"),
+		LogFormat:  getEnv("CLI_APP_LOG_FORMAT", LogFormatText),
+		LogMaxSize: l.size("CLI_APP_LOG_MAX_SIZE", "10MB"),
+		LogMaxAge:  l.duration("CLI_APP_LOG_MAX_AGE", "24h"),
+		LogKeep:    l.int("CLI_APP_LOG_KEEP", "7"),
+
+		HTTPAddr: os.Getenv("CLI_APP_HTTP_ADDR"),
+		APIToken: l.secret("CLI_APP_API_TOKEN", ""),
+
+		APIRateLimit:      l.int("CLI_APP_API_RATE_LIMIT", "120"),
+		APITokenRateLimit: l.int("CLI_APP_API_TOKEN_RATE_LIMIT", "600"),
+		APIRateBurst:      l.int("CLI_APP_API_RATE_BURST", "30"),
+
+		BasePath:       strings.TrimRight(os.Getenv("CLI_APP_BASE_PATH"), "/"),
+		CORSOrigins:    list(os.Getenv("CLI_APP_CORS_ORIGINS")),
+		TrustedProxies: list(os.Getenv("CLI_APP_TRUSTED_PROXIES")),
+
+		RequireLogin: l.bool("CLI_APP_REQUIRE_LOGIN", "false"),
+		SessionTTL:   l.duration("CLI_APP_SESSION_TTL", "720h"),
+
+		OIDCIssuer:       os.Getenv("CLI_APP_OIDC_ISSUER"),
+		OIDCClientID:     os.Getenv("CLI_APP_OIDC_CLIENT_ID"),
+		OIDCClientSecret: l.secret("CLI_APP_OIDC_CLIENT_SECRET", ""),
+		OIDCRedirectURL:  os.Getenv("CLI_APP_OIDC_REDIRECT_URL"),
+
+		SentryDSN:         l.secret("CLI_APP_SENTRY_DSN", ""),
+		SentryEnvironment: os.Getenv("CLI_APP_SENTRY_ENVIRONMENT"),
+		ErrorReportAfter:  l.int("CLI_APP_ERROR_REPORT_AFTER", "3"),
+
+		DisableAfterFailures: l.int("CLI_APP_DISABLE_AFTER_FAILURES", "10"),
+		QuietAfterGaps:       l.int("CLI_APP_QUIET_AFTER_GAPS", "10"),
+		QuietMinimum:         l.duration("CLI_APP_QUIET_MINIMUM", "72h"),
+
+		Profile:    os.Getenv("CLI_APP_PROFILE"),
+		Server:     os.Getenv("CLI_APP_SERVER"),
+		SocketPath: os.Getenv("CLI_APP_SOCKET_PATH"),
 
-	return &Config{
-		Interval:   interval,
-		Workers:    workers,
 		PGHost:     getEnv("POSTGRES_HOST", "localhost"),
 		PGPort:     getEnv("POSTGRES_PORT", "5432"),
-		PGUser:     getEnv("POSTGRES_USER", "postgres"),
-		PGPassword: getEnv("POSTGRES_PASSWORD", "changem"),
+		PGUser:     l.secret("POSTGRES_USER", "postgres"),
+		PGPassword: l.secret("POSTGRES_PASSWORD", "changem"),
 		PGDBName:   getEnv("POSTGRES_DBNAME", "rsshub"),
+
+		ReadDSN:       l.secret("CLI_APP_READ_DSN", ""),
+		ReadCacheSize: l.int("CLI_APP_READ_CACHE_SIZE", "0"),
+		ReadCacheTTL:  l.duration("CLI_APP_READ_CACHE_TTL", "30s"),
+
+		SecretsKey: l.secret("CLI_APP_SECRETS_KEY", ""),
+
+		ReadLater: ReadLater{
+			Service: os.Getenv("CLI_APP_READ_LATER"),
+
+			WallabagURL:          os.Getenv("CLI_APP_WALLABAG_URL"),
+			WallabagClientID:     os.Getenv("CLI_APP_WALLABAG_CLIENT_ID"),
+			WallabagClientSecret: l.secret("CLI_APP_WALLABAG_CLIENT_SECRET", ""),
+			WallabagUsername:     os.Getenv("CLI_APP_WALLABAG_USERNAME"),
+			WallabagPassword:     l.secret("CLI_APP_WALLABAG_PASSWORD", ""),
+
+			PocketConsumerKey: l.secret("CLI_APP_POCKET_CONSUMER_KEY", ""),
+			PocketAccessToken: l.secret("CLI_APP_POCKET_ACCESS_TOKEN", ""),
+
+			InstapaperUsername: os.Getenv("CLI_APP_INSTAPAPER_USERNAME"),
+			InstapaperPassword: l.secret("CLI_APP_INSTAPAPER_PASSWORD", ""),
+		},
+	}
+	if cfg.SocketPath == "" {
+		cfg.SocketPath = cfg.defaultSocketPath()
+	}
+	cfg.validate(l)
+	return cfg, errors.Join(l.errs...)
+}
+
+// maxSocketPath is the longest Unix socket path every supported platform
+// accepts.
+const maxSocketPath = 103
+
+// defaultSocketPath names the control socket after a hash of the database
+// address and name, so it is stable across restarts but distinct per
+// database.
+func (c *Config) defaultSocketPath() string {
+	dir := os.Getenv("XDG_RUNTIME_DIR")
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	sum := sha256.Sum256([]byte(net.JoinHostPort(c.PGHost, c.PGPort) + "/" + c.PGDBName))
+	return filepath.Join(dir, fmt.Sprintf("rsshub-%x.sock", sum[:6]))
+}
+
+// validate checks the parsed values against their allowed ranges.
+func (c *Config) validate(l *loader) {
+	if c.Interval < MinInterval {
+		l.fail("CLI_APP_TIMER_INTERVAL", "must be at least %s, got %s", MinInterval, c.Interval)
+	}
+	if c.Workers < 1 || c.Workers > MaxWorkers {
+		l.fail("CLI_APP_WORKERS_COUNT", "must be between 1 and %d, got %d", MaxWorkers, c.Workers)
+	}
+	if c.Scheduling != SchedulingLeader && c.Scheduling != SchedulingClaim {
+		l.fail("CLI_APP_SCHEDULING_MODE", "must be %q or %q, got %q", SchedulingLeader, SchedulingClaim, c.Scheduling)
+	}
+	if c.MaxPerHost < 1 {
+		l.fail("CLI_APP_MAX_PER_HOST", "must be at least 1, got %d", c.MaxPerHost)
+	}
+	if len(c.SocketPath) > maxSocketPath {
+		l.fail("CLI_APP_SOCKET_PATH", "must be at most %d bytes long, got %d", maxSocketPath, len(c.SocketPath))
+	}
+	if c.DNSServer != "" {
+		if _, _, err := resolver.ParseServer(c.DNSServer); err != nil {
+			l.fail("CLI_APP_DNS_SERVER", "%v", err)
+		}
+	}
+	for _, scheme := range c.FetchSchemes {
+		if scheme != "http" && scheme != "https" {
+			l.fail("CLI_APP_FETCH_SCHEMES", "must list http and/or https, got %q", scheme)
+		}
+	}
+	if days := map[time.Weekday]bool{}; len(c.PauseDays) > 0 {
+		for _, d := range c.PauseDays {
+			days[d] = true
+		}
+		if len(days) == 7 {
+			l.fail("CLI_APP_PAUSE_DAYS", "must leave at least one day to fetch on")
+		}
+	}
+	if c.CatchUpAfter < 0 {
+		l.fail("CLI_APP_CATCH_UP_AFTER", "must not be negative (0 never catches up), got %s", c.CatchUpAfter)
+	}
+	if c.CatchUpWorkers < 0 || c.CatchUpWorkers > MaxWorkers {
+		l.fail("CLI_APP_CATCH_UP_WORKERS", "must be between 0 (twice the workers) and %d, got %d", MaxWorkers, c.CatchUpWorkers)
+	}
+	if c.DNSCacheMaxTTL < 0 {
+		l.fail("CLI_APP_DNS_CACHE_MAX_TTL", "must not be negative (0 turns the cache off), got %s", c.DNSCacheMaxTTL)
+	}
+	if c.SecretsKey != "" {
+		if _, err := secretbox.ParseKey(c.SecretsKey); err != nil {
+			l.fail("CLI_APP_SECRETS_KEY", "%v (generate one with rsshub secrets new-key)", err)
+		}
+	}
+	if c.ShutdownGrace < 0 {
+		l.fail("CLI_APP_SHUTDOWN_GRACE", "must not be negative (0 cancels fetches at once), got %s", c.ShutdownGrace)
+	}
+	if c.TickHistory < 1 || c.TickHistory > MaxTickHistory {
+		l.fail("CLI_APP_TICK_HISTORY", "must be between 1 and %d, got %d", MaxTickHistory, c.TickHistory)
+	}
+	if c.FetchTimeout <= 0 {
+		l.fail("CLI_APP_FETCH_TIMEOUT", "must be a positive duration, got %s", c.FetchTimeout)
+	}
+	if c.MaxRedirects < 0 {
+		l.fail("CLI_APP_MAX_REDIRECTS", "must not be negative, got %d", c.MaxRedirects)
+	}
+	if c.MaxBodySize <= 0 {
+		l.fail("CLI_APP_MAX_BODY_SIZE", "must be a positive size, got %d", c.MaxBodySize)
+	}
+	if c.MaxItems < 0 {
+		l.fail("CLI_APP_MAX_ITEMS_PER_FEED", "must not be negative (0 means unlimited), got %d", c.MaxItems)
+	}
+	if c.MaxScheduleDelay < 0 {
+		l.fail("CLI_APP_MAX_SCHEDULE_DELAY", "must not be negative (0 ignores feed schedules), got %s", c.MaxScheduleDelay)
+	}
+	if c.PollMinInterval < 0 {
+		l.fail("CLI_APP_POLL_MIN_INTERVAL", "must not be negative, got %s", c.PollMinInterval)
+	}
+	if c.PollMaxInterval < c.PollMinInterval {
+		l.fail("CLI_APP_POLL_MAX_INTERVAL", "must be at least CLI_APP_POLL_MIN_INTERVAL (%s), got %s", c.PollMinInterval, c.PollMaxInterval)
+	}
+	if c.RetentionMonths < 0 {
+		l.fail("CLI_APP_RETENTION_MONTHS", "must not be negative (0 keeps everything), got %d", c.RetentionMonths)
+	}
+	if c.DedupWindow < 0 {
+		l.fail("CLI_APP_DEDUP_WINDOW", "must not be negative (0 looks through all articles), got %s", c.DedupWindow)
+	}
+	if c.ReadCacheSize < 0 {
+		l.fail("CLI_APP_READ_CACHE_SIZE", "must not be negative (0 disables the cache), got %d", c.ReadCacheSize)
+	}
+	if c.ReadCacheSize > 0 && c.ReadCacheTTL <= 0 {
+		l.fail("CLI_APP_READ_CACHE_TTL", "must be more than 0, got %s", c.ReadCacheTTL)
+	}
+	if c.WriteBatchSize < 0 || c.WriteBatchSize > MaxWriteBatchSize {
+		l.fail("CLI_APP_WRITE_BATCH_SIZE", "must be between 0 (no batching) and %d, got %d", MaxWriteBatchSize, c.WriteBatchSize)
+	}
+	if c.WriteBatchSize > 0 && (c.WriteBatchDelay <= 0 || c.WriteBatchDelay > 10*time.Second) {
+		l.fail("CLI_APP_WRITE_BATCH_DELAY", "must be more than 0 and at most 10s, got %s", c.WriteBatchDelay)
+	}
+	if c.FetchLogRetention < 0 {
+		l.fail("CLI_APP_FETCH_LOG_RETENTION", "must not be negative (0 keeps everything), got %s", c.FetchLogRetention)
+	}
+	if c.LogFormat != LogFormatText && c.LogFormat != LogFormatJSON {
+		l.fail("CLI_APP_LOG_FORMAT", "must be %q or %q, got %q", LogFormatText, LogFormatJSON, c.LogFormat)
+	}
+	if c.LogMaxAge < 0 {
+		l.fail("CLI_APP_LOG_MAX_AGE", "must not be negative (0 disables it), got %s", c.LogMaxAge)
+	}
+	if c.LogKeep < 0 {
+		l.fail("CLI_APP_LOG_KEEP", "must not be negative, got %d", c.LogKeep)
+	}
+	if c.LogFile == "" && !c.LogStdout {
+		l.fail("CLI_APP_LOG_STDOUT", "can only be false with CLI_APP_LOG_FILE set")
+	}
+	if c.HTTPAddr != "" {
+		if _, _, err := net.SplitHostPort(c.HTTPAddr); err != nil {
+			l.fail("CLI_APP_HTTP_ADDR", "must be host:port or :port, got %q", c.HTTPAddr)
+		}
+	}
+	if c.APIRateLimit < 0 {
+		l.fail("CLI_APP_API_RATE_LIMIT", "must not be negative (0 disables it), got %d", c.APIRateLimit)
+	}
+	if c.APITokenRateLimit < 0 {
+		l.fail("CLI_APP_API_TOKEN_RATE_LIMIT", "must not be negative (0 disables it), got %d", c.APITokenRateLimit)
+	}
+	if c.APIRateBurst < 1 {
+		l.fail("CLI_APP_API_RATE_BURST", "must be at least 1, got %d", c.APIRateBurst)
+	}
+	if c.BasePath != "" && !strings.HasPrefix(c.BasePath, "/") {
+		l.fail("CLI_APP_BASE_PATH", "must start with /, got %q", c.BasePath)
+	}
+	for _, o := range c.CORSOrigins {
+		if u, err := url.Parse(o); o != "*" && (err != nil || u.Scheme == "" || u.Host == "" || u.Path != "") {
+			l.fail("CLI_APP_CORS_ORIGINS", "must be origins such as https://app.example.com or *, got %q", o)
+		}
+	}
+	for _, p := range c.TrustedProxies {
+		_, _, err := net.ParseCIDR(p)
+		if err != nil && net.ParseIP(p) == nil {
+			l.fail("CLI_APP_TRUSTED_PROXIES", "must be IP addresses or CIDR ranges, got %q", p)
+		}
+	}
+	if c.SessionTTL < time.Minute {
+		l.fail("CLI_APP_SESSION_TTL", "must be at least 1m, got %s", c.SessionTTL)
+	}
+	if c.OIDCIssuer != "" {
+		if err := CheckServerURL(c.OIDCIssuer); err != nil {
+			l.fail("CLI_APP_OIDC_ISSUER", "%v", err)
+		}
+		if c.OIDCClientID == "" {
+			l.fail("CLI_APP_OIDC_CLIENT_ID", "is required with CLI_APP_OIDC_ISSUER")
+		}
+		if err := CheckServerURL(c.OIDCRedirectURL); err != nil {
+			l.fail("CLI_APP_OIDC_REDIRECT_URL", "is required with CLI_APP_OIDC_ISSUER and %v", err)
+		}
+	}
+	if c.SentryDSN != "" {
+		u, err := url.Parse(c.SentryDSN)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.User == nil || strings.Trim(u.Path, "/") == "" {
+			// The DSN holds a key, so it is not echoed.
+			l.fail("CLI_APP_SENTRY_DSN", "must be a DSN like https://<key>@<host>/<project>")
+		}
 	}
+	if c.ErrorReportAfter < 1 {
+		l.fail("CLI_APP_ERROR_REPORT_AFTER", "must be at least 1, got %d", c.ErrorReportAfter)
+	}
+	if c.DisableAfterFailures < 0 {
+		l.fail("CLI_APP_DISABLE_AFTER_FAILURES", "must not be negative (0 never disables feeds), got %d", c.DisableAfterFailures)
+	}
+	if c.QuietAfterGaps < 0 {
+		l.fail("CLI_APP_QUIET_AFTER_GAPS", "must not be negative (0 never reports quiet feeds), got %d", c.QuietAfterGaps)
+	}
+	if c.QuietMinimum < 0 {
+		l.fail("CLI_APP_QUIET_MINIMUM", "must not be negative, got %s", c.QuietMinimum)
+	}
+	if c.Server != "" {
+		if err := CheckServerURL(c.Server); err != nil {
+			l.fail("CLI_APP_SERVER", "%v", err)
+		}
+	}
+	if port, err := strconv.Atoi(c.PGPort); err != nil || port < 1 || port > 65535 {
+		l.fail("POSTGRES_PORT", "must be a port number, got %q", c.PGPort)
+	}
+}
+
+// CheckServerURL validates the base URL of a remote rsshub API.
+func CheckServerURL(s string) error {
+	u, err := url.Parse(s)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("must be an http:// or https:// URL, got %q", s)
+	}
+	return nil
+}
+
+// loader parses environment variables, recording an error for each value
+// that does not parse and falling back to its default.
+type loader struct {
+	errs []error
+}
+
+func (l *loader) fail(key, format string, args ...any) {
+	l.errs = append(l.errs, fmt.Errorf("%s: "+format, append([]any{key}, args...)...))
+}
+
+func (l *loader) duration(key, defaultVal string) time.Duration {
+	d, err := time.ParseDuration(getEnv(key, defaultVal))
+	if err != nil {
+		l.fail(key, "invalid duration %q (use a number with a unit, e.g. 90s, 3m or 1h)", os.Getenv(key))
+		d, _ = time.ParseDuration(defaultVal)
+	}
+	return d
+}
+
+func (l *loader) int(key, defaultVal string) int {
+	n, err := strconv.Atoi(getEnv(key, defaultVal))
+	if err != nil {
+		l.fail(key, "invalid whole number %q", os.Getenv(key))
+		n, _ = strconv.Atoi(defaultVal)
+	}
+	return n
+}
+
+func (l *loader) bool(key, defaultVal string) bool {
+	b, err := strconv.ParseBool(getEnv(key, defaultVal))
+	if err != nil {
+		l.fail(key, "invalid boolean %q (use true or false)", os.Getenv(key))
+		b, _ = strconv.ParseBool(defaultVal)
+	}
+	return b
+}
+
+// pipeline parses a comma-separated list of pipeline steps from value,
+// the setting key's or part of it.
+func (l *loader) pipeline(key, value string) []string {
+	steps := []string{}
+	seen := map[string]bool{}
+	for _, s := range list(value) {
+		known := false
+		for _, k := range PipelineSteps {
+			known = known || s == k
+		}
+		switch {
+		case !known:
+			l.fail(key, "unknown pipeline step %q (steps are %s)", s, strings.Join(PipelineSteps, ", "))
+		case seen[s]:
+			l.fail(key, "pipeline step %q is listed twice", s)
+		default:
+			seen[s] = true
+			steps = append(steps, s)
+		}
+	}
+	return steps
+}
+
+// feedPipelines parses per-feed pipelines such as
+// "hn=normalize,filter,enrich;podcasts=enrich". An empty list after the
+// = stores the feed's items as parsed.
+func (l *loader) feedPipelines(key string) map[string][]string {
+	out := map[string][]string{}
+	for _, entry := range strings.Split(os.Getenv(key), ";") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		name, steps, ok := strings.Cut(entry, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			l.fail(key, "want feed=step,step;feed=step, got %q", entry)
+			continue
+		}
+		out[name] = l.pipeline(key, steps)
+	}
+	return out
+}
+
+func (l *loader) size(key, defaultVal string) int64 {
+	n, err := ParseSize(getEnv(key, defaultVal))
+	if err != nil {
+		l.fail(key, "invalid size %q (use e.g. 512KB, 10MB or 1GB)", os.Getenv(key))
+		n, _ = ParseSize(defaultVal)
+	}
+	return n
+}
+
+// DSN returns the Postgres connection string for the configured database.
+// Credentials are escaped since secrets read from files or a secret store
+// often contain URL-reserved characters.
+func (c *Config) DSN() string {
+	u := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(c.PGUser, c.PGPassword),
+		Host:     net.JoinHostPort(c.PGHost, c.PGPort),
+		Path:     "/" + c.PGDBName,
+		RawQuery: "sslmode=disable",
+	}
+	return u.String()
 }
 
 func getEnv(key, defaultVal string) string {
@@ -47,3 +709,38 @@ func getEnv(key, defaultVal string) string {
 	}
 	return val
 }
+
+// list splits a comma-separated setting, dropping empty entries.
+func list(s string) []string {
+	var out []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// ParseSize parses a byte size such as "512", "64KB", "10MB" or "1GB".
+func ParseSize(s string) (int64, error) {
+	str := strings.ToUpper(strings.TrimSpace(s))
+	mult := int64(1)
+	for _, unit := range []struct {
+		suffix string
+		mult   int64
+	}{{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"B", 1}} {
+		if strings.HasSuffix(str, unit.suffix) {
+			str = strings.TrimSpace(strings.TrimSuffix(str, unit.suffix))
+			mult = unit.mult
+			break
+		}
+	}
+	n, err := strconv.ParseInt(str, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	if n > math.MaxInt64/mult {
+		return 0, fmt.Errorf("size %q is too large", s)
+	}
+	return n * mult, nil
+}
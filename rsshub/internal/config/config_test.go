@@ -0,0 +1,43 @@
+package config
+
+import (
+	"math"
+	"testing"
+)
+
+// TestParseSize checks the units ParseSize accepts and that it refuses
+// sizes that do not fit in an int64 rather than wrapping them.
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    int64
+		wantErr bool
+	}{
+		{in: "512", want: 512},
+		{in: "64KB", want: 64 << 10},
+		{in: " 10 mb ", want: 10 << 20},
+		{in: "1GB", want: 1 << 30},
+		{in: "0B", want: 0},
+		{in: "9223372036854775807", want: math.MaxInt64},
+		{in: "9223372036854775807B", want: math.MaxInt64},
+		{in: "8589934591GB", want: 8589934591 << 30},
+		{in: "8589934592GB", wantErr: true},
+		{in: "9007199254740992KB", wantErr: true},
+		{in: "9223372036854775808", wantErr: true},
+		{in: "-1MB", wantErr: true},
+		{in: "MB", wantErr: true},
+		{in: "1TB", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseSize(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseSize(%q) = %d, want an error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParseSize(%q) = %d, %v, want %d", tt.in, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Window is a daily span of local time, from From up to To, both offsets
+// from midnight. A window whose To is not after its From runs past
+// midnight.
+type Window struct {
+	From, To time.Duration
+}
+
+func (w Window) String() string {
+	clock := func(d time.Duration) string {
+		return fmt.Sprintf("%02d:%02d", int(d/time.Hour), int(d%time.Hour/time.Minute))
+	}
+	return clock(w.From) + "-" + clock(w.To)
+}
+
+// Contains reports whether the time of day t falls in the window.
+func (w Window) Contains(t time.Time) bool {
+	since := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second
+	if w.From < w.To {
+		return since >= w.From && since < w.To
+	}
+	return since >= w.From || since < w.To
+}
+
+// ParseWindows parses comma-separated windows such as
+// "01:00-06:00,22:30-23:00".
+func ParseWindows(s string) ([]Window, error) {
+	var windows []Window
+	for _, part := range list(s) {
+		from, to, ok := strings.Cut(part, "-")
+		if !ok {
+			return nil, fmt.Errorf("invalid window %q (use HH:MM-HH:MM)", part)
+		}
+		var w Window
+		var err error
+		if w.From, err = parseClock(from); err == nil {
+			w.To, err = parseClock(to)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid window %q: %v", part, err)
+		}
+		if w.From == w.To {
+			return nil, fmt.Errorf("invalid window %q: it is empty", part)
+		}
+		windows = append(windows, w)
+	}
+	return windows, nil
+}
+
+// parseClock parses a time of day written HH:MM, 24:00 included.
+func parseClock(s string) (time.Duration, error) {
+	var h, m int
+	if n, err := fmt.Sscanf(strings.TrimSpace(s), "%d:%d", &h, &m); err != nil || n != 2 {
+		return 0, fmt.Errorf("%q is not a time like 06:30", s)
+	}
+	if h < 0 || m < 0 || m > 59 || h > 24 || (h == 24 && m > 0) {
+		return 0, fmt.Errorf("%q is not a time of day", s)
+	}
+	return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute, nil
+}
+
+// weekdayNames maps full and three-letter day names to weekdays.
+var weekdayNames = map[string]time.Weekday{}
+
+func init() {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		name := strings.ToLower(d.String())
+		weekdayNames[name] = d
+		weekdayNames[name[:3]] = d
+	}
+}
+
+// ParseWeekdays parses comma-separated day names such as "sat,sun".
+func ParseWeekdays(s string) ([]time.Weekday, error) {
+	var days []time.Weekday
+	for _, name := range list(s) {
+		d, ok := weekdayNames[strings.ToLower(name)]
+		if !ok {
+			return nil, fmt.Errorf("%q is not a day of the week", name)
+		}
+		days = append(days, d)
+	}
+	return days, nil
+}
+
+func (l *loader) windows(key string) []Window {
+	w, err := ParseWindows(getEnv(key, ""))
+	if err != nil {
+		l.fail(key, "%v", err)
+	}
+	return w
+}
+
+func (l *loader) weekdays(key string) []time.Weekday {
+	d, err := ParseWeekdays(getEnv(key, ""))
+	if err != nil {
+		l.fail(key, "%v", err)
+	}
+	return d
+}
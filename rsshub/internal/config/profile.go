@@ -0,0 +1,115 @@
+package config
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// A profiles file holds named sets of settings, so that one binary can
+// manage several separate archives, each with its own database, daemon
+// socket or server:
+//
+//	[work]
+//	POSTGRES_HOST=db.work.example
+//	POSTGRES_DB=rsshub
+//	CLI_APP_SOCKET_PATH=/run/user/1000/rsshub-work.sock
+//
+//	[personal]
+//	CLI_APP_SERVER=https://rss.example.net
+//
+// Keys are the environment variables LoadConfig reads; blank lines and
+// lines starting with # are ignored.
+
+// ProfilesPath returns the profiles file: CLI_APP_PROFILES_FILE, or
+// rsshub/profiles under the user's configuration directory.
+func ProfilesPath() string {
+	if path := os.Getenv("CLI_APP_PROFILES_FILE"); path != "" {
+		return path
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "rsshub", "profiles")
+}
+
+// ReadProfiles parses the profiles file at path into each profile's
+// settings.
+func ReadProfiles(path string) (map[string]map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	profiles := map[string]map[string]string{}
+	var current map[string]string
+	sc := bufio.NewScanner(f)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			name := strings.TrimSpace(line[1 : len(line)-1])
+			if name == "" {
+				return nil, fmt.Errorf("%s:%d: empty profile name", path, n)
+			}
+			if profiles[name] != nil {
+				return nil, fmt.Errorf("%s:%d: profile %s defined twice", path, n, name)
+			}
+			current = map[string]string{}
+			profiles[name] = current
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		switch {
+		case !ok:
+			return nil, fmt.Errorf("%s:%d: expected KEY=value or [profile]", path, n)
+		case current == nil:
+			return nil, fmt.Errorf("%s:%d: %s is outside any [profile]", path, n, key)
+		case !strings.HasPrefix(key, "CLI_APP_") && !strings.HasPrefix(key, "POSTGRES_") && !strings.HasPrefix(key, "VAULT_"):
+			return nil, fmt.Errorf("%s:%d: %s is not an rsshub setting", path, n, key)
+		case key == "CLI_APP_PROFILE" || key == "CLI_APP_PROFILES_FILE":
+			return nil, fmt.Errorf("%s:%d: %s cannot be set by a profile", path, n, key)
+		}
+		current[key] = strings.TrimSpace(value)
+	}
+	return profiles, sc.Err()
+}
+
+// ApplyProfile sets the environment variables of the named profile, for
+// LoadConfig to read. A profile's settings take precedence over the
+// environment, since choosing a profile is the more deliberate act. The
+// name is kept in CLI_APP_PROFILE, where Config.Profile picks it up.
+func ApplyProfile(name string) error {
+	path := ProfilesPath()
+	if path == "" {
+		return errors.New("no configuration directory to find profiles in; set CLI_APP_PROFILES_FILE")
+	}
+	profiles, err := ReadProfiles(path)
+	if err != nil {
+		return err
+	}
+	settings, ok := profiles[name]
+	if !ok {
+		names := make([]string, 0, len(profiles))
+		for n := range profiles {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("not found in %s (profiles: %s)", path, strings.Join(names, ", "))
+	}
+	for key, value := range settings {
+		if err := os.Setenv(key, value); err != nil {
+			return err
+		}
+	}
+	return os.Setenv("CLI_APP_PROFILE", name)
+}
@@ -0,0 +1,165 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// secretTimeout bounds each lookup in an external secret store.
+const secretTimeout = 10 * time.Second
+
+// secret reads a credential. KEY_FILE, if set, names a file holding the
+// value (as mounted by Docker or Kubernetes secrets) and takes precedence
+// over KEY. The value may then reference an external store:
+//
+//	vault:<path>#<field>   read <field> from Vault at VAULT_ADDR/v1/<path>
+//	sops:<file>#<key>      decrypt <key> from a SOPS-encrypted file
+//	keyring:<service>#<account>  read a password from the OS keyring
+//
+// Anything else is used literally.
+func (l *loader) secret(key, defaultVal string) string {
+	val := getEnv(key, defaultVal)
+	if path := os.Getenv(key + "_FILE"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			l.fail(key+"_FILE", "%v", err)
+			return val
+		}
+		val = strings.TrimRight(string(data), "\r\n")
+	}
+	resolved, err := resolveSecret(val)
+	if err != nil {
+		l.fail(key, "%v", err)
+		return ""
+	}
+	return resolved
+}
+
+func resolveSecret(val string) (string, error) {
+	scheme, ref, ok := strings.Cut(val, ":")
+	if !ok {
+		return val, nil
+	}
+	switch scheme {
+	case "vault":
+		path, field, ok := strings.Cut(ref, "#")
+		if !ok || path == "" || field == "" {
+			return "", fmt.Errorf("vault reference must look like vault:<path>#<field>")
+		}
+		return readVault(path, field)
+	case "sops":
+		file, key, ok := strings.Cut(ref, "#")
+		if !ok || file == "" || key == "" {
+			return "", fmt.Errorf("sops reference must look like sops:<file>#<key>")
+		}
+		return readSOPS(file, key)
+	case "keyring":
+		service, account, ok := strings.Cut(ref, "#")
+		if !ok || service == "" || account == "" {
+			return "", fmt.Errorf("keyring reference must look like keyring:<service>#<account>")
+		}
+		return readKeyring(service, account)
+	}
+	return val, nil
+}
+
+// readVault reads one field of a Vault KV secret using VAULT_ADDR and
+// VAULT_TOKEN (or VAULT_TOKEN_FILE). Both KV v1 and v2 responses are
+// understood; for v2 the path includes "data/", e.g. secret/data/rsshub.
+func readVault(path, field string) (string, error) {
+	addr := os.Getenv("VAULT_ADDR")
+	if addr == "" {
+		return "", fmt.Errorf("vault reference needs VAULT_ADDR")
+	}
+	token := os.Getenv("VAULT_TOKEN")
+	if file := os.Getenv("VAULT_TOKEN_FILE"); file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return "", fmt.Errorf("reading VAULT_TOKEN_FILE: %w", err)
+		}
+		token = strings.TrimSpace(string(data))
+	}
+	if token == "" {
+		return "", fmt.Errorf("vault reference needs VAULT_TOKEN or VAULT_TOKEN_FILE")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), secretTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(addr, "/")+"/v1/"+strings.TrimLeft(path, "/"), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if ns := os.Getenv("VAULT_NAMESPACE"); ns != "" {
+		req.Header.Set("X-Vault-Namespace", ns)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("vault: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("vault: reading %s: %s", path, resp.Status)
+	}
+
+	var body struct {
+		Data map[string]any `json:"data"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&body)
+	if err != nil {
+		return "", fmt.Errorf("vault: %w", err)
+	}
+	fields := body.Data
+	if inner, ok := fields["data"].(map[string]any); ok {
+		fields = inner
+	}
+	v, ok := fields[field].(string)
+	if !ok {
+		return "", fmt.Errorf("vault: %s has no string field %q", path, field)
+	}
+	return v, nil
+}
+
+// readSOPS decrypts one top-level key from a SOPS-encrypted file with the
+// sops binary, which finds its keys (age, PGP, KMS) the usual way.
+func readSOPS(file, key string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), secretTimeout)
+	defer cancel()
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "sops", "--decrypt", "--extract", fmt.Sprintf("[%q]", key), file)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("sops: decrypting %s: %v: %s", file, err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimRight(string(out), "\r\n"), nil
+}
+
+// readKeyring reads a password from the OS keyring: the login keychain
+// with security on macOS, and the Secret Service with secret-tool
+// elsewhere, where it is stored under service and account attributes.
+func readKeyring(service, account string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), secretTimeout)
+	defer cancel()
+	var cmd *exec.Cmd
+	if runtime.GOOS == "darwin" {
+		cmd = exec.CommandContext(ctx, "security", "find-generic-password", "-s", service, "-a", account, "-w")
+	} else {
+		cmd = exec.CommandContext(ctx, "secret-tool", "lookup", "service", service, "account", account)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("keyring: reading %s#%s: %v: %s", service, account, err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimRight(string(out), "\r\n"), nil
+}
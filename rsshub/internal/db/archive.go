@@ -0,0 +1,118 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+
+	"rsshub/internal/models"
+)
+
+// PurgeFeedKeepArticles permanently removes a feed like PurgeFeed, but first
+// copies its articles into archived_articles, together with the feed's name
+// and URL, so history survives unsubscribing. Revisions are not archived.
+// It returns how many articles were archived.
+func (d *DB) PurgeFeedKeepArticles(name string) (int64, error) {
+	tx, err := d.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`INSERT INTO archived_articles
+		(id, feed_id, feed_name, feed_url, created_at, updated_at, title, link, published_at, description, read_at, starred_at, author,
+		guid, comments_url, enclosure_url, enclosure_type, enclosure_length, latitude, longitude,
+		duration_seconds, episode, season, image_url, video_id, thumbnail_url, views, word_count, published_at_source)
+	SELECT a.id, a.feed_id, f.name, f.url, a.created_at, a.updated_at, a.title, a.link, a.published_at, a.description, a.read_at, a.starred_at, a.author,
+		a.guid, a.comments_url, a.enclosure_url, a.enclosure_type, a.enclosure_length, a.latitude, a.longitude,
+		a.duration_seconds, a.episode, a.season, a.image_url, a.video_id, a.thumbnail_url, a.views, a.word_count, a.published_at_source
+	FROM articles a JOIN feeds f ON f.id = a.feed_id
+	WHERE f.name = $1
+	ON CONFLICT (id) DO NOTHING`, name)
+	if err != nil {
+		return 0, err
+	}
+	archived, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	res, err = tx.Exec(`DELETE FROM feeds WHERE name = $1`, name)
+	err = affectedOne(res, err, name)
+	if err != nil {
+		return 0, err
+	}
+	return archived, tx.Commit()
+}
+
+// QueryArchivedArticles returns up to limit archived articles after the
+// cursor, newest first, optionally only those of one former feed, plus
+// whether more follow.
+func (d *DB) QueryArchivedArticles(feedName string, after *Cursor, limit int) ([]models.ArchivedArticle, bool, error) {
+	query := `SELECT id, feed_id, feed_name, feed_url, created_at, updated_at, title, link, published_at, description, read_at, starred_at, archived_at, author,
+		guid, comments_url, enclosure_url, enclosure_type, enclosure_length, latitude, longitude,
+		duration_seconds, episode, season, image_url, video_id, thumbnail_url, views, word_count, published_at_source
+	FROM archived_articles
+	WHERE ($1 = '' OR feed_name = $1)`
+	args := []any{feedName, limit + 1}
+	if after != nil {
+		query += ` AND (published_at, id) < ($3, $4)`
+		args = append(args, after.Time.UTC(), after.ID)
+	}
+	query += ` ORDER BY published_at DESC, id DESC LIMIT $2`
+
+	rows, err := d.readQuery(query, args...)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rows.Close()
+
+	var articles []models.ArchivedArticle
+	for rows.Next() {
+		var a models.ArchivedArticle
+		var updated, read, starred sql.NullTime
+		var description, author, guid, comments, enclosureURL, enclosureType, imageURL, videoID, thumbnailURL sql.NullString
+		var enclosureLength, duration, episode, season, views, words sql.NullInt64
+		var lat, lon sql.NullFloat64
+		err := rows.Scan(&a.ID, &a.FeedID, &a.FeedName, &a.FeedURL, &a.CreatedAt, &updated, &a.Title, &a.Link,
+			&a.PublishedAt, &description, &read, &starred, &a.ArchivedAt, &author,
+			&guid, &comments, &enclosureURL, &enclosureType, &enclosureLength, &lat, &lon,
+			&duration, &episode, &season, &imageURL, &videoID, &thumbnailURL, &views, &words, &a.PublishedAtSource)
+		if err != nil {
+			return nil, false, err
+		}
+		a.UpdatedAt = updated.Time
+		a.ReadAt = read.Time
+		a.StarredAt = starred.Time
+		a.Description = description.String
+		a.Author = author.String
+		a.GUID = guid.String
+		a.CommentsURL = comments.String
+		a.EnclosureURL = enclosureURL.String
+		a.EnclosureType = enclosureType.String
+		a.EnclosureLength = enclosureLength.Int64
+		a.Location = geoPoint(lat, lon)
+		a.Duration = time.Duration(duration.Int64) * time.Second
+		a.Episode = int(episode.Int64)
+		a.Season = int(season.Int64)
+		a.ImageURL = imageURL.String
+		a.VideoID = videoID.String
+		a.ThumbnailURL = thumbnailURL.String
+		a.Views = views.Int64
+		a.WordCount = int(words.Int64)
+		articles = append(articles, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, false, err
+	}
+	more := len(articles) > limit
+	if more {
+		articles = articles[:limit]
+	}
+	return articles, more, nil
+}
+
+// ArchivedArticles returns the newest archived articles, optionally only
+// those of one former feed.
+func (d *DB) ArchivedArticles(feedName string, limit int) ([]models.ArchivedArticle, error) {
+	articles, _, err := d.QueryArchivedArticles(feedName, nil, limit)
+	return articles, err
+}
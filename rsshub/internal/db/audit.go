@@ -0,0 +1,173 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"rsshub/internal/models"
+)
+
+// Sources of audited operations.
+const (
+	SourceCLI    = "cli"
+	SourceAPI    = "api"
+	SourceDaemon = "daemon"
+)
+
+// Actor identifies who performed an audited operation and through what.
+type Actor struct {
+	Name   string
+	Source string
+}
+
+// Audit records an administrative operation. It is best-effort: a failure
+// is logged and never fails the operation being audited.
+func (d *DB) Audit(actor Actor, action, target, oldValue, newValue string) {
+	_, err := d.Exec(`INSERT INTO audit_log (actor, source, action, target, old_value, new_value)
+	VALUES ($1, $2, $3, $4, NULLIF($5, ''), NULLIF($6, ''))`,
+		actor.Name, actor.Source, action, target, oldValue, newValue)
+	if err != nil {
+		d.Log.Errorf("Error writing audit log (%s %s by %s): %v", action, target, actor.Name, err)
+	}
+}
+
+// ListAudit returns the newest audit entries, optionally only those about
+// one target such as a feed name.
+func (d *DB) ListAudit(target string, limit int) ([]models.AuditEntry, error) {
+	entries, _, err := d.QueryAudit(target, nil, limit)
+	return entries, err
+}
+
+// QueryAudit returns up to limit audit entries older than the cursor,
+// newest first, plus whether more follow.
+func (d *DB) QueryAudit(target string, after *SeqCursor, limit int) ([]models.AuditEntry, bool, error) {
+	query := `SELECT id, at, actor, source, action, target, old_value, new_value
+	FROM audit_log
+	WHERE ($1 = '' OR target = $1)`
+	args := []any{target, limit + 1}
+	if after != nil {
+		query += ` AND id < $3`
+		args = append(args, int64(*after))
+	}
+	query += ` ORDER BY id DESC LIMIT $2`
+
+	rows, err := d.readQuery(query, args...)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rows.Close()
+
+	var entries []models.AuditEntry
+	for rows.Next() {
+		var e models.AuditEntry
+		var oldValue, newValue sql.NullString
+		err := rows.Scan(&e.ID, &e.At, &e.Actor, &e.Source, &e.Action, &e.Target, &oldValue, &newValue)
+		if err != nil {
+			return nil, false, err
+		}
+		e.OldValue = oldValue.String
+		e.NewValue = newValue.String
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, false, err
+	}
+	more := len(entries) > limit
+	if more {
+		entries = entries[:limit]
+	}
+	return entries, more, nil
+}
+
+// Audited wraps DB so that feed administration is recorded in the audit
+// log under one actor. Reads pass through unchanged.
+type Audited struct {
+	*DB
+	actor Actor
+}
+
+// As returns a view of d whose feed changes are audited as actor.
+func (d *DB) As(actor Actor) *Audited {
+	return &Audited{DB: d, actor: actor}
+}
+
+func (a *Audited) AddFeed(feed *models.Feed) error {
+	err := a.DB.AddFeed(feed)
+	if err == nil {
+		a.Audit(a.actor, "add-feed", feed.Name, "", feed.URL)
+	}
+	return err
+}
+
+func (a *Audited) AddFeedNumbered(feed *models.Feed) error {
+	err := a.DB.AddFeedNumbered(feed)
+	if err == nil {
+		a.Audit(a.actor, "add-feed", feed.Name, "", feed.URL)
+	}
+	return err
+}
+
+// AddNote attaches a note to an article in the actor's name. Notes are
+// reading data, so they are not audited.
+func (a *Audited) AddNote(articleID uuid.UUID, quote, text string) (models.ArticleNote, error) {
+	return a.DB.AddNote(articleID, quote, text, a.actor.Name)
+}
+
+func (a *Audited) DeleteFeed(name string) error {
+	old, _ := a.GetFeedByName(name)
+	err := a.DB.DeleteFeed(name)
+	if err == nil {
+		a.Audit(a.actor, "delete-feed", name, old.URL, "")
+	}
+	return err
+}
+
+func (a *Audited) PurgeFeed(name string) error {
+	err := a.DB.PurgeFeed(name)
+	if err == nil {
+		a.Audit(a.actor, "purge-feed", name, "", "")
+	}
+	return err
+}
+
+func (a *Audited) PurgeFeedKeepArticles(name string) (int64, error) {
+	archived, err := a.DB.PurgeFeedKeepArticles(name)
+	if err == nil {
+		a.Audit(a.actor, "purge-feed", name, "", fmt.Sprintf("%d articles archived", archived))
+	}
+	return archived, err
+}
+
+func (a *Audited) MergeFeeds(from, into string) (models.MergeResult, error) {
+	res, err := a.DB.MergeFeeds(from, into)
+	if err == nil {
+		a.Audit(a.actor, "merge-feed", from, "", fmt.Sprintf("into %s: %d moved, %d duplicates", into, res.Moved, res.Duplicates))
+	}
+	return res, err
+}
+
+func (a *Audited) SetFeedQuirks(name string, q models.FeedQuirks) error {
+	old, _ := a.DB.GetFeedQuirks(name)
+	err := a.DB.SetFeedQuirks(name, q)
+	if err == nil {
+		a.Audit(a.actor, "set-quirks", name, quirksSummary(old), quirksSummary(q))
+	}
+	return err
+}
+
+func (a *Audited) EnableFeed(name string) error {
+	err := a.DB.EnableFeed(name)
+	if err == nil {
+		a.Audit(a.actor, "enable-feed", name, "", "")
+	}
+	return err
+}
+
+func (a *Audited) RestoreFeed(name string) error {
+	err := a.DB.RestoreFeed(name)
+	if err == nil {
+		a.Audit(a.actor, "restore-feed", name, "", "")
+	}
+	return err
+}
@@ -0,0 +1,76 @@
+package db
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"rsshub/internal/models"
+)
+
+// DailyUsage returns the bytes a feed has downloaded today with its own
+// daily cap, and the bytes all feeds have downloaded today.
+func (d *DB) DailyUsage(feedID uuid.UUID) (feedBytes, feedLimit, totalBytes int64, err error) {
+	err = d.QueryRow(`SELECT
+		COALESCE((SELECT bytes FROM feed_bandwidth WHERE feed_id = f.id AND day = CURRENT_DATE), 0),
+		f.daily_byte_limit,
+		COALESCE((SELECT SUM(bytes) FROM feed_bandwidth WHERE day = CURRENT_DATE), 0)
+	FROM feeds f
+	WHERE f.id = $1`, feedID).Scan(&feedBytes, &feedLimit, &totalBytes)
+	return feedBytes, feedLimit, totalBytes, err
+}
+
+// DeferFeedUntilTomorrow makes a feed due again at the start of the next
+// day, when its bandwidth budget resets.
+func (d *DB) DeferFeedUntilTomorrow(feedID uuid.UUID) error {
+	_, err := d.Exec(`UPDATE feeds SET next_fetch_at = CURRENT_DATE + INTERVAL '1 day' WHERE id = $1`, feedID)
+	return err
+}
+
+// SetFeedByteLimit caps how many bytes a feed may download per day; 0
+// removes the cap.
+func (d *DB) SetFeedByteLimit(name string, limit int64) error {
+	res, err := d.Exec(`UPDATE feeds SET daily_byte_limit = $2 WHERE name = $1 AND deleted_at IS NULL`, name, limit)
+	return affectedOne(res, err, name)
+}
+
+// GetBandwidth returns each feed's downloads today and over the last days
+// days, including today, for feeds that downloaded anything or have a
+// cap, heaviest first.
+func (d *DB) GetBandwidth(days int) ([]models.FeedBandwidth, error) {
+	rows, err := d.readQuery(`SELECT f.name,
+		COALESCE(SUM(b.bytes) FILTER (WHERE b.day = CURRENT_DATE), 0),
+		COALESCE(SUM(b.fetches) FILTER (WHERE b.day = CURRENT_DATE), 0),
+		COALESCE(SUM(b.bytes), 0),
+		f.daily_byte_limit
+	FROM feeds f
+	LEFT JOIN feed_bandwidth b ON b.feed_id = f.id AND b.day > CURRENT_DATE - $1::int
+	WHERE f.deleted_at IS NULL
+	GROUP BY f.id, f.name, f.daily_byte_limit
+	HAVING COALESCE(SUM(b.bytes), 0) > 0 OR f.daily_byte_limit > 0
+	ORDER BY COALESCE(SUM(b.bytes), 0) DESC, f.name`, days)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var usage []models.FeedBandwidth
+	for rows.Next() {
+		var u models.FeedBandwidth
+		err := rows.Scan(&u.FeedName, &u.TodayBytes, &u.TodayFetches, &u.PeriodBytes, &u.DailyLimit)
+		if err != nil {
+			return nil, err
+		}
+		usage = append(usage, u)
+	}
+	return usage, rows.Err()
+}
+
+// PruneBandwidth deletes daily usage older than maxAge and returns how
+// many days of feeds it removed.
+func (d *DB) PruneBandwidth(maxAge time.Duration) (int64, error) {
+	res, err := d.Exec(`DELETE FROM feed_bandwidth WHERE day < CURRENT_DATE - $1::float8 * INTERVAL '1 second'`, maxAge.Seconds())
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
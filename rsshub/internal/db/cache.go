@@ -0,0 +1,130 @@
+package db
+
+import (
+	"container/list"
+	"encoding/json"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// readCache keeps the latest answers to the feed and article listings the
+// API serves most, so a busy web UI does not query Postgres on every
+// request. It is cleared by every write made through the DB holding it and
+// by InvalidateReadCache, which the API calls for each article ingested
+// anywhere. ttl bounds how stale an answer gets from changes it hears of
+// neither way, such as fetch results and edits made by other processes.
+type readCache struct {
+	size int
+	ttl  time.Duration
+
+	mu      sync.Mutex
+	gen     uint64 // bumped on every invalidation
+	entries map[string]*list.Element
+	recent  *list.List // of *cacheEntry, most recently used first
+}
+
+type cacheEntry struct {
+	key   string
+	value any
+	at    time.Time
+}
+
+// WithReadCache returns a DB sharing d's connections that answers
+// ListFeeds, QueryFeeds and QueryArticles, and so GetArticles, from a
+// cache of up to size recent answers kept for at most ttl. A size of 0
+// returns d itself.
+func (d *DB) WithReadCache(size int, ttl time.Duration) *DB {
+	if size <= 0 {
+		return d
+	}
+	c := *d
+	c.cache = &readCache{size: size, ttl: ttl, entries: make(map[string]*list.Element), recent: list.New()}
+	return &c
+}
+
+// InvalidateReadCache drops every cached answer, for writes made outside
+// this DB, such as articles stored by the fetch daemon.
+func (d *DB) InvalidateReadCache() {
+	if d.cache != nil {
+		d.cache.invalidate()
+	}
+}
+
+func (c *readCache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.gen++
+	clear(c.entries)
+	c.recent.Init()
+}
+
+func (c *readCache) get(key string) (any, uint64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, c.gen, false
+	}
+	e := el.Value.(*cacheEntry)
+	if time.Since(e.at) > c.ttl {
+		c.recent.Remove(el)
+		delete(c.entries, key)
+		return nil, c.gen, false
+	}
+	c.recent.MoveToFront(el)
+	return e.value, c.gen, true
+}
+
+// put caches value unless the cache was invalidated since gen, when the
+// query behind it started: it may predate a write.
+func (c *readCache) put(key string, value any, gen uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if gen != c.gen {
+		return
+	}
+	if el, ok := c.entries[key]; ok {
+		c.recent.Remove(el)
+	}
+	c.entries[key] = c.recent.PushFront(&cacheEntry{key: key, value: value, at: time.Now()})
+	for c.recent.Len() > c.size {
+		oldest := c.recent.Back()
+		c.recent.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// cached answers a read from d's cache, if it has one, or loads and caches
+// it. Callers get a copy, so they may change what they are given.
+func cached[T any](d *DB, kind string, params any, load func() ([]T, bool, error)) ([]T, bool, error) {
+	if d.cache == nil {
+		return load()
+	}
+	key, err := json.Marshal(params)
+	if err != nil {
+		return load()
+	}
+	type answer struct {
+		items []T
+		more  bool
+	}
+	k := kind + string(key)
+	v, gen, ok := d.cache.get(k)
+	if ok {
+		a := v.(answer)
+		return slices.Clone(a.items), a.more, nil
+	}
+	items, more, err := load()
+	if err == nil {
+		d.cache.put(k, answer{slices.Clone(items), more}, gen)
+	}
+	return items, more, err
+}
+
+// writes reports whether a statement may change data: anything but a
+// plain SELECT.
+func writes(query string) bool {
+	return !strings.HasPrefix(strings.ToUpper(strings.TrimSpace(query)), "SELECT")
+}
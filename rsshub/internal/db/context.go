@@ -0,0 +1,67 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+)
+
+// WithContext returns a DB sharing d's connections whose queries, and the
+// transactions it begins, are cancelled with ctx. The CLI uses it so that
+// Ctrl-C or a command's timeout ends a query under way instead of leaving
+// it running on the server.
+func (d *DB) WithContext(ctx context.Context) *DB {
+	c := *d
+	c.ctx = ctx
+	return &c
+}
+
+func (d *DB) queryContext() context.Context {
+	if d.ctx == nil {
+		return context.Background()
+	}
+	return d.ctx
+}
+
+// Exec, Query, QueryRow and Begin run under d's context, so every method
+// of DB honours WithContext without taking a context of its own. Those
+// that may write clear the read cache, if any, once they have run; a
+// transaction clears it when it commits.
+
+func (d *DB) Exec(query string, args ...any) (sql.Result, error) {
+	defer d.InvalidateReadCache()
+	return d.DB.ExecContext(d.queryContext(), query, args...)
+}
+
+func (d *DB) Query(query string, args ...any) (*sql.Rows, error) {
+	if writes(query) {
+		defer d.InvalidateReadCache()
+	}
+	return d.DB.QueryContext(d.queryContext(), query, args...)
+}
+
+func (d *DB) QueryRow(query string, args ...any) *sql.Row {
+	if writes(query) {
+		defer d.InvalidateReadCache()
+	}
+	return d.DB.QueryRowContext(d.queryContext(), query, args...)
+}
+
+func (d *DB) Begin() (*Tx, error) {
+	tx, err := d.DB.BeginTx(d.queryContext(), nil)
+	if err != nil {
+		return nil, err
+	}
+	return &Tx{Tx: tx, d: d}, nil
+}
+
+// Tx is a transaction begun by DB.Begin. Answers cached while it runs
+// cannot see its writes, so committing it clears the read cache.
+type Tx struct {
+	*sql.Tx
+	d *DB
+}
+
+func (t *Tx) Commit() error {
+	defer t.d.InvalidateReadCache()
+	return t.Tx.Commit()
+}
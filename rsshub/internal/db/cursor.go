@@ -0,0 +1,70 @@
+package db
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor is an opaque keyset pagination position: the sort timestamp of the
+// last row seen plus its id to break ties. Keyset pagination stays fast and
+// stable however deep a client pages, unlike OFFSET.
+type Cursor struct {
+	Time time.Time
+	ID   uuid.UUID
+}
+
+func (c Cursor) String() string {
+	raw := c.Time.UTC().Format(time.RFC3339Nano) + "|" + c.ID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func ParseCursor(s string) (Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+	ts, id, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return Cursor{}, ErrInvalidCursor
+	}
+	t, err := time.Parse(time.RFC3339Nano, ts)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+	u, err := uuid.Parse(id)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+	return Cursor{Time: t, ID: u}, nil
+}
+
+// SeqCursor is the keyset position in tables ordered by a serial id alone,
+// such as the audit log, whose ids already follow insertion order.
+type SeqCursor int64
+
+func (c SeqCursor) String() string {
+	return base64.RawURLEncoding.EncodeToString([]byte("seq|" + strconv.FormatInt(int64(c), 10)))
+}
+
+func ParseSeqCursor(s string) (SeqCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return 0, ErrInvalidCursor
+	}
+	n, ok := strings.CutPrefix(string(raw), "seq|")
+	if !ok {
+		return 0, ErrInvalidCursor
+	}
+	id, err := strconv.ParseInt(n, 10, 64)
+	if err != nil {
+		return 0, ErrInvalidCursor
+	}
+	return SeqCursor(id), nil
+}
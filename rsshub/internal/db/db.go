@@ -1,23 +1,45 @@
 package db
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"time"
+
 	"github.com/google/uuid"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 	"rsshub/internal/config"
+	"rsshub/internal/logging"
 	"rsshub/internal/models"
 )
 
+// PartitionMonthsAhead is how many future monthly article partitions are
+// kept ready when partitioning is enabled.
+const PartitionMonthsAhead = 2
+
+var (
+	ErrNotFound = errors.New("not found")
+	ErrExists   = errors.New("already exists")
+)
+
+// uniqueViolation is the Postgres error code for a unique constraint hit.
+const uniqueViolation = "23505"
+
 type DB struct {
 	*sql.DB
+	replica *replica
+	// ctx, if set, cancels every query run through this DB; see
+	// WithContext.
+	ctx   context.Context
+	cache *readCache // nil unless WithReadCache
+	// Log receives what the DB reports without failing the call, such
+	// as a failed audit write.
+	Log logging.Logger
 }
 
 func NewDB(cfg *config.Config) (*DB, error) {
-	dsn := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable",
-		cfg.PGUser, cfg.PGPassword, cfg.PGHost, cfg.PGPort, cfg.PGDBName)
-
-	db, err := sql.Open("postgres", dsn)
+	db, err := sql.Open("postgres", cfg.DSN())
 	if err != nil {
 		return nil, err
 	}
@@ -33,7 +55,46 @@ func NewDB(cfg *config.Config) (*DB, error) {
 		return nil, err
 	}
 
-	return &DB{db}, nil
+	d := &DB{DB: db, Log: logging.New(cfg)}
+	err = d.initFeedURLIndex()
+	if err != nil {
+		return nil, fmt.Errorf("indexing feed URLs: %w", err)
+	}
+	if cfg.ReadDSN != "" {
+		d.replica, err = openReplica(cfg.ReadDSN, d.Log)
+		if err != nil {
+			return nil, fmt.Errorf("opening read replica: %w", err)
+		}
+	}
+	missing, err := d.statsMissing()
+	if err != nil {
+		return nil, err
+	}
+	if missing {
+		err = d.RebuildStats()
+		if err != nil {
+			return nil, fmt.Errorf("building feed stats: %w", err)
+		}
+	}
+	if cfg.PartitionArticles {
+		err = d.initPartitions()
+		if err != nil {
+			return nil, fmt.Errorf("partitioning articles: %w", err)
+		}
+	}
+	return d, nil
+}
+
+// articleIndexQueries create the secondary indexes on articles, once every
+// column they cover exists. PartitionArticles runs them again for the
+// table it builds.
+var articleIndexQueries = []string{
+	`CREATE INDEX IF NOT EXISTS articles_published_at_idx ON articles (published_at DESC);`,
+	`CREATE INDEX IF NOT EXISTS articles_feed_published_idx ON articles (feed_id, published_at DESC);`,
+	`CREATE INDEX IF NOT EXISTS articles_starred_idx ON articles (starred_at DESC) WHERE starred_at IS NOT NULL;`,
+	`CREATE INDEX IF NOT EXISTS articles_author_idx ON articles (lower(author)) WHERE author IS NOT NULL;`,
+	`CREATE INDEX IF NOT EXISTS articles_feed_guid_idx ON articles (feed_id, guid) WHERE guid IS NOT NULL;`,
+	`CREATE INDEX IF NOT EXISTS articles_location_idx ON articles (latitude, longitude) WHERE latitude IS NOT NULL;`,
 }
 
 func initSchema(db *sql.DB) error {
@@ -57,8 +118,222 @@ func initSchema(db *sql.DB) error {
 			feed_id UUID REFERENCES feeds(id) ON DELETE CASCADE
 		);`,
 		`CREATE UNIQUE INDEX IF NOT EXISTS articles_feed_link_idx ON articles (feed_id, link);`,
+		`ALTER TABLE feeds ADD COLUMN IF NOT EXISTS claimed_by TEXT;`,
+		`ALTER TABLE feeds ADD COLUMN IF NOT EXISTS claimed_until TIMESTAMP;`,
+		`CREATE INDEX IF NOT EXISTS feeds_updated_at_idx ON feeds (updated_at ASC NULLS FIRST);`,
+		`ALTER TABLE articles ADD COLUMN IF NOT EXISTS read_at TIMESTAMP;`,
+		`ALTER TABLE articles ADD COLUMN IF NOT EXISTS starred_at TIMESTAMP;`,
+		`ALTER TABLE feeds ADD COLUMN IF NOT EXISTS url_normalized TEXT;`,
+		`ALTER TABLE feeds ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;`,
+		`CREATE TABLE IF NOT EXISTS feed_stats (
+			feed_id UUID PRIMARY KEY REFERENCES feeds(id) ON DELETE CASCADE,
+			article_count BIGINT NOT NULL DEFAULT 0,
+			last_published_at TIMESTAMP
+		);`,
+		`CREATE TABLE IF NOT EXISTS feed_daily_counts (
+			feed_id UUID NOT NULL REFERENCES feeds(id) ON DELETE CASCADE,
+			day DATE NOT NULL,
+			article_count BIGINT NOT NULL DEFAULT 0,
+			PRIMARY KEY (feed_id, day)
+		);`,
+		`CREATE TABLE IF NOT EXISTS audit_log (
+			id BIGSERIAL PRIMARY KEY,
+			at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			actor TEXT NOT NULL,
+			source TEXT NOT NULL,
+			action TEXT NOT NULL,
+			target TEXT NOT NULL DEFAULT '',
+			old_value TEXT,
+			new_value TEXT
+		);`,
+		`CREATE INDEX IF NOT EXISTS audit_log_target_idx ON audit_log (target, id DESC);`,
+		`CREATE TABLE IF NOT EXISTS article_revisions (
+			id BIGSERIAL PRIMARY KEY,
+			article_id UUID NOT NULL,
+			feed_id UUID NOT NULL REFERENCES feeds(id) ON DELETE CASCADE,
+			title TEXT NOT NULL,
+			description TEXT,
+			valid_from TIMESTAMP NOT NULL,
+			replaced_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE INDEX IF NOT EXISTS article_revisions_article_idx ON article_revisions (article_id, id);`,
+		`CREATE TABLE IF NOT EXISTS article_notes (
+			id BIGSERIAL PRIMARY KEY,
+			article_id UUID NOT NULL,
+			feed_id UUID NOT NULL REFERENCES feeds(id) ON DELETE CASCADE,
+			quote TEXT NOT NULL DEFAULT '',
+			body TEXT NOT NULL,
+			created_by TEXT NOT NULL,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE INDEX IF NOT EXISTS article_notes_article_idx ON article_notes (article_id, id);`,
+		`CREATE TABLE IF NOT EXISTS users (
+			id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			name TEXT NOT NULL UNIQUE,
+			password_hash TEXT,
+			oidc_subject TEXT UNIQUE
+		);`,
+		`CREATE TABLE IF NOT EXISTS sessions (
+			token_hash TEXT PRIMARY KEY,
+			user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			expires_at TIMESTAMP NOT NULL
+		);`,
+		`CREATE INDEX IF NOT EXISTS sessions_user_idx ON sessions (user_id);`,
+		`CREATE TABLE IF NOT EXISTS archived_articles (
+			id UUID PRIMARY KEY,
+			feed_id UUID NOT NULL,
+			feed_name TEXT NOT NULL,
+			feed_url TEXT NOT NULL,
+			created_at TIMESTAMP NOT NULL,
+			updated_at TIMESTAMP,
+			title TEXT NOT NULL,
+			link TEXT NOT NULL,
+			published_at TIMESTAMP NOT NULL,
+			description TEXT,
+			read_at TIMESTAMP,
+			starred_at TIMESTAMP,
+			archived_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE INDEX IF NOT EXISTS archived_articles_feed_idx ON archived_articles (feed_name, published_at DESC);`,
+		`ALTER TABLE articles ADD COLUMN IF NOT EXISTS author TEXT;`,
+		`ALTER TABLE archived_articles ADD COLUMN IF NOT EXISTS author TEXT;`,
+		`ALTER TABLE articles ADD COLUMN IF NOT EXISTS guid TEXT;`,
+		`ALTER TABLE articles ADD COLUMN IF NOT EXISTS comments_url TEXT;`,
+		`ALTER TABLE articles ADD COLUMN IF NOT EXISTS enclosure_url TEXT;`,
+		`ALTER TABLE articles ADD COLUMN IF NOT EXISTS enclosure_type TEXT;`,
+		`ALTER TABLE articles ADD COLUMN IF NOT EXISTS enclosure_length BIGINT;`,
+		`ALTER TABLE archived_articles ADD COLUMN IF NOT EXISTS guid TEXT;`,
+		`ALTER TABLE archived_articles ADD COLUMN IF NOT EXISTS comments_url TEXT;`,
+		`ALTER TABLE archived_articles ADD COLUMN IF NOT EXISTS enclosure_url TEXT;`,
+		`ALTER TABLE archived_articles ADD COLUMN IF NOT EXISTS enclosure_type TEXT;`,
+		`ALTER TABLE archived_articles ADD COLUMN IF NOT EXISTS enclosure_length BIGINT;`,
+		`ALTER TABLE articles ADD COLUMN IF NOT EXISTS latitude DOUBLE PRECISION;`,
+		`ALTER TABLE articles ADD COLUMN IF NOT EXISTS longitude DOUBLE PRECISION;`,
+		`ALTER TABLE archived_articles ADD COLUMN IF NOT EXISTS latitude DOUBLE PRECISION;`,
+		`ALTER TABLE archived_articles ADD COLUMN IF NOT EXISTS longitude DOUBLE PRECISION;`,
+		`ALTER TABLE articles ADD COLUMN IF NOT EXISTS duration_seconds INTEGER;`,
+		`ALTER TABLE articles ADD COLUMN IF NOT EXISTS episode INTEGER;`,
+		`ALTER TABLE articles ADD COLUMN IF NOT EXISTS season INTEGER;`,
+		`ALTER TABLE articles ADD COLUMN IF NOT EXISTS image_url TEXT;`,
+		`ALTER TABLE archived_articles ADD COLUMN IF NOT EXISTS duration_seconds INTEGER;`,
+		`ALTER TABLE archived_articles ADD COLUMN IF NOT EXISTS episode INTEGER;`,
+		`ALTER TABLE archived_articles ADD COLUMN IF NOT EXISTS season INTEGER;`,
+		`ALTER TABLE archived_articles ADD COLUMN IF NOT EXISTS image_url TEXT;`,
+		`ALTER TABLE articles ADD COLUMN IF NOT EXISTS video_id TEXT;`,
+		`ALTER TABLE articles ADD COLUMN IF NOT EXISTS thumbnail_url TEXT;`,
+		`ALTER TABLE articles ADD COLUMN IF NOT EXISTS views BIGINT;`,
+		`ALTER TABLE archived_articles ADD COLUMN IF NOT EXISTS video_id TEXT;`,
+		`ALTER TABLE archived_articles ADD COLUMN IF NOT EXISTS thumbnail_url TEXT;`,
+		`ALTER TABLE archived_articles ADD COLUMN IF NOT EXISTS views BIGINT;`,
+		`CREATE TABLE IF NOT EXISTS feed_quirks (
+			feed_id UUID PRIMARY KEY REFERENCES feeds(id) ON DELETE CASCADE,
+			date_layout TEXT NOT NULL DEFAULT '',
+			insecure_tls BOOLEAN NOT NULL DEFAULT false,
+			plain_description BOOLEAN NOT NULL DEFAULT false,
+			strip_title_prefix TEXT NOT NULL DEFAULT '',
+			updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`ALTER TABLE feed_quirks ADD COLUMN IF NOT EXISTS ca_bundle TEXT NOT NULL DEFAULT '';`,
+		`ALTER TABLE feed_quirks ADD COLUMN IF NOT EXISTS cert_pins TEXT NOT NULL DEFAULT '';`,
+		`CREATE TABLE IF NOT EXISTS downloads (
+			feed_id UUID NOT NULL REFERENCES feeds(id) ON DELETE CASCADE,
+			url TEXT NOT NULL,
+			article_id UUID NOT NULL,
+			path TEXT NOT NULL,
+			bytes BIGINT NOT NULL,
+			downloaded_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (feed_id, url)
+		);`,
+		`CREATE TABLE IF NOT EXISTS feed_schedules (
+			feed_id UUID PRIMARY KEY REFERENCES feeds(id) ON DELETE CASCADE,
+			ttl_minutes INTEGER NOT NULL DEFAULT 0,
+			skip_hours INTEGER[] NOT NULL DEFAULT '{}',
+			skip_days INTEGER[] NOT NULL DEFAULT '{}',
+			updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`ALTER TABLE feeds ADD COLUMN IF NOT EXISTS next_fetch_at TIMESTAMP;`,
+		`ALTER TABLE feed_schedules ADD COLUMN IF NOT EXISTS cadence_seconds INTEGER NOT NULL DEFAULT 0;`,
+		`ALTER TABLE feed_schedules ADD COLUMN IF NOT EXISTS update_interval_seconds INTEGER NOT NULL DEFAULT 0;`,
+		`ALTER TABLE feed_schedules ADD COLUMN IF NOT EXISTS max_age_seconds INTEGER NOT NULL DEFAULT 0;`,
+		`CREATE TABLE IF NOT EXISTS shared_feeds (
+			user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			feed_id UUID NOT NULL REFERENCES feeds(id) ON DELETE CASCADE,
+			shared_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (user_id, feed_id)
+		);`,
+		`CREATE TABLE IF NOT EXISTS feed_validators (
+			feed_id UUID PRIMARY KEY REFERENCES feeds(id) ON DELETE CASCADE,
+			etag TEXT NOT NULL DEFAULT '',
+			last_modified TEXT NOT NULL DEFAULT '',
+			body_hash TEXT NOT NULL DEFAULT '',
+			updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE TABLE IF NOT EXISTS mute_rules (
+			id BIGSERIAL PRIMARY KEY,
+			keyword TEXT NOT NULL,
+			pattern TEXT NOT NULL,
+			created_by TEXT NOT NULL,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			expires_at TIMESTAMP NOT NULL
+		);`,
+		`CREATE TABLE IF NOT EXISTS fetch_log (
+			id BIGSERIAL PRIMARY KEY,
+			feed_id UUID NOT NULL REFERENCES feeds(id) ON DELETE CASCADE,
+			instance TEXT NOT NULL,
+			worker INTEGER NOT NULL,
+			fetched_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			duration_ms INTEGER NOT NULL,
+			status INTEGER,
+			bytes BIGINT NOT NULL DEFAULT 0,
+			items INTEGER NOT NULL DEFAULT 0,
+			new_items INTEGER NOT NULL DEFAULT 0,
+			error TEXT
+		);`,
+		`CREATE INDEX IF NOT EXISTS fetch_log_feed_idx ON fetch_log (feed_id, fetched_at DESC);`,
+		`CREATE INDEX IF NOT EXISTS fetch_log_fetched_at_idx ON fetch_log (fetched_at);`,
+		`ALTER TABLE fetch_log ADD COLUMN IF NOT EXISTS decoded_bytes BIGINT NOT NULL DEFAULT 0;`,
+		`ALTER TABLE feeds ADD COLUMN IF NOT EXISTS failures INTEGER NOT NULL DEFAULT 0;`,
+		`ALTER TABLE feeds ADD COLUMN IF NOT EXISTS disabled_at TIMESTAMP;`,
+		`ALTER TABLE feeds ADD COLUMN IF NOT EXISTS disabled_reason TEXT;`,
+		`ALTER TABLE feeds ADD COLUMN IF NOT EXISTS quiet_reported_for TIMESTAMP;`,
+		`ALTER TABLE articles ADD COLUMN IF NOT EXISTS word_count INTEGER;`,
+		`ALTER TABLE archived_articles ADD COLUMN IF NOT EXISTS word_count INTEGER;`,
+		`ALTER TABLE articles ADD COLUMN IF NOT EXISTS published_at_source TEXT NOT NULL DEFAULT 'published';`,
+		`ALTER TABLE archived_articles ADD COLUMN IF NOT EXISTS published_at_source TEXT NOT NULL DEFAULT 'published';`,
+		`ALTER TABLE articles ADD COLUMN IF NOT EXISTS content_hash TEXT;`,
+		`CREATE TABLE IF NOT EXISTS feed_bandwidth (
+			feed_id UUID NOT NULL REFERENCES feeds(id) ON DELETE CASCADE,
+			day DATE NOT NULL,
+			bytes BIGINT NOT NULL DEFAULT 0,
+			fetches INTEGER NOT NULL DEFAULT 0,
+			PRIMARY KEY (feed_id, day)
+		);`,
+		`ALTER TABLE feeds ADD COLUMN IF NOT EXISTS daily_byte_limit BIGINT NOT NULL DEFAULT 0;`,
+		`CREATE TABLE IF NOT EXISTS raw_items (
+			feed_id UUID NOT NULL REFERENCES feeds(id) ON DELETE CASCADE,
+			link TEXT NOT NULL,
+			published_at TIMESTAMP NOT NULL,
+			raw TEXT NOT NULL,
+			fetched_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (feed_id, link)
+		);`,
+		`CREATE TABLE IF NOT EXISTS secrets (
+			id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+			name TEXT NOT NULL,
+			feed_id UUID REFERENCES feeds(id) ON DELETE CASCADE,
+			key_id TEXT NOT NULL,
+			nonce BYTEA NOT NULL,
+			ciphertext BYTEA NOT NULL,
+			updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS secrets_scope_idx ON secrets ((COALESCE(feed_id::text, '')), name);`,
 	}
 
+	queries = append(queries, articleIndexQueries...)
+	queries = append(queries, articleTriggerQueries...)
+
 	for _, q := range queries {
 		_, err := db.Exec(q)
 		if err != nil {
@@ -68,18 +343,83 @@ func initSchema(db *sql.DB) error {
 	return nil
 }
 
+func (d *DB) initPartitions() error {
+	partitioned, err := d.ArticlesPartitioned()
+	if err != nil {
+		return err
+	}
+	if !partitioned {
+		err = d.PartitionArticles()
+	} else {
+		err = d.ensureArticleLinkGuard()
+	}
+	if err != nil {
+		return err
+	}
+	return d.EnsureArticlePartitions(PartitionMonthsAhead)
+}
+
+// AddFeed subscribes to a feed. It fails with ErrExists if the name is
+// taken, or with a DuplicateURLError if the URL is already subscribed
+// under another name.
 func (d *DB) AddFeed(feed *models.Feed) error {
-	_, err := d.Exec(`INSERT INTO feeds (name, url) VALUES ($1, $2)`, feed.Name, feed.URL)
+	normalized := NormalizeFeedURL(feed.URL)
+	dup, err := d.feedByURL(normalized)
+	if err != nil {
+		return err
+	}
+	if dup != nil {
+		return dup
+	}
+
+	_, err = d.Exec(`INSERT INTO feeds (name, url, url_normalized) VALUES ($1, $2, $3)`, feed.Name, feed.URL, normalized)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+		if pqErr.Constraint == feedURLIndex {
+			dup, err = d.feedByURL(normalized)
+			if err == nil && dup != nil {
+				return dup
+			}
+		}
+		var deleted bool
+		d.QueryRow(`SELECT deleted_at IS NOT NULL FROM feeds WHERE name = $1`, feed.Name).Scan(&deleted)
+		if deleted {
+			return fmt.Errorf("feed %q was deleted; restore or purge it to reuse the name: %w", feed.Name, ErrExists)
+		}
+		return fmt.Errorf("feed %q %w", feed.Name, ErrExists)
+	}
 	return err
 }
 
-func (d *DB) ListFeeds(limit int) ([]models.Feed, error) {
-	query := `SELECT id, created_at, updated_at, name, url FROM feeds ORDER BY created_at DESC`
+// ListFeeds returns the feeds f selects in its order, all of them when
+// limit is 0.
+func (d *DB) ListFeeds(f models.FeedFilter, limit int) ([]models.Feed, error) {
+	key := struct {
+		Filter models.FeedFilter
+		Limit  int
+	}{f, limit}
+	feeds, _, err := cached(d, "feeds", key, func() ([]models.Feed, bool, error) {
+		feeds, err := d.listFeeds(f, limit)
+		return feeds, false, err
+	})
+	return feeds, err
+}
+
+func (d *DB) listFeeds(f models.FeedFilter, limit int) ([]models.Feed, error) {
+	order, ok := feedOrders[f.Sort]
+	if !ok {
+		return nil, fmt.Errorf("unknown feed order %q", f.Sort)
+	}
+	where, args := feedConditions(f, nil)
+	query := `SELECT ` + feedColumns(f.Wide) + `
+	FROM feeds f` + feedJoins(f.Wide) + `
+	WHERE f.deleted_at IS NULL` + where + `
+	ORDER BY ` + order
 	if limit > 0 {
 		query += fmt.Sprintf(" LIMIT %d", limit)
 	}
 
-	rows, err := d.Query(query)
+	rows, err := d.readQuery(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -87,59 +427,262 @@ func (d *DB) ListFeeds(limit int) ([]models.Feed, error) {
 
 	var feeds []models.Feed
 	for rows.Next() {
-		var f models.Feed
-		var updated sql.NullTime
-		err := rows.Scan(&f.ID, &f.CreatedAt, &updated, &f.Name, &f.URL)
+		f, err := scanFeed(rows, f.Wide)
 		if err != nil {
 			return nil, err
 		}
-		if updated.Valid {
-			f.UpdatedAt = updated.Time
-		}
 		feeds = append(feeds, f)
 	}
 	return feeds, nil
 }
 
+// DeleteFeed soft-deletes a feed: it stops being fetched and listed, but
+// it and its articles are kept until purged and can be restored.
 func (d *DB) DeleteFeed(name string) error {
-	_, err := d.Exec(`DELETE FROM feeds WHERE name = $1`, name)
+	res, err := d.Exec(`UPDATE feeds SET deleted_at = CURRENT_TIMESTAMP WHERE name = $1 AND deleted_at IS NULL`, name)
+	return affectedOne(res, err, name)
+}
+
+// RestoreFeed undoes DeleteFeed.
+func (d *DB) RestoreFeed(name string) error {
+	res, err := d.Exec(`UPDATE feeds SET deleted_at = NULL WHERE name = $1 AND deleted_at IS NOT NULL`, name)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err == nil && n == 0 {
+		return fmt.Errorf("deleted feed %q %w", name, ErrNotFound)
+	}
 	return err
 }
 
-func (d *DB) GetArticles(feedName string, limit int) ([]models.Article, error) {
-	query := `SELECT a.id, a.created_at, a.updated_at, a.title, a.link, a.published_at, a.description, a.feed_id
-	FROM articles a
-	JOIN feeds f ON a.feed_id = f.id
-	WHERE f.name = $1
-	ORDER BY a.published_at DESC
-	LIMIT $2`
+// PurgeFeed permanently removes a feed, deleted or not, with all its
+// articles.
+func (d *DB) PurgeFeed(name string) error {
+	res, err := d.Exec(`DELETE FROM feeds WHERE name = $1`, name)
+	return affectedOne(res, err, name)
+}
 
-	rows, err := d.Query(query, feedName, limit)
+// FeedImpact counts the rows that belong to a feed, deleted or not, so
+// callers can show what a delete or purge would affect.
+func (d *DB) FeedImpact(name string) (models.FeedImpact, error) {
+	var im models.FeedImpact
+	err := d.QueryRow(`SELECT f.deleted_at IS NOT NULL,
+		(SELECT count(*) FROM articles a WHERE a.feed_id = f.id),
+		(SELECT count(*) FROM articles a WHERE a.feed_id = f.id AND a.read_at IS NOT NULL),
+		(SELECT count(*) FROM articles a WHERE a.feed_id = f.id AND a.starred_at IS NOT NULL),
+		(SELECT count(*) FROM article_revisions r WHERE r.feed_id = f.id)
+	FROM feeds f WHERE f.name = $1`, name).
+		Scan(&im.Deleted, &im.Articles, &im.Read, &im.Starred, &im.Revisions)
+	if err == sql.ErrNoRows {
+		return im, fmt.Errorf("feed %q %w", name, ErrNotFound)
+	}
+	return im, err
+}
+
+// affectedOne turns a statement that matched no feed into ErrNotFound.
+func affectedOne(res sql.Result, err error, name string) error {
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err == nil && n == 0 {
+		return fmt.Errorf("feed %q %w", name, ErrNotFound)
+	}
+	return err
+}
+
+// GetArticles returns the newest articles matching every criterion set in
+// f, at least one of which must be.
+func (d *DB) GetArticles(f models.ArticleFilter, limit int) ([]models.Article, error) {
+	if f.FeedName == "" && f.Author == "" && f.Near == nil {
+		return nil, errors.New("a feed name, author or location is required")
+	}
+	articles, _, err := d.QueryArticles(ArticleQuery{
+		FeedName:    f.FeedName,
+		Author:      f.Author,
+		Near:        f.Near,
+		RadiusKM:    f.RadiusKM,
+		MaxReadTime: f.MaxReadTime,
+		ShowMuted:   f.ShowMuted,
+		Limit:       limit,
+	})
+	return articles, err
+}
+
+// RecordFeedFailure counts a failed fetch of a feed and returns how many
+// fetches in a row have now failed.
+func (d *DB) RecordFeedFailure(feedID uuid.UUID) (int, error) {
+	var n int
+	err := d.QueryRow(`UPDATE feeds SET failures = failures + 1 WHERE id = $1 RETURNING failures`, feedID).Scan(&n)
+	return n, err
+}
+
+// ResetFeedFailures ends a feed's run of failed fetches.
+func (d *DB) ResetFeedFailures(feedID uuid.UUID) error {
+	_, err := d.Exec(`UPDATE feeds SET failures = 0 WHERE id = $1 AND failures > 0`, feedID)
+	return err
+}
+
+// DisableFeed stops scheduling a feed until it is enabled again, keeping
+// reason for the user. It reports false if the feed was already disabled.
+func (d *DB) DisableFeed(feedID uuid.UUID, reason string) (bool, error) {
+	res, err := d.Exec(`UPDATE feeds SET disabled_at = CURRENT_TIMESTAMP, disabled_reason = $2
+	WHERE id = $1 AND disabled_at IS NULL`, feedID, reason)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// EnableFeed undoes DisableFeed. The failure count starts over and the
+// feed is due at once.
+func (d *DB) EnableFeed(name string) error {
+	res, err := d.Exec(`UPDATE feeds SET disabled_at = NULL, disabled_reason = NULL, failures = 0, next_fetch_at = NULL
+	WHERE name = $1 AND deleted_at IS NULL AND disabled_at IS NOT NULL`, name)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err == nil && n == 0 {
+		return fmt.Errorf("disabled feed %q %w", name, ErrNotFound)
+	}
+	return err
+}
+
+func (d *DB) GetFeedByName(name string) (models.Feed, error) {
+	var f models.Feed
+	var updated, disabled sql.NullTime
+	var reason sql.NullString
+	err := d.QueryRow(`SELECT id, created_at, updated_at, name, url, disabled_at, disabled_reason
+	FROM feeds WHERE name = $1 AND deleted_at IS NULL`, name).
+		Scan(&f.ID, &f.CreatedAt, &updated, &f.Name, &f.URL, &disabled, &reason)
+	if err == sql.ErrNoRows {
+		return f, fmt.Errorf("feed %q %w", name, ErrNotFound)
+	}
+	if updated.Valid {
+		f.UpdatedAt = updated.Time
+	}
+	f.DisabledAt, f.DisabledReason = disabled.Time, reason.String
+	return f, err
+}
+
+// due selects feeds that are not disabled and whose published schedule
+// allows fetching them now.
+const due = `(disabled_at IS NULL AND (next_fetch_at IS NULL OR next_fetch_at <= CURRENT_TIMESTAMP))`
+
+// FeedOrder is the order in which feeds due for a fetch are picked.
+type FeedOrder int
+
+const (
+	// OldestFirst picks the feeds fetched longest ago first.
+	OldestFirst FeedOrder = iota
+	// ActiveFirst picks feeds never fetched, then those that published
+	// most recently, so after downtime the feeds that matter catch up
+	// before those that have gone quiet.
+	ActiveFirst
+)
+
+func (o FeedOrder) orderBy() string {
+	if o == ActiveFirst {
+		return `ORDER BY updated_at IS NULL DESC,
+		(SELECT last_published_at FROM feed_stats s WHERE s.feed_id = feeds.id) DESC NULLS LAST,
+		updated_at ASC`
+	}
+	return `ORDER BY updated_at ASC NULLS FIRST`
+}
+
+// DueBacklog counts the feeds due for a fetch and returns when the one
+// fetched longest ago was last fetched, zero if none was fetched yet.
+func (d *DB) DueBacklog() (int, time.Time, error) {
+	var n int
+	var oldest sql.NullTime
+	err := d.QueryRow(`SELECT COUNT(*), MIN(updated_at) FROM feeds WHERE deleted_at IS NULL AND `+due).Scan(&n, &oldest)
+	return n, oldest.Time, err
+}
+
+// uuidStrings turns ids into an array parameter, read as $n::uuid[].
+func uuidStrings(ids []uuid.UUID) pq.StringArray {
+	s := make(pq.StringArray, len(ids))
+	for i, id := range ids {
+		s[i] = id.String()
+	}
+	return s
+}
+
+// feedHost is the lowercased host of the feed URL in table, keyed the way
+// the aggregator's host limiter keys it: a URL it cannot parse is its own
+// host.
+func feedHost(table string) string {
+	return `lower(coalesce(substring(` + table + `.url from '^[^:/?#]+://(?:[^/?#@]*@)?([^/?#:]+)'), ` + table + `.url))`
+}
+
+// perHostDue is the condition for the due feeds within each host's first
+// perHost, in order, once the feeds in skip from that host are counted.
+// Capping the batch per host keeps feeds from one busy host, which the
+// workers would only pass over, from crowding out those of every other.
+func perHostDue(order FeedOrder, skipArg, perHostArg, extra string) string {
+	return `id IN (
+		SELECT id FROM (
+			SELECT id, row_number() OVER (PARTITION BY ` + feedHost("feeds") + ` ` + order.orderBy() + `)
+				+ (SELECT count(*) FROM feeds busy WHERE busy.id = ANY(` + skipArg + `::uuid[]) AND ` + feedHost("busy") + ` = ` + feedHost("feeds") + `) AS slot
+			FROM feeds
+			WHERE deleted_at IS NULL AND ` + extra + due + ` AND id <> ALL(` + skipArg + `::uuid[])
+		) ranked WHERE slot <= ` + perHostArg + `
+	)`
+}
+
+// GetOutdatedFeeds returns up to limit of the due feeds, in order and at
+// most perHost from any one host, leaving out those in skip: feeds the
+// caller is still fetching, which would otherwise be returned tick after
+// tick ahead of the rest, and which count towards their host's perHost.
+func (d *DB) GetOutdatedFeeds(limit, perHost int, order FeedOrder, skip []uuid.UUID) ([]models.Feed, error) {
+	query := `SELECT id, created_at, updated_at, name, url FROM feeds
+	WHERE ` + perHostDue(order, "$2", "$3", "") + `
+	` + order.orderBy() + ` LIMIT $1`
+
+	rows, err := d.Query(query, limit, uuidStrings(skip), perHost)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var articles []models.Article
+	var feeds []models.Feed
 	for rows.Next() {
-		var a models.Article
+		var f models.Feed
 		var updated sql.NullTime
-		err := rows.Scan(&a.ID, &a.CreatedAt, &updated, &a.Title, &a.Link, &a.PublishedAt, &a.Description, &a.FeedID)
+		err := rows.Scan(&f.ID, &f.CreatedAt, &updated, &f.Name, &f.URL)
 		if err != nil {
 			return nil, err
 		}
 		if updated.Valid {
-			a.UpdatedAt = updated.Time
+			f.UpdatedAt = updated.Time
 		}
-		articles = append(articles, a)
+		feeds = append(feeds, f)
 	}
-	return articles, nil
+	return feeds, nil
 }
 
-func (d *DB) GetOutdatedFeeds(limit int) ([]models.Feed, error) {
-	query := `SELECT id, created_at, updated_at, name, url FROM feeds ORDER BY updated_at ASC NULLS FIRST LIMIT $1`
+// ClaimOutdatedFeeds atomically claims up to limit of the due feeds, in
+// order and at most perHost from any one host, for owner. Rows locked by a
+// concurrent claim are skipped, so several instances can call this at once
+// without picking the same feed. A claim lapses after lease in case its
+// owner dies before releasing it; feeds in skip, which owner is still
+// fetching, are not claimed again when their lease lapses first, and count
+// towards their host's perHost.
+func (d *DB) ClaimOutdatedFeeds(owner string, lease time.Duration, limit, perHost int, order FeedOrder, skip []uuid.UUID) ([]models.Feed, error) {
+	query := `UPDATE feeds SET claimed_by = $1, claimed_until = CURRENT_TIMESTAMP + $2 * INTERVAL '1 second'
+	WHERE id IN (
+		SELECT id FROM feeds
+		WHERE ` + perHostDue(order, "$4", "$5", "(claimed_until IS NULL OR claimed_until < CURRENT_TIMESTAMP) AND ") + `
+		` + order.orderBy() + `
+		LIMIT $3
+		FOR UPDATE SKIP LOCKED
+	)
+	RETURNING id, created_at, updated_at, name, url`
 
-	rows, err := d.Query(query, limit)
+	rows, err := d.Query(query, owner, lease.Seconds(), limit, uuidStrings(skip), perHost)
 	if err != nil {
 		return nil, err
 	}
@@ -161,18 +704,226 @@ func (d *DB) GetOutdatedFeeds(limit int) ([]models.Feed, error) {
 	return feeds, nil
 }
 
-func (d *DB) ArticleExists(feedID uuid.UUID, link string) (bool, error) {
-	var count int
-	err := d.QueryRow(`SELECT COUNT(*) FROM articles WHERE feed_id = $1 AND link = $2`, feedID, link).Scan(&count)
-	return count > 0, err
+// ReleaseFeedClaim drops owner's claim on a feed so it can be scheduled again.
+func (d *DB) ReleaseFeedClaim(id uuid.UUID, owner string) error {
+	_, err := d.Exec(`UPDATE feeds SET claimed_by = NULL, claimed_until = NULL WHERE id = $1 AND claimed_by = $2`, id, owner)
+	return err
+}
+
+// ArticleExists reports whether a feed has an article with link,
+// published within the window before now, or at all when window is 0.
+// Bounding the window lets partitioned tables skip old partitions.
+func (d *DB) ArticleExists(feedID uuid.UUID, link string, window time.Duration) (bool, error) {
+	var exists bool
+	err := d.QueryRow(`SELECT EXISTS (SELECT 1 FROM articles WHERE feed_id = $1 AND link = $2
+		AND ($3::float8 = 0 OR published_at >= CURRENT_TIMESTAMP - $3::float8 * INTERVAL '1 second'))`,
+		feedID, link, window.Seconds()).Scan(&exists)
+	return exists, err
 }
 
 func (d *DB) InsertArticle(article *models.Article) error {
-	_, err := d.Exec(`INSERT INTO articles (title, link, published_at, description, feed_id)
-		VALUES ($1, $2, $3, $4, $5)`, article.Title, article.Link, article.PublishedAt, article.Description, article.FeedID)
+	var inserted int64
+	err := d.QueryRow(`WITH inserted AS (
+		INSERT INTO articles (title, link, published_at, description, feed_id, author,
+			guid, comments_url, enclosure_url, enclosure_type, enclosure_length, latitude, longitude,
+			duration_seconds, episode, season, image_url, video_id, thumbnail_url, views, word_count, published_at_source, content_hash)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), NULLIF($7, ''), NULLIF($8, ''), NULLIF($9, ''), NULLIF($10, ''), NULLIF($11::bigint, 0), $12, $13,
+			NULLIF($14, 0), NULLIF($15, 0), NULLIF($16, 0), NULLIF($17, ''), NULLIF($18, ''), NULLIF($19, ''), NULLIF($20::bigint, 0), $21,
+			COALESCE(NULLIF($22, ''), 'published'), NULLIF($23, ''))
+		ON CONFLICT DO NOTHING
+		RETURNING feed_id, published_at
+	)`+recordInsertStats, article.Title, article.Link, article.PublishedAt, article.Description, article.FeedID, article.Author,
+		article.GUID, article.CommentsURL, article.EnclosureURL, article.EnclosureType, article.EnclosureLength,
+		latitude(article.Location), longitude(article.Location),
+		durationSeconds(article.Duration), article.Episode, article.Season, article.ImageURL,
+		article.VideoID, article.ThumbnailURL, article.Views, article.WordCount, article.PublishedAtSource, article.ContentHash).Scan(&inserted)
+	if err == nil && inserted == 0 {
+		return fmt.Errorf("article %s %w", article.Link, ErrExists)
+	}
 	return err
 }
 
+// BulkInsertArticles loads articles with COPY into a temporary table and
+// moves them into articles in one statement, skipping duplicates, which is
+// much faster than InsertArticle for backfills and very large feeds. It
+// returns how many articles were new and how many existing ones were
+// revised because their title or description changed.
+//
+// Only articles published within window are compared, as for
+// ArticleExists.
+func (d *DB) BulkInsertArticles(articles []models.Article, window time.Duration) (inserted, revised int64, err error) {
+	byFeed, revised, err := d.BulkInsertArticlesByFeed(articles, window)
+	for _, n := range byFeed {
+		inserted += n
+	}
+	return inserted, revised, err
+}
+
+// BulkInsertArticlesByFeed is BulkInsertArticles for a batch gathered from
+// several feeds, counting the articles inserted for each feed. Feeds with
+// none inserted are left out.
+func (d *DB) BulkInsertArticlesByFeed(articles []models.Article, window time.Duration) (inserted map[uuid.UUID]int64, revised int64, err error) {
+	if len(articles) == 0 {
+		return nil, 0, nil
+	}
+	tx, err := d.Begin()
+	if err != nil {
+		return nil, 0, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`CREATE TEMP TABLE articles_import (
+		title TEXT,
+		link TEXT,
+		published_at TIMESTAMP,
+		description TEXT,
+		feed_id UUID,
+		author TEXT,
+		guid TEXT,
+		comments_url TEXT,
+		enclosure_url TEXT,
+		enclosure_type TEXT,
+		enclosure_length BIGINT,
+		latitude DOUBLE PRECISION,
+		longitude DOUBLE PRECISION,
+		duration_seconds INTEGER,
+		episode INTEGER,
+		season INTEGER,
+		image_url TEXT,
+		video_id TEXT,
+		thumbnail_url TEXT,
+		views BIGINT,
+		word_count INTEGER,
+		published_at_source TEXT,
+		content_hash TEXT
+	) ON COMMIT DROP`)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	stmt, err := tx.Prepare(pq.CopyIn("articles_import", "title", "link", "published_at", "description", "feed_id", "author",
+		"guid", "comments_url", "enclosure_url", "enclosure_type", "enclosure_length", "latitude", "longitude",
+		"duration_seconds", "episode", "season", "image_url", "video_id", "thumbnail_url", "views", "word_count", "published_at_source", "content_hash"))
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, a := range articles {
+		_, err = stmt.Exec(a.Title, a.Link, a.PublishedAt, a.Description, a.FeedID, a.Author,
+			a.GUID, a.CommentsURL, a.EnclosureURL, a.EnclosureType, a.EnclosureLength,
+			latitude(a.Location), longitude(a.Location),
+			durationSeconds(a.Duration), a.Episode, a.Season, a.ImageURL,
+			a.VideoID, a.ThumbnailURL, a.Views, a.WordCount, a.PublishedAtSource, a.ContentHash)
+		if err != nil {
+			stmt.Close()
+			return nil, 0, err
+		}
+	}
+	_, err = stmt.Exec()
+	if err != nil {
+		stmt.Close()
+		return nil, 0, err
+	}
+	err = stmt.Close()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	revised, err = reviseFromImport(tx, window)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := tx.Query(`WITH inserted AS (
+		INSERT INTO articles (title, link, published_at, description, feed_id, author,
+			guid, comments_url, enclosure_url, enclosure_type, enclosure_length, latitude, longitude,
+			duration_seconds, episode, season, image_url, video_id, thumbnail_url, views, word_count, published_at_source, content_hash)
+		SELECT DISTINCT ON (feed_id, link) title, link, published_at, description, feed_id, NULLIF(author, ''),
+			NULLIF(guid, ''), NULLIF(comments_url, ''), NULLIF(enclosure_url, ''), NULLIF(enclosure_type, ''), NULLIF(enclosure_length, 0),
+			latitude, longitude,
+			NULLIF(duration_seconds, 0), NULLIF(episode, 0), NULLIF(season, 0), NULLIF(image_url, ''),
+			NULLIF(video_id, ''), NULLIF(thumbnail_url, ''), NULLIF(views, 0), word_count,
+			COALESCE(NULLIF(published_at_source, ''), 'published'), NULLIF(content_hash, '')
+		FROM articles_import i
+		WHERE NOT EXISTS (SELECT 1 FROM articles a WHERE a.feed_id = i.feed_id AND a.link = i.link
+			AND ($1::float8 = 0 OR a.published_at >= CURRENT_TIMESTAMP - $1::float8 * INTERVAL '1 second'))
+		ON CONFLICT DO NOTHING
+		RETURNING feed_id, published_at
+	)`+recordInsertStatsByFeed, window.Seconds())
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+	inserted = make(map[uuid.UUID]int64)
+	for rows.Next() {
+		var feedID uuid.UUID
+		var n int64
+		if err := rows.Scan(&feedID, &n); err != nil {
+			return nil, 0, err
+		}
+		inserted[feedID] = n
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	rows.Close()
+	return inserted, revised, tx.Commit()
+}
+
+// MarkArticleRead records that an article has been read. Marking an
+// already read article again keeps its original read time.
+func (d *DB) MarkArticleRead(id uuid.UUID) error {
+	res, err := d.Exec(`UPDATE articles SET read_at = COALESCE(read_at, CURRENT_TIMESTAMP) WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err == nil && n == 0 {
+		return fmt.Errorf("article %s %w", id, ErrNotFound)
+	}
+	return err
+}
+
+// SetArticleStarred stars or unstars an article. Starring an already
+// starred article keeps its original star time.
+func (d *DB) SetArticleStarred(id uuid.UUID, starred bool) error {
+	query := `UPDATE articles SET starred_at = COALESCE(starred_at, CURRENT_TIMESTAMP) WHERE id = $1`
+	if !starred {
+		query = `UPDATE articles SET starred_at = NULL WHERE id = $1`
+	}
+	res, err := d.Exec(query, id)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err == nil && n == 0 {
+		return fmt.Errorf("article %s %w", id, ErrNotFound)
+	}
+	return err
+}
+
+// StarredArticles returns every starred article of the subscribed feeds,
+// most recently starred first.
+func (d *DB) StarredArticles() ([]models.Article, error) {
+	rows, err := d.readQuery(`SELECT ` + articleColumns + ` FROM articles a
+	WHERE a.starred_at IS NOT NULL
+		AND EXISTS (SELECT 1 FROM feeds f WHERE f.id = a.feed_id AND f.deleted_at IS NULL)
+	ORDER BY a.starred_at DESC, a.id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var articles []models.Article
+	for rows.Next() {
+		a, err := scanArticle(rows)
+		if err != nil {
+			return nil, err
+		}
+		articles = append(articles, a)
+	}
+	return articles, rows.Err()
+}
+
 func (d *DB) UpdateFeedUpdatedAt(id uuid.UUID) error {
 	_, err := d.Exec(`UPDATE feeds SET updated_at = CURRENT_TIMESTAMP WHERE id = $1`, id)
 	return err
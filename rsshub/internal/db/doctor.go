@@ -0,0 +1,125 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+)
+
+// schemaObject is one table column or index the current code relies on,
+// with the migration that introduces it.
+type schemaObject struct {
+	table, column, index string
+	migration            string
+}
+
+// expectedSchema lists what initSchema creates, grouped by migration.
+var expectedSchema = []schemaObject{
+	{table: "feeds", column: "id", migration: "create_feeds_table"},
+	{table: "feeds", column: "name", migration: "create_feeds_table"},
+	{table: "feeds", column: "url", migration: "create_feeds_table"},
+	{table: "feeds", column: "updated_at", migration: "create_feeds_table"},
+	{table: "articles", column: "id", migration: "create_articles_table"},
+	{table: "articles", column: "feed_id", migration: "create_articles_table"},
+	{table: "articles", column: "link", migration: "create_articles_table"},
+	{table: "articles", column: "published_at", migration: "create_articles_table"},
+	{table: "feeds", column: "claimed_by", migration: "add_feeds_claim_columns"},
+	{table: "feeds", column: "claimed_until", migration: "add_feeds_claim_columns"},
+	{table: "articles", index: "articles_published_at_idx", migration: "create_hot_path_indexes"},
+	{table: "articles", index: "articles_feed_published_idx", migration: "create_hot_path_indexes"},
+	{table: "feeds", index: "feeds_updated_at_idx", migration: "create_hot_path_indexes"},
+	{table: "feed_stats", column: "article_count", migration: "create_feed_stats_tables"},
+	{table: "feed_daily_counts", column: "article_count", migration: "create_feed_stats_tables"},
+	{table: "articles", column: "read_at", migration: "add_articles_read_at"},
+	{table: "articles", column: "starred_at", migration: "add_articles_starred_at"},
+	{table: "feeds", column: "url_normalized", migration: "add_feeds_url_normalized"},
+	{table: "feeds", index: "feeds_url_normalized_idx", migration: "add_feeds_url_normalized"},
+	{table: "feeds", column: "deleted_at", migration: "add_feeds_deleted_at"},
+	{table: "audit_log", column: "action", migration: "create_audit_log_table"},
+	{table: "audit_log", index: "audit_log_target_idx", migration: "create_audit_log_table"},
+	{table: "article_revisions", column: "valid_from", migration: "create_article_revisions_table"},
+	{table: "article_revisions", index: "article_revisions_article_idx", migration: "create_article_revisions_table"},
+	{table: "users", column: "password_hash", migration: "create_users_and_sessions_tables"},
+	{table: "users", column: "oidc_subject", migration: "create_users_and_sessions_tables"},
+	{table: "sessions", column: "expires_at", migration: "create_users_and_sessions_tables"},
+	{table: "sessions", index: "sessions_user_idx", migration: "create_users_and_sessions_tables"},
+	{table: "archived_articles", column: "feed_name", migration: "create_archived_articles_table"},
+	{table: "archived_articles", index: "archived_articles_feed_idx", migration: "create_archived_articles_table"},
+	{table: "feed_quirks", column: "date_layout", migration: "create_feed_quirks_table"},
+	{table: "feed_quirks", column: "ca_bundle", migration: "add_feed_quirks_tls"},
+	{table: "feed_quirks", column: "cert_pins", migration: "add_feed_quirks_tls"},
+	{table: "downloads", column: "path", migration: "create_downloads_table"},
+	{table: "feed_schedules", column: "ttl_minutes", migration: "create_feed_schedules_table"},
+	{table: "feeds", column: "next_fetch_at", migration: "create_feed_schedules_table"},
+	{table: "feed_schedules", column: "cadence_seconds", migration: "add_feed_schedules_cadence"},
+	{table: "feed_schedules", column: "update_interval_seconds", migration: "add_feed_schedules_update_interval"},
+	{table: "feed_schedules", column: "max_age_seconds", migration: "add_feed_schedules_max_age"},
+	{table: "fetch_log", column: "duration_ms", migration: "create_fetch_log_table"},
+	{table: "fetch_log", index: "fetch_log_feed_idx", migration: "create_fetch_log_table"},
+	{table: "fetch_log", column: "decoded_bytes", migration: "add_fetch_log_decoded_bytes"},
+	{table: "feeds", column: "disabled_at", migration: "add_feeds_disabled"},
+	{table: "feeds", column: "quiet_reported_for", migration: "add_feeds_quiet_reported"},
+	{table: "articles", column: "author", migration: "add_articles_author"},
+	{table: "archived_articles", column: "author", migration: "add_articles_author"},
+	{table: "articles", index: "articles_author_idx", migration: "add_articles_author"},
+	{table: "articles", column: "guid", migration: "add_articles_guid_comments_enclosure"},
+	{table: "articles", column: "enclosure_url", migration: "add_articles_guid_comments_enclosure"},
+	{table: "archived_articles", column: "guid", migration: "add_articles_guid_comments_enclosure"},
+	{table: "articles", index: "articles_feed_guid_idx", migration: "add_articles_guid_comments_enclosure"},
+	{table: "articles", column: "latitude", migration: "add_articles_location"},
+	{table: "archived_articles", column: "latitude", migration: "add_articles_location"},
+	{table: "articles", index: "articles_location_idx", migration: "add_articles_location"},
+	{table: "articles", column: "duration_seconds", migration: "add_articles_podcast"},
+	{table: "archived_articles", column: "duration_seconds", migration: "add_articles_podcast"},
+	{table: "articles", column: "video_id", migration: "add_articles_video"},
+	{table: "archived_articles", column: "video_id", migration: "add_articles_video"},
+	{table: "articles", column: "word_count", migration: "add_articles_word_count"},
+	{table: "archived_articles", column: "word_count", migration: "add_articles_word_count"},
+	{table: "articles", column: "published_at_source", migration: "add_articles_published_at_source"},
+	{table: "archived_articles", column: "published_at_source", migration: "add_articles_published_at_source"},
+	{table: "articles", column: "content_hash", migration: "add_articles_content_hash"},
+	{table: "feed_bandwidth", column: "bytes", migration: "create_feed_bandwidth_table"},
+	{table: "feeds", column: "daily_byte_limit", migration: "create_feed_bandwidth_table"},
+	{table: "raw_items", column: "raw", migration: "create_raw_items_table"},
+	{table: "secrets", column: "ciphertext", migration: "create_secrets_table"},
+	{table: "secrets", index: "secrets_scope_idx", migration: "create_secrets_table"},
+	{table: "feed_validators", column: "body_hash", migration: "create_feed_validators_table"},
+	{table: "shared_feeds", column: "shared_at", migration: "create_shared_feeds_table"},
+	{table: "mute_rules", column: "pattern", migration: "create_mute_rules_table"},
+	{table: "article_notes", column: "quote", migration: "create_article_notes_table"},
+}
+
+// SchemaIssue is a column or index missing from the database.
+type SchemaIssue struct {
+	Object    string
+	Migration string
+}
+
+// CheckSchema compares the database against the schema this build expects
+// without changing anything, unlike NewDB which creates what is missing.
+// Issues are returned in migration order.
+func CheckSchema(ctx context.Context, conn *sql.DB) ([]SchemaIssue, error) {
+	var issues []SchemaIssue
+	for _, o := range expectedSchema {
+		var exists bool
+		var err error
+		name := o.table + "." + o.column
+		if o.index != "" {
+			name = o.index
+			err = conn.QueryRowContext(ctx, `SELECT EXISTS (
+				SELECT 1 FROM pg_indexes WHERE schemaname = current_schema() AND indexname = $1
+			)`, o.index).Scan(&exists)
+		} else {
+			err = conn.QueryRowContext(ctx, `SELECT EXISTS (
+				SELECT 1 FROM information_schema.columns
+				WHERE table_schema = current_schema() AND table_name = $1 AND column_name = $2
+			)`, o.table, o.column).Scan(&exists)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			issues = append(issues, SchemaIssue{Object: name, Migration: o.migration})
+		}
+	}
+	return issues, nil
+}
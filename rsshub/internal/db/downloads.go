@@ -0,0 +1,39 @@
+package db
+
+import (
+	"github.com/google/uuid"
+	"rsshub/internal/models"
+)
+
+// DownloadedURLs returns the enclosure URLs of a feed that have already been
+// downloaded.
+func (d *DB) DownloadedURLs(feedID uuid.UUID) (map[string]bool, error) {
+	rows, err := d.Query(`SELECT url FROM downloads WHERE feed_id = $1`, feedID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	urls := map[string]bool{}
+	for rows.Next() {
+		var u string
+		if err := rows.Scan(&u); err != nil {
+			return nil, err
+		}
+		urls[u] = true
+	}
+	return urls, rows.Err()
+}
+
+// RecordDownload notes that an enclosure was saved, replacing any earlier
+// record of the same URL, e.g. after a forced re-download.
+func (d *DB) RecordDownload(dl models.Download) error {
+	_, err := d.Exec(`INSERT INTO downloads (feed_id, url, article_id, path, bytes)
+	VALUES ($1, $2, $3, $4, $5)
+	ON CONFLICT (feed_id, url) DO UPDATE SET
+		article_id = EXCLUDED.article_id,
+		path = EXCLUDED.path,
+		bytes = EXCLUDED.bytes,
+		downloaded_at = CURRENT_TIMESTAMP`, dl.FeedID, dl.URL, dl.ArticleID, dl.Path, dl.Bytes)
+	return err
+}
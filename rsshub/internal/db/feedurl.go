@@ -0,0 +1,181 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/lib/pq"
+
+	"rsshub/internal/models"
+)
+
+// feedURLIndex is the unique index over feeds.url_normalized.
+const feedURLIndex = "feeds_url_normalized_idx"
+
+// DuplicateURLError reports that a feed's URL is already subscribed under
+// another name, possibly by a soft-deleted feed. It matches ErrExists with
+// errors.Is.
+type DuplicateURLError struct {
+	Name    string
+	Deleted bool
+}
+
+func (e *DuplicateURLError) Error() string {
+	if e.Deleted {
+		return fmt.Sprintf("already subscribed (as %s, deleted; restore or purge it first)", e.Name)
+	}
+	return fmt.Sprintf("already subscribed (as %s)", e.Name)
+}
+
+func (e *DuplicateURLError) Unwrap() error {
+	return ErrExists
+}
+
+// NormalizeFeedURL reduces a feed URL to a key that is equal for spellings
+// that fetch the same feed: scheme, "www.", default ports, fragments,
+// trailing slashes, tracking parameters and query order are ignored, and
+// the host is lower-cased.
+func NormalizeFeedURL(raw string) string {
+	raw = strings.TrimSpace(raw)
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return strings.ToLower(raw)
+	}
+
+	host := strings.ToLower(u.Hostname())
+	host = strings.TrimPrefix(host, "www.")
+	if port := u.Port(); port != "" && port != "80" && port != "443" {
+		host = net.JoinHostPort(host, port)
+	}
+
+	path := strings.TrimRight(u.EscapedPath(), "/")
+
+	query := u.Query()
+	for key := range query {
+		if strings.HasPrefix(strings.ToLower(key), "utm_") {
+			query.Del(key)
+		}
+	}
+	key := host + path
+	if len(query) > 0 {
+		key += "?" + query.Encode()
+	}
+	return key
+}
+
+// SubscribedURL returns the feed subscribed at rawURL, in any spelling
+// NormalizeFeedURL treats as equal, as a DuplicateURLError, or nil if the
+// URL is not subscribed.
+func (d *DB) SubscribedURL(rawURL string) (*DuplicateURLError, error) {
+	return d.feedByURL(NormalizeFeedURL(rawURL))
+}
+
+var nonName = regexp.MustCompile(`[^\p{L}\p{N}]+`)
+
+// maxFeedName keeps generated names short enough to type.
+const maxFeedName = 40
+
+// FeedName suggests a feed name from its title, or from its host when the
+// title has nothing usable: lower case, with runs of other characters
+// turned into dashes.
+func FeedName(title, rawURL string) string {
+	name := strings.Trim(nonName.ReplaceAllString(strings.ToLower(title), "-"), "-")
+	if name == "" {
+		if u, err := url.Parse(rawURL); err == nil {
+			name = strings.Trim(nonName.ReplaceAllString(strings.TrimPrefix(u.Hostname(), "www."), "-"), "-")
+		}
+	}
+	if r := []rune(name); len(r) > maxFeedName {
+		name = strings.TrimRight(string(r[:maxFeedName]), "-")
+	}
+	if name == "" {
+		name = "feed"
+	}
+	return name
+}
+
+// AddFeedNumbered subscribes like AddFeed, but when the name is taken it
+// tries name-2, name-3 and so on, leaving feed.Name set to the one used.
+// A URL that is already subscribed still fails with a DuplicateURLError.
+func (d *DB) AddFeedNumbered(feed *models.Feed) error {
+	base := feed.Name
+	for n := 2; ; n++ {
+		err := d.AddFeed(feed)
+		var dup *DuplicateURLError
+		if err == nil || errors.As(err, &dup) || !errors.Is(err, ErrExists) {
+			return err
+		}
+		feed.Name = fmt.Sprintf("%s-%d", base, n)
+	}
+}
+
+// feedByURL returns the feed subscribed at the normalized URL as a
+// DuplicateURLError, or nil if there is none.
+func (d *DB) feedByURL(normalized string) (*DuplicateURLError, error) {
+	var dup DuplicateURLError
+	err := d.QueryRow(`SELECT name, deleted_at IS NOT NULL FROM feeds WHERE url_normalized = $1`, normalized).
+		Scan(&dup.Name, &dup.Deleted)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &dup, nil
+}
+
+// initFeedURLIndex fills url_normalized for feeds added before the column
+// existed and then enforces uniqueness. Existing duplicates cannot be
+// resolved automatically, so they are reported and the index is left out
+// until one of each pair is deleted; AddFeed still rejects new duplicates.
+func (d *DB) initFeedURLIndex() error {
+	rows, err := d.Query(`SELECT id, url FROM feeds WHERE url_normalized IS NULL`)
+	if err != nil {
+		return err
+	}
+	type pending struct{ id, url string }
+	var feeds []pending
+	for rows.Next() {
+		var p pending
+		err = rows.Scan(&p.id, &p.url)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		feeds = append(feeds, p)
+	}
+	rows.Close()
+	for _, f := range feeds {
+		_, err = d.Exec(`UPDATE feeds SET url_normalized = $1 WHERE id = $2`, NormalizeFeedURL(f.url), f.id)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = d.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS ` + feedURLIndex + ` ON feeds (url_normalized)`)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+		d.Log.Infof("Warning: some feeds share a URL and will ingest the same articles twice; delete the duplicates:")
+		dup, err := d.Query(`SELECT url_normalized, string_agg(name, ', ' ORDER BY name)
+			FROM feeds GROUP BY url_normalized HAVING COUNT(*) > 1`)
+		if err != nil {
+			return err
+		}
+		defer dup.Close()
+		for dup.Next() {
+			var key, names string
+			err = dup.Scan(&key, &names)
+			if err != nil {
+				return err
+			}
+			d.Log.Infof("  %s: %s", key, names)
+		}
+		return dup.Err()
+	}
+	return err
+}
@@ -0,0 +1,116 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+
+	"rsshub/internal/models"
+)
+
+// RecordFetch appends one fetch attempt to fetch_log, stamped now, and
+// adds the bytes it downloaded, compressed as they were, to the feed's
+// usage for today.
+func (d *DB) RecordFetch(e models.FetchLogEntry) error {
+	_, err := d.Exec(`WITH usage AS (
+		INSERT INTO feed_bandwidth (feed_id, day, bytes, fetches) VALUES ($1, CURRENT_DATE, $6, 1)
+		ON CONFLICT (feed_id, day) DO UPDATE SET
+			bytes = feed_bandwidth.bytes + EXCLUDED.bytes,
+			fetches = feed_bandwidth.fetches + 1
+	)
+	INSERT INTO fetch_log (feed_id, instance, worker, duration_ms, status, bytes, decoded_bytes, items, new_items, error)
+	VALUES ($1, $2, $3, $4, NULLIF($5, 0), $6, $7, $8, $9, NULLIF($10, ''))`,
+		e.FeedID, e.Instance, e.Worker, e.Duration.Milliseconds(), e.Status, e.Bytes, e.DecodedBytes, e.Items, e.NewItems, e.Error)
+	return err
+}
+
+// PruneFetchLog deletes fetch_log entries older than maxAge and returns how
+// many it removed.
+func (d *DB) PruneFetchLog(maxAge time.Duration) (int64, error) {
+	res, err := d.Exec(`DELETE FROM fetch_log WHERE fetched_at < CURRENT_TIMESTAMP - $1 * INTERVAL '1 second'`, maxAge.Seconds())
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// GetFeedHealth summarises each feed's fetches over the last window, feeds
+// that keep failing first. The failing streak and the last fetch look past
+// the window, so a disabled feed still shows its state.
+func (d *DB) GetFeedHealth(window time.Duration) ([]models.FeedHealth, error) {
+	rows, err := d.readQuery(`SELECT f.name,
+		COUNT(l.feed_id),
+		COUNT(l.feed_id) FILTER (WHERE l.error IS NOT NULL) AS failures,
+		f.failures AS failing, f.disabled_at,
+		COALESCE(AVG(l.duration_ms), 0),
+		COALESCE(SUM(l.bytes), 0),
+		COALESCE(SUM(l.decoded_bytes), 0),
+		COALESCE(SUM(l.new_items), 0),
+		last.fetched_at, last.duration_ms, last.status, last.error
+	FROM feeds f
+	LEFT JOIN fetch_log l ON l.feed_id = f.id AND l.fetched_at > CURRENT_TIMESTAMP - $1 * INTERVAL '1 second'
+	LEFT JOIN LATERAL (
+		SELECT fetched_at, duration_ms, status, error FROM fetch_log
+		WHERE feed_id = f.id
+		ORDER BY fetched_at DESC LIMIT 1
+	) last ON true
+	WHERE f.deleted_at IS NULL
+	GROUP BY f.id, f.name, f.failures, f.disabled_at, last.fetched_at, last.duration_ms, last.status, last.error
+	ORDER BY failing DESC, failures DESC, f.name`, window.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var health []models.FeedHealth
+	for rows.Next() {
+		var h models.FeedHealth
+		var avgMS float64
+		var disabled, lastAt sql.NullTime
+		var lastMS, lastStatus sql.NullInt64
+		var lastError sql.NullString
+		err := rows.Scan(&h.FeedName, &h.Fetches, &h.Failures, &h.Failing, &disabled, &avgMS, &h.Bytes, &h.DecodedBytes,
+			&h.NewItems, &lastAt, &lastMS, &lastStatus, &lastError)
+		if err != nil {
+			return nil, err
+		}
+		h.DisabledAt = disabled.Time
+		h.AvgDuration = time.Duration(avgMS * float64(time.Millisecond))
+		h.LastFetch = models.FetchLogEntry{
+			FetchedAt: lastAt.Time,
+			Duration:  time.Duration(lastMS.Int64) * time.Millisecond,
+			Status:    int(lastStatus.Int64),
+			Error:     lastError.String,
+		}
+		health = append(health, h)
+	}
+	return health, rows.Err()
+}
+
+// GetWorkerStats summarises the fetches each worker of each instance made
+// over the last window.
+func (d *DB) GetWorkerStats(window time.Duration) ([]models.WorkerStats, error) {
+	rows, err := d.readQuery(`SELECT instance, worker, COUNT(*),
+		COUNT(*) FILTER (WHERE error IS NOT NULL),
+		AVG(duration_ms), SUM(bytes), SUM(new_items)
+	FROM fetch_log
+	WHERE fetched_at > CURRENT_TIMESTAMP - $1 * INTERVAL '1 second'
+	GROUP BY instance, worker
+	ORDER BY instance, worker`, window.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stats []models.WorkerStats
+	for rows.Next() {
+		var s models.WorkerStats
+		var avgMS float64
+		err := rows.Scan(&s.Instance, &s.Worker, &s.Fetches, &s.Failures, &avgMS, &s.Bytes, &s.NewItems)
+		if err != nil {
+			return nil, err
+		}
+		s.AvgDuration = time.Duration(avgMS * float64(time.Millisecond))
+		stats = append(stats, s)
+	}
+	return stats, rows.Err()
+}
@@ -0,0 +1,27 @@
+package db
+
+import "rsshub/internal/models"
+
+// ImportArticle stores an article brought over from another reader, unless
+// its feed already has one with the same link, and marks it read or
+// starred as asked. Existing marks are kept. It reports whether the
+// article was new.
+func (d *DB) ImportArticle(article *models.Article, read, starred bool) (bool, error) {
+	exists, err := d.ArticleExists(article.FeedID, article.Link, 0)
+	if err != nil {
+		return false, err
+	}
+	if !exists {
+		if err := d.InsertArticle(article); err != nil {
+			return false, err
+		}
+	}
+	if !read && !starred {
+		return !exists, nil
+	}
+	_, err = d.Exec(`UPDATE articles SET
+		read_at = CASE WHEN $3 THEN COALESCE(read_at, CURRENT_TIMESTAMP) ELSE read_at END,
+		starred_at = CASE WHEN $4 THEN COALESCE(starred_at, CURRENT_TIMESTAMP) ELSE starred_at END
+	WHERE feed_id = $1 AND link = $2`, article.FeedID, article.Link, read, starred)
+	return !exists, err
+}
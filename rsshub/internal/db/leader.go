@@ -0,0 +1,73 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+)
+
+// Leader campaigns for a cluster-wide role using a session-level Postgres
+// advisory lock. The lock lives on a dedicated connection, so it is released
+// automatically by the server if this process dies or loses its connection,
+// letting another instance take over.
+type Leader struct {
+	db   *sql.DB
+	key  int64
+	conn *sql.Conn
+}
+
+func NewLeader(db *sql.DB, key int64) *Leader {
+	return &Leader{db: db, key: key}
+}
+
+// Acquire reports whether this process holds the lock, trying to take it if
+// it does not. A held lock is re-validated by pinging its connection; if the
+// connection is gone, so is the lock, and a fresh attempt is made.
+func (l *Leader) Acquire(ctx context.Context) (bool, error) {
+	if l.conn != nil {
+		if err := l.conn.PingContext(ctx); err == nil {
+			return true, nil
+		}
+		discard(l.conn)
+		l.conn = nil
+	}
+
+	conn, err := l.db.Conn(ctx)
+	if err != nil {
+		return false, err
+	}
+	var locked bool
+	err = conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, l.key).Scan(&locked)
+	if err != nil {
+		discard(conn)
+		return false, err
+	}
+	if !locked {
+		conn.Close()
+		return false, nil
+	}
+	l.conn = conn
+	return true, nil
+}
+
+// Release gives up the lock if it is held.
+func (l *Leader) Release(ctx context.Context) error {
+	if l.conn == nil {
+		return nil
+	}
+	_, err := l.conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, l.key)
+	if err != nil {
+		discard(l.conn)
+	} else {
+		l.conn.Close()
+	}
+	l.conn = nil
+	return err
+}
+
+// discard closes conn without returning it to the pool: if its session
+// still holds the lock, ending it is the only sure way to release the lock.
+func discard(conn *sql.Conn) {
+	conn.Raw(func(any) error { return driver.ErrBadConn })
+	conn.Close()
+}
@@ -0,0 +1,97 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"rsshub/internal/models"
+)
+
+// MergeFeeds moves the articles of feed from into feed into and removes
+// from, for sites that moved and left two overlapping feeds. Articles are
+// matched by link or GUID: where both feeds have one, the target's copy is
+// kept and picks up the other's read and starred state. Stored revisions move along
+// with their articles.
+func (d *DB) MergeFeeds(from, into string) (models.MergeResult, error) {
+	var res models.MergeResult
+	if from == into {
+		return res, fmt.Errorf("cannot merge feed %q into itself", from)
+	}
+	tx, err := d.Begin()
+	if err != nil {
+		return res, err
+	}
+	defer tx.Rollback()
+
+	fromID, err := lockFeed(tx, from)
+	if err != nil {
+		return res, err
+	}
+	intoID, err := lockFeed(tx, into)
+	if err != nil {
+		return res, err
+	}
+
+	_, err = tx.Exec(`UPDATE articles t SET
+		read_at = COALESCE(t.read_at, s.read_at),
+		starred_at = COALESCE(t.starred_at, s.starred_at)
+	FROM articles s
+	WHERE s.feed_id = $1 AND t.feed_id = $2 AND `+sameArticle, fromID, intoID)
+	if err != nil {
+		return res, err
+	}
+	r, err := tx.Exec(`DELETE FROM articles s
+	WHERE s.feed_id = $1 AND EXISTS (SELECT 1 FROM articles t WHERE t.feed_id = $2 AND `+sameArticle+`)`, fromID, intoID)
+	if err != nil {
+		return res, err
+	}
+	res.Duplicates, err = r.RowsAffected()
+	if err != nil {
+		return res, err
+	}
+	r, err = tx.Exec(`UPDATE articles SET feed_id = $2 WHERE feed_id = $1`, fromID, intoID)
+	if err != nil {
+		return res, err
+	}
+	res.Moved, err = r.RowsAffected()
+	if err != nil {
+		return res, err
+	}
+
+	queries := []string{
+		`UPDATE article_revisions SET feed_id = $2 WHERE feed_id = $1`,
+		`UPDATE article_notes SET feed_id = $2 WHERE feed_id = $1`,
+		`UPDATE raw_items r SET feed_id = $2 WHERE feed_id = $1
+			AND NOT EXISTS (SELECT 1 FROM raw_items t WHERE t.feed_id = $2 AND t.link = r.link)`,
+		`DELETE FROM feed_stats WHERE feed_id = $2`,
+		`DELETE FROM feed_daily_counts WHERE feed_id = $2`,
+		`INSERT INTO feed_stats (feed_id, article_count, last_published_at)
+		SELECT feed_id, COUNT(*), MAX(published_at) FROM articles WHERE feed_id = $2 GROUP BY feed_id`,
+		`INSERT INTO feed_daily_counts (feed_id, day, article_count)
+		SELECT feed_id, published_at::date, COUNT(*) FROM articles WHERE feed_id = $2 GROUP BY feed_id, published_at::date`,
+		`DELETE FROM feeds WHERE id = $1`,
+	}
+	for _, q := range queries {
+		_, err = tx.Exec(q, fromID, intoID)
+		if err != nil {
+			return res, err
+		}
+	}
+	return res, tx.Commit()
+}
+
+// sameArticle matches a source article s to a target article t. A site
+// that changed domains changes its links, but usually keeps its GUIDs.
+const sameArticle = `(t.link = s.link OR t.guid = s.guid)`
+
+// lockFeed returns the id of a live feed, locking its row until the
+// transaction ends.
+func lockFeed(tx *Tx, name string) (uuid.UUID, error) {
+	var id uuid.UUID
+	err := tx.QueryRow(`SELECT id FROM feeds WHERE name = $1 AND deleted_at IS NULL FOR UPDATE`, name).Scan(&id)
+	if err == sql.ErrNoRows {
+		return id, fmt.Errorf("feed %q %w", name, ErrNotFound)
+	}
+	return id, err
+}
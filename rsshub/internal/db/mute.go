@@ -0,0 +1,89 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	"rsshub/internal/models"
+)
+
+// unmuted keeps the articles a that no active mute rule matches.
+const unmuted = `NOT EXISTS (SELECT 1 FROM mute_rules m WHERE m.expires_at > CURRENT_TIMESTAMP
+	AND (a.title ~* m.pattern OR a.description ~* m.pattern))`
+
+// mutePattern matches keyword case-insensitively as whole words, so that
+// muting "elon" leaves "melon" alone; ends that are not word characters
+// match anywhere.
+func mutePattern(keyword string) string {
+	pattern := regexp.QuoteMeta(keyword)
+	runes := []rune(keyword)
+	if isWordRune(runes[0]) {
+		pattern = `\m` + pattern
+	}
+	if isWordRune(runes[len(runes)-1]) {
+		pattern += `\M`
+	}
+	return pattern
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// MuteKeyword hides articles mentioning keyword from listings for the
+// given time. Expired rules are dropped on the way.
+func (d *DB) MuteKeyword(keyword string, period time.Duration, createdBy string) (models.MuteRule, error) {
+	keyword = strings.TrimSpace(keyword)
+	if keyword == "" {
+		return models.MuteRule{}, errors.New("keyword must not be empty")
+	}
+	if _, err := d.Exec(`DELETE FROM mute_rules WHERE expires_at <= CURRENT_TIMESTAMP`); err != nil {
+		return models.MuteRule{}, err
+	}
+	rule := models.MuteRule{Keyword: keyword, CreatedBy: createdBy}
+	err := d.QueryRow(`INSERT INTO mute_rules (keyword, pattern, created_by, expires_at)
+	VALUES ($1, $2, $3, CURRENT_TIMESTAMP + $4 * INTERVAL '1 second')
+	RETURNING id, created_at, expires_at`, keyword, mutePattern(keyword), createdBy, period.Seconds()).
+		Scan(&rule.ID, &rule.CreatedAt, &rule.ExpiresAt)
+	return rule, err
+}
+
+// Unmute lifts a mute rule before it expires.
+func (d *DB) Unmute(id int64) error {
+	res, err := d.Exec(`DELETE FROM mute_rules WHERE id = $1 AND expires_at > CURRENT_TIMESTAMP`, id)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err == nil && n == 0 {
+		return fmt.Errorf("mute rule %d %w", id, ErrNotFound)
+	}
+	return err
+}
+
+// MuteRules returns the active mute rules, soonest to expire first.
+func (d *DB) MuteRules() ([]models.MuteRule, error) {
+	rows, err := d.readQuery(`SELECT id, keyword, created_by, created_at, expires_at
+	FROM mute_rules
+	WHERE expires_at > CURRENT_TIMESTAMP
+	ORDER BY expires_at, id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []models.MuteRule
+	for rows.Next() {
+		var r models.MuteRule
+		err := rows.Scan(&r.ID, &r.Keyword, &r.CreatedBy, &r.CreatedAt, &r.ExpiresAt)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, r)
+	}
+	return rules, rows.Err()
+}
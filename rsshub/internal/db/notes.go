@@ -0,0 +1,95 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"rsshub/internal/models"
+)
+
+// AddNote attaches a note to an article, optionally about a quoted
+// passage of it.
+func (d *DB) AddNote(articleID uuid.UUID, quote, text, createdBy string) (models.ArticleNote, error) {
+	note := models.ArticleNote{
+		ArticleID: articleID,
+		Quote:     strings.TrimSpace(quote),
+		Text:      strings.TrimSpace(text),
+		CreatedBy: createdBy,
+	}
+	if note.Text == "" {
+		return note, errors.New("note must not be empty")
+	}
+	err := d.QueryRow(`INSERT INTO article_notes (article_id, feed_id, quote, body, created_by)
+	SELECT a.id, a.feed_id, $2, $3, $4 FROM articles a WHERE a.id = $1
+	RETURNING id, created_at`, articleID, note.Quote, note.Text, createdBy).Scan(&note.ID, &note.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return note, fmt.Errorf("article %s %w", articleID, ErrNotFound)
+	}
+	return note, err
+}
+
+// DeleteNote removes a note.
+func (d *DB) DeleteNote(id int64) error {
+	res, err := d.Exec(`DELETE FROM article_notes WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err == nil && n == 0 {
+		return fmt.Errorf("note %d %w", id, ErrNotFound)
+	}
+	return err
+}
+
+// ArticleNotes returns an article's notes, oldest first.
+func (d *DB) ArticleNotes(articleID uuid.UUID) ([]models.ArticleNote, error) {
+	rows, err := d.readQuery(`SELECT id, article_id, quote, body, created_by, created_at
+	FROM article_notes
+	WHERE article_id = $1
+	ORDER BY id`, articleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var notes []models.ArticleNote
+	for rows.Next() {
+		var n models.ArticleNote
+		err := rows.Scan(&n.ID, &n.ArticleID, &n.Quote, &n.Text, &n.CreatedBy, &n.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		notes = append(notes, n)
+	}
+	return notes, rows.Err()
+}
+
+// SearchNotes returns up to limit notes whose text or quote contains
+// query, ignoring case, or the latest notes when query is empty, newest
+// first and with their article's title and link.
+func (d *DB) SearchNotes(query string, limit int) ([]models.ArticleNote, error) {
+	rows, err := d.readQuery(`SELECT n.id, n.article_id, n.quote, n.body, n.created_by, n.created_at, a.title, a.link
+	FROM article_notes n
+	JOIN articles a ON a.id = n.article_id
+	WHERE $1 = '' OR strpos(lower(n.body || ' ' || n.quote), lower($1)) > 0
+	ORDER BY n.id DESC
+	LIMIT $2`, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var notes []models.ArticleNote
+	for rows.Next() {
+		var n models.ArticleNote
+		err := rows.Scan(&n.ID, &n.ArticleID, &n.Quote, &n.Text, &n.CreatedBy, &n.CreatedAt, &n.ArticleTitle, &n.ArticleLink)
+		if err != nil {
+			return nil, err
+		}
+		notes = append(notes, n)
+	}
+	return notes, rows.Err()
+}
@@ -0,0 +1,106 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/lib/pq"
+	"rsshub/internal/logging"
+	"rsshub/internal/models"
+)
+
+// ArticlesChannel is the NOTIFY channel announcing newly inserted articles.
+const ArticlesChannel = "rsshub_articles"
+
+// notifyArticleFunction publishes an inserted article on ArticlesChannel.
+// pg_notify fails on payloads of 8000 bytes or more, and a failing
+// trigger would abort the insert, a whole bulk batch with it. Text fields
+// are capped in characters, at most 6 bytes each once JSON-escaped, which
+// keeps the payload well under the limit; should it still reach it, only
+// the ids and date are sent.
+const notifyArticleFunction = `CREATE OR REPLACE FUNCTION notify_article_inserted() RETURNS trigger AS $$
+	DECLARE
+		payload TEXT;
+	BEGIN
+		payload := json_build_object(
+			'id', NEW.id,
+			'feed_id', NEW.feed_id,
+			'feed_name', left((SELECT name FROM feeds WHERE id = NEW.feed_id), 200),
+			'title', left(NEW.title, 300),
+			'link', left(NEW.link, 500),
+			'published_at', to_char(NEW.published_at, 'YYYY-MM-DD"T"HH24:MI:SS"Z"')
+		)::text;
+		IF octet_length(payload) >= 8000 THEN
+			payload := json_build_object(
+				'id', NEW.id,
+				'feed_id', NEW.feed_id,
+				'published_at', to_char(NEW.published_at, 'YYYY-MM-DD"T"HH24:MI:SS"Z"')
+			)::text;
+		END IF;
+		PERFORM pg_notify('` + ArticlesChannel + `', payload);
+		RETURN NEW;
+	END;
+	$$ LANGUAGE plpgsql;`
+
+// articleTriggerQueries keep the notify function current and install the
+// trigger on articles where it is missing, as on a database that predates
+// it or a table just partitioned. See
+// migrations/create_articles_notify_trigger.up.sql.
+var articleTriggerQueries = []string{
+	notifyArticleFunction,
+	`DO $$
+	BEGIN
+		IF NOT EXISTS (SELECT 1 FROM pg_trigger WHERE tgname = 'articles_notify' AND tgrelid = 'articles'::regclass) THEN
+			CREATE TRIGGER articles_notify AFTER INSERT ON articles
+				FOR EACH ROW EXECUTE FUNCTION notify_article_inserted();
+		END IF;
+	END;
+	$$;`,
+}
+
+// ListenArticles streams article insert notifications until ctx is done.
+// It uses its own connection, reconnecting automatically; events missed
+// while disconnected are not replayed. Listener errors go to log.
+func ListenArticles(ctx context.Context, dsn string, log logging.Logger) (<-chan models.ArticleEvent, error) {
+	listener := pq.NewListener(dsn, time.Second, time.Minute, func(ev pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Errorf("Article listener: %v", err)
+		}
+	})
+	err := listener.Listen(ArticlesChannel)
+	if err != nil {
+		listener.Close()
+		return nil, err
+	}
+
+	events := make(chan models.ArticleEvent, 64)
+	go func() {
+		defer close(events)
+		defer listener.Close()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case n := <-listener.Notify:
+				if n == nil {
+					continue // connection re-established
+				}
+				var ev models.ArticleEvent
+				err := json.Unmarshal([]byte(n.Extra), &ev)
+				if err != nil {
+					log.Errorf("Article listener: bad payload: %v", err)
+					continue
+				}
+				select {
+				case events <- ev:
+				case <-ctx.Done():
+					return
+				}
+			case <-time.After(90 * time.Second):
+				go listener.Ping()
+			}
+		}
+	}()
+	return events, nil
+}
@@ -0,0 +1,256 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// partitionLayout names monthly article partitions, e.g. articles_y2024m06.
+const partitionLayout = "articles_y2006m01"
+
+// ArticlesPartitioned reports whether articles is a partitioned table.
+func (d *DB) ArticlesPartitioned() (bool, error) {
+	var kind string
+	err := d.QueryRow(`SELECT relkind FROM pg_class WHERE oid = 'articles'::regclass`).Scan(&kind)
+	return kind == "p", err
+}
+
+// PartitionArticles converts a plain articles table into one partitioned by
+// month of published_at, copying existing rows into per-month partitions.
+// Postgres requires unique keys on a partitioned table to include the
+// partition key, so the primary key becomes (id, published_at) and the
+// per-feed link index gains published_at; article_links keeps each link
+// once per feed instead. See migrations/partition_articles.up.sql.
+func (d *DB) PartitionArticles() error {
+	tx, err := d.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	queries := []string{
+		`ALTER TABLE articles RENAME TO articles_unpartitioned`,
+		`ALTER INDEX articles_feed_link_idx RENAME TO articles_unpartitioned_feed_link_idx`,
+		`CREATE TABLE articles (LIKE articles_unpartitioned INCLUDING DEFAULTS) PARTITION BY RANGE (published_at)`,
+		`ALTER TABLE articles ADD PRIMARY KEY (id, published_at)`,
+		`ALTER TABLE articles ADD FOREIGN KEY (feed_id) REFERENCES feeds(id) ON DELETE CASCADE`,
+		`CREATE UNIQUE INDEX articles_feed_link_idx ON articles (feed_id, link, published_at)`,
+		`CREATE TABLE articles_default PARTITION OF articles DEFAULT`,
+	}
+	for _, q := range queries {
+		_, err = tx.Exec(q)
+		if err != nil {
+			return err
+		}
+	}
+
+	var oldest, newest sql.NullTime
+	err = tx.QueryRow(`SELECT MIN(published_at), MAX(published_at) FROM articles_unpartitioned`).Scan(&oldest, &newest)
+	if err != nil {
+		return err
+	}
+	if oldest.Valid {
+		for m := monthStart(oldest.Time); !m.After(newest.Time); m = m.AddDate(0, 1, 0) {
+			err = createPartition(tx, m)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	queries = []string{
+		`INSERT INTO articles SELECT * FROM articles_unpartitioned`,
+		`DROP TABLE articles_unpartitioned`,
+	}
+	queries = append(queries, articleIndexQueries...)
+	queries = append(queries, articleTriggerQueries...)
+	for _, q := range queries {
+		_, err = tx.Exec(q)
+		if err != nil {
+			return err
+		}
+	}
+	err = guardArticleLinks(tx)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// articleLinkGuardQueries keep each link once per feed in a partitioned
+// articles table: an article first claims its feed and link in
+// article_links and, as under ON CONFLICT DO NOTHING, is not inserted if
+// another article holds them. Claims go with the feed, or with the
+// partition holding the article when it is dropped.
+var articleLinkGuardQueries = []string{
+	`CREATE TABLE article_links (
+		feed_id UUID NOT NULL REFERENCES feeds(id) ON DELETE CASCADE,
+		link TEXT NOT NULL,
+		PRIMARY KEY (feed_id, link)
+	)`,
+	`INSERT INTO article_links (feed_id, link)
+		SELECT DISTINCT feed_id, link FROM articles WHERE feed_id IS NOT NULL`,
+	`CREATE OR REPLACE FUNCTION claim_article_link() RETURNS trigger AS $$
+	BEGIN
+		IF NEW.feed_id IS NULL THEN
+			RETURN NEW;
+		END IF;
+		INSERT INTO article_links (feed_id, link) VALUES (NEW.feed_id, NEW.link) ON CONFLICT DO NOTHING;
+		IF NOT FOUND AND TG_OP = 'INSERT' THEN
+			RETURN NULL;
+		END IF;
+		RETURN NEW;
+	END;
+	$$ LANGUAGE plpgsql`,
+	`CREATE TRIGGER articles_claim_link BEFORE INSERT OR UPDATE OF feed_id, link ON articles
+		FOR EACH ROW EXECUTE FUNCTION claim_article_link()`,
+}
+
+// ensureArticleLinkGuard adds the article_links guard to a table
+// partitioned before it existed.
+func (d *DB) ensureArticleLinkGuard() error {
+	tx, err := d.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	err = guardArticleLinks(tx)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// guardArticleLinks installs the article_links guard, filled from the
+// articles already stored, unless it exists.
+func guardArticleLinks(tx *Tx) error {
+	var exists bool
+	err := tx.QueryRow(`SELECT to_regclass('article_links') IS NOT NULL`).Scan(&exists)
+	if err != nil || exists {
+		return err
+	}
+	for _, q := range articleLinkGuardQueries {
+		_, err = tx.Exec(q)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EnsureArticlePartitions creates partitions for the current month and the
+// next monthsAhead months if they do not exist yet.
+func (d *DB) EnsureArticlePartitions(monthsAhead int) error {
+	start := monthStart(time.Now().UTC())
+	for i := 0; i <= monthsAhead; i++ {
+		tx, err := d.Begin()
+		if err != nil {
+			return err
+		}
+		err = createPartition(tx, start.AddDate(0, i, 0))
+		if err == nil {
+			err = tx.Commit()
+		}
+		tx.Rollback()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DropArticlePartitionsBefore drops every monthly partition whose range ends
+// on or before cutoff, discarding its articles wholesale, and returns the
+// names of the dropped partitions.
+func (d *DB) DropArticlePartitionsBefore(cutoff time.Time) ([]string, error) {
+	rows, err := d.Query(`SELECT c.relname FROM pg_inherits i
+		JOIN pg_class c ON c.oid = i.inhrelid
+		WHERE i.inhparent = 'articles'::regclass`)
+	if err != nil {
+		return nil, err
+	}
+	var expired []string
+	for rows.Next() {
+		var name string
+		err := rows.Scan(&name)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		month, err := time.Parse(partitionLayout, name)
+		if err != nil {
+			continue // default partition or one not managed here
+		}
+		if !month.AddDate(0, 1, 0).After(cutoff) {
+			expired = append(expired, name)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, name := range expired {
+		err := d.dropArticlePartition(name)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return expired, nil
+}
+
+// dropArticlePartition drops a partition, releasing its articles' links
+// in article_links: dropping a table does not run their row triggers.
+func (d *DB) dropArticlePartition(name string) error {
+	tx, err := d.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	queries := []string{
+		fmt.Sprintf(`DELETE FROM article_links l USING %s a WHERE l.feed_id = a.feed_id AND l.link = a.link`, name),
+		fmt.Sprintf(`DROP TABLE %s`, name),
+	}
+	for _, q := range queries {
+		_, err = tx.Exec(q)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// createPartition creates the partition for month unless it exists.
+// Postgres refuses a partition whose range holds rows of the default
+// partition, such as articles dated ahead of every partition made so far,
+// so the new partition is built on its own from those rows, moved out of
+// the default partition, and attached once they are gone from it.
+func createPartition(tx *Tx, month time.Time) error {
+	name := month.Format(partitionLayout)
+	var exists bool
+	err := tx.QueryRow(`SELECT to_regclass($1) IS NOT NULL`, name).Scan(&exists)
+	if err != nil || exists {
+		return err
+	}
+	from, to := month.Format("2006-01-02"), month.AddDate(0, 1, 0).Format("2006-01-02")
+	queries := []string{
+		fmt.Sprintf(`CREATE TABLE %s (LIKE articles INCLUDING DEFAULTS)`, name),
+		fmt.Sprintf(`WITH moved AS (
+			DELETE FROM articles_default WHERE published_at >= '%s' AND published_at < '%s' RETURNING *
+		)
+		INSERT INTO %s SELECT * FROM moved`, from, to, name),
+		fmt.Sprintf(`ALTER TABLE articles ATTACH PARTITION %s FOR VALUES FROM ('%s') TO ('%s')`, name, from, to),
+	}
+	for _, q := range queries {
+		_, err = tx.Exec(q)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func monthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,389 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"rsshub/internal/geo"
+	"rsshub/internal/models"
+)
+
+// ArticleQuery selects a page of articles, newest first.
+type ArticleQuery struct {
+	FeedID      uuid.UUID // zero means all feeds
+	FeedName    string    // ignored when FeedID is set
+	Author      string    // matched case-insensitively; empty means any
+	Near        *models.GeoPoint
+	RadiusKM    float64 // required with Near
+	UnreadOnly  bool
+	StarredOnly bool
+	// HasEnclosure keeps only articles with an attached media file.
+	HasEnclosure bool
+	// MaxReadTime keeps only articles known to be quicker to read.
+	MaxReadTime time.Duration
+	// ShowMuted includes articles hidden by mute rules.
+	ShowMuted bool
+	After     *Cursor
+	Limit     int
+}
+
+const articleColumns = `a.id, a.created_at, a.updated_at, a.title, a.link, a.published_at, a.description, a.feed_id, a.read_at, a.starred_at, a.author,
+	a.guid, a.comments_url, a.enclosure_url, a.enclosure_type, a.enclosure_length, a.latitude, a.longitude,
+	a.duration_seconds, a.episode, a.season, a.image_url, a.video_id, a.thumbnail_url, a.views, a.word_count, a.published_at_source`
+
+func scanArticle(rows interface{ Scan(...any) error }) (models.Article, error) {
+	var a models.Article
+	var updated, read, starred sql.NullTime
+	var description, author, guid, comments, enclosureURL, enclosureType, imageURL, videoID, thumbnailURL sql.NullString
+	var enclosureLength, duration, episode, season, views, words sql.NullInt64
+	var lat, lon sql.NullFloat64
+	err := rows.Scan(&a.ID, &a.CreatedAt, &updated, &a.Title, &a.Link, &a.PublishedAt, &description, &a.FeedID, &read, &starred, &author,
+		&guid, &comments, &enclosureURL, &enclosureType, &enclosureLength, &lat, &lon,
+		&duration, &episode, &season, &imageURL, &videoID, &thumbnailURL, &views, &words, &a.PublishedAtSource)
+	if err != nil {
+		return a, err
+	}
+	if updated.Valid {
+		a.UpdatedAt = updated.Time
+	}
+	if read.Valid {
+		a.ReadAt = read.Time
+	}
+	if starred.Valid {
+		a.StarredAt = starred.Time
+	}
+	a.Description = description.String
+	a.Author = author.String
+	a.GUID = guid.String
+	a.CommentsURL = comments.String
+	a.EnclosureURL = enclosureURL.String
+	a.EnclosureType = enclosureType.String
+	a.EnclosureLength = enclosureLength.Int64
+	a.Location = geoPoint(lat, lon)
+	a.Duration = time.Duration(duration.Int64) * time.Second
+	a.Episode = int(episode.Int64)
+	a.Season = int(season.Int64)
+	a.ImageURL = imageURL.String
+	a.VideoID = videoID.String
+	a.ThumbnailURL = thumbnailURL.String
+	a.Views = views.Int64
+	a.WordCount = int(words.Int64)
+	return a, nil
+}
+
+// greatCircleKM is the haversine distance in kilometres from an article to
+// the point given by its two arguments, with the same earth radius as the
+// geo package.
+const greatCircleKM = `(2 * 6371 * asin(sqrt(
+	power(sin(radians(a.latitude - %[1]s) / 2), 2) +
+	cos(radians(%[1]s)) * cos(radians(a.latitude)) * power(sin(radians(a.longitude - %[2]s) / 2), 2))))`
+
+// QueryArticles returns up to q.Limit articles after q.After, plus whether
+// more follow.
+func (d *DB) QueryArticles(q ArticleQuery) ([]models.Article, bool, error) {
+	return cached(d, "articles", q, func() ([]models.Article, bool, error) {
+		return d.queryArticles(q)
+	})
+}
+
+func (d *DB) queryArticles(q ArticleQuery) ([]models.Article, bool, error) {
+	query, args := articleSelect(q)
+	args = append(args, q.Limit+1)
+	query += fmt.Sprintf(" LIMIT $%d", len(args))
+
+	rows, err := d.readQuery(query, args...)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rows.Close()
+
+	var articles []models.Article
+	for rows.Next() {
+		a, err := scanArticle(rows)
+		if err != nil {
+			return nil, false, err
+		}
+		articles = append(articles, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, false, err
+	}
+	more := len(articles) > q.Limit
+	if more {
+		articles = articles[:q.Limit]
+	}
+	err = d.shortenIDs(articles)
+	return articles, more, err
+}
+
+// articleSelect builds the query for the articles q selects, newest first,
+// without a LIMIT, and its arguments. q.Limit is ignored.
+func articleSelect(q ArticleQuery) (string, []any) {
+	var where []string
+	var args []any
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	if q.FeedID != uuid.Nil {
+		where = append(where, "a.feed_id = "+arg(q.FeedID))
+	} else if q.FeedName != "" {
+		where = append(where, "a.feed_id = (SELECT id FROM feeds WHERE name = "+arg(q.FeedName)+")")
+	}
+	where = append(where, "EXISTS (SELECT 1 FROM feeds f WHERE f.id = a.feed_id AND f.deleted_at IS NULL)")
+	if !q.ShowMuted {
+		where = append(where, unmuted)
+	}
+	if q.Author != "" {
+		where = append(where, "lower(a.author) = lower("+arg(q.Author)+")")
+	}
+	if q.Near != nil {
+		box := geo.BoundingBox(*q.Near, q.RadiusKM)
+		where = append(where, fmt.Sprintf("a.latitude BETWEEN %s AND %s", arg(box.MinLat), arg(box.MaxLat)))
+		if box.Lon != nil {
+			where = append(where, fmt.Sprintf("a.longitude BETWEEN %s AND %s", arg(box.Lon[0]), arg(box.Lon[1])))
+		}
+		where = append(where, fmt.Sprintf(greatCircleKM+" <= %s", arg(q.Near.Lat), arg(q.Near.Lon), arg(q.RadiusKM)))
+	}
+	if q.UnreadOnly {
+		where = append(where, "a.read_at IS NULL")
+	}
+	if q.StarredOnly {
+		where = append(where, "a.starred_at IS NOT NULL")
+	}
+	if q.HasEnclosure {
+		where = append(where, "a.enclosure_url IS NOT NULL")
+	}
+	if q.MaxReadTime > 0 {
+		where = append(where, "a.word_count <= "+arg(int(q.MaxReadTime.Minutes()*models.WordsPerMinute)))
+	}
+	if q.After != nil {
+		where = append(where, fmt.Sprintf("(a.published_at, a.id) < (%s, %s)", arg(q.After.Time.UTC()), arg(q.After.ID)))
+	}
+
+	query := `SELECT ` + articleColumns + ` FROM articles a WHERE ` + strings.Join(where, " AND ")
+	return query + " ORDER BY a.published_at DESC, a.id DESC", args
+}
+
+// StreamArticles calls fn with each article matching every criterion set
+// in f, newest first, as the rows arrive rather than collecting them all;
+// a limit of 0 means no limit. It stops at the first error fn returns and
+// returns it.
+func (d *DB) StreamArticles(f models.ArticleFilter, limit int, fn func(models.Article) error) error {
+	if f.FeedName == "" && f.Author == "" && f.Near == nil {
+		return errors.New("a feed name, author or location is required")
+	}
+	query, args := articleSelect(ArticleQuery{
+		FeedName:    f.FeedName,
+		Author:      f.Author,
+		Near:        f.Near,
+		RadiusKM:    f.RadiusKM,
+		MaxReadTime: f.MaxReadTime,
+		ShowMuted:   f.ShowMuted,
+	})
+	if limit > 0 {
+		args = append(args, limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	rows, err := d.readQuery(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		a, err := scanArticle(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(a); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// QueryFeeds returns up to limit of the feeds f selects after the cursor,
+// with their stats, plus whether more follow. Pages are always newest
+// first, whatever f's order.
+func (d *DB) QueryFeeds(f models.FeedFilter, after *Cursor, limit int) ([]models.Feed, bool, error) {
+	key := struct {
+		Filter models.FeedFilter
+		After  *Cursor
+		Limit  int
+	}{f, after, limit}
+	return cached(d, "feed-page", key, func() ([]models.Feed, bool, error) {
+		return d.queryFeeds(f, after, limit)
+	})
+}
+
+func (d *DB) queryFeeds(f models.FeedFilter, after *Cursor, limit int) ([]models.Feed, bool, error) {
+	where, args := feedConditions(f, []any{limit + 1})
+	query := `SELECT ` + feedColumns(f.Wide) + `
+	FROM feeds f` + feedJoins(f.Wide) + `
+	WHERE f.deleted_at IS NULL` + where
+	if after != nil {
+		query += fmt.Sprintf(` AND (f.created_at, f.id) < ($%d, $%d)`, len(args)+1, len(args)+2)
+		args = append(args, after.Time.UTC(), after.ID)
+	}
+	query += ` ORDER BY ` + feedOrders[models.FeedSortAdded] + ` LIMIT $1`
+
+	rows, err := d.readQuery(query, args...)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rows.Close()
+
+	var feeds []models.Feed
+	for rows.Next() {
+		f, err := scanFeed(rows, f.Wide)
+		if err != nil {
+			return nil, false, err
+		}
+		feeds = append(feeds, f)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, false, err
+	}
+	more := len(feeds) > limit
+	if more {
+		feeds = feeds[:limit]
+	}
+	return feeds, more, nil
+}
+
+// feedOrders holds the ORDER BY clause for each feed listing order. Each
+// ends in a unique column so the order is stable.
+var feedOrders = map[string]string{
+	"":                      `f.created_at DESC, f.id DESC`,
+	models.FeedSortAdded:    `f.created_at DESC, f.id DESC`,
+	models.FeedSortName:     `f.name`,
+	models.FeedSortUpdated:  `f.updated_at DESC NULLS LAST, f.name`,
+	models.FeedSortArticles: `COALESCE(s.article_count, 0) DESC, f.name`,
+}
+
+// feedConditions turns f's filters into AND clauses for a feed listing,
+// numbering their placeholders after args, which it returns extended.
+func feedConditions(f models.FeedFilter, args []any) (string, []any) {
+	var where strings.Builder
+	if f.Match != "" {
+		args = append(args, strings.ToLower(f.Match))
+		fmt.Fprintf(&where, ` AND (strpos(lower(f.name), $%[1]d) > 0 OR strpos(lower(f.url), $%[1]d) > 0)`, len(args))
+	}
+	if f.Errored {
+		where.WriteString(` AND f.failures > 0`)
+	}
+	if f.Disabled {
+		where.WriteString(` AND f.disabled_at IS NOT NULL`)
+	}
+	return where.String(), args
+}
+
+// feedColumns lists what feed listings select, with the fetch status and
+// unread count added for wide ones. feedJoins supplies the tables they
+// need and scanFeed reads them back.
+func feedColumns(wide bool) string {
+	columns := `f.id, f.created_at, f.updated_at, f.name, f.url, f.disabled_at, f.disabled_reason,
+		COALESCE(s.article_count, 0), s.last_published_at`
+	if wide {
+		columns += `, last.fetched_at, last.duration_ms, last.status, last.error, f.failures,
+		(SELECT COUNT(*) FROM articles a WHERE a.feed_id = f.id AND a.read_at IS NULL)`
+	}
+	return columns
+}
+
+func feedJoins(wide bool) string {
+	joins := `
+	LEFT JOIN feed_stats s ON s.feed_id = f.id`
+	if wide {
+		joins += `
+	LEFT JOIN LATERAL (
+		SELECT fetched_at, duration_ms, status, error FROM fetch_log
+		WHERE feed_id = f.id
+		ORDER BY fetched_at DESC LIMIT 1
+	) last ON true`
+	}
+	return joins
+}
+
+func scanFeed(rows interface{ Scan(...any) error }, wide bool) (models.Feed, error) {
+	var f models.Feed
+	var updated, disabled, lastPublished, lastAt sql.NullTime
+	var reason, lastError sql.NullString
+	var lastMS, lastStatus sql.NullInt64
+	dest := []any{&f.ID, &f.CreatedAt, &updated, &f.Name, &f.URL, &disabled, &reason, &f.ArticleCount, &lastPublished}
+	if wide {
+		dest = append(dest, &lastAt, &lastMS, &lastStatus, &lastError, &f.Failures, &f.UnreadCount)
+	}
+	err := rows.Scan(dest...)
+	if err != nil {
+		return f, err
+	}
+	f.UpdatedAt = updated.Time
+	f.DisabledAt, f.DisabledReason = disabled.Time, reason.String
+	f.LastPublishedAt = lastPublished.Time
+	f.LastFetch = models.FetchLogEntry{
+		FeedID:    f.ID,
+		FetchedAt: lastAt.Time,
+		Duration:  time.Duration(lastMS.Int64) * time.Millisecond,
+		Status:    int(lastStatus.Int64),
+		Error:     lastError.String,
+	}
+	return f, nil
+}
+
+func (d *DB) GetArticle(id uuid.UUID) (models.Article, error) {
+	row := d.QueryRow(`SELECT `+articleColumns+` FROM articles a WHERE a.id = $1`, id)
+	a, err := scanArticle(row)
+	if err == sql.ErrNoRows {
+		return a, fmt.Errorf("article %s %w", id, ErrNotFound)
+	}
+	return a, err
+}
+
+func (d *DB) GetFeedByID(id uuid.UUID) (models.Feed, error) {
+	var f models.Feed
+	var updated sql.NullTime
+	err := d.QueryRow(`SELECT id, created_at, updated_at, name, url FROM feeds WHERE id = $1 AND deleted_at IS NULL`, id).
+		Scan(&f.ID, &f.CreatedAt, &updated, &f.Name, &f.URL)
+	if err == sql.ErrNoRows {
+		return f, fmt.Errorf("feed %s %w", id, ErrNotFound)
+	}
+	if updated.Valid {
+		f.UpdatedAt = updated.Time
+	}
+	return f, err
+}
+
+// latitude and longitude turn an optional location into nullable column
+// values.
+func latitude(p *models.GeoPoint) sql.NullFloat64 {
+	if p == nil {
+		return sql.NullFloat64{}
+	}
+	return sql.NullFloat64{Float64: p.Lat, Valid: true}
+}
+
+func longitude(p *models.GeoPoint) sql.NullFloat64 {
+	if p == nil {
+		return sql.NullFloat64{}
+	}
+	return sql.NullFloat64{Float64: p.Lon, Valid: true}
+}
+
+// durationSeconds is how an episode duration is stored; 0 means unknown.
+func durationSeconds(d time.Duration) int64 {
+	return int64(d / time.Second)
+}
+
+func geoPoint(lat, lon sql.NullFloat64) *models.GeoPoint {
+	if !lat.Valid || !lon.Valid {
+		return nil
+	}
+	return &models.GeoPoint{Lat: lat.Float64, Lon: lon.Float64}
+}
@@ -0,0 +1,104 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"rsshub/internal/models"
+)
+
+// GetFeedQuirks returns the parser overrides for a live feed, all unset if
+// it has none.
+func (d *DB) GetFeedQuirks(name string) (models.FeedQuirks, error) {
+	var q models.FeedQuirks
+	var has bool
+	err := d.QueryRow(`SELECT q.feed_id IS NOT NULL,
+		COALESCE(q.date_layout, ''), COALESCE(q.insecure_tls, false),
+		COALESCE(q.ca_bundle, ''), COALESCE(q.cert_pins, ''),
+		COALESCE(q.plain_description, false), COALESCE(q.strip_title_prefix, '')
+	FROM feeds f LEFT JOIN feed_quirks q ON q.feed_id = f.id
+	WHERE f.name = $1 AND f.deleted_at IS NULL`, name).
+		Scan(&has, &q.DateLayout, &q.InsecureTLS, &q.CABundle, &q.CertPins, &q.PlainDescription, &q.StripTitlePrefix)
+	if err == sql.ErrNoRows {
+		return q, fmt.Errorf("feed %q %w", name, ErrNotFound)
+	}
+	return q, err
+}
+
+// FeedQuirksByID returns the parser overrides the fetcher applies to a feed.
+func (d *DB) FeedQuirksByID(feedID uuid.UUID) (models.FeedQuirks, error) {
+	var q models.FeedQuirks
+	err := d.QueryRow(`SELECT date_layout, insecure_tls, ca_bundle, cert_pins, plain_description, strip_title_prefix
+	FROM feed_quirks WHERE feed_id = $1`, feedID).
+		Scan(&q.DateLayout, &q.InsecureTLS, &q.CABundle, &q.CertPins, &q.PlainDescription, &q.StripTitlePrefix)
+	if err == sql.ErrNoRows {
+		return q, nil
+	}
+	return q, err
+}
+
+// SetFeedQuirks replaces a live feed's parser overrides. Setting them all
+// to their zero values removes the feed's row. The feed's validators are
+// forgotten so its next fetch is parsed with the new overrides even if
+// the feed has not changed.
+func (d *DB) SetFeedQuirks(name string, q models.FeedQuirks) error {
+	_, err := d.Exec(`DELETE FROM feed_validators WHERE feed_id = (
+		SELECT id FROM feeds WHERE name = $1 AND deleted_at IS NULL)`, name)
+	if err != nil {
+		return err
+	}
+	if q == (models.FeedQuirks{}) {
+		res, err := d.Exec(`DELETE FROM feed_quirks WHERE feed_id = (
+			SELECT id FROM feeds WHERE name = $1 AND deleted_at IS NULL)`, name)
+		if err != nil {
+			return err
+		}
+		// No row is fine as long as the feed exists.
+		if n, _ := res.RowsAffected(); n > 0 {
+			return nil
+		}
+		_, err = d.GetFeedQuirks(name)
+		return err
+	}
+	res, err := d.Exec(`INSERT INTO feed_quirks (feed_id, date_layout, insecure_tls, ca_bundle, cert_pins, plain_description, strip_title_prefix)
+	SELECT id, $2, $3, $4, $5, $6, $7 FROM feeds WHERE name = $1 AND deleted_at IS NULL
+	ON CONFLICT (feed_id) DO UPDATE SET
+		date_layout = EXCLUDED.date_layout,
+		insecure_tls = EXCLUDED.insecure_tls,
+		ca_bundle = EXCLUDED.ca_bundle,
+		cert_pins = EXCLUDED.cert_pins,
+		plain_description = EXCLUDED.plain_description,
+		strip_title_prefix = EXCLUDED.strip_title_prefix,
+		updated_at = CURRENT_TIMESTAMP`,
+		name, q.DateLayout, q.InsecureTLS, q.CABundle, q.CertPins, q.PlainDescription, q.StripTitlePrefix)
+	return affectedOne(res, err, name)
+}
+
+// quirksSummary renders overrides for the audit log.
+func quirksSummary(q models.FeedQuirks) string {
+	var parts []string
+	if q.DateLayout != "" {
+		parts = append(parts, fmt.Sprintf("date-layout=%q", q.DateLayout))
+	}
+	if q.InsecureTLS {
+		parts = append(parts, "insecure-tls")
+	}
+	if q.CABundle != "" {
+		parts = append(parts, fmt.Sprintf("ca-bundle=%d bytes", len(q.CABundle)))
+	}
+	if q.CertPins != "" {
+		parts = append(parts, fmt.Sprintf("cert-pins=%q", q.CertPins))
+	}
+	if q.PlainDescription {
+		parts = append(parts, "plain-description")
+	}
+	if q.StripTitlePrefix != "" {
+		parts = append(parts, fmt.Sprintf("strip-title-prefix=%q", q.StripTitlePrefix))
+	}
+	if len(parts) == 0 {
+		return "none"
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,51 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+
+	"rsshub/internal/models"
+)
+
+// RandomArticles picks up to n articles at random from those f selects.
+func (d *DB) RandomArticles(f models.RandomFilter, n int) ([]models.Article, error) {
+	where := []string{"EXISTS (SELECT 1 FROM feeds f WHERE f.id = a.feed_id AND f.deleted_at IS NULL)", unmuted}
+	args := []any{n}
+	if f.FeedName != "" {
+		args = append(args, f.FeedName)
+		where = append(where, fmt.Sprintf("a.feed_id = (SELECT id FROM feeds WHERE name = $%d)", len(args)))
+	}
+	switch {
+	case f.Unread && f.Starred:
+		where = append(where, "(a.read_at IS NULL OR a.starred_at IS NOT NULL)")
+	case f.Unread:
+		where = append(where, "a.read_at IS NULL")
+	case f.Starred:
+		where = append(where, "a.starred_at IS NOT NULL")
+	}
+	if f.OlderThan > 0 {
+		args = append(args, f.OlderThan.Seconds())
+		where = append(where, fmt.Sprintf("a.published_at < CURRENT_TIMESTAMP - $%d * INTERVAL '1 second'", len(args)))
+	}
+
+	rows, err := d.readQuery(`SELECT `+articleColumns+` FROM articles a
+	WHERE `+strings.Join(where, " AND ")+`
+	ORDER BY random() LIMIT $1`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var articles []models.Article
+	for rows.Next() {
+		a, err := scanArticle(rows)
+		if err != nil {
+			return nil, err
+		}
+		articles = append(articles, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return articles, d.shortenIDs(articles)
+}
@@ -0,0 +1,68 @@
+package db
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"rsshub/internal/models"
+)
+
+// SaveRawItems keeps the markup of the articles that carry it, replacing
+// what was kept for the same feed and link when it differs.
+func (d *DB) SaveRawItems(articles []models.Article) error {
+	tx, err := d.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`INSERT INTO raw_items (feed_id, link, published_at, raw) VALUES ($1, $2, $3, $4)
+	ON CONFLICT (feed_id, link) DO UPDATE SET
+		published_at = EXCLUDED.published_at, raw = EXCLUDED.raw, fetched_at = CURRENT_TIMESTAMP
+	WHERE raw_items.raw IS DISTINCT FROM EXCLUDED.raw`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for _, a := range articles {
+		if a.Raw == "" {
+			continue
+		}
+		_, err = stmt.Exec(a.FeedID, a.Link, a.PublishedAt, a.Raw)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// RawItems returns the markup kept for a feed's items published after
+// since, oldest first.
+func (d *DB) RawItems(feedID uuid.UUID, since time.Time) ([][]byte, error) {
+	rows, err := d.readQuery(`SELECT raw FROM raw_items WHERE feed_id = $1 AND published_at >= $2 ORDER BY published_at`, feedID, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items [][]byte
+	for rows.Next() {
+		var raw []byte
+		if err := rows.Scan(&raw); err != nil {
+			return nil, err
+		}
+		items = append(items, raw)
+	}
+	return items, rows.Err()
+}
+
+// PruneRawItemsBefore deletes the markup of items published before
+// cutoff, whose articles retention has dropped, and returns how many
+// items it removed.
+func (d *DB) PruneRawItemsBefore(cutoff time.Time) (int64, error) {
+	res, err := d.Exec(`DELETE FROM raw_items WHERE published_at < $1`, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
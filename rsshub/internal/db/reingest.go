@@ -0,0 +1,94 @@
+package db
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"rsshub/internal/models"
+)
+
+// ReconcileArticle brings a stored article in line with a fresh parse of
+// it: title and description changes are revised as by ReviseArticle, and
+// the publication date, author, enclosure and other metadata are
+// overwritten where they differ. A date the item does not carry itself,
+// from the response's Last-Modified or the time it was fetched, never
+// replaces the stored one. Read and starred marks and view counts are
+// kept. It reports whether the text was revised and whether any metadata
+// changed.
+func (d *DB) ReconcileArticle(article *models.Article) (revised, updated bool, err error) {
+	revised, err = d.ReviseArticle(article)
+	if err != nil {
+		return false, false, err
+	}
+	ownDate := article.PublishedAtSource != models.DateSourceLastModified && article.PublishedAtSource != models.DateSourceFirstSeen
+	res, err := d.Exec(`UPDATE articles SET published_at = CASE WHEN $18 THEN $3 ELSE published_at END,
+		published_at_source = CASE WHEN $18 THEN COALESCE(NULLIF($19, ''), 'published') ELSE published_at_source END,
+		author = NULLIF($4, ''), guid = NULLIF($5, ''),
+		comments_url = NULLIF($6, ''), enclosure_url = NULLIF($7, ''), enclosure_type = NULLIF($8, ''),
+		enclosure_length = NULLIF($9::bigint, 0), latitude = $10, longitude = $11, duration_seconds = NULLIF($12, 0),
+		episode = NULLIF($13, 0), season = NULLIF($14, 0), image_url = NULLIF($15, ''), video_id = NULLIF($16, ''),
+		thumbnail_url = NULLIF($17, ''), updated_at = CURRENT_TIMESTAMP
+	WHERE feed_id = $1 AND link = $2
+		AND (($18 AND (published_at, published_at_source) IS DISTINCT FROM ($3, COALESCE(NULLIF($19, ''), 'published')))
+			OR (author, guid, comments_url, enclosure_url, enclosure_type, enclosure_length,
+			latitude, longitude, duration_seconds, episode, season, image_url, video_id, thumbnail_url)
+		IS DISTINCT FROM (NULLIF($4, ''), NULLIF($5, ''), NULLIF($6, ''), NULLIF($7, ''), NULLIF($8, ''),
+			NULLIF($9::bigint, 0), $10, $11, NULLIF($12, 0), NULLIF($13, 0), NULLIF($14, 0), NULLIF($15, ''),
+			NULLIF($16, ''), NULLIF($17, '')))`,
+		article.FeedID, article.Link, article.PublishedAt, article.Author, article.GUID, article.CommentsURL,
+		article.EnclosureURL, article.EnclosureType, article.EnclosureLength,
+		latitude(article.Location), longitude(article.Location), durationSeconds(article.Duration),
+		article.Episode, article.Season, article.ImageURL, article.VideoID, article.ThumbnailURL,
+		ownDate, article.PublishedAtSource)
+	if err != nil {
+		return revised, false, err
+	}
+	n, err := res.RowsAffected()
+	return revised, n > 0, err
+}
+
+// ArticleLinksSince returns the links of a feed's articles published
+// after since.
+func (d *DB) ArticleLinksSince(feedID uuid.UUID, since time.Time) ([]string, error) {
+	rows, err := d.readQuery(`SELECT link FROM articles WHERE feed_id = $1 AND published_at >= $2`, feedID, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var links []string
+	for rows.Next() {
+		var link string
+		if err := rows.Scan(&link); err != nil {
+			return nil, err
+		}
+		links = append(links, link)
+	}
+	return links, rows.Err()
+}
+
+// RefreshFeedStats recomputes one feed's rows of the aggregate tables,
+// for after its articles were re-dated.
+func (d *DB) RefreshFeedStats(feedID uuid.UUID) error {
+	tx, err := d.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	queries := []string{
+		`DELETE FROM feed_stats WHERE feed_id = $1`,
+		`DELETE FROM feed_daily_counts WHERE feed_id = $1`,
+		`INSERT INTO feed_stats (feed_id, article_count, last_published_at)
+		SELECT feed_id, COUNT(*), MAX(published_at) FROM articles WHERE feed_id = $1 GROUP BY feed_id`,
+		`INSERT INTO feed_daily_counts (feed_id, day, article_count)
+		SELECT feed_id, published_at::date, COUNT(*) FROM articles WHERE feed_id = $1 GROUP BY feed_id, published_at::date`,
+	}
+	for _, q := range queries {
+		_, err = tx.Exec(q, feedID)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
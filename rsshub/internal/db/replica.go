@@ -0,0 +1,69 @@
+package db
+
+import (
+	"database/sql"
+	"sync/atomic"
+	"time"
+
+	"rsshub/internal/logging"
+)
+
+// replicaRetry is how long reads stay on the primary after the replica
+// fails before it is tried again.
+const replicaRetry = 30 * time.Second
+
+// replica is an optional read-only connection pool for list and report
+// queries, so API and CLI reads do not compete with ingestion. Point
+// lookups stay on the primary: they are cheap and often follow a write
+// the replica may not have replayed yet.
+type replica struct {
+	db        *sql.DB
+	log       logging.Logger
+	downUntil atomic.Int64 // unix nanoseconds
+}
+
+func openReplica(dsn string, log logging.Logger) (*replica, error) {
+	conn, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, err
+	}
+	r := &replica{db: conn, log: log}
+	err = conn.Ping()
+	if err != nil {
+		r.markDown(err)
+	}
+	return r, nil
+}
+
+func (r *replica) usable() bool {
+	return time.Now().UnixNano() >= r.downUntil.Load()
+}
+
+func (r *replica) markDown(err error) {
+	r.log.Errorf("Read replica unavailable, using primary for %s: %v", replicaRetry, err)
+	r.downUntil.Store(time.Now().Add(replicaRetry).UnixNano())
+}
+
+// readQuery runs a read-only query on the replica when one is configured
+// and healthy, falling back to the primary if the replica errors.
+func (d *DB) readQuery(query string, args ...any) (*sql.Rows, error) {
+	if r := d.replica; r != nil && r.usable() {
+		rows, err := r.db.QueryContext(d.queryContext(), query, args...)
+		if err == nil {
+			return rows, nil
+		}
+		if d.queryContext().Err() != nil {
+			return nil, err
+		}
+		r.markDown(err)
+	}
+	return d.Query(query, args...)
+}
+
+// Close closes the primary pool and the replica pool, if any.
+func (d *DB) Close() error {
+	if d.replica != nil {
+		d.replica.db.Close()
+	}
+	return d.DB.Close()
+}
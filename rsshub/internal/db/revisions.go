@@ -0,0 +1,95 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"rsshub/internal/models"
+)
+
+// ReviseArticle updates a stored article whose title or description
+// changed upstream, first copying the previous version into
+// article_revisions. It reports whether anything changed.
+//
+// When the article carries a ContentHash, changes to markup alone are
+// ignored: the stored copy is only rewritten if its text hash differs.
+func (d *DB) ReviseArticle(article *models.Article) (bool, error) {
+	res, err := d.Exec(`WITH old AS (
+		SELECT id, feed_id, title, description, COALESCE(updated_at, created_at) AS since
+		FROM articles
+		WHERE feed_id = $1 AND link = $2
+			AND (title IS DISTINCT FROM $3 OR description IS DISTINCT FROM $4)
+			AND ($6 = '' OR content_hash IS DISTINCT FROM $6)
+		FOR UPDATE
+	),
+	revision AS (
+		INSERT INTO article_revisions (article_id, feed_id, title, description, valid_from)
+		SELECT id, feed_id, title, description, since FROM old
+	)
+	UPDATE articles a SET title = $3, description = $4, word_count = $5, content_hash = NULLIF($6, ''),
+		updated_at = CURRENT_TIMESTAMP
+	FROM old WHERE a.id = old.id`, article.FeedID, article.Link, article.Title, article.Description, article.WordCount, article.ContentHash)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// reviseFromImport is ReviseArticle for every row of the articles_import
+// temp table used by BulkInsertArticles, for articles published within
+// window, or all of them when it is 0.
+func reviseFromImport(tx *Tx, window time.Duration) (int64, error) {
+	res, err := tx.Exec(`WITH incoming AS (
+		SELECT DISTINCT ON (feed_id, link) feed_id, link, title, description, word_count, content_hash FROM articles_import
+	),
+	old AS (
+		SELECT a.id, a.feed_id, a.title, a.description, COALESCE(a.updated_at, a.created_at) AS since,
+			i.title AS new_title, i.description AS new_description, i.word_count AS new_word_count,
+			NULLIF(i.content_hash, '') AS new_content_hash
+		FROM articles a
+		JOIN incoming i ON a.feed_id = i.feed_id AND a.link = i.link
+		WHERE (a.title IS DISTINCT FROM i.title OR a.description IS DISTINCT FROM i.description)
+			AND (COALESCE(i.content_hash, '') = '' OR a.content_hash IS DISTINCT FROM i.content_hash)
+			AND ($1::float8 = 0 OR a.published_at >= CURRENT_TIMESTAMP - $1::float8 * INTERVAL '1 second')
+		FOR UPDATE OF a
+	),
+	revision AS (
+		INSERT INTO article_revisions (article_id, feed_id, title, description, valid_from)
+		SELECT id, feed_id, title, description, since FROM old
+	)
+	UPDATE articles a SET title = old.new_title, description = old.new_description, word_count = old.new_word_count,
+		content_hash = old.new_content_hash, updated_at = CURRENT_TIMESTAMP
+	FROM old WHERE a.id = old.id`, window.Seconds())
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// GetArticleRevisions returns the earlier versions of an article, oldest
+// first. The current version is the article itself.
+func (d *DB) GetArticleRevisions(id uuid.UUID) ([]models.ArticleRevision, error) {
+	rows, err := d.Query(`SELECT id, article_id, title, description, valid_from, replaced_at
+	FROM article_revisions
+	WHERE article_id = $1
+	ORDER BY id`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var revisions []models.ArticleRevision
+	for rows.Next() {
+		var r models.ArticleRevision
+		var description sql.NullString
+		err := rows.Scan(&r.ID, &r.ArticleID, &r.Title, &description, &r.ValidFrom, &r.ReplacedAt)
+		if err != nil {
+			return nil, err
+		}
+		r.Description = description.String
+		revisions = append(revisions, r)
+	}
+	return revisions, rows.Err()
+}
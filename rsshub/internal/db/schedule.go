@@ -0,0 +1,166 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"rsshub/internal/models"
+)
+
+// SetFeedSchedule stores a feed's polling hints and cadence and defers its
+// next fetch by delay from now; a delay of 0 makes it due again at once.
+// Feeds with neither lose their row.
+func (d *DB) SetFeedSchedule(feedID uuid.UUID, s models.FeedSchedule, delay time.Duration) error {
+	tx, err := d.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if s.TTL == 0 && len(s.SkipHours) == 0 && len(s.SkipDays) == 0 && s.UpdateInterval == 0 && s.MaxAge == 0 && s.Cadence == 0 {
+		_, err = tx.Exec(`DELETE FROM feed_schedules WHERE feed_id = $1`, feedID)
+	} else {
+		hours := make([]int64, len(s.SkipHours))
+		for i, h := range s.SkipHours {
+			hours[i] = int64(h)
+		}
+		days := make([]int64, len(s.SkipDays))
+		for i, d := range s.SkipDays {
+			days[i] = int64(d)
+		}
+		_, err = tx.Exec(`INSERT INTO feed_schedules (feed_id, ttl_minutes, skip_hours, skip_days, update_interval_seconds, max_age_seconds, cadence_seconds)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (feed_id) DO UPDATE SET
+			ttl_minutes = EXCLUDED.ttl_minutes,
+			skip_hours = EXCLUDED.skip_hours,
+			skip_days = EXCLUDED.skip_days,
+			update_interval_seconds = EXCLUDED.update_interval_seconds,
+			max_age_seconds = EXCLUDED.max_age_seconds,
+			cadence_seconds = EXCLUDED.cadence_seconds,
+			updated_at = CURRENT_TIMESTAMP`, feedID, int64(s.TTL/time.Minute), pq.Array(hours), pq.Array(days),
+			int64(s.UpdateInterval/time.Second), int64(s.MaxAge/time.Second), int64(s.Cadence/time.Second))
+	}
+	if err != nil {
+		return err
+	}
+
+	if delay > 0 {
+		_, err = tx.Exec(`UPDATE feeds SET next_fetch_at = CURRENT_TIMESTAMP + $2 * INTERVAL '1 second' WHERE id = $1`,
+			feedID, delay.Seconds())
+	} else {
+		_, err = tx.Exec(`UPDATE feeds SET next_fetch_at = NULL WHERE id = $1`, feedID)
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// FeedSchedule returns the polling hints stored by SetFeedSchedule, or
+// zero values if the feed has none.
+func (d *DB) FeedSchedule(feedID uuid.UUID) (models.FeedSchedule, error) {
+	var s models.FeedSchedule
+	var ttlMinutes, updateSeconds, maxAgeSeconds, cadenceSeconds int64
+	var hours, days []int64
+	err := d.QueryRow(`SELECT ttl_minutes, skip_hours, skip_days, update_interval_seconds, max_age_seconds, cadence_seconds
+	FROM feed_schedules WHERE feed_id = $1`, feedID).
+		Scan(&ttlMinutes, pq.Array(&hours), pq.Array(&days), &updateSeconds, &maxAgeSeconds, &cadenceSeconds)
+	if err == sql.ErrNoRows {
+		return s, nil
+	}
+	if err != nil {
+		return s, err
+	}
+	s.TTL = time.Duration(ttlMinutes) * time.Minute
+	s.UpdateInterval = time.Duration(updateSeconds) * time.Second
+	s.MaxAge = time.Duration(maxAgeSeconds) * time.Second
+	s.Cadence = time.Duration(cadenceSeconds) * time.Second
+	for _, h := range hours {
+		s.SkipHours = append(s.SkipHours, int(h))
+	}
+	for _, day := range days {
+		s.SkipDays = append(s.SkipDays, time.Weekday(day))
+	}
+	return s, nil
+}
+
+// cadenceWindow is how many of a feed's newest articles its cadence is
+// measured over, and cadenceMinimum how many it takes to measure one.
+const (
+	cadenceWindow  = 20
+	cadenceMinimum = 3
+)
+
+// FeedCadence estimates the average time between a feed's posts from its
+// newest articles, counting the time since the last one, so a feed that
+// goes quiet stretches out. It returns 0 for feeds with too few articles.
+func (d *DB) FeedCadence(feedID uuid.UUID) (time.Duration, error) {
+	var n int
+	var seconds float64
+	err := d.QueryRow(`SELECT COUNT(*), COALESCE(EXTRACT(EPOCH FROM CURRENT_TIMESTAMP::timestamp - MIN(published_at)), 0)
+	FROM (
+		SELECT published_at FROM articles
+		WHERE feed_id = $1 AND published_at <= CURRENT_TIMESTAMP
+		ORDER BY published_at DESC
+		LIMIT $2
+	) recent`, feedID, cadenceWindow).Scan(&n, &seconds)
+	if err != nil || n < cadenceMinimum || seconds <= 0 {
+		return 0, err
+	}
+	return time.Duration(seconds/float64(n)) * time.Second, nil
+}
+
+// QuietFeeds returns the enabled feeds whose newest article is older than
+// gaps times their usual time between posts, and at least minQuiet old.
+// The usual gap is measured over the same window as FeedCadence, up to
+// the newest article, so the silence itself does not stretch it.
+func (d *DB) QuietFeeds(gaps int, minQuiet time.Duration) ([]models.QuietFeed, error) {
+	rows, err := d.readQuery(`SELECT f.id, f.name, r.last, r.gap
+	FROM feeds f
+	CROSS JOIN LATERAL (
+		SELECT COUNT(*) AS n, MAX(published_at) AS last,
+			EXTRACT(EPOCH FROM MAX(published_at) - MIN(published_at)) / NULLIF(COUNT(*) - 1, 0) AS gap
+		FROM (
+			SELECT published_at FROM articles
+			WHERE feed_id = f.id AND published_at <= CURRENT_TIMESTAMP
+			ORDER BY published_at DESC
+			LIMIT $1
+		) recent
+	) r
+	WHERE f.deleted_at IS NULL AND f.disabled_at IS NULL AND r.n >= $2 AND r.gap > 0
+		AND r.last < CURRENT_TIMESTAMP - GREATEST($3 * r.gap, $4) * INTERVAL '1 second'
+	ORDER BY r.last`, cadenceWindow, cadenceMinimum, gaps, minQuiet.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var feeds []models.QuietFeed
+	for rows.Next() {
+		var f models.QuietFeed
+		var gap float64
+		err := rows.Scan(&f.FeedID, &f.FeedName, &f.LastPublishedAt, &gap)
+		if err != nil {
+			return nil, err
+		}
+		f.UsualGap = time.Duration(gap) * time.Second
+		feeds = append(feeds, f)
+	}
+	return feeds, rows.Err()
+}
+
+// MarkQuietReported records that a feed's silence since its newest article
+// has been reported, and reports false if it already was. A feed that
+// posts again and then falls silent is reported anew.
+func (d *DB) MarkQuietReported(feedID uuid.UUID) (bool, error) {
+	res, err := d.Exec(`UPDATE feeds SET quiet_reported_for = latest.published_at
+	FROM (SELECT MAX(published_at) AS published_at FROM articles WHERE feed_id = $1) latest
+	WHERE id = $1 AND quiet_reported_for IS DISTINCT FROM latest.published_at`, feedID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
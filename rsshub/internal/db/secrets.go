@@ -0,0 +1,169 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"rsshub/internal/models"
+	"rsshub/internal/secretbox"
+)
+
+// Secrets are credentials kept encrypted, each under a name and, for
+// those a single feed uses, that feed. The key never reaches the
+// database; rows record its ID so a wrong key is told apart from damage.
+
+// secretScope is what a secret's ciphertext is bound to, so that a row
+// copied onto another name or feed fails to decrypt.
+func secretScope(feedID uuid.NullUUID, name string) string {
+	if !feedID.Valid {
+		return name
+	}
+	return feedID.UUID.String() + "/" + name
+}
+
+// secretFeedID looks up the feed a secret belongs to; "" is none.
+func (d *DB) secretFeedID(feedName string) (uuid.NullUUID, error) {
+	if feedName == "" {
+		return uuid.NullUUID{}, nil
+	}
+	var id uuid.UUID
+	err := d.QueryRow(`SELECT id FROM feeds WHERE name = $1 AND deleted_at IS NULL`, feedName).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return uuid.NullUUID{}, fmt.Errorf("feed %q %w", feedName, ErrNotFound)
+	}
+	return uuid.NullUUID{UUID: id, Valid: err == nil}, err
+}
+
+// SetSecret encrypts value under key and stores it as the secret name of
+// feedName, or a global one when feedName is empty, replacing any value
+// it had.
+func (d *DB) SetSecret(key *secretbox.Key, feedName, name string, value []byte) error {
+	feedID, err := d.secretFeedID(feedName)
+	if err != nil {
+		return err
+	}
+	nonce, ciphertext, err := key.Seal(secretScope(feedID, name), value)
+	if err != nil {
+		return err
+	}
+	_, err = d.Exec(`INSERT INTO secrets (name, feed_id, key_id, nonce, ciphertext) VALUES ($1, $2, $3, $4, $5)
+	ON CONFLICT ((COALESCE(feed_id::text, '')), name) DO UPDATE SET
+		key_id = EXCLUDED.key_id, nonce = EXCLUDED.nonce, ciphertext = EXCLUDED.ciphertext, updated_at = CURRENT_TIMESTAMP`,
+		name, feedID, key.ID(), nonce, ciphertext)
+	return err
+}
+
+// Secret returns the decrypted value of the secret name of feedName, or
+// of the global one when feedName is empty.
+func (d *DB) Secret(key *secretbox.Key, feedName, name string) ([]byte, error) {
+	feedID, err := d.secretFeedID(feedName)
+	if err != nil {
+		return nil, err
+	}
+	var keyID string
+	var nonce, ciphertext []byte
+	err = d.QueryRow(`SELECT key_id, nonce, ciphertext FROM secrets
+	WHERE name = $1 AND feed_id IS NOT DISTINCT FROM $2`, name, feedID).Scan(&keyID, &nonce, &ciphertext)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("secret %q %w", name, ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return key.Open(secretScope(feedID, name), keyID, nonce, ciphertext)
+}
+
+// DeleteSecret removes the secret name of feedName, or the global one
+// when feedName is empty.
+func (d *DB) DeleteSecret(feedName, name string) error {
+	feedID, err := d.secretFeedID(feedName)
+	if err != nil {
+		return err
+	}
+	res, err := d.Exec(`DELETE FROM secrets WHERE name = $1 AND feed_id IS NOT DISTINCT FROM $2`, name, feedID)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err == nil && n == 0 {
+		return fmt.Errorf("secret %q %w", name, ErrNotFound)
+	}
+	return err
+}
+
+// ListSecrets describes every stored secret, global ones first.
+func (d *DB) ListSecrets() ([]models.Secret, error) {
+	rows, err := d.Query(`SELECT s.name, COALESCE(f.name, ''), s.key_id, s.updated_at
+	FROM secrets s LEFT JOIN feeds f ON f.id = s.feed_id
+	ORDER BY f.name NULLS FIRST, s.name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var secrets []models.Secret
+	for rows.Next() {
+		var s models.Secret
+		if err := rows.Scan(&s.Name, &s.FeedName, &s.KeyID, &s.UpdatedAt); err != nil {
+			return nil, err
+		}
+		secrets = append(secrets, s)
+	}
+	return secrets, rows.Err()
+}
+
+// RotateSecretsKey re-encrypts every secret from oldKey to newKey in one
+// transaction and returns how many it re-encrypted. Secrets already under
+// newKey, as after an interrupted rotation, are left as they are; one
+// under neither key fails the whole rotation.
+func (d *DB) RotateSecretsKey(oldKey, newKey *secretbox.Key) (int, error) {
+	tx, err := d.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	type sealed struct {
+		id                uuid.UUID
+		name, keyID       string
+		feedID            uuid.NullUUID
+		nonce, ciphertext []byte
+	}
+	rows, err := tx.Query(`SELECT id, name, feed_id, key_id, nonce, ciphertext FROM secrets WHERE key_id <> $1 FOR UPDATE`, newKey.ID())
+	if err != nil {
+		return 0, err
+	}
+	var pending []sealed
+	for rows.Next() {
+		var s sealed
+		if err := rows.Scan(&s.id, &s.name, &s.feedID, &s.keyID, &s.nonce, &s.ciphertext); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		pending = append(pending, s)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, s := range pending {
+		scope := secretScope(s.feedID, s.name)
+		value, err := oldKey.Open(scope, s.keyID, s.nonce, s.ciphertext)
+		if err != nil {
+			return 0, err
+		}
+		nonce, ciphertext, err := newKey.Seal(scope, value)
+		if err != nil {
+			return 0, err
+		}
+		_, err = tx.Exec(`UPDATE secrets SET key_id = $2, nonce = $3, ciphertext = $4, updated_at = CURRENT_TIMESTAMP WHERE id = $1`,
+			s.id, newKey.ID(), nonce, ciphertext)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return len(pending), tx.Commit()
+}
@@ -0,0 +1,59 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	"rsshub/internal/models"
+)
+
+// ShareFeed adds a live feed to a user's public subscription list. Sharing
+// a feed twice is not an error.
+func (d *DB) ShareFeed(userID uuid.UUID, feedName string) error {
+	feed, err := d.GetFeedByName(feedName)
+	if err != nil {
+		return err
+	}
+	_, err = d.Exec(`INSERT INTO shared_feeds (user_id, feed_id) VALUES ($1, $2)
+	ON CONFLICT DO NOTHING`, userID, feed.ID)
+	return err
+}
+
+// UnshareFeed takes a feed off a user's public subscription list.
+func (d *DB) UnshareFeed(userID uuid.UUID, feedName string) error {
+	res, err := d.Exec(`DELETE FROM shared_feeds WHERE user_id = $1 AND feed_id = (
+		SELECT id FROM feeds WHERE name = $2 AND deleted_at IS NULL)`, userID, feedName)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err == nil && n == 0 {
+		return fmt.Errorf("shared feed %q %w", feedName, ErrNotFound)
+	}
+	return err
+}
+
+// SharedFeeds returns the live feeds a user has shared, by name. Feeds
+// deleted since come back if they are restored.
+func (d *DB) SharedFeeds(userID uuid.UUID) ([]models.Feed, error) {
+	rows, err := d.readQuery(`SELECT f.id, f.created_at, f.name, f.url
+	FROM shared_feeds s
+	JOIN feeds f ON f.id = s.feed_id
+	WHERE s.user_id = $1 AND f.deleted_at IS NULL
+	ORDER BY lower(f.name)`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var feeds []models.Feed
+	for rows.Next() {
+		var f models.Feed
+		err := rows.Scan(&f.ID, &f.CreatedAt, &f.Name, &f.URL)
+		if err != nil {
+			return nil, err
+		}
+		feeds = append(feeds, f)
+	}
+	return feeds, rows.Err()
+}
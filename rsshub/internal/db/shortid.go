@@ -0,0 +1,119 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"rsshub/internal/models"
+)
+
+// Articles can be named on the command line by a prefix of their ID, as
+// git names commits: listings show the shortest prefix of at least eight
+// hex digits that only one article has, and any such prefix of at least
+// MinShortID digits is accepted. Dashes are ignored, so a full ID works
+// too.
+
+// MinShortID is the shortest article ID prefix accepted.
+const MinShortID = 4
+
+var (
+	ErrInvalidID = errors.New("is not an article ID or a prefix of one")
+	ErrAmbiguous = errors.New("matches more than one article")
+)
+
+// ResolveArticleID returns the ID of the article ref names, ref being a
+// full ID or a prefix of one. A full ID is returned as is, whether or not
+// the article exists.
+func (d *DB) ResolveArticleID(ref string) (uuid.UUID, error) {
+	if id, err := uuid.Parse(ref); err == nil {
+		return id, nil
+	}
+	lo, hi, err := articleIDRange(ref)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	rows, err := d.Query(`SELECT id FROM articles WHERE id BETWEEN $1 AND $2 LIMIT 2`, lo, hi)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return uuid.Nil, err
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return uuid.Nil, err
+	}
+	switch len(ids) {
+	case 0:
+		return uuid.Nil, fmt.Errorf("article %s %w", ref, ErrNotFound)
+	case 1:
+		return ids[0], nil
+	}
+	return uuid.Nil, fmt.Errorf("article ID %s %w; give more of its digits", ref, ErrAmbiguous)
+}
+
+// articleIDRange returns the lowest and highest IDs starting with prefix,
+// which an index on articles(id) can look up.
+func articleIDRange(prefix string) (lo, hi uuid.UUID, err error) {
+	hex := strings.ToLower(strings.ReplaceAll(prefix, "-", ""))
+	if len(hex) < MinShortID || len(hex) > 32 || strings.Trim(hex, "0123456789abcdef") != "" {
+		return uuid.Nil, uuid.Nil, fmt.Errorf("%q %w (at least %d hex digits)", prefix, ErrInvalidID, MinShortID)
+	}
+	lo, err = uuid.Parse(hex + strings.Repeat("0", 32-len(hex)))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+	hi, err = uuid.Parse(hex + strings.Repeat("f", 32-len(hex)))
+	return lo, hi, err
+}
+
+// shortenIDs sets each article's ShortID, comparing its ID with the IDs
+// sorting just before and after it, the ones sharing most of its digits.
+func (d *DB) shortenIDs(articles []models.Article) error {
+	if len(articles) == 0 {
+		return nil
+	}
+	ids := make([]uuid.UUID, len(articles))
+	for i, a := range articles {
+		ids[i] = a.ID
+	}
+	rows, err := d.readQuery(`SELECT i.id,
+		(SELECT a.id FROM articles a WHERE a.id < i.id ORDER BY a.id DESC LIMIT 1),
+		(SELECT a.id FROM articles a WHERE a.id > i.id ORDER BY a.id LIMIT 1)
+	FROM unnest($1::uuid[]) AS i(id)`, uuidStrings(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	short := make(map[uuid.UUID]string, len(ids))
+	for rows.Next() {
+		var id uuid.UUID
+		var before, after uuid.NullUUID
+		if err := rows.Scan(&id, &before, &after); err != nil {
+			return err
+		}
+		var neighbours []uuid.UUID
+		for _, n := range []uuid.NullUUID{before, after} {
+			if n.Valid {
+				neighbours = append(neighbours, n.UUID)
+			}
+		}
+		short[id] = models.UniqueShortID(id, neighbours...)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for i := range articles {
+		articles[i].ShortID = short[articles[i].ID]
+	}
+	return nil
+}
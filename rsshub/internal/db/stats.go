@@ -0,0 +1,191 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+
+	"rsshub/internal/models"
+)
+
+// recordInsertStats is appended to an "inserted AS (INSERT ... RETURNING
+// feed_id, published_at)" CTE so the aggregate tables are bumped in the same
+// statement as the insert. The whole query yields the number of rows
+// inserted; with recordInsertStatsByFeed, the number per feed.
+const (
+	recordInsertStats       = insertStats + `SELECT COUNT(*) FROM inserted`
+	recordInsertStatsByFeed = insertStats + `SELECT feed_id, COUNT(*) FROM inserted GROUP BY feed_id`
+)
+
+const insertStats = `,
+	stats AS (
+		INSERT INTO feed_stats (feed_id, article_count, last_published_at)
+		SELECT feed_id, COUNT(*), MAX(published_at) FROM inserted GROUP BY feed_id
+		ON CONFLICT (feed_id) DO UPDATE SET
+			article_count = feed_stats.article_count + EXCLUDED.article_count,
+			last_published_at = GREATEST(feed_stats.last_published_at, EXCLUDED.last_published_at)
+	),
+	daily AS (
+		INSERT INTO feed_daily_counts (feed_id, day, article_count)
+		SELECT feed_id, published_at::date, COUNT(*) FROM inserted GROUP BY feed_id, published_at::date
+		ON CONFLICT (feed_id, day) DO UPDATE SET
+			article_count = feed_daily_counts.article_count + EXCLUDED.article_count
+	)
+	`
+
+// RebuildStats recomputes the aggregate tables from articles. It is needed
+// after bulk removals that bypass the insert path, such as dropping expired
+// partitions.
+func (d *DB) RebuildStats() error {
+	tx, err := d.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	queries := []string{
+		`DELETE FROM feed_stats`,
+		`DELETE FROM feed_daily_counts`,
+		`INSERT INTO feed_stats (feed_id, article_count, last_published_at)
+		SELECT feed_id, COUNT(*), MAX(published_at) FROM articles GROUP BY feed_id`,
+		`INSERT INTO feed_daily_counts (feed_id, day, article_count)
+		SELECT feed_id, published_at::date, COUNT(*) FROM articles GROUP BY feed_id, published_at::date`,
+	}
+	for _, q := range queries {
+		_, err = tx.Exec(q)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// statsMissing reports whether articles exist that the aggregate tables
+// have never seen, which is the case right after they are introduced.
+func (d *DB) statsMissing() (bool, error) {
+	var missing bool
+	err := d.QueryRow(`SELECT NOT EXISTS (SELECT 1 FROM feed_stats) AND EXISTS (SELECT 1 FROM articles)`).Scan(&missing)
+	return missing, err
+}
+
+// GetFeedStats returns per-feed article counts, most active feeds first.
+func (d *DB) GetFeedStats() ([]models.FeedStats, error) {
+	rows, err := d.readQuery(`SELECT f.name, COALESCE(s.article_count, 0), s.last_published_at
+	FROM feeds f
+	LEFT JOIN feed_stats s ON s.feed_id = f.id
+	WHERE f.deleted_at IS NULL
+	ORDER BY COALESCE(s.article_count, 0) DESC, f.name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stats []models.FeedStats
+	for rows.Next() {
+		var s models.FeedStats
+		var last sql.NullTime
+		err := rows.Scan(&s.FeedName, &s.ArticleCount, &last)
+		if err != nil {
+			return nil, err
+		}
+		if last.Valid {
+			s.LastPublishedAt = last.Time
+		}
+		stats = append(stats, s)
+	}
+	return stats, rows.Err()
+}
+
+// GetDailyCounts returns the number of articles published on each of the
+// last days days across all feeds, oldest first. Days without articles are
+// included with a zero count.
+func (d *DB) GetDailyCounts(days int) ([]models.DailyCount, error) {
+	rows, err := d.readQuery(`SELECT g.day::date, COALESCE(SUM(c.article_count), 0)
+	FROM generate_series(CURRENT_DATE - ($1 - 1) * INTERVAL '1 day', CURRENT_DATE, INTERVAL '1 day') AS g(day)
+	LEFT JOIN feed_daily_counts c ON c.day = g.day::date
+	GROUP BY g.day
+	ORDER BY g.day`, days)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var counts []models.DailyCount
+	for rows.Next() {
+		var c models.DailyCount
+		err := rows.Scan(&c.Day, &c.Count)
+		if err != nil {
+			return nil, err
+		}
+		counts = append(counts, c)
+	}
+	return counts, rows.Err()
+}
+
+// GetAuthorStats counts articles per author, most prolific first, in all
+// feeds or only feedName's, up to limit authors (0 for all). Names that
+// differ only in case are one author, as for the author filter.
+func (d *DB) GetAuthorStats(feedName string, limit int) ([]models.AuthorStats, error) {
+	rows, err := d.readQuery(`SELECT MIN(a.author), COUNT(*), COUNT(DISTINCT a.feed_id), MAX(a.published_at)
+	FROM articles a
+	JOIN feeds f ON f.id = a.feed_id AND f.deleted_at IS NULL
+	WHERE a.author IS NOT NULL AND ($1 = '' OR f.name = $1)
+	GROUP BY lower(a.author)
+	ORDER BY COUNT(*) DESC, MIN(a.author)
+	LIMIT NULLIF($2, 0)`, feedName, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stats []models.AuthorStats
+	for rows.Next() {
+		var s models.AuthorStats
+		err := rows.Scan(&s.Author, &s.Articles, &s.Feeds, &s.LastPublishedAt)
+		if err != nil {
+			return nil, err
+		}
+		stats = append(stats, s)
+	}
+	return stats, rows.Err()
+}
+
+// ArticlesPublishedOn returns the articles published on day, a UTC date
+// as in feed_daily_counts, grouped by feed in name order, each feed's in
+// publication order.
+func (d *DB) ArticlesPublishedOn(day time.Time) ([]models.FeedArticles, error) {
+	rows, err := d.readQuery(`SELECT f.name, `+articleColumns+`
+	FROM articles a
+	JOIN feeds f ON f.id = a.feed_id AND f.deleted_at IS NULL
+	WHERE a.published_at >= $1::date AND a.published_at < $1::date + 1
+	ORDER BY f.name, a.published_at, a.id`, day.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var feeds []models.FeedArticles
+	for rows.Next() {
+		var name string
+		a, err := scanArticle(leading{rows, []any{&name}})
+		if err != nil {
+			return nil, err
+		}
+		if len(feeds) == 0 || feeds[len(feeds)-1].FeedName != name {
+			feeds = append(feeds, models.FeedArticles{FeedName: name})
+		}
+		last := &feeds[len(feeds)-1]
+		last.Articles = append(last.Articles, a)
+	}
+	return feeds, rows.Err()
+}
+
+// leading scans a row's first columns into extra and the rest into what
+// Scan is given, so scanArticle can read rows that lead with more.
+type leading struct {
+	rows  *sql.Rows
+	extra []any
+}
+
+func (l leading) Scan(dest ...any) error {
+	return l.rows.Scan(append(l.extra, dest...)...)
+}
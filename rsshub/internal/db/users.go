@@ -0,0 +1,165 @@
+package db
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"rsshub/internal/models"
+)
+
+const userColumns = `id, created_at, name, password_hash IS NOT NULL, COALESCE(oidc_subject, '')`
+
+func scanUser(row interface{ Scan(...any) error }) (models.User, error) {
+	var u models.User
+	err := row.Scan(&u.ID, &u.CreatedAt, &u.Name, &u.HasPassword, &u.OIDCSubject)
+	return u, err
+}
+
+// CreateUser adds a user. passwordHash may be empty for users who only sign
+// in through OIDC.
+func (d *DB) CreateUser(name, passwordHash, oidcSubject string) (models.User, error) {
+	u, err := scanUser(d.QueryRow(`INSERT INTO users (name, password_hash, oidc_subject)
+	VALUES ($1, NULLIF($2, ''), NULLIF($3, ''))
+	RETURNING `+userColumns, name, passwordHash, oidcSubject))
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return u, fmt.Errorf("user %q %w", name, ErrExists)
+	}
+	return u, err
+}
+
+func (d *DB) GetUserByName(name string) (models.User, error) {
+	u, err := scanUser(d.QueryRow(`SELECT `+userColumns+` FROM users WHERE name = $1`, name))
+	if err == sql.ErrNoRows {
+		return u, fmt.Errorf("user %q %w", name, ErrNotFound)
+	}
+	return u, err
+}
+
+func (d *DB) GetUserByOIDCSubject(subject string) (models.User, error) {
+	u, err := scanUser(d.QueryRow(`SELECT `+userColumns+` FROM users WHERE oidc_subject = $1`, subject))
+	if err == sql.ErrNoRows {
+		return u, fmt.Errorf("OIDC user %w", ErrNotFound)
+	}
+	return u, err
+}
+
+// PasswordHash returns the user's stored password hash, or "" if the user
+// has no password.
+func (d *DB) PasswordHash(userID uuid.UUID) (string, error) {
+	var hash sql.NullString
+	err := d.QueryRow(`SELECT password_hash FROM users WHERE id = $1`, userID).Scan(&hash)
+	if err == sql.ErrNoRows {
+		return "", fmt.Errorf("user %w", ErrNotFound)
+	}
+	return hash.String, err
+}
+
+func (d *DB) ListUsers() ([]models.User, error) {
+	rows, err := d.Query(`SELECT ` + userColumns + ` FROM users ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []models.User
+	for rows.Next() {
+		u, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+	return users, rows.Err()
+}
+
+// SetPassword replaces a user's password hash and signs out their existing
+// sessions.
+func (d *DB) SetPassword(name, passwordHash string) error {
+	tx, err := d.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var id uuid.UUID
+	err = tx.QueryRow(`UPDATE users SET password_hash = $2 WHERE name = $1 RETURNING id`, name, passwordHash).Scan(&id)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("user %q %w", name, ErrNotFound)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`DELETE FROM sessions WHERE user_id = $1`, id)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeleteUser removes a user and, through the foreign key, their sessions.
+func (d *DB) DeleteUser(name string) error {
+	res, err := d.Exec(`DELETE FROM users WHERE name = $1`, name)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err == nil && n == 0 {
+		return fmt.Errorf("user %q %w", name, ErrNotFound)
+	}
+	return err
+}
+
+// CreateSession signs a user in for ttl and returns the session token for
+// the cookie. Only its hash is stored, so a leaked database does not leak
+// live sessions. Expired sessions are cleaned up on the way.
+func (d *DB) CreateSession(userID uuid.UUID, ttl time.Duration) (string, error) {
+	raw := make([]byte, 32)
+	_, err := rand.Read(raw)
+	if err != nil {
+		return "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+
+	_, err = d.Exec(`DELETE FROM sessions WHERE expires_at < CURRENT_TIMESTAMP`)
+	if err != nil {
+		return "", err
+	}
+	_, err = d.Exec(`INSERT INTO sessions (token_hash, user_id, expires_at)
+	VALUES ($1, $2, CURRENT_TIMESTAMP + $3 * INTERVAL '1 second')`,
+		sessionHash(token), userID, int64(ttl.Seconds()))
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// SessionUser returns the user signed in with token, failing with
+// ErrNotFound for unknown or expired sessions.
+func (d *DB) SessionUser(token string) (models.User, error) {
+	u, err := scanUser(d.QueryRow(`SELECT u.id, u.created_at, u.name, u.password_hash IS NOT NULL, COALESCE(u.oidc_subject, '')
+	FROM sessions s JOIN users u ON u.id = s.user_id
+	WHERE s.token_hash = $1 AND s.expires_at > CURRENT_TIMESTAMP`, sessionHash(token)))
+	if err == sql.ErrNoRows {
+		return u, fmt.Errorf("session %w", ErrNotFound)
+	}
+	return u, err
+}
+
+func (d *DB) DeleteSession(token string) error {
+	_, err := d.Exec(`DELETE FROM sessions WHERE token_hash = $1`, sessionHash(token))
+	return err
+}
+
+func sessionHash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
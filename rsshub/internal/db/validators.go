@@ -0,0 +1,33 @@
+package db
+
+import (
+	"database/sql"
+
+	"github.com/google/uuid"
+	"rsshub/internal/models"
+)
+
+// FeedValidators returns what identified a feed's last successful fetch,
+// or zero values if nothing was recorded.
+func (d *DB) FeedValidators(feedID uuid.UUID) (models.FeedValidators, error) {
+	var v models.FeedValidators
+	err := d.QueryRow(`SELECT etag, last_modified, body_hash FROM feed_validators WHERE feed_id = $1`, feedID).
+		Scan(&v.ETag, &v.LastModified, &v.BodyHash)
+	if err == sql.ErrNoRows {
+		return v, nil
+	}
+	return v, err
+}
+
+// SetFeedValidators records what identified a feed's latest successful
+// fetch, so the next one can skip an unchanged feed.
+func (d *DB) SetFeedValidators(feedID uuid.UUID, v models.FeedValidators) error {
+	_, err := d.Exec(`INSERT INTO feed_validators (feed_id, etag, last_modified, body_hash)
+	VALUES ($1, $2, $3, $4)
+	ON CONFLICT (feed_id) DO UPDATE SET
+		etag = EXCLUDED.etag,
+		last_modified = EXCLUDED.last_modified,
+		body_hash = EXCLUDED.body_hash,
+		updated_at = CURRENT_TIMESTAMP`, feedID, v.ETag, v.LastModified, v.BodyHash)
+	return err
+}
@@ -0,0 +1,204 @@
+// Package download saves podcast enclosures to disk. Transfers go to a
+// ".part" file first and are resumed with a Range request when a previous
+// attempt was interrupted.
+package download
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DefaultTemplate files episodes in one directory per feed, named by
+// publication date and title.
+const DefaultTemplate = `{{.Feed}}/{{.Date}} {{.Title}}{{.Ext}}`
+
+// Episode is the data passed to filename templates. Feed and Title are
+// already made safe to use as path segments.
+type Episode struct {
+	Feed      string
+	Title     string
+	Published time.Time
+	// Date is Published as YYYY-MM-DD.
+	Date    string
+	Season  int
+	Episode int
+	ID      string
+	// Ext is the file extension, with its dot, taken from the URL or else
+	// the enclosure's MIME type; empty if neither gives one.
+	Ext string
+}
+
+// NewEpisode builds template data for an enclosure.
+func NewEpisode(feed, title string, published time.Time, season, episode int, id, enclosureURL, mimeType string) Episode {
+	return Episode{
+		Feed:      segment(feed),
+		Title:     segment(title),
+		Published: published,
+		Date:      published.UTC().Format("2006-01-02"),
+		Season:    season,
+		Episode:   episode,
+		ID:        id,
+		Ext:       extension(enclosureURL, mimeType),
+	}
+}
+
+// ParseTemplate parses a filename template. Slashes in its output create
+// subdirectories.
+func ParseTemplate(text string) (*template.Template, error) {
+	return template.New("filename").Option("missingkey=error").Parse(text)
+}
+
+// FileName renders an episode's path relative to the download directory,
+// refusing results that are empty, absolute or climb out of it.
+func FileName(t *template.Template, e Episode) (string, error) {
+	var b strings.Builder
+	if err := t.Execute(&b, e); err != nil {
+		return "", err
+	}
+	name := filepath.Clean(filepath.FromSlash(strings.TrimSpace(b.String())))
+	if !filepath.IsLocal(name) {
+		return "", fmt.Errorf("filename template gave %q, which is not a path inside the destination", b.String())
+	}
+	return name, nil
+}
+
+// maxSegment keeps names under common filesystem limits of 255 bytes,
+// leaving room for the date and extension.
+const maxSegment = 200
+
+// segment makes s usable as a single path element on common filesystems.
+func segment(s string) string {
+	s = strings.Map(func(r rune) rune {
+		switch {
+		case r == '/' || r == '\\' || r == ':' || r == '*' || r == '?' || r == '"' || r == '<' || r == '>' || r == '|':
+			return '-'
+		case unicode.IsControl(r):
+			return -1
+		}
+		return r
+	}, s)
+	s = strings.Join(strings.Fields(s), " ")
+	for len(s) > maxSegment {
+		_, size := utf8.DecodeLastRuneInString(s)
+		s = s[:len(s)-size]
+	}
+	s = strings.Trim(s, " .")
+	if s == "" {
+		return "untitled"
+	}
+	return s
+}
+
+// extension picks a file extension for an enclosure.
+func extension(enclosureURL, mimeType string) string {
+	if u, err := url.Parse(enclosureURL); err == nil {
+		if ext := path.Ext(u.Path); len(ext) > 1 && len(ext) <= 6 && isAlnum(ext[1:]) {
+			return strings.ToLower(ext)
+		}
+	}
+	if mimeType != "" {
+		if exts, _ := mime.ExtensionsByType(mimeType); len(exts) > 0 {
+			return exts[0]
+		}
+	}
+	return ""
+}
+
+func isAlnum(s string) bool {
+	for _, r := range s {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// Fetch downloads rawURL to dest and returns the file's size. Data is
+// written to dest+".part", which is renamed into place once complete; a
+// ".part" file left by an earlier attempt is continued where the server
+// supports ranges and restarted where it does not.
+func Fetch(ctx context.Context, client *http.Client, rawURL, dest string) (int64, error) {
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return 0, err
+	}
+	part := dest + ".part"
+	var offset int64
+	if fi, err := os.Stat(part); err == nil {
+		offset = fi.Size()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return 0, err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	flags := os.O_WRONLY | os.O_CREATE
+	switch {
+	case resp.StatusCode == http.StatusPartialContent && offset > 0:
+		start, err := rangeStart(resp.Header.Get("Content-Range"))
+		if err != nil || start != offset {
+			return 0, fmt.Errorf("server resumed at an unexpected offset (%q)", resp.Header.Get("Content-Range"))
+		}
+		flags |= os.O_APPEND
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		// The part file already holds the whole enclosure.
+		return offset, os.Rename(part, dest)
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		offset = 0
+		flags |= os.O_TRUNC
+	default:
+		return 0, fmt.Errorf("GET %s: %s", rawURL, resp.Status)
+	}
+
+	f, err := os.OpenFile(part, flags, 0o644)
+	if err != nil {
+		return 0, err
+	}
+	n, err := io.Copy(f, resp.Body)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return offset + n, err
+	}
+	if resp.ContentLength >= 0 && n != resp.ContentLength {
+		return offset + n, fmt.Errorf("GET %s: got %d of %d bytes", rawURL, n, resp.ContentLength)
+	}
+	return offset + n, os.Rename(part, dest)
+}
+
+// rangeStart returns the first byte position of a "bytes start-end/size"
+// Content-Range header.
+func rangeStart(h string) (int64, error) {
+	spec, ok := strings.CutPrefix(h, "bytes ")
+	if !ok {
+		return 0, errors.New("not a byte range")
+	}
+	start, _, ok := strings.Cut(spec, "-")
+	if !ok {
+		return 0, errors.New("malformed byte range")
+	}
+	return strconv.ParseInt(start, 10, 64)
+}
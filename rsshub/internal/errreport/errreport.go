@@ -0,0 +1,206 @@
+// Package errreport sends panics and persistent errors to Sentry, or any
+// service speaking its store API, so unattended deployments hear about
+// systemic failures. A nil *Reporter is valid and reports nothing.
+package errreport
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+
+	"rsshub/internal/config"
+	"rsshub/internal/logging"
+	"rsshub/internal/version"
+)
+
+// sendTimeout bounds each report. Reports never block the caller except
+// for panics, which are sent before the process goes down.
+const sendTimeout = 10 * time.Second
+
+// Reporter posts events to one Sentry project.
+type Reporter struct {
+	endpoint    string
+	auth        string
+	environment string
+	serverName  string
+	client      *http.Client
+	log         logging.Logger
+}
+
+// ParseDSN checks a Sentry DSN of the form
+// https://<key>@<host>[/<path>]/<project> and returns the store endpoint
+// and public key it names.
+func ParseDSN(dsn string) (endpoint, key string, err error) {
+	u, err := url.Parse(dsn)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.User == nil {
+		return "", "", errors.New("must be a DSN like https://<key>@<host>/<project>")
+	}
+	path := strings.Trim(u.Path, "/")
+	i := strings.LastIndex(path, "/")
+	project := path[i+1:]
+	if project == "" {
+		return "", "", errors.New("DSN has no project ID")
+	}
+	prefix := ""
+	if i >= 0 {
+		prefix = "/" + path[:i]
+	}
+	return fmt.Sprintf("%s://%s%s/api/%s/store/", u.Scheme, u.Host, prefix, project), u.User.Username(), nil
+}
+
+// New returns a reporter for the configured DSN, or nil if none is set.
+func New(cfg *config.Config) (*Reporter, error) {
+	if cfg.SentryDSN == "" {
+		return nil, nil
+	}
+	endpoint, key, err := ParseDSN(cfg.SentryDSN)
+	if err != nil {
+		return nil, err
+	}
+	hostname, _ := os.Hostname()
+	return &Reporter{
+		endpoint:    endpoint,
+		auth:        fmt.Sprintf("Sentry sentry_version=7, sentry_key=%s, sentry_client=rsshub/%s", key, version.Version),
+		environment: cfg.SentryEnvironment,
+		serverName:  hostname,
+		client:      &http.Client{Timeout: sendTimeout},
+		log:         logging.New(cfg),
+	}, nil
+}
+
+// Event is what a report says. Tags are indexed and searchable in Sentry;
+// Extra is free-form context shown with the event.
+type Event struct {
+	Level   string
+	Message string
+	Tags    map[string]string
+	Extra   map[string]any
+}
+
+// Error reports ev in the background.
+func (r *Reporter) Error(ev Event) {
+	if r == nil {
+		return
+	}
+	if ev.Level == "" {
+		ev.Level = "error"
+	}
+	go func() {
+		if err := r.send(r.payload(ev, nil)); err != nil {
+			r.log.Errorf("Error reporting to Sentry: %v", err)
+		}
+	}()
+}
+
+// Recover reports a panic in progress and then resumes it, so the process
+// still crashes as it would have. Call it deferred at the top of a
+// goroutine:
+//
+//	defer reporter.Recover("worker")
+func (r *Reporter) Recover(where string) {
+	if r == nil {
+		return
+	}
+	v := recover()
+	if v == nil {
+		return
+	}
+	ev := Event{Level: "fatal", Message: fmt.Sprint(v), Tags: map[string]string{"where": where}}
+	if err := r.send(r.payload(ev, frames())); err != nil {
+		r.log.Errorf("Error reporting panic to Sentry: %v", err)
+	}
+	panic(v)
+}
+
+type frame struct {
+	Function string `json:"function"`
+	Filename string `json:"filename"`
+	Lineno   int    `json:"lineno"`
+	InApp    bool   `json:"in_app"`
+}
+
+// frames returns the panicking goroutine's stack, oldest call first as
+// Sentry expects, skipping the runtime's panic machinery and Recover.
+func frames() []frame {
+	pc := make([]uintptr, 64)
+	n := runtime.Callers(4, pc)
+	it := runtime.CallersFrames(pc[:n])
+	var out []frame
+	for {
+		f, more := it.Next()
+		out = append([]frame{{
+			Function: f.Function,
+			Filename: f.File,
+			Lineno:   f.Line,
+			InApp:    strings.HasPrefix(f.Function, "rsshub/") || strings.HasPrefix(f.Function, "main."),
+		}}, out...)
+		if !more {
+			break
+		}
+	}
+	return out
+}
+
+func (r *Reporter) payload(ev Event, stack []frame) map[string]any {
+	id := make([]byte, 16)
+	rand.Read(id)
+	p := map[string]any{
+		"event_id":    hex.EncodeToString(id),
+		"timestamp":   time.Now().UTC().Format(time.RFC3339),
+		"platform":    "go",
+		"level":       ev.Level,
+		"logger":      "rsshub",
+		"release":     "rsshub@" + version.Get().Version,
+		"server_name": r.serverName,
+		"message":     map[string]string{"formatted": ev.Message},
+		"tags":        ev.Tags,
+		"extra":       ev.Extra,
+	}
+	if r.environment != "" {
+		p["environment"] = r.environment
+	}
+	if stack != nil {
+		p["exception"] = map[string]any{"values": []map[string]any{{
+			"type":       "panic",
+			"value":      ev.Message,
+			"stacktrace": map[string]any{"frames": stack},
+		}}}
+	}
+	return p
+}
+
+func (r *Reporter) send(payload map[string]any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Sentry-Auth", r.auth)
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
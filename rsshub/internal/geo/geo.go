@@ -0,0 +1,95 @@
+// Package geo parses the coordinates and distances used to filter
+// articles by location.
+package geo
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"rsshub/internal/models"
+)
+
+// earthRadiusKM is the mean radius used for great-circle distances, the
+// same figure the SQL filter uses.
+const earthRadiusKM = 6371.0
+
+// ParsePoint parses "lat,lon" or "lat lon" in decimal degrees, as given on
+// the command line or in a georss:point.
+func ParsePoint(s string) (models.GeoPoint, error) {
+	fields := strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' || r == '\n' })
+	if len(fields) != 2 {
+		return models.GeoPoint{}, fmt.Errorf("invalid point %q (use lat,lon in decimal degrees)", s)
+	}
+	lat, err1 := strconv.ParseFloat(fields[0], 64)
+	lon, err2 := strconv.ParseFloat(fields[1], 64)
+	if err1 != nil || err2 != nil {
+		return models.GeoPoint{}, fmt.Errorf("invalid point %q (use lat,lon in decimal degrees)", s)
+	}
+	p := models.GeoPoint{Lat: lat, Lon: lon}
+	return p, Check(p)
+}
+
+// Check reports whether p lies within the valid coordinate ranges.
+func Check(p models.GeoPoint) error {
+	if math.IsNaN(p.Lat) || p.Lat < -90 || p.Lat > 90 {
+		return fmt.Errorf("latitude %g is outside -90..90", p.Lat)
+	}
+	if math.IsNaN(p.Lon) || p.Lon < -180 || p.Lon > 180 {
+		return fmt.Errorf("longitude %g is outside -180..180", p.Lon)
+	}
+	return nil
+}
+
+// ParseDistance parses a radius such as "50km", "500m" or "10mi" and
+// returns it in kilometres. A bare number is taken as kilometres.
+func ParseDistance(s string) (float64, error) {
+	str := strings.ToLower(strings.TrimSpace(s))
+	mult := 1.0
+	for _, unit := range []struct {
+		suffix string
+		mult   float64
+	}{{"km", 1}, {"mi", 1.609344}, {"m", 0.001}} {
+		if strings.HasSuffix(str, unit.suffix) {
+			str = strings.TrimSpace(strings.TrimSuffix(str, unit.suffix))
+			mult = unit.mult
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(str, 64)
+	if err != nil || n <= 0 || math.IsInf(n, 0) {
+		return 0, fmt.Errorf("invalid distance %q (use e.g. 500m, 50km or 10mi)", s)
+	}
+	return n * mult, nil
+}
+
+// Box is a latitude/longitude range enclosing a circle, used to narrow a
+// distance query to rows an index can find. Lon is nil when the circle
+// reaches a pole or crosses the antimeridian, where no simple longitude
+// range encloses it.
+type Box struct {
+	MinLat, MaxLat float64
+	Lon            *[2]float64
+}
+
+// BoundingBox returns the box enclosing every point within km of p.
+func BoundingBox(p models.GeoPoint, km float64) Box {
+	d := km / earthRadiusKM // angular radius
+	dLat := d * 180 / math.Pi
+	b := Box{MinLat: p.Lat - dLat, MaxLat: p.Lat + dLat}
+	if b.MinLat <= -90 || b.MaxLat >= 90 {
+		b.MinLat, b.MaxLat = math.Max(b.MinLat, -90), math.Min(b.MaxLat, 90)
+		return b
+	}
+	x := math.Sin(d) / math.Cos(p.Lat*math.Pi/180)
+	if x >= 1 {
+		return b
+	}
+	dLon := math.Asin(x) * 180 / math.Pi
+	if p.Lon-dLon < -180 || p.Lon+dLon > 180 {
+		return b
+	}
+	b.Lon = &[2]float64{p.Lon - dLon, p.Lon + dLon}
+	return b
+}
@@ -0,0 +1,361 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+)
+
+// Request is a GraphQL request as sent over HTTP.
+type Request struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName,omitempty"`
+	Variables     map[string]any `json:"variables,omitempty"`
+}
+
+// Response carries the result data and any errors. Data is omitted when
+// the request could not be executed at all.
+type Response struct {
+	Data   any     `json:"data,omitempty"`
+	Errors []Error `json:"errors,omitempty"`
+}
+
+// IsMutation reports whether the request runs a mutation. A request that
+// does not parse, or names no operation it has, is not one; Execute
+// reports what is wrong with it.
+func (r Request) IsMutation() bool {
+	doc, err := parse(r.Query)
+	if err != nil {
+		return false
+	}
+	op, err := selectOperation(doc, r.OperationName)
+	return err == nil && op.kind == "mutation"
+}
+
+type Error struct {
+	Message string `json:"message"`
+	Path    []any  `json:"path,omitempty"`
+}
+
+type executor struct {
+	ctx    context.Context
+	schema *Schema
+	doc    *document
+	vars   map[string]any
+	errors []Error
+}
+
+// Execute validates and runs a request against the schema.
+func (s *Schema) Execute(ctx context.Context, req Request) *Response {
+	doc, err := parse(req.Query)
+	if err != nil {
+		return &Response{Errors: []Error{{Message: "syntax error: " + err.Error()}}}
+	}
+	op, err := selectOperation(doc, req.OperationName)
+	if err != nil {
+		return &Response{Errors: []Error{{Message: err.Error()}}}
+	}
+	root := s.Query
+	if op.kind == "mutation" {
+		root = s.Mutation
+		if root == nil {
+			return &Response{Errors: []Error{{Message: "schema does not support mutations"}}}
+		}
+	}
+	vars, err := coerceVariables(op, req.Variables)
+	if err != nil {
+		return &Response{Errors: []Error{{Message: err.Error()}}}
+	}
+
+	v := &validator{doc: doc, vars: op.vars}
+	v.selections(root, op.selections, nil, map[string]bool{})
+	if len(v.errors) > 0 {
+		return &Response{Errors: v.errors}
+	}
+
+	e := &executor{ctx: ctx, schema: s, doc: doc, vars: vars}
+	data := e.selectionSet(root, op.selections, nil, nil)
+	return &Response{Data: data, Errors: e.errors}
+}
+
+func selectOperation(doc *document, name string) (*operation, error) {
+	if name == "" {
+		if len(doc.operations) > 1 {
+			return nil, fmt.Errorf("operationName is required when the document has several operations")
+		}
+		return doc.operations[0], nil
+	}
+	for _, op := range doc.operations {
+		if op.name == name {
+			return op, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown operation %q", name)
+}
+
+func coerceVariables(op *operation, given map[string]any) (map[string]any, error) {
+	vars := map[string]any{}
+	for _, def := range op.vars {
+		val, ok := given[def.name]
+		if !ok && def.hasDef {
+			val, ok = literal(def.def, nil), true
+		}
+		if (!ok || val == nil) && def.nonNull {
+			return nil, fmt.Errorf("variable $%s of type %s is required", def.name, typeRefString(def.typ))
+		}
+		if ok {
+			vars[def.name] = val
+		}
+	}
+	return vars, nil
+}
+
+func typeRefString(t *typeRef) string {
+	s := t.name
+	if t.list != nil {
+		s = "[" + typeRefString(t.list) + "]"
+	}
+	if t.nonNull {
+		s += "!"
+	}
+	return s
+}
+
+// literal converts a parsed value to plain Go data, substituting variables.
+func literal(v value, vars map[string]any) any {
+	switch v := v.(type) {
+	case variable:
+		return vars[string(v)]
+	case enumValue:
+		return string(v)
+	case []value:
+		list := make([]any, len(v))
+		for i, item := range v {
+			list[i] = literal(item, vars)
+		}
+		return list
+	case objectValue:
+		obj := map[string]any{}
+		for _, f := range v {
+			obj[f.name] = literal(f.value, vars)
+		}
+		return obj
+	}
+	return v
+}
+
+// coerceArg converts an argument value to the Go type of its scalar:
+// int, float64, string or bool.
+func coerceArg(a Arg, v any) (any, error) {
+	if v == nil {
+		if a.Required {
+			return nil, fmt.Errorf("argument %q is required", a.Name)
+		}
+		return nil, nil
+	}
+	switch a.Type {
+	case Int:
+		switch n := v.(type) {
+		case int64:
+			if n >= math.MinInt32 && n <= math.MaxInt32 {
+				return int(n), nil
+			}
+		case float64:
+			if n == math.Trunc(n) && n >= math.MinInt32 && n <= math.MaxInt32 {
+				return int(n), nil
+			}
+		}
+	case Float:
+		switch n := v.(type) {
+		case int64:
+			return float64(n), nil
+		case float64:
+			return n, nil
+		}
+	case Boolean:
+		if b, ok := v.(bool); ok {
+			return b, nil
+		}
+	case ID:
+		switch id := v.(type) {
+		case string:
+			return id, nil
+		case int64:
+			return fmt.Sprint(id), nil
+		}
+	default:
+		if s, ok := v.(string); ok {
+			return s, nil
+		}
+	}
+	return nil, fmt.Errorf("argument %q expects %s, got %v", a.Name, a.typeString(), v)
+}
+
+func (e *executor) args(f *Field, ast *field) (map[string]any, error) {
+	given := map[string]any{}
+	for _, a := range ast.args {
+		given[a.name] = literal(a.value, e.vars)
+	}
+	args := map[string]any{}
+	for _, a := range f.Args {
+		v, ok := given[a.Name]
+		if !ok || v == nil {
+			v = a.Default
+		}
+		cv, err := coerceArg(a, v)
+		if err != nil {
+			return nil, err
+		}
+		if cv != nil {
+			args[a.Name] = cv
+		}
+	}
+	return args, nil
+}
+
+func (e *executor) included(dirs []directive) bool {
+	for _, d := range dirs {
+		if d.name != "skip" && d.name != "include" {
+			continue
+		}
+		cond := false
+		for _, a := range d.args {
+			if a.name == "if" {
+				cond, _ = literal(a.value, e.vars).(bool)
+			}
+		}
+		if d.name == "skip" && cond || d.name == "include" && !cond {
+			return false
+		}
+	}
+	return true
+}
+
+// collectFields flattens fragments into an ordered list of response keys,
+// merging fields requested more than once under the same key.
+func (e *executor) collectFields(obj *Object, sels []selection, keys *[]string, fields map[string][]*field, visited map[string]bool) {
+	for _, sel := range sels {
+		switch sel := sel.(type) {
+		case *field:
+			if !e.included(sel.directives) {
+				continue
+			}
+			key := sel.responseKey()
+			if _, ok := fields[key]; !ok {
+				*keys = append(*keys, key)
+			}
+			fields[key] = append(fields[key], sel)
+		case *fragmentSpread:
+			if visited[sel.name] || !e.included(sel.directives) {
+				continue
+			}
+			visited[sel.name] = true
+			frag := e.doc.fragments[sel.name]
+			if frag.on == obj.Name {
+				e.collectFields(obj, frag.selections, keys, fields, visited)
+			}
+		case *inlineFragment:
+			if !e.included(sel.directives) || sel.on != "" && sel.on != obj.Name {
+				continue
+			}
+			e.collectFields(obj, sel.selections, keys, fields, visited)
+		}
+	}
+}
+
+func (e *executor) selectionSet(obj *Object, sels []selection, source any, path []any) *orderedMap {
+	var keys []string
+	fields := map[string][]*field{}
+	e.collectFields(obj, sels, &keys, fields, map[string]bool{})
+
+	out := &orderedMap{values: map[string]any{}}
+	for _, key := range keys {
+		asts := fields[key]
+		out.keys = append(out.keys, key)
+		fieldPath := append(append([]any{}, path...), key)
+		if asts[0].name == "__typename" {
+			out.values[key] = obj.Name
+			continue
+		}
+		def := obj.field(asts[0].name)
+		out.values[key] = e.resolveField(def, asts, source, fieldPath)
+	}
+	return out
+}
+
+func (e *executor) resolveField(def *Field, asts []*field, source any, path []any) any {
+	args, err := e.args(def, asts[0])
+	if err != nil {
+		e.errors = append(e.errors, Error{Message: err.Error(), Path: path})
+		return nil
+	}
+	var val any
+	if def.Resolve != nil {
+		val, err = def.Resolve(Params{Context: e.ctx, Source: source, Args: args})
+	} else if m, ok := source.(map[string]any); ok {
+		val = m[def.Name]
+	}
+	if err != nil {
+		e.errors = append(e.errors, Error{Message: err.Error(), Path: path})
+		return nil
+	}
+	var sels []selection
+	for _, ast := range asts {
+		sels = append(sels, ast.selections...)
+	}
+	return e.complete(def.Type, sels, val, path)
+}
+
+func (e *executor) complete(t Type, sels []selection, val any, path []any) any {
+	if val == nil {
+		return nil
+	}
+	switch t := t.(type) {
+	case *Object:
+		return e.selectionSet(t, sels, val, path)
+	case *List:
+		items, ok := val.([]any)
+		if !ok {
+			e.errors = append(e.errors, Error{Message: "internal error: list resolver returned non-list", Path: path})
+			return nil
+		}
+		out := make([]any, len(items))
+		for i, item := range items {
+			out[i] = e.complete(t.Of, sels, item, append(append([]any{}, path...), i))
+		}
+		return out
+	}
+	return val
+}
+
+// orderedMap marshals its keys in insertion order, as GraphQL requires
+// results to follow the order of the query.
+type orderedMap struct {
+	keys   []string
+	values map[string]any
+}
+
+func (m *orderedMap) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, k := range m.keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		key, err := json.Marshal(k)
+		if err != nil {
+			return nil, err
+		}
+		b.Write(key)
+		b.WriteByte(':')
+		val, err := json.Marshal(m.values[k])
+		if err != nil {
+			return nil, err
+		}
+		b.Write(val)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
@@ -0,0 +1,153 @@
+// Package graphql implements the subset of GraphQL needed to serve rsshub's
+// data model: queries and mutations with arguments, variables, aliases,
+// fragments and the @skip/@include directives, executed against a schema
+// of objects, lists and scalars declared in Go. Introspection is not
+// supported; Schema.SDL describes the schema instead.
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Type describes the shape of a field's result.
+type Type interface {
+	String() string
+}
+
+// Scalar values are serialized to JSON as returned by resolvers.
+type Scalar struct {
+	Name string
+}
+
+func (s *Scalar) String() string { return s.Name }
+
+var (
+	String  = &Scalar{Name: "String"}
+	Int     = &Scalar{Name: "Int"}
+	Float   = &Scalar{Name: "Float"}
+	Boolean = &Scalar{Name: "Boolean"}
+	ID      = &Scalar{Name: "ID"}
+	// Time is an RFC 3339 timestamp.
+	Time = &Scalar{Name: "Time"}
+)
+
+// List results must be returned by resolvers as []any.
+type List struct {
+	Of Type
+}
+
+func (l *List) String() string { return "[" + l.Of.String() + "]" }
+
+// Object is a type with fields. Fields are resolved against the value the
+// parent field resolved to.
+type Object struct {
+	Name        string
+	Description string
+	Fields      []*Field
+}
+
+func (o *Object) String() string { return o.Name }
+
+func (o *Object) field(name string) *Field {
+	for _, f := range o.Fields {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// Field is one field of an Object. A nil Resolve reads Name from a
+// map[string]any source.
+type Field struct {
+	Name        string
+	Description string
+	Type        Type
+	Args        []Arg
+	Resolve     func(p Params) (any, error)
+}
+
+// Arg declares a scalar argument. Missing optional arguments take Default.
+type Arg struct {
+	Name     string
+	Type     *Scalar
+	Required bool
+	Default  any
+}
+
+func (a Arg) typeString() string {
+	if a.Required {
+		return a.Type.Name + "!"
+	}
+	return a.Type.Name
+}
+
+// Params is passed to resolvers.
+type Params struct {
+	Context context.Context
+	Source  any
+	Args    map[string]any
+}
+
+// Schema is the root of a GraphQL API. Mutation may be nil.
+type Schema struct {
+	Query    *Object
+	Mutation *Object
+}
+
+// SDL renders the schema in GraphQL schema definition language.
+func (s *Schema) SDL() string {
+	var b strings.Builder
+	seen := map[string]bool{}
+	var visit func(o *Object)
+	visit = func(o *Object) {
+		if o == nil || seen[o.Name] {
+			return
+		}
+		seen[o.Name] = true
+		if o.Description != "" {
+			fmt.Fprintf(&b, "\"\"\"%s\"\"\"\n", o.Description)
+		}
+		fmt.Fprintf(&b, "type %s {\n", o.Name)
+		for _, f := range o.Fields {
+			if f.Description != "" {
+				fmt.Fprintf(&b, "  \"%s\"\n", f.Description)
+			}
+			fmt.Fprintf(&b, "  %s", f.Name)
+			if len(f.Args) > 0 {
+				var args []string
+				for _, a := range f.Args {
+					arg := a.Name + ": " + a.typeString()
+					if a.Default != nil {
+						arg += fmt.Sprintf(" = %v", a.Default)
+					}
+					args = append(args, arg)
+				}
+				fmt.Fprintf(&b, "(%s)", strings.Join(args, ", "))
+			}
+			fmt.Fprintf(&b, ": %s\n", f.Type)
+		}
+		b.WriteString("}\n\n")
+		for _, f := range o.Fields {
+			if obj := namedObject(f.Type); obj != nil {
+				visit(obj)
+			}
+		}
+	}
+	visit(s.Query)
+	visit(s.Mutation)
+	b.WriteString("scalar Time\n")
+	return b.String()
+}
+
+func namedObject(t Type) *Object {
+	switch t := t.(type) {
+	case *Object:
+		return t
+	case *List:
+		return namedObject(t.Of)
+	}
+	return nil
+}
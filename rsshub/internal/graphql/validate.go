@@ -0,0 +1,125 @@
+package graphql
+
+import "fmt"
+
+// validator statically checks a document against the schema before any
+// resolver runs, so malformed queries fail without side effects.
+type validator struct {
+	doc    *document
+	vars   []varDef
+	errors []Error
+}
+
+func (v *validator) errorf(path []any, format string, args ...any) {
+	v.errors = append(v.errors, Error{Message: fmt.Sprintf(format, args...), Path: path})
+}
+
+func (v *validator) selections(obj *Object, sels []selection, path []any, active map[string]bool) {
+	for _, sel := range sels {
+		switch sel := sel.(type) {
+		case *field:
+			v.field(obj, sel, append(append([]any{}, path...), sel.responseKey()), active)
+		case *fragmentSpread:
+			frag, ok := v.doc.fragments[sel.name]
+			if !ok {
+				v.errorf(path, "unknown fragment %q", sel.name)
+				continue
+			}
+			if active[sel.name] {
+				v.errorf(path, "fragment %q spreads itself", sel.name)
+				continue
+			}
+			if frag.on != obj.Name {
+				v.errorf(path, "fragment %q on %s cannot be spread on %s", sel.name, frag.on, obj.Name)
+				continue
+			}
+			active[sel.name] = true
+			v.selections(obj, frag.selections, path, active)
+			delete(active, sel.name)
+		case *inlineFragment:
+			if sel.on != "" && sel.on != obj.Name {
+				v.errorf(path, "inline fragment on %s cannot be used on %s", sel.on, obj.Name)
+				continue
+			}
+			v.selections(obj, sel.selections, path, active)
+		}
+	}
+}
+
+func (v *validator) field(obj *Object, f *field, path []any, active map[string]bool) {
+	v.variablesDeclared(f.args, path)
+	for _, d := range f.directives {
+		if d.name != "skip" && d.name != "include" {
+			v.errorf(path, "unknown directive @%s", d.name)
+		}
+		v.variablesDeclared(d.args, path)
+	}
+	if f.name == "__typename" {
+		if len(f.selections) > 0 {
+			v.errorf(path, "field __typename cannot have a selection set")
+		}
+		return
+	}
+	def := obj.field(f.name)
+	if def == nil {
+		v.errorf(path, "cannot query field %q on type %s", f.name, obj.Name)
+		return
+	}
+	for _, a := range f.args {
+		known := false
+		for _, da := range def.Args {
+			known = known || da.Name == a.name
+		}
+		if !known {
+			v.errorf(path, "unknown argument %q on field %s.%s", a.name, obj.Name, f.name)
+		}
+	}
+	for _, da := range def.Args {
+		if !da.Required {
+			continue
+		}
+		given := false
+		for _, a := range f.args {
+			given = given || a.name == da.Name
+		}
+		if !given {
+			v.errorf(path, "field %s.%s requires argument %q", obj.Name, f.name, da.Name)
+		}
+	}
+
+	child := namedObject(def.Type)
+	switch {
+	case child == nil && len(f.selections) > 0:
+		v.errorf(path, "field %q of type %s must not have a selection set", f.name, def.Type)
+	case child != nil && len(f.selections) == 0:
+		v.errorf(path, "field %q of type %s must have a selection set", f.name, def.Type)
+	case child != nil:
+		v.selections(child, f.selections, path, active)
+	}
+}
+
+func (v *validator) variablesDeclared(args []argument, path []any) {
+	for _, a := range args {
+		v.valueVariables(a.value, path)
+	}
+}
+
+func (v *validator) valueVariables(val value, path []any) {
+	switch val := val.(type) {
+	case variable:
+		for _, def := range v.vars {
+			if def.name == string(val) {
+				return
+			}
+		}
+		v.errorf(path, "variable $%s is not defined", string(val))
+	case []value:
+		for _, item := range val {
+			v.valueVariables(item, path)
+		}
+	case objectValue:
+		for _, f := range val {
+			v.valueVariables(f.value, path)
+		}
+	}
+}
@@ -0,0 +1,511 @@
+// Package importer pulls subscriptions and reading state out of other
+// self-hosted feed readers through their APIs.
+package importer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Sources lists the supported reader names.
+var Sources = []string{"miniflux", "freshrss", "ttrss"}
+
+// Feed is a subscription in the source reader. Category is its folder or
+// label there, if any.
+type Feed struct {
+	Title    string
+	URL      string
+	Category string
+}
+
+// Entry is an article the source reader has starred or marked read.
+// FeedURL is the URL of the subscription it came from.
+type Entry struct {
+	FeedURL   string
+	Title     string
+	Link      string
+	Author    string
+	Content   string
+	Published time.Time
+	Read      bool
+	Starred   bool
+}
+
+// Source is an account on another reader.
+type Source interface {
+	Feeds(ctx context.Context) ([]Feed, error)
+	// Starred returns up to limit starred entries, newest first.
+	Starred(ctx context.Context, limit int) ([]Entry, error)
+	// Read returns up to limit read entries, newest first.
+	Read(ctx context.Context, limit int) ([]Entry, error)
+}
+
+// requestTimeout bounds each call to a reader.
+const requestTimeout = 60 * time.Second
+
+// New returns the named reader at base. Miniflux takes an API key;
+// FreshRSS and Tiny Tiny RSS take a user name and, as key, the API
+// password or the account password.
+func New(name, base, user, key string) (Source, error) {
+	base = strings.TrimRight(base, "/")
+	if base == "" || key == "" {
+		return nil, errors.New("--url and --api-key are required")
+	}
+	client := &http.Client{Timeout: requestTimeout}
+	switch name {
+	case "miniflux":
+		return &miniflux{base: base, key: key, client: client}, nil
+	case "freshrss":
+		if user == "" {
+			return nil, errors.New("freshrss needs --user as well as its API password as --api-key")
+		}
+		if !strings.Contains(base, "greader.php") {
+			base += "/api/greader.php"
+		}
+		return &freshrss{base: base, user: user, password: key, client: client}, nil
+	case "ttrss":
+		if user == "" {
+			return nil, errors.New("ttrss needs --user as well as the password as --api-key")
+		}
+		return &ttrss{base: strings.TrimSuffix(base, "/api"), user: user, password: key, client: client}, nil
+	}
+	return nil, fmt.Errorf("unknown reader %q (supported: %s)", name, strings.Join(Sources, ", "))
+}
+
+// check turns a non-2xx response into an error carrying the start of the
+// body, which is where these readers explain what went wrong.
+func check(source string, resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return fmt.Errorf("%s: %s: %s", source, resp.Status, strings.TrimSpace(string(body)))
+}
+
+// getJSON decodes the JSON answer to a GET carrying header.
+func getJSON(ctx context.Context, client *http.Client, source, u string, header http.Header, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := check(source, resp); err != nil {
+		return err
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// miniflux uses the REST API under /v1 with an X-Auth-Token header.
+type miniflux struct {
+	base   string
+	key    string
+	client *http.Client
+}
+
+func (m *miniflux) header() http.Header {
+	return http.Header{"X-Auth-Token": {m.key}}
+}
+
+func (m *miniflux) Feeds(ctx context.Context) ([]Feed, error) {
+	var feeds []struct {
+		Title    string `json:"title"`
+		FeedURL  string `json:"feed_url"`
+		Category struct {
+			Title string `json:"title"`
+		} `json:"category"`
+	}
+	err := getJSON(ctx, m.client, "miniflux", m.base+"/v1/feeds", m.header(), &feeds)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Feed, len(feeds))
+	for i, f := range feeds {
+		out[i] = Feed{Title: f.Title, URL: f.FeedURL, Category: f.Category.Title}
+	}
+	return out, nil
+}
+
+func (m *miniflux) Starred(ctx context.Context, limit int) ([]Entry, error) {
+	return m.entries(ctx, url.Values{"starred": {"true"}}, limit)
+}
+
+func (m *miniflux) Read(ctx context.Context, limit int) ([]Entry, error) {
+	return m.entries(ctx, url.Values{"status": {"read"}}, limit)
+}
+
+// minifluxPage is how many entries each /v1/entries call asks for.
+const minifluxPage = 100
+
+func (m *miniflux) entries(ctx context.Context, q url.Values, limit int) ([]Entry, error) {
+	q.Set("order", "published_at")
+	q.Set("direction", "desc")
+	var out []Entry
+	for len(out) < limit {
+		q.Set("limit", strconv.Itoa(min(minifluxPage, limit-len(out))))
+		q.Set("offset", strconv.Itoa(len(out)))
+		var page struct {
+			Entries []struct {
+				URL         string    `json:"url"`
+				Title       string    `json:"title"`
+				Author      string    `json:"author"`
+				Content     string    `json:"content"`
+				PublishedAt time.Time `json:"published_at"`
+				Status      string    `json:"status"`
+				Starred     bool      `json:"starred"`
+				Feed        struct {
+					FeedURL string `json:"feed_url"`
+				} `json:"feed"`
+			} `json:"entries"`
+		}
+		err := getJSON(ctx, m.client, "miniflux", m.base+"/v1/entries?"+q.Encode(), m.header(), &page)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range page.Entries {
+			out = append(out, Entry{
+				FeedURL: e.Feed.FeedURL, Title: e.Title, Link: e.URL, Author: e.Author, Content: e.Content,
+				Published: e.PublishedAt, Read: e.Status == "read", Starred: e.Starred,
+			})
+		}
+		if len(page.Entries) < minifluxPage {
+			break
+		}
+	}
+	return out, nil
+}
+
+// freshrss speaks the Google Reader API that FreshRSS serves from
+// greader.php, signing in with ClientLogin.
+type freshrss struct {
+	base     string
+	user     string
+	password string
+	client   *http.Client
+	auth     string
+	feedURLs map[string]string // stream ID to feed URL
+}
+
+func (f *freshrss) login(ctx context.Context) error {
+	if f.auth != "" {
+		return nil
+	}
+	form := url.Values{"Email": {f.user}, "Passwd": {f.password}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, f.base+"/accounts/ClientLogin", strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := check("freshrss", resp); err != nil {
+		return err
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if err != nil {
+		return err
+	}
+	for _, line := range strings.Split(string(body), "\n") {
+		if token, ok := strings.CutPrefix(strings.TrimSpace(line), "Auth="); ok {
+			f.auth = token
+			return nil
+		}
+	}
+	return errors.New("freshrss: sign-in returned no Auth token")
+}
+
+func (f *freshrss) header() http.Header {
+	return http.Header{"Authorization": {"GoogleLogin auth=" + f.auth}}
+}
+
+func (f *freshrss) Feeds(ctx context.Context) ([]Feed, error) {
+	if err := f.login(ctx); err != nil {
+		return nil, err
+	}
+	var list struct {
+		Subscriptions []struct {
+			ID         string `json:"id"`
+			Title      string `json:"title"`
+			URL        string `json:"url"`
+			Categories []struct {
+				Label string `json:"label"`
+			} `json:"categories"`
+		} `json:"subscriptions"`
+	}
+	err := getJSON(ctx, f.client, "freshrss", f.base+"/reader/api/0/subscription/list?output=json", f.header(), &list)
+	if err != nil {
+		return nil, err
+	}
+	f.feedURLs = make(map[string]string, len(list.Subscriptions))
+	out := make([]Feed, len(list.Subscriptions))
+	for i, s := range list.Subscriptions {
+		out[i] = Feed{Title: s.Title, URL: s.URL}
+		if len(s.Categories) > 0 {
+			out[i].Category = s.Categories[0].Label
+		}
+		f.feedURLs[s.ID] = s.URL
+	}
+	return out, nil
+}
+
+func (f *freshrss) Starred(ctx context.Context, limit int) ([]Entry, error) {
+	return f.stream(ctx, "user/-/state/com.google/starred", limit)
+}
+
+func (f *freshrss) Read(ctx context.Context, limit int) ([]Entry, error) {
+	return f.stream(ctx, "user/-/state/com.google/read", limit)
+}
+
+// stream pages through a stream's items with continuation tokens. Items
+// name their feed by stream ID, so the subscription list is loaded first.
+func (f *freshrss) stream(ctx context.Context, id string, limit int) ([]Entry, error) {
+	if f.feedURLs == nil {
+		if _, err := f.Feeds(ctx); err != nil {
+			return nil, err
+		}
+	}
+	var out []Entry
+	continuation := ""
+	for len(out) < limit {
+		q := url.Values{"output": {"json"}, "n": {strconv.Itoa(min(1000, limit-len(out)))}}
+		if continuation != "" {
+			q.Set("c", continuation)
+		}
+		var page struct {
+			Items []struct {
+				Title      string   `json:"title"`
+				Author     string   `json:"author"`
+				Published  int64    `json:"published"`
+				Categories []string `json:"categories"`
+				Canonical  []struct {
+					Href string `json:"href"`
+				} `json:"canonical"`
+				Alternate []struct {
+					Href string `json:"href"`
+				} `json:"alternate"`
+				Summary struct {
+					Content string `json:"content"`
+				} `json:"summary"`
+				Origin struct {
+					StreamID string `json:"streamId"`
+				} `json:"origin"`
+			} `json:"items"`
+			Continuation string `json:"continuation"`
+		}
+		err := getJSON(ctx, f.client, "freshrss", f.base+"/reader/api/0/stream/contents/"+id+"?"+q.Encode(), f.header(), &page)
+		if err != nil {
+			return nil, err
+		}
+		for _, it := range page.Items {
+			e := Entry{
+				FeedURL: f.feedURLs[it.Origin.StreamID], Title: it.Title, Author: it.Author,
+				Content: it.Summary.Content, Published: time.Unix(it.Published, 0),
+			}
+			if len(it.Canonical) > 0 {
+				e.Link = it.Canonical[0].Href
+			} else if len(it.Alternate) > 0 {
+				e.Link = it.Alternate[0].Href
+			}
+			for _, c := range it.Categories {
+				switch {
+				case strings.HasSuffix(c, "/state/com.google/read"):
+					e.Read = true
+				case strings.HasSuffix(c, "/state/com.google/starred"):
+					e.Starred = true
+				}
+			}
+			out = append(out, e)
+		}
+		continuation = page.Continuation
+		if continuation == "" || len(page.Items) == 0 {
+			break
+		}
+	}
+	return out, nil
+}
+
+// ttrss speaks the Tiny Tiny RSS JSON API at /api/, where every call is a
+// POST naming its operation and carrying the session ID.
+type ttrss struct {
+	base     string
+	user     string
+	password string
+	client   *http.Client
+	sid      string
+	feedURLs map[string]string // feed ID to feed URL
+}
+
+// Tiny Tiny RSS virtual feeds.
+const (
+	ttrssStarred = -1
+	ttrssAll     = -4
+	ttrssPage    = 200
+)
+
+// ttrssID is an ID that Tiny Tiny RSS sends as a number or a string
+// depending on its version.
+type ttrssID string
+
+func (id *ttrssID) UnmarshalJSON(b []byte) error {
+	*id = ttrssID(strings.Trim(string(b), `"`))
+	return nil
+}
+
+func (t *ttrss) call(ctx context.Context, op map[string]any, out any) error {
+	if t.sid != "" {
+		op["sid"] = t.sid
+	}
+	body, err := json.Marshal(op)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.base+"/api/", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := check("ttrss", resp); err != nil {
+		return err
+	}
+	var reply struct {
+		Status  int             `json:"status"`
+		Content json.RawMessage `json:"content"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&reply); err != nil {
+		return err
+	}
+	if reply.Status != 0 {
+		var e struct {
+			Error string `json:"error"`
+		}
+		json.Unmarshal(reply.Content, &e)
+		return fmt.Errorf("ttrss: %s failed: %s", op["op"], e.Error)
+	}
+	return json.Unmarshal(reply.Content, out)
+}
+
+func (t *ttrss) login(ctx context.Context) error {
+	if t.sid != "" {
+		return nil
+	}
+	var session struct {
+		SessionID string `json:"session_id"`
+	}
+	err := t.call(ctx, map[string]any{"op": "login", "user": t.user, "password": t.password}, &session)
+	t.sid = session.SessionID
+	return err
+}
+
+func (t *ttrss) Feeds(ctx context.Context) ([]Feed, error) {
+	if err := t.login(ctx); err != nil {
+		return nil, err
+	}
+	var cats []struct {
+		ID    ttrssID `json:"id"`
+		Title string  `json:"title"`
+	}
+	err := t.call(ctx, map[string]any{"op": "getCategories"}, &cats)
+	if err != nil {
+		return nil, err
+	}
+	categories := make(map[ttrssID]string, len(cats))
+	for _, c := range cats {
+		categories[c.ID] = c.Title
+	}
+	var feeds []struct {
+		ID      ttrssID `json:"id"`
+		Title   string  `json:"title"`
+		FeedURL string  `json:"feed_url"`
+		CatID   ttrssID `json:"cat_id"`
+	}
+	// Category -3 is every feed that is not virtual.
+	err = t.call(ctx, map[string]any{"op": "getFeeds", "cat_id": -3}, &feeds)
+	if err != nil {
+		return nil, err
+	}
+	t.feedURLs = make(map[string]string, len(feeds))
+	out := make([]Feed, 0, len(feeds))
+	for _, f := range feeds {
+		if f.FeedURL == "" {
+			continue
+		}
+		out = append(out, Feed{Title: f.Title, URL: f.FeedURL, Category: categories[f.CatID]})
+		t.feedURLs[string(f.ID)] = f.FeedURL
+	}
+	return out, nil
+}
+
+func (t *ttrss) Starred(ctx context.Context, limit int) ([]Entry, error) {
+	return t.headlines(ctx, ttrssStarred, limit, func(Entry) bool { return true })
+}
+
+// Read walks all articles keeping the read ones, since Tiny Tiny RSS has
+// no view of read articles alone.
+func (t *ttrss) Read(ctx context.Context, limit int) ([]Entry, error) {
+	return t.headlines(ctx, ttrssAll, limit, func(e Entry) bool { return e.Read })
+}
+
+func (t *ttrss) headlines(ctx context.Context, feed, limit int, keep func(Entry) bool) ([]Entry, error) {
+	if t.feedURLs == nil {
+		if _, err := t.Feeds(ctx); err != nil {
+			return nil, err
+		}
+	}
+	var out []Entry
+	for skip := 0; len(out) < limit; skip += ttrssPage {
+		var page []struct {
+			Title   string  `json:"title"`
+			Link    string  `json:"link"`
+			Author  string  `json:"author"`
+			Content string  `json:"content"`
+			Updated int64   `json:"updated"`
+			Unread  bool    `json:"unread"`
+			Marked  bool    `json:"marked"`
+			FeedID  ttrssID `json:"feed_id"`
+		}
+		err := t.call(ctx, map[string]any{
+			"op": "getHeadlines", "feed_id": feed, "limit": ttrssPage, "skip": skip,
+			"view_mode": "all_articles", "order_by": "feed_dates", "show_content": true,
+		}, &page)
+		if err != nil {
+			return nil, err
+		}
+		for _, h := range page {
+			e := Entry{
+				FeedURL: t.feedURLs[string(h.FeedID)], Title: h.Title, Link: h.Link, Author: h.Author,
+				Content: h.Content, Published: time.Unix(h.Updated, 0), Read: !h.Unread, Starred: h.Marked,
+			}
+			if keep(e) && len(out) < limit {
+				out = append(out, e)
+			}
+		}
+		if len(page) < ttrssPage {
+			break
+		}
+	}
+	return out, nil
+}
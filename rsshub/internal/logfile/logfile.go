@@ -0,0 +1,136 @@
+// Package logfile writes the daemon's log to a file that rotates by size
+// and age, keeping a bounded number of old files.
+package logfile
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// stampFormat names rotated files; it sorts in time order.
+const stampFormat = "20060102T150405.000"
+
+// Writer is an io.Writer appending to a log file. The file is rotated
+// before a write that would take it past MaxSize bytes, or once it has been
+// open for MaxAge; either limit is off when zero. Rotated files are renamed
+// to path.<timestamp> and all but the newest Keep are removed.
+type Writer struct {
+	path    string
+	maxSize int64
+	maxAge  time.Duration
+	keep    int
+
+	mu     sync.Mutex
+	f      *os.File
+	size   int64
+	opened time.Time
+}
+
+// Open opens or creates the log file at path, appending to what it
+// already holds.
+func Open(path string, maxSize int64, maxAge time.Duration, keep int) (*Writer, error) {
+	w := &Writer{path: path, maxSize: maxSize, maxAge: maxAge, keep: keep}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *Writer) open() error {
+	f, err := os.OpenFile(w.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	w.f, w.size, w.opened = f, info.Size(), time.Now()
+	return nil
+}
+
+// Write appends p to the file, rotating it first if due. A failed rotation
+// does not lose p: it is still written, to the old file if need be, and the
+// rotation's error is returned after it.
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.f == nil {
+		return 0, os.ErrClosed
+	}
+	var rotateErr error
+	if w.due(int64(len(p))) {
+		rotateErr = w.rotate()
+	}
+	if w.f == nil {
+		return 0, rotateErr
+	}
+	n, err := w.f.Write(p)
+	w.size += int64(n)
+	if err == nil {
+		err = rotateErr
+	}
+	return n, err
+}
+
+// due reports whether writing n more bytes should go to a new file. An
+// empty file is never rotated, so a single oversized write still lands.
+func (w *Writer) due(n int64) bool {
+	if w.size == 0 {
+		return false
+	}
+	if w.maxSize > 0 && w.size+n > w.maxSize {
+		return true
+	}
+	return w.maxAge > 0 && time.Since(w.opened) >= w.maxAge
+}
+
+// rotate moves the current file aside and opens a new one. If the rename
+// fails the current file is reopened, so w.f is only left nil when path
+// cannot be opened at all.
+func (w *Writer) rotate() error {
+	w.f.Close()
+	w.f = nil
+	rotated := w.path + "." + time.Now().Format(stampFormat)
+	renameErr := os.Rename(w.path, rotated)
+	if err := w.open(); err != nil {
+		return err
+	}
+	if renameErr != nil {
+		return fmt.Errorf("rotating log file: %w", renameErr)
+	}
+	return w.prune()
+}
+
+// prune removes rotated files beyond the newest keep.
+func (w *Writer) prune() error {
+	old, err := filepath.Glob(w.path + ".*")
+	if err != nil {
+		return err
+	}
+	sort.Strings(old)
+	for len(old) > w.keep {
+		if err := os.Remove(old[0]); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("removing old log file: %w", err)
+		}
+		old = old[1:]
+	}
+	return nil
+}
+
+// Close closes the current file.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.f == nil {
+		return nil
+	}
+	err := w.f.Close()
+	w.f = nil
+	return err
+}
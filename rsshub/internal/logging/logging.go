@@ -0,0 +1,84 @@
+// Package logging writes the daemon's log lines, as plain text or JSON
+// depending on CLI_APP_LOG_FORMAT.
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"rsshub/internal/config"
+	"rsshub/internal/models"
+)
+
+// Log levels, as written in JSON log lines.
+const (
+	levelDebug = "debug"
+	levelInfo  = "info"
+	levelError = "error"
+)
+
+// Logger writes the daemon's log lines to stdout, as plain text or, in
+// JSON mode, one object per line. A Logger made by Fetch tags every line
+// with the feed and a correlation ID shared by one fetch of it. The zero
+// Logger writes plain text.
+type Logger struct {
+	json    bool
+	fetchID string
+	feed    string
+	url     string
+}
+
+// New returns a Logger in the format cfg asks for.
+func New(cfg *config.Config) Logger {
+	return Logger{json: cfg.LogFormat == config.LogFormatJSON}
+}
+
+type logLine struct {
+	Time    time.Time `json:"time"`
+	Level   string    `json:"level"`
+	Msg     string    `json:"msg"`
+	FetchID string    `json:"fetch_id,omitempty"`
+	Feed    string    `json:"feed,omitempty"`
+	URL     string    `json:"url,omitempty"`
+}
+
+// Fetch returns a Logger for one fetch of feed under a new correlation ID.
+func (l Logger) Fetch(feed models.Feed) Logger {
+	l.fetchID = uuid.NewString()
+	l.feed = feed.Name
+	l.url = feed.URL
+	return l
+}
+
+// FetchID returns the correlation ID Fetch gave l, empty if none.
+func (l Logger) FetchID() string { return l.fetchID }
+
+func (l Logger) Debugf(format string, args ...any) { l.write(levelDebug, format, args...) }
+func (l Logger) Infof(format string, args ...any)  { l.write(levelInfo, format, args...) }
+func (l Logger) Errorf(format string, args ...any) { l.write(levelError, format, args...) }
+
+func (l Logger) write(level, format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	if !l.json {
+		fmt.Println(msg)
+		return
+	}
+	b, err := json.Marshal(logLine{
+		Time:    time.Now().UTC(),
+		Level:   level,
+		Msg:     msg,
+		FetchID: l.fetchID,
+		Feed:    l.feed,
+		URL:     l.url,
+	})
+	if err != nil {
+		fmt.Println(msg)
+		return
+	}
+	// os.Stdout is looked up per line since it may be redirected to a
+	// log file after the Logger is made.
+	os.Stdout.Write(append(b, '\n'))
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -12,6 +13,21 @@ type Feed struct {
 	UpdatedAt time.Time
 	Name      string
 	URL       string
+
+	// DisabledAt is set when the feed stopped being fetched after failing
+	// too many times in a row; DisabledReason holds the last error.
+	DisabledAt     time.Time
+	DisabledReason string
+
+	// Filled from feed_stats by listing queries only.
+	ArticleCount    int64
+	LastPublishedAt time.Time
+
+	// Filled by wide listings only: the last fetch attempt, how many
+	// fetches in a row have failed, and the unread article count.
+	LastFetch   FetchLogEntry
+	Failures    int
+	UnreadCount int64
 }
 
 type Article struct {
@@ -23,6 +39,349 @@ type Article struct {
 	PublishedAt time.Time
 	Description string
 	FeedID      uuid.UUID
+	ReadAt      time.Time
+	StarredAt   time.Time
+	Author      string
+
+	// PublishedAtSource says where PublishedAt came from, one of the
+	// DateSource constants.
+	PublishedAtSource string
+
+	// Item metadata kept as published; empty or zero when absent.
+	GUID            string
+	CommentsURL     string
+	EnclosureURL    string
+	EnclosureType   string
+	EnclosureLength int64
+
+	// Location is where the item is about, from GeoRSS or W3C geo tags.
+	Location *GeoPoint
+
+	// Podcast episode metadata from the iTunes namespace; zero when absent.
+	Duration time.Duration
+	Episode  int
+	Season   int
+	ImageURL string
+
+	// Video metadata from Media RSS and YouTube's namespace. Views is the
+	// count when the article was first stored.
+	VideoID      string
+	ThumbnailURL string
+	Views        int64
+
+	// WordCount is the length of the stored description, counted at
+	// ingest; zero when unknown.
+	WordCount int
+
+	// ContentHash fingerprints the text of Title and Description, see
+	// rss.ContentHash; empty when not computed.
+	ContentHash string
+
+	// ShortID is the shortest prefix of ID no other article had when this
+	// one was listed, see UniqueShortID; empty when not worked out.
+	ShortID string
+
+	// Raw is the item as fetched, wrapped in its feed's root elements so
+	// that namespace declarations survive; empty unless
+	// CLI_APP_STORE_RAW_ITEMS is on.
+	Raw string
+}
+
+// ShortID is the first eight hex digits of an article ID, the least of it
+// the CLI shows. It accepts any prefix only one article has wherever an
+// article ID goes.
+func ShortID(id uuid.UUID) string {
+	return id.String()[:8]
+}
+
+// UniqueShortID is the shortest prefix of id, no shorter than ShortID,
+// that none of others starts with, as git abbreviates commit hashes.
+func UniqueShortID(id uuid.UUID, others ...uuid.UUID) string {
+	hex := strings.ReplaceAll(id.String(), "-", "")
+	n := len(ShortID(id))
+	for _, other := range others {
+		o := strings.ReplaceAll(other.String(), "-", "")
+		common := 0
+		for common < len(hex) && hex[common] == o[common] {
+			common++
+		}
+		n = max(n, common+1)
+	}
+	return hex[:min(n, len(hex))]
+}
+
+// Where an article's publication date came from, as stored in
+// articles.published_at_source. Items without a usable date of their own
+// fall back to the Last-Modified header of the feed response they came in,
+// and then to when they were first fetched.
+const (
+	DateSourcePublished    = "published"
+	DateSourceUpdated      = "updated"
+	DateSourceDCDate       = "dc_date"
+	DateSourceLastModified = "last_modified"
+	DateSourceFirstSeen    = "first_seen"
+)
+
+// WordsPerMinute is the reading speed reading times are estimated at.
+const WordsPerMinute = 230
+
+// GeoPoint is a WGS84 position in decimal degrees.
+type GeoPoint struct {
+	Lat float64
+	Lon float64
+}
+
+// Orders for feed listings.
+const (
+	FeedSortAdded    = "added" // newest first, the default
+	FeedSortName     = "name"
+	FeedSortUpdated  = "updated"  // most recently fetched first
+	FeedSortArticles = "articles" // most articles first
+)
+
+// FeedFilter selects and orders feeds for listing; the zero value lists
+// them all, newest first. Wide listings also carry each feed's fetch
+// status and unread count.
+type FeedFilter struct {
+	Match    string // case-insensitive substring of the name or URL
+	Errored  bool   // only feeds whose last fetch failed
+	Disabled bool   // only feeds disabled after repeated failures
+	Sort     string
+	Wide     bool
+}
+
+// ArticleFilter selects articles for the CLI's article listings. At least
+// one of FeedName, Author or Near is set; Near needs RadiusKM.
+type ArticleFilter struct {
+	FeedName    string
+	Author      string
+	Near        *GeoPoint
+	RadiusKM    float64
+	MaxReadTime time.Duration // zero means any length
+	ShowMuted   bool          // include articles hidden by mute rules
+}
+
+// RandomFilter selects the articles random and resurface pick from. With
+// both Unread and Starred set, either will do.
+type RandomFilter struct {
+	FeedName  string
+	Unread    bool
+	Starred   bool
+	OlderThan time.Duration // only articles published at least this long ago
+}
+
+// ArchivedArticle is an article kept after its feed was purged.
+type ArchivedArticle struct {
+	Article
+	FeedName   string
+	FeedURL    string
+	ArchivedAt time.Time
+}
+
+// ArticleRevision is an earlier version of an article, replaced when the
+// upstream item's title or description changed.
+type ArticleRevision struct {
+	ID          int64
+	ArticleID   uuid.UUID
+	Title       string
+	Description string
+	ValidFrom   time.Time
+	ReplacedAt  time.Time
+}
+
+// FeedImpact counts what deleting or purging a feed affects.
+type FeedImpact struct {
+	Deleted   bool
+	Articles  int64
+	Read      int64
+	Starred   int64
+	Revisions int64
+}
+
+// FeedQuirks are per-feed parser overrides for feeds that break the usual
+// rules. DateLayout is a Go time layout tried before the standard ones.
+// CABundle holds PEM certificates the feed's server is verified against
+// instead of the system's, and CertPins comma-separated sha256/<base64>
+// hashes of public keys, one of which the server must present.
+type FeedQuirks struct {
+	DateLayout       string
+	InsecureTLS      bool
+	CABundle         string
+	CertPins         string
+	PlainDescription bool
+	StripTitlePrefix string
+}
+
+// Download records an enclosure saved to disk by the download command, so
+// later runs skip it.
+type Download struct {
+	FeedID       uuid.UUID
+	ArticleID    uuid.UUID
+	URL          string
+	Path         string
+	Bytes        int64
+	DownloadedAt time.Time
+}
+
+// FetchLogEntry is one attempt to fetch a feed, kept in fetch_log. Status
+// is the HTTP status, 0 when no response arrived; Bytes counts the body as
+// it arrived and DecodedBytes once decompressed; Items counts the items
+// parsed and NewItems the articles they added.
+type FetchLogEntry struct {
+	FeedID       uuid.UUID
+	Instance     string
+	Worker       int
+	FetchedAt    time.Time
+	Duration     time.Duration
+	Status       int
+	Bytes        int64
+	DecodedBytes int64
+	Items        int
+	NewItems     int
+	Error        string
+}
+
+// FeedHealth summarises a feed's fetches over a recent window. Failing
+// counts the failures since its last successful fetch.
+type FeedHealth struct {
+	FeedName     string
+	DisabledAt   time.Time
+	Fetches      int64
+	Failures     int64
+	Failing      int64
+	AvgDuration  time.Duration
+	Bytes        int64
+	DecodedBytes int64
+	NewItems     int64
+	LastFetch    FetchLogEntry
+}
+
+// QuietFeed is a feed that has gone without posting for much longer than
+// it usually does between posts.
+type QuietFeed struct {
+	FeedID          uuid.UUID
+	FeedName        string
+	LastPublishedAt time.Time
+	UsualGap        time.Duration
+}
+
+// WorkerStats summarises one worker's fetches over a recent window.
+type WorkerStats struct {
+	Instance    string
+	Worker      int
+	Fetches     int64
+	Failures    int64
+	AvgDuration time.Duration
+	Bytes       int64
+	NewItems    int64
+}
+
+// FeedBandwidth is how much a feed downloaded today and over a recent
+// period, with its own daily cap, 0 when it has none.
+type FeedBandwidth struct {
+	FeedName     string
+	TodayBytes   int64
+	TodayFetches int64
+	PeriodBytes  int64
+	DailyLimit   int64
+}
+
+// Secret describes a stored credential without its value. FeedName is
+// empty for secrets not tied to a feed; KeyID names the key it is
+// encrypted with.
+type Secret struct {
+	Name      string
+	FeedName  string
+	KeyID     string
+	UpdatedAt time.Time
+}
+
+// MergeResult counts what merging one feed into another did. Duplicates
+// are articles the target already had.
+type MergeResult struct {
+	Moved      int64
+	Duplicates int64
+}
+
+type FeedStats struct {
+	FeedName        string
+	ArticleCount    int64
+	LastPublishedAt time.Time
+}
+
+// AuthorStats is how much one author has written, across the feeds
+// counted.
+type AuthorStats struct {
+	Author          string
+	Articles        int64
+	Feeds           int64
+	LastPublishedAt time.Time
+}
+
+// FeedArticles are the articles one feed published in some span.
+type FeedArticles struct {
+	FeedName string
+	Articles []Article
+}
+
+type DailyCount struct {
+	Day   time.Time
+	Count int64
+}
+
+// AuditEntry records one administrative operation.
+type AuditEntry struct {
+	ID       int64
+	At       time.Time
+	Actor    string
+	Source   string
+	Action   string
+	Target   string
+	OldValue string
+	NewValue string
+}
+
+// User can sign in to the API with a password, through OIDC single
+// sign-on, or both.
+type User struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	Name        string
+	HasPassword bool
+	OIDCSubject string
+}
+
+// ArticleNote is a reader's note on an article, optionally about a quoted
+// passage. ArticleTitle and ArticleLink are filled by note searches.
+type ArticleNote struct {
+	ID           int64
+	ArticleID    uuid.UUID
+	Quote        string
+	Text         string
+	CreatedBy    string
+	CreatedAt    time.Time
+	ArticleTitle string
+	ArticleLink  string
+}
+
+// MuteRule hides articles whose title or description mentions Keyword
+// from listings until ExpiresAt.
+type MuteRule struct {
+	ID        int64
+	Keyword   string
+	CreatedBy string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+// ArticleEvent announces a newly inserted article.
+type ArticleEvent struct {
+	ID          uuid.UUID `json:"id"`
+	FeedID      uuid.UUID `json:"feed_id"`
+	FeedName    string    `json:"feed_name"`
+	Title       string    `json:"title"`
+	Link        string    `json:"link"`
+	PublishedAt time.Time `json:"published_at"`
 }
 
 type RSSFeed struct {
@@ -31,12 +390,161 @@ type RSSFeed struct {
 		Link        string    `xml:"link"`
 		Description string    `xml:"description"`
 		Item        []RSSItem `xml:"item"`
+
+		// Polling hints: minutes to cache the feed, and UTC hours (0-23)
+		// and weekdays during which it should not be fetched.
+		TTL       string `xml:"ttl"`
+		SkipHours struct {
+			Hours []string `xml:"hour"`
+		} `xml:"skipHours"`
+		SkipDays struct {
+			Days []string `xml:"day"`
+		} `xml:"skipDays"`
+
+		// Syndication module: the channel updates UpdateFrequency times
+		// per UpdatePeriod (hourly, daily, weekly, monthly or yearly).
+		UpdatePeriod    string `xml:"http://purl.org/rss/1.0/modules/syndication/ updatePeriod"`
+		UpdateFrequency string `xml:"http://purl.org/rss/1.0/modules/syndication/ updateFrequency"`
 	} `xml:"channel"`
 }
 
+// FeedSchedule is what decides how often a feed is polled. The feed asks
+// not to be fetched within TTL of the last fetch, nor during SkipHours or
+// on SkipDays, both in UTC, and may declare that it updates every
+// UpdateInterval. MaxAge is how long its server said the last response
+// stays fresh, through Cache-Control or Expires. Cadence is the observed
+// average time between its recent posts, 0 while there are too few to
+// tell.
+type FeedSchedule struct {
+	TTL            time.Duration
+	SkipHours      []int
+	SkipDays       []time.Weekday
+	UpdateInterval time.Duration
+	MaxAge         time.Duration
+	Cadence        time.Duration
+}
+
+// FeedValidators identify the version of a feed seen on its last
+// successful fetch: the ETag and Last-Modified headers for a conditional
+// GET, and a SHA-256 of the body, hex-encoded, for servers that send
+// neither.
+type FeedValidators struct {
+	ETag         string
+	LastModified string
+	BodyHash     string
+}
+
+// RSSEnclosure is a media file attached to an item, e.g. a podcast
+// episode. Length is in bytes and often missing or wrong.
+type RSSEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Type   string `xml:"type,attr"`
+	Length string `xml:"length,attr"`
+}
+
+// W3CGeoPoint is a geo:Point element wrapping geo:lat and geo:long.
+type W3CGeoPoint struct {
+	Lat  string `xml:"http://www.w3.org/2003/01/geo/wgs84_pos# lat"`
+	Long string `xml:"http://www.w3.org/2003/01/geo/wgs84_pos# long"`
+}
+
+// RSSAuthor holds an item's <author>: an RSS e-mail address such as
+// "jo@example.com (Jo Bloggs)", or an Atom-style element with a <name>.
+type RSSAuthor struct {
+	Text string `xml:",chardata"`
+	Name string `xml:"name"`
+}
+
 type RSSItem struct {
-	Title       string `xml:"title"`
-	Link        string `xml:"link"`
-	Description string `xml:"description"`
-	PubDate     string `xml:"pubDate"`
+	Title       string       `xml:"title"`
+	Link        string       `xml:"link"`
+	Description string       `xml:"description"`
+	PubDate     string       `xml:"pubDate"`
+	GUID        string       `xml:"guid"`
+	Comments    string       `xml:"comments"`
+	Enclosure   RSSEnclosure `xml:"enclosure"`
+
+	// Dublin Core and content module extensions. WordPress and others put
+	// the full body in content:encoded and keep description as an excerpt.
+	Author  RSSAuthor `xml:"author"`
+	Creator string    `xml:"http://purl.org/dc/elements/1.1/ creator"`
+
+	DCDate  string `xml:"http://purl.org/dc/elements/1.1/ date"`
+	Content string `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+
+	// Updated is an Atom entry's updated time, which RSS items may carry
+	// as atom:updated too.
+	Updated string `xml:"http://www.w3.org/2005/Atom updated"`
+
+	// LastModified is the Last-Modified header of the response the item
+	// came in, filled in by the fetcher rather than decoded.
+	LastModified string `xml:"-"`
+
+	// Raw is the item's markup as a one-item feed document, filled in by
+	// rss.ParseRaw rather than decoded.
+	Raw []byte `xml:"-"`
+
+	// GeoRSS Simple and W3C Basic Geo positions.
+	GeoRSSPoint string      `xml:"http://www.georss.org/georss point"`
+	GeoLat      string      `xml:"http://www.w3.org/2003/01/geo/wgs84_pos# lat"`
+	GeoLong     string      `xml:"http://www.w3.org/2003/01/geo/wgs84_pos# long"`
+	GeoPoint    W3CGeoPoint `xml:"http://www.w3.org/2003/01/geo/wgs84_pos# Point"`
+
+	// Apple Podcasts (iTunes) episode tags.
+	ITunesDuration string      `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd duration"`
+	ITunesEpisode  string      `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd episode"`
+	ITunesSeason   string      `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd season"`
+	ITunesImage    ITunesImage `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd image"`
+
+	// Media RSS, used by video feeds. YouTube puts everything in a group.
+	MediaGroup     MediaGroup     `xml:"http://search.yahoo.com/mrss/ group"`
+	MediaThumbnail MediaThumbnail `xml:"http://search.yahoo.com/mrss/ thumbnail"`
+
+	// VideoID is a YouTube entry's yt:videoId.
+	VideoID string `xml:"http://www.youtube.com/xml/schemas/2015 videoId"`
+}
+
+// MediaGroup is a media:group: a description, thumbnail and viewing
+// statistics for one media object.
+type MediaGroup struct {
+	Description string         `xml:"http://search.yahoo.com/mrss/ description"`
+	Thumbnail   MediaThumbnail `xml:"http://search.yahoo.com/mrss/ thumbnail"`
+	Community   struct {
+		Statistics struct {
+			Views string `xml:"views,attr"`
+		} `xml:"http://search.yahoo.com/mrss/ statistics"`
+	} `xml:"http://search.yahoo.com/mrss/ community"`
+}
+
+// MediaThumbnail is a media:thumbnail image.
+type MediaThumbnail struct {
+	URL string `xml:"url,attr"`
+}
+
+// AtomEntry is an Atom <entry>. The parser maps entries onto RSSItem, so
+// Atom feeds such as YouTube channels are stored like RSS ones.
+type AtomEntry struct {
+	ID        string     `xml:"http://www.w3.org/2005/Atom id"`
+	Title     string     `xml:"http://www.w3.org/2005/Atom title"`
+	Links     []AtomLink `xml:"http://www.w3.org/2005/Atom link"`
+	Published string     `xml:"http://www.w3.org/2005/Atom published"`
+	Updated   string     `xml:"http://www.w3.org/2005/Atom updated"`
+	Summary   string     `xml:"http://www.w3.org/2005/Atom summary"`
+	Content   string     `xml:"http://www.w3.org/2005/Atom content"`
+	Author    RSSAuthor  `xml:"http://www.w3.org/2005/Atom author"`
+
+	VideoID    string     `xml:"http://www.youtube.com/xml/schemas/2015 videoId"`
+	MediaGroup MediaGroup `xml:"http://search.yahoo.com/mrss/ group"`
+}
+
+// AtomLink is an Atom <link>; the article link is the one whose rel is
+// "alternate" or absent.
+type AtomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+}
+
+// ITunesImage is an <itunes:image>, which carries its URL in href.
+type ITunesImage struct {
+	Href string `xml:"href,attr"`
 }
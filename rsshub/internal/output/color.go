@@ -0,0 +1,63 @@
+package output
+
+import (
+	"io"
+	"os"
+	"sync"
+)
+
+// Style is an ANSI SGR sequence that picks out one kind of value in
+// listings.
+type Style string
+
+// Styles for the parts of a listing worth finding at a glance.
+const (
+	Plain  Style = ""
+	Name   Style = "1"    // feed names, bold
+	When   Style = "2"    // dates, dim
+	Bad    Style = "31"   // errors and disabled feeds, red
+	Warn   Style = "33"   // failing or quiet feeds, yellow
+	Good   Style = "32"   // healthy feeds, green
+	Unread Style = "1;36" // unread counts and markers, bold cyan
+	header Style = "1"
+)
+
+// noColor is set by --no-color.
+var noColor bool
+
+// DisableColor turns colour off for the rest of the run, as --no-color
+// asks.
+func DisableColor() {
+	noColor = true
+}
+
+// Colors reports whether w should get colour: it must be a terminal,
+// and neither --no-color nor $NO_COLOR (https://no-color.org) may be set.
+func Colors(w io.Writer) bool {
+	if noColor || os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	f, ok := w.(*os.File)
+	return ok && isTerminal(f)
+}
+
+// stdoutColors is Colors(os.Stdout), asked once.
+var stdoutColors = sync.OnceValue(func() bool {
+	return Colors(os.Stdout)
+})
+
+// Paint wraps s in the style for printing to stdout, or returns it as is
+// when stdout gets no colour.
+func (s Style) Paint(text string) string {
+	if !stdoutColors() {
+		return text
+	}
+	return s.wrap(text)
+}
+
+func (s Style) wrap(text string) string {
+	if s == Plain || text == "" {
+		return text
+	}
+	return "\x1b[" + string(s) + "m" + text + "\x1b[0m"
+}
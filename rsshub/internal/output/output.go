@@ -0,0 +1,227 @@
+// Package output renders what CLI commands list in the format their
+// --format flag asks for: an aligned table fitted to the terminal, JSON,
+// or a Go text/template applied to each row.
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+	"unicode/utf8"
+)
+
+// Formats every command understands besides templates.
+const (
+	Table = "table"
+	JSON  = "json"
+)
+
+// minFlexWidth is as narrow as a flexible column is squeezed to fit the
+// terminal. Tables wider than that still overflow.
+const minFlexWidth = 12
+
+// Column is one column of a table. Flexible columns, such as titles and
+// URLs, are truncated when the table is wider than the terminal; the
+// others always show in full. Right aligns the column, for numbers.
+// Style, if set, picks the colour of each cell on a terminal.
+type Column[T any] struct {
+	Header   string
+	Value    func(T) string
+	Flexible bool
+	Right    bool
+	Style    func(T) Style
+}
+
+// Format is a checked --format value: Table, JSON, one of the names a
+// command lays out itself, or a template.
+type Format struct {
+	Name string
+	tmpl *template.Template
+}
+
+// Parse checks a --format value. It accepts table, json, the names in own
+// that the command lays out by hand, and any value containing "{{", which
+// is a template run once per row; \t and \n in a template stand for a tab
+// and a newline.
+func Parse(value string, own ...string) (Format, error) {
+	if strings.Contains(value, "{{") {
+		text := strings.NewReplacer(`\t`, "\t", `\n`, "\n").Replace(value)
+		tmpl, err := template.New("format").Funcs(funcs).Parse(text)
+		if err != nil {
+			return Format{}, err
+		}
+		return Format{tmpl: tmpl}, nil
+	}
+	names := append([]string{Table, JSON}, own...)
+	for _, name := range names {
+		if value == name {
+			return Format{Name: value}, nil
+		}
+	}
+	return Format{}, fmt.Errorf("unknown format %q: use %s, or a template such as '{{.Name}}'", value, strings.Join(names, ", "))
+}
+
+// IsTemplate reports whether f is a template rather than a named format.
+func (f Format) IsTemplate() bool {
+	return f.tmpl != nil
+}
+
+// funcs are available to --format templates.
+var funcs = template.FuncMap{
+	"date": Date,
+	"trunc": func(n int, s string) string {
+		return Truncate(s, n)
+	},
+	"json": func(v any) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
+// Write renders rows to w in format f. Tables use cols; JSON encodes
+// toJSON of each row, or the rows themselves when toJSON is nil; templates
+// see each row as dot.
+func Write[T any](w io.Writer, f Format, rows []T, cols []Column[T], toJSON func(T) any) error {
+	switch {
+	case f.tmpl != nil:
+		for _, row := range rows {
+			if err := f.tmpl.Execute(w, row); err != nil {
+				return err
+			}
+			if _, err := io.WriteString(w, "\n"); err != nil {
+				return err
+			}
+		}
+		return nil
+	case f.Name == JSON:
+		if toJSON == nil {
+			return WriteJSON(w, rows)
+		}
+		out := make([]any, len(rows))
+		for i, row := range rows {
+			out[i] = toJSON(row)
+		}
+		return WriteJSON(w, out)
+	case f.Name == Table:
+		return writeTable(w, rows, cols, Width(w), Colors(w))
+	}
+	return fmt.Errorf("format %q is laid out by the command", f.Name)
+}
+
+// WriteJSON writes v as indented JSON.
+func WriteJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func writeTable[T any](w io.Writer, rows []T, cols []Column[T], width int, color bool) error {
+	cells := make([][]string, len(rows)+1)
+	styles := make([][]Style, len(rows)+1)
+	cells[0] = make([]string, len(cols))
+	styles[0] = make([]Style, len(cols))
+	widths := make([]int, len(cols))
+	for j, c := range cols {
+		cells[0][j] = strings.ToUpper(c.Header)
+		styles[0][j] = header
+		widths[j] = utf8.RuneCountInString(cells[0][j])
+	}
+	for i, row := range rows {
+		cells[i+1] = make([]string, len(cols))
+		styles[i+1] = make([]Style, len(cols))
+		for j, c := range cols {
+			// Tabs and newlines would break the alignment.
+			v := strings.Join(strings.Fields(c.Value(row)), " ")
+			cells[i+1][j] = v
+			if c.Style != nil {
+				styles[i+1][j] = c.Style(row)
+			}
+			widths[j] = max(widths[j], utf8.RuneCountInString(v))
+		}
+	}
+	fit(widths, cols, width)
+
+	var b strings.Builder
+	for i, line := range cells {
+		b.Reset()
+		for j, v := range line {
+			v = Truncate(v, widths[j])
+			// Pad by the visible width, before escapes are added.
+			pad := strings.Repeat(" ", widths[j]-utf8.RuneCountInString(v))
+			if color {
+				v = styles[i][j].wrap(v)
+			}
+			if j > 0 {
+				b.WriteString("  ")
+			}
+			switch {
+			case cols[j].Right:
+				b.WriteString(pad + v)
+			case j < len(line)-1:
+				b.WriteString(v + pad)
+			default:
+				b.WriteString(v)
+			}
+		}
+		b.WriteString("\n")
+		if _, err := io.WriteString(w, b.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fit narrows flexible columns, the rightmost first, until the table fits
+// width or they are all at minFlexWidth. A width of 0 means unlimited.
+func fit[T any](widths []int, cols []Column[T], width int) {
+	if width <= 0 {
+		return
+	}
+	total := 2 * (len(widths) - 1)
+	for _, n := range widths {
+		total += n
+	}
+	for j := len(cols) - 1; j >= 0 && total > width; j-- {
+		if !cols[j].Flexible || widths[j] <= minFlexWidth {
+			continue
+		}
+		cut := min(total-width, widths[j]-minFlexWidth)
+		widths[j] -= cut
+		total -= cut
+	}
+}
+
+// Truncate shortens s to at most n characters, ending it with an ellipsis
+// when anything was cut.
+func Truncate(s string, n int) string {
+	if n <= 0 || utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	r := []rune(s)
+	return string(r[:n-1]) + "…"
+}
+
+// Date formats t to the minute, or "never" for the zero time.
+func Date(t time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+	return t.Format("2006-01-02 15:04")
+}
+
+// Width is how many columns w can show: $COLUMNS if set, else the width
+// of the terminal w writes to, else 0 for unlimited, as when piped.
+func Width(w io.Writer) int {
+	if n, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && n > 0 {
+		return n
+	}
+	if f, ok := w.(*os.File); ok {
+		return terminalWidth(f)
+	}
+	return 0
+}
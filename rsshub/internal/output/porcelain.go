@@ -0,0 +1,47 @@
+package output
+
+import (
+	"io"
+	"strings"
+	"time"
+)
+
+// porcelainEscaper keeps each porcelain record on one line and its fields
+// apart: backslash, tab, newline and carriage return are written as \\,
+// \t, \n and \r.
+var porcelainEscaper = strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`)
+
+// WritePorcelain writes one line per row: the fields, escaped, separated
+// by tabs. There is no header; callers document the fields and their
+// order, which must never change.
+func WritePorcelain[T any](w io.Writer, rows []T, fields []func(T) string) error {
+	for _, row := range rows {
+		if err := WritePorcelainRow(w, row, fields); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WritePorcelainRow writes the one line WritePorcelain would for row, for
+// callers that stream rows as they come.
+func WritePorcelainRow[T any](w io.Writer, row T, fields []func(T) string) error {
+	var b strings.Builder
+	for i, field := range fields {
+		if i > 0 {
+			b.WriteByte('\t')
+		}
+		b.WriteString(porcelainEscaper.Replace(field(row)))
+	}
+	b.WriteByte('\n')
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// PorcelainTime formats t as RFC 3339 in UTC, or empty for the zero time.
+func PorcelainTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,18 @@
+//go:build !linux
+
+package output
+
+import "os"
+
+// terminalWidth reports 0, unlimited, as the terminal is only queried on
+// Linux; $COLUMNS still applies.
+func terminalWidth(f *os.File) int {
+	return 0
+}
+
+// isTerminal reports whether f is a character device, which is as close
+// as we get to asking for a terminal without the Linux ioctls.
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,177 @@
+// Package readlater pushes article URLs to read-later services.
+package readlater
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"rsshub/internal/config"
+)
+
+// Services lists the supported service names.
+var Services = []string{"wallabag", "pocket", "instapaper"}
+
+// Service saves a URL to a read-later account.
+type Service interface {
+	Save(ctx context.Context, link, title string) error
+}
+
+// requestTimeout bounds each call to a service.
+const requestTimeout = 30 * time.Second
+
+// New returns the named service, or the configured default if name is
+// empty, failing if its credentials are not configured.
+func New(cfg config.ReadLater, name string) (Service, error) {
+	if name == "" {
+		name = cfg.Service
+	}
+	client := &http.Client{Timeout: requestTimeout}
+	switch name {
+	case "":
+		return nil, errors.New("no read-later service given; pass --to or set CLI_APP_READ_LATER")
+	case "wallabag":
+		if cfg.WallabagURL == "" || cfg.WallabagClientID == "" || cfg.WallabagClientSecret == "" ||
+			cfg.WallabagUsername == "" || cfg.WallabagPassword == "" {
+			return nil, errors.New("wallabag needs CLI_APP_WALLABAG_URL, _CLIENT_ID, _CLIENT_SECRET, _USERNAME and _PASSWORD")
+		}
+		return &wallabag{cfg: cfg, client: client}, nil
+	case "pocket":
+		if cfg.PocketConsumerKey == "" || cfg.PocketAccessToken == "" {
+			return nil, errors.New("pocket needs CLI_APP_POCKET_CONSUMER_KEY and CLI_APP_POCKET_ACCESS_TOKEN")
+		}
+		return &pocket{cfg: cfg, client: client}, nil
+	case "instapaper":
+		if cfg.InstapaperUsername == "" {
+			return nil, errors.New("instapaper needs CLI_APP_INSTAPAPER_USERNAME (and _PASSWORD if the account has one)")
+		}
+		return &instapaper{cfg: cfg, client: client}, nil
+	}
+	return nil, fmt.Errorf("unknown read-later service %q (supported: %s)", name, strings.Join(Services, ", "))
+}
+
+// check turns a non-2xx response into an error carrying the start of the
+// body, which is where these services explain what went wrong.
+func check(service string, resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return fmt.Errorf("%s: %s: %s", service, resp.Status, strings.TrimSpace(string(body)))
+}
+
+// wallabag uses the OAuth password grant and then POST /api/entries.json.
+type wallabag struct {
+	cfg    config.ReadLater
+	client *http.Client
+}
+
+func (w *wallabag) Save(ctx context.Context, link, title string) error {
+	base := strings.TrimRight(w.cfg.WallabagURL, "/")
+	form := url.Values{
+		"grant_type":    {"password"},
+		"client_id":     {w.cfg.WallabagClientID},
+		"client_secret": {w.cfg.WallabagClientSecret},
+		"username":      {w.cfg.WallabagUsername},
+		"password":      {w.cfg.WallabagPassword},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, base+"/oauth/v2/token", strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("wallabag: %w", err)
+	}
+	defer resp.Body.Close()
+	err = check("wallabag", resp)
+	if err != nil {
+		return err
+	}
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&token)
+	if err != nil {
+		return fmt.Errorf("wallabag: reading token: %w", err)
+	}
+
+	form = url.Values{"url": {link}, "title": {title}}
+	req, err = http.NewRequestWithContext(ctx, http.MethodPost, base+"/api/entries.json", strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+	resp, err = w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("wallabag: %w", err)
+	}
+	defer resp.Body.Close()
+	return check("wallabag", resp)
+}
+
+// pocket uses the v3 add endpoint with a pre-authorized access token.
+type pocket struct {
+	cfg    config.ReadLater
+	client *http.Client
+}
+
+func (p *pocket) Save(ctx context.Context, link, title string) error {
+	body, err := json.Marshal(map[string]string{
+		"url":          link,
+		"title":        title,
+		"consumer_key": p.cfg.PocketConsumerKey,
+		"access_token": p.cfg.PocketAccessToken,
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://getpocket.com/v3/add", strings.NewReader(string(body)))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=UTF-8")
+	req.Header.Set("X-Accept", "application/json")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("pocket: %w", err)
+	}
+	defer resp.Body.Close()
+	if err := check("pocket", resp); err != nil {
+		if reason := resp.Header.Get("X-Error"); reason != "" {
+			return fmt.Errorf("pocket: %s", reason)
+		}
+		return err
+	}
+	return nil
+}
+
+// instapaper uses the Simple API with HTTP basic auth.
+type instapaper struct {
+	cfg    config.ReadLater
+	client *http.Client
+}
+
+func (i *instapaper) Save(ctx context.Context, link, title string) error {
+	form := url.Values{"url": {link}, "title": {title}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://www.instapaper.com/api/add", strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(i.cfg.InstapaperUsername, i.cfg.InstapaperPassword)
+	resp, err := i.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("instapaper: %w", err)
+	}
+	defer resp.Body.Close()
+	return check("instapaper", resp)
+}
@@ -0,0 +1,186 @@
+package resolver
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// recordingConn passes on a stream connection to a DNS server, where each
+// message is preceded by its length, noting the TTLs of the responses.
+type recordingConn struct {
+	net.Conn
+	r   *Resolver
+	buf []byte
+}
+
+func (c *recordingConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	c.buf = append(c.buf, p[:n]...)
+	for len(c.buf) >= 2 {
+		l := int(binary.BigEndian.Uint16(c.buf))
+		if len(c.buf) < 2+l {
+			break
+		}
+		c.r.record(c.buf[2 : 2+l])
+		c.buf = c.buf[2+l:]
+	}
+	return n, err
+}
+
+// recordingPacketConn is recordingConn for UDP, one message per packet.
+// It stays a net.PacketConn, which tells the Go resolver not to frame
+// messages.
+type recordingPacketConn struct {
+	*net.UDPConn
+	r *Resolver
+}
+
+func (c *recordingPacketConn) Read(p []byte) (int, error) {
+	n, err := c.UDPConn.Read(p)
+	if n > 0 {
+		c.r.record(p[:n])
+	}
+	return n, err
+}
+
+// maxDoHResponse bounds a DNS over HTTPS answer, the largest a DNS
+// message can be.
+const maxDoHResponse = 65535
+
+// dohConn carries the Go resolver's queries over HTTPS (RFC 8484). The
+// resolver writes each query framed as for TCP; the answer is read back
+// framed the same way.
+type dohConn struct {
+	ctx      context.Context
+	client   *http.Client
+	url      string
+	deadline time.Time
+	answer   bytes.Reader
+}
+
+func (c *dohConn) Write(b []byte) (int, error) {
+	if len(b) < 2 || int(binary.BigEndian.Uint16(b)) != len(b)-2 {
+		return 0, errors.New("dns over https: malformed query")
+	}
+	ctx := c.ctx
+	if !c.deadline.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, c.deadline)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(b[2:]))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/dns-message")
+	req.Header.Set("Accept", "application/dns-message")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("dns over https: unexpected status: %s", resp.Status)
+	}
+	msg, err := io.ReadAll(io.LimitReader(resp.Body, maxDoHResponse+1))
+	if err != nil {
+		return 0, err
+	}
+	if len(msg) > maxDoHResponse {
+		return 0, errors.New("dns over https: answer too large")
+	}
+	framed := binary.BigEndian.AppendUint16(make([]byte, 0, 2+len(msg)), uint16(len(msg)))
+	c.answer.Reset(append(framed, msg...))
+	return len(b), nil
+}
+
+func (c *dohConn) Read(p []byte) (int, error) {
+	return c.answer.Read(p)
+}
+
+func (c *dohConn) Close() error                       { return nil }
+func (c *dohConn) LocalAddr() net.Addr                { return dohAddr(c.url) }
+func (c *dohConn) RemoteAddr() net.Addr               { return dohAddr(c.url) }
+func (c *dohConn) SetDeadline(t time.Time) error      { c.deadline = t; return nil }
+func (c *dohConn) SetReadDeadline(t time.Time) error  { return nil }
+func (c *dohConn) SetWriteDeadline(t time.Time) error { c.deadline = t; return nil }
+
+type dohAddr string
+
+func (a dohAddr) Network() string { return "https" }
+func (a dohAddr) String() string  { return string(a) }
+
+// answerTTL reads the name a DNS response answers and the lowest TTL among
+// its answer records. ok is false for malformed responses and those
+// without answers.
+func answerTTL(msg []byte) (name string, ttl time.Duration, ok bool) {
+	if len(msg) < 12 {
+		return "", 0, false
+	}
+	questions := binary.BigEndian.Uint16(msg[4:])
+	answers := binary.BigEndian.Uint16(msg[6:])
+	if questions != 1 || answers == 0 {
+		return "", 0, false
+	}
+	name, off, ok := readName(msg, 12)
+	if !ok {
+		return "", 0, false
+	}
+	off += 4 // type and class
+	lowest := uint32(1<<32 - 1)
+	for range answers {
+		_, next, ok := readName(msg, off)
+		if !ok || next+10 > len(msg) {
+			return "", 0, false
+		}
+		lowest = min(lowest, binary.BigEndian.Uint32(msg[next+4:]))
+		off = next + 10 + int(binary.BigEndian.Uint16(msg[next+8:]))
+		if off > len(msg) {
+			return "", 0, false
+		}
+	}
+	return name, time.Duration(lowest) * time.Second, true
+}
+
+// readName reads the possibly compressed domain name at off in msg and
+// returns it in lower case without the final dot, and the offset just
+// past it.
+func readName(msg []byte, off int) (string, int, bool) {
+	var labels []string
+	end := -1
+	for jumps := 0; ; {
+		if off >= len(msg) {
+			return "", 0, false
+		}
+		l := int(msg[off])
+		switch {
+		case l == 0:
+			if end < 0 {
+				end = off + 1
+			}
+			return strings.ToLower(strings.Join(labels, ".")), end, true
+		case l&0xc0 == 0xc0:
+			if off+1 >= len(msg) || jumps > 32 {
+				return "", 0, false
+			}
+			if end < 0 {
+				end = off + 2
+			}
+			off = int(binary.BigEndian.Uint16(msg[off:]) & 0x3fff)
+			jumps++
+		case l&0xc0 != 0 || off+1+l > len(msg):
+			return "", 0, false
+		default:
+			labels = append(labels, string(msg[off+1:off+1+l]))
+			off += 1 + l
+		}
+	}
+}
@@ -0,0 +1,200 @@
+// Package resolver looks up the hosts feeds are fetched from, through the
+// system's DNS servers or one of the user's choosing, and caches the
+// answers for as long as their TTL allows.
+package resolver
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A server is written as host[:port] or udp://host[:port] for plain DNS,
+// tcp://host[:port] for DNS over TCP only, tls://host[:port] for DNS over
+// TLS, or an https:// URL for DNS over HTTPS. Hosts named in tls and https
+// servers are themselves looked up with the system resolver.
+var schemes = map[string]string{"udp": "53", "tcp": "53", "tls": "853", "https": ""}
+
+// Resolver resolves and dials hosts for an HTTP transport.
+type Resolver struct {
+	// Dialer connects to the addresses DialContext resolves; lookups go
+	// through a dialer of their own.
+	Dialer net.Dialer
+
+	r      *net.Resolver
+	dns    net.Dialer
+	maxTTL time.Duration
+
+	mu    sync.Mutex
+	cache map[string]entry
+	// ttls holds the lowest TTL in the last answer for each name, as the
+	// DNS responses go by, for lookup to pick up.
+	ttls map[string]time.Duration
+}
+
+// maxPending bounds the TTLs noted for names no lookup has picked up.
+const maxPending = 1024
+
+type entry struct {
+	addrs   []net.IPAddr
+	expires time.Time
+}
+
+// ParseServer checks a server as New accepts it and returns its scheme
+// and address, with the default port filled in for all but https.
+func ParseServer(server string) (scheme, addr string, err error) {
+	scheme, rest, ok := strings.Cut(server, "://")
+	if !ok {
+		scheme, rest = "udp", server
+	}
+	port, known := schemes[scheme]
+	switch {
+	case !known:
+		return "", "", fmt.Errorf("unknown DNS server scheme %q (want udp, tcp, tls or https)", scheme)
+	case scheme == "https":
+		u, err := url.Parse(server)
+		if err != nil || u.Host == "" {
+			return "", "", fmt.Errorf("invalid DNS over HTTPS URL %q", server)
+		}
+		return scheme, server, nil
+	case rest == "":
+		return "", "", errors.New("DNS server has no host")
+	}
+	if _, _, err := net.SplitHostPort(rest); err != nil {
+		rest = net.JoinHostPort(strings.Trim(rest, "[]"), port)
+	}
+	return scheme, rest, nil
+}
+
+// New returns a resolver that asks server, or the system's servers when
+// it is empty, and keeps answers for their TTL but at most maxTTL; 0
+// turns the cache off.
+func New(server string, maxTTL time.Duration) (*Resolver, error) {
+	r := &Resolver{maxTTL: maxTTL, cache: map[string]entry{}, ttls: map[string]time.Duration{}}
+	dial := r.dns.DialContext
+	if server != "" {
+		scheme, addr, err := ParseServer(server)
+		if err != nil {
+			return nil, err
+		}
+		switch scheme {
+		case "udp":
+			dial = func(ctx context.Context, network, _ string) (net.Conn, error) {
+				return r.dns.DialContext(ctx, network, addr)
+			}
+		case "tcp":
+			dial = func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return r.dns.DialContext(ctx, "tcp", addr)
+			}
+		case "tls":
+			host, _, _ := net.SplitHostPort(addr)
+			tlsDialer := &tls.Dialer{NetDialer: &r.dns, Config: &tls.Config{ServerName: host}}
+			dial = func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return tlsDialer.DialContext(ctx, "tcp", addr)
+			}
+		case "https":
+			client := &http.Client{Timeout: 10 * time.Second}
+			dial = func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return &dohConn{ctx: ctx, client: client, url: addr}, nil
+			}
+		}
+	}
+	r.r = &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			c, err := dial(ctx, network, address)
+			if err != nil {
+				return nil, err
+			}
+			if udp, ok := c.(*net.UDPConn); ok {
+				return &recordingPacketConn{UDPConn: udp, r: r}, nil
+			}
+			return &recordingConn{Conn: c, r: r}, nil
+		},
+	}
+	return r, nil
+}
+
+// LookupIPAddr returns the addresses of host, from the cache while its
+// answer is fresh.
+func (r *Resolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	key := strings.ToLower(strings.TrimSuffix(host, "."))
+	now := time.Now()
+	if r.maxTTL > 0 {
+		r.mu.Lock()
+		e, ok := r.cache[key]
+		r.mu.Unlock()
+		if ok && now.Before(e.expires) {
+			return e.addrs, nil
+		}
+	}
+	addrs, err := r.r.LookupIPAddr(ctx, host)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ttl, seen := r.ttls[key]
+	delete(r.ttls, key)
+	if err != nil {
+		return nil, err
+	}
+	// Answers from the hosts file carry no TTL and are cheap to repeat.
+	if r.maxTTL > 0 && seen && ttl > 0 {
+		r.cache[key] = entry{addrs: addrs, expires: now.Add(min(ttl, r.maxTTL))}
+	}
+	return addrs, nil
+}
+
+// DialContext connects to address like net.Dialer, resolving its host
+// through r and trying each of its addresses in turn.
+func (r *Resolver) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	if net.ParseIP(host) != nil {
+		return r.Dialer.DialContext(ctx, network, address)
+	}
+	addrs, err := r.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	err = &net.AddrError{Err: "no suitable address", Addr: host}
+	for _, a := range addrs {
+		if (network == "tcp4" && a.IP.To4() == nil) || (network == "tcp6" && a.IP.To4() != nil) {
+			continue
+		}
+		var c net.Conn
+		c, err = r.Dialer.DialContext(ctx, network, net.JoinHostPort(a.String(), port))
+		if err == nil {
+			return c, nil
+		}
+	}
+	return nil, err
+}
+
+// record notes the lowest TTL among the answers of a DNS response, under
+// the name it answers. Responses without answers are ignored, so failed
+// lookups are not cached.
+func (r *Resolver) record(msg []byte) {
+	name, ttl, ok := answerTTL(msg)
+	if !ok {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// Names tried through search domains are never looked up again;
+	// forget them all once they pile up.
+	if len(r.ttls) > maxPending {
+		clear(r.ttls)
+	}
+	// A and AAAA come back separately; the shorter TTL wins.
+	if prev, seen := r.ttls[name]; !seen || ttl < prev {
+		r.ttls[name] = ttl
+	}
+}
@@ -0,0 +1,72 @@
+package rss
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"rsshub/internal/models"
+)
+
+// ParsePubDate parses an item's pubDate in the formats feeds use in
+// practice.
+func ParsePubDate(s string) (time.Time, error) {
+	formats := []string{
+		time.RFC1123,  // e.g., "Tue, 20 Aug 2024 10:20:30 GMT"
+		time.RFC1123Z, // e.g., "Tue, 20 Aug 2024 10:20:30 -0000"
+		time.RFC822,   // Similar, but with 2-digit year
+		time.RFC822Z,
+		"2006-01-02T15:04:05Z", // ISO 8601 variant
+		"2006-01-02T15:04:05-07:00",
+	}
+	for _, f := range formats {
+		t, err := time.Parse(f, s)
+		if err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("no matching format for pubDate: %s", s)
+}
+
+// ParsePubDateLayout tries layout before the standard formats, for feeds
+// with a known odd date format. An empty layout is skipped.
+func ParsePubDateLayout(s, layout string) (time.Time, error) {
+	if layout != "" {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return ParsePubDate(s)
+}
+
+// ItemPublished works out when an item was published. It tries the item's
+// pubDate or atom:published, its atom:updated and its dc:date, each with
+// layout first, then the Last-Modified header the item came with, and
+// finally seen, the time it was first fetched. It returns the date, the
+// models.DateSource it came from, and the first of the item's own dates
+// that did not parse, if any.
+func ItemPublished(item models.RSSItem, layout string, seen time.Time) (at time.Time, source, unparsed string) {
+	candidates := []struct{ date, source string }{
+		{item.PubDate, models.DateSourcePublished},
+		{item.Updated, models.DateSourceUpdated},
+		{item.DCDate, models.DateSourceDCDate},
+	}
+	for _, c := range candidates {
+		date := strings.TrimSpace(c.date)
+		if date == "" {
+			continue
+		}
+		t, err := ParsePubDateLayout(date, layout)
+		if err == nil {
+			return t, c.source, unparsed
+		}
+		if unparsed == "" {
+			unparsed = date
+		}
+	}
+	if t, err := http.ParseTime(item.LastModified); err == nil {
+		return t, models.DateSourceLastModified, unparsed
+	}
+	return seen, models.DateSourceFirstSeen, unparsed
+}
@@ -0,0 +1,129 @@
+package rss
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"rsshub/internal/models"
+)
+
+// FeedLink is a feed found for a web page.
+type FeedLink struct {
+	URL   string
+	Title string
+}
+
+// feedTypes are the link types that announce a feed rsshub can read.
+var feedTypes = map[string]bool{
+	"application/rss+xml":  true,
+	"application/atom+xml": true,
+}
+
+// guessPaths are tried on the page's site when it announces no feed.
+var guessPaths = []string{"/feed", "/rss", "/feed.xml", "/rss.xml", "/atom.xml", "/index.xml"}
+
+var (
+	htmlLinkTag = regexp.MustCompile(`(?is)<link\b[^>]*>`)
+	htmlBaseTag = regexp.MustCompile(`(?is)<base\b[^>]*>`)
+	htmlAttr    = regexp.MustCompile(`(?s)([a-zA-Z-]+)\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'>]+))`)
+)
+
+// Discover finds the feeds for pageURL. A URL that already is a feed is
+// returned as is. Otherwise the page's <link rel="alternate"> tags are
+// read, and failing those a few common feed paths on its site are tried.
+func (f *Fetcher) Discover(ctx context.Context, pageURL string) ([]FeedLink, error) {
+	body, _, err := f.Fetch(ctx, pageURL)
+	if err != nil {
+		return nil, err
+	}
+	if title, ok := feedTitle(body); ok {
+		return []FeedLink{{URL: pageURL, Title: title}}, nil
+	}
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return nil, err
+	}
+	if tag := htmlBaseTag.Find(body); tag != nil {
+		if href, err := base.Parse(htmlAttrs(tag)["href"]); err == nil {
+			base = href
+		}
+	}
+	var links []FeedLink
+	seen := make(map[string]bool)
+	for _, tag := range htmlLinkTag.FindAll(body, -1) {
+		attrs := htmlAttrs(tag)
+		if !hasToken(attrs["rel"], "alternate") || !feedTypes[strings.ToLower(strings.TrimSpace(attrs["type"]))] {
+			continue
+		}
+		href, err := base.Parse(strings.TrimSpace(attrs["href"]))
+		if err != nil || attrs["href"] == "" || seen[href.String()] {
+			continue
+		}
+		seen[href.String()] = true
+		links = append(links, FeedLink{URL: href.String(), Title: attrs["title"]})
+	}
+	if len(links) > 0 {
+		return links, nil
+	}
+	for _, path := range guessPaths {
+		guess := base.ResolveReference(&url.URL{Path: path}).String()
+		body, _, err := f.Fetch(ctx, guess)
+		if err != nil {
+			continue
+		}
+		if title, ok := feedTitle(body); ok {
+			return []FeedLink{{URL: guess, Title: title}}, nil
+		}
+	}
+	return nil, nil
+}
+
+// feedTitle reports whether body is an RSS or Atom document, and its
+// title if so.
+func feedTitle(body []byte) (string, bool) {
+	dec := newGuardedDecoder(bytes.NewReader(body))
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return "", false
+		}
+		if start, ok := tok.(xml.StartElement); ok {
+			if start.Name.Local != "rss" && !(start.Name.Local == "feed" && start.Name.Space == atomNS) {
+				return "", false
+			}
+			break
+		}
+	}
+	feed, err := Parse(bytes.NewReader(body), 1, func(models.RSSItem) error { return nil })
+	if err != nil {
+		return "", false
+	}
+	return strings.TrimSpace(feed.Channel.Title), true
+}
+
+// htmlAttrs returns a tag's attributes by lower-cased name, unescaped.
+func htmlAttrs(tag []byte) map[string]string {
+	attrs := make(map[string]string)
+	for _, m := range htmlAttr.FindAllSubmatch(tag, -1) {
+		name := strings.ToLower(string(m[1]))
+		if _, ok := attrs[name]; !ok {
+			attrs[name] = html.UnescapeString(string(m[2]) + string(m[3]) + string(m[4]))
+		}
+	}
+	return attrs
+}
+
+// hasToken reports whether the space-separated list has tok, ignoring case.
+func hasToken(list, tok string) bool {
+	for _, t := range strings.Fields(list) {
+		if strings.EqualFold(t, tok) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,103 @@
+package rss
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+// acceptEncoding is what fetches ask for. Setting it ourselves turns off
+// the transport's transparent gzip, so decode sees, and Stream counts,
+// the bytes as they arrive.
+const acceptEncoding = "gzip, deflate, br"
+
+// ErrUnsupportedEncoding is returned for bodies in a content coding rsshub
+// cannot decode.
+var ErrUnsupportedEncoding = errors.New("unsupported content encoding")
+
+// decode undoes the Content-Encoding of a body, the last coding applied
+// first. Servers get it wrong often enough that the body is sniffed too:
+// a body labelled gzip, deflate or br that already looks like a document is
+// read as is, and an unlabelled one that starts like gzip, such as a
+// .xml.gz served as it is stored, is decompressed anyway.
+func decode(r io.Reader, contentEncoding string) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	var codings []string
+	for _, c := range strings.Split(contentEncoding, ",") {
+		if c = strings.ToLower(strings.TrimSpace(c)); c != "" && c != "identity" {
+			codings = append(codings, c)
+		}
+	}
+	if len(codings) == 0 && isGzip(br) {
+		codings = []string{"gzip"}
+	}
+	for i := len(codings) - 1; i >= 0; i-- {
+		var next io.Reader
+		var err error
+		switch codings[i] {
+		case "gzip", "x-gzip":
+			if !isGzip(br) {
+				continue
+			}
+			next, err = gzip.NewReader(br)
+		case "deflate":
+			switch {
+			case looksPlain(br):
+				continue
+			case isZlib(br):
+				next, err = zlib.NewReader(br)
+			default:
+				// Some servers send a bare deflate stream without the zlib
+				// wrapping the coding calls for.
+				next = flate.NewReader(br)
+			}
+		case "br":
+			// Brotli has no magic number, so only a body that looks
+			// like a document is taken to be mislabelled.
+			if looksPlain(br) {
+				continue
+			}
+			next = brotli.NewReader(br)
+		default:
+			return nil, fmt.Errorf("%w %q", ErrUnsupportedEncoding, codings[i])
+		}
+		if err != nil {
+			return nil, fmt.Errorf("decoding %s body: %w", codings[i], err)
+		}
+		br = bufio.NewReader(next)
+	}
+	return br, nil
+}
+
+// isGzip reports whether r starts with the gzip magic number.
+func isGzip(r *bufio.Reader) bool {
+	b, _ := r.Peek(2)
+	return len(b) == 2 && b[0] == 0x1f && b[1] == 0x8b
+}
+
+// isZlib reports whether r starts with a zlib header using deflate.
+func isZlib(r *bufio.Reader) bool {
+	b, _ := r.Peek(2)
+	return len(b) == 2 && b[0]&0x0f == 8 && (uint16(b[0])<<8|uint16(b[1]))%31 == 0
+}
+
+// looksPlain reports whether r starts like an uncompressed document: a
+// byte order mark, whitespace or markup.
+func looksPlain(r *bufio.Reader) bool {
+	b, _ := r.Peek(1)
+	if len(b) == 0 {
+		return true
+	}
+	switch b[0] {
+	case '<', ' ', '\t', '\r', '\n', 0xef, 0xfe, 0xff:
+		return true
+	}
+	return false
+}
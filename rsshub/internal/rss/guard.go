@@ -0,0 +1,49 @@
+package rss
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+)
+
+// maxXMLDepth bounds element nesting. Real feeds rarely exceed a dozen
+// levels; anything deeper is a parser attack or garbage.
+const maxXMLDepth = 64
+
+var (
+	ErrEntityDeclaration = errors.New("xml: entity declarations are not allowed")
+	ErrTooDeep           = errors.New("xml: document nesting too deep")
+)
+
+// guardedTokens wraps a decoder and rejects documents that declare entities
+// (the basis of billion-laughs style expansion) or nest too deeply.
+type guardedTokens struct {
+	d     *xml.Decoder
+	depth int
+}
+
+func newGuardedDecoder(r io.Reader) *xml.Decoder {
+	return xml.NewTokenDecoder(&guardedTokens{d: xml.NewDecoder(r)})
+}
+
+func (g *guardedTokens) Token() (xml.Token, error) {
+	tok, err := g.d.Token()
+	if err != nil {
+		return tok, err
+	}
+	switch t := tok.(type) {
+	case xml.Directive:
+		if bytes.Contains(t, []byte("ENTITY")) {
+			return nil, ErrEntityDeclaration
+		}
+	case xml.StartElement:
+		g.depth++
+		if g.depth > maxXMLDepth {
+			return nil, ErrTooDeep
+		}
+	case xml.EndElement:
+		g.depth--
+	}
+	return tok, nil
+}
@@ -0,0 +1,170 @@
+package rss
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"rsshub/internal/geo"
+	"rsshub/internal/models"
+)
+
+// ItemDate returns the item's pubDate or atom:published, else its
+// atom:updated, else its dc:date.
+func ItemDate(item models.RSSItem) string {
+	if item.PubDate != "" {
+		return item.PubDate
+	}
+	if item.Updated != "" {
+		return item.Updated
+	}
+	return item.DCDate
+}
+
+// ItemBody returns the item's content:encoded, the full article where a
+// feed provides one, or else its description, or else its plain-text
+// media:description escaped as HTML, which is all YouTube entries have.
+func ItemBody(item models.RSSItem) string {
+	if strings.TrimSpace(item.Content) != "" {
+		return item.Content
+	}
+	if strings.TrimSpace(item.Description) != "" {
+		return item.Description
+	}
+	return html.EscapeString(item.MediaGroup.Description)
+}
+
+// ItemAuthor returns the item's author: its dc:creator, else the name in
+// an Atom-style <author>, else the name in an RSS "email (Name)" author,
+// else the address itself.
+func ItemAuthor(item models.RSSItem) string {
+	if s := strings.TrimSpace(item.Creator); s != "" {
+		return s
+	}
+	if s := strings.TrimSpace(item.Author.Name); s != "" {
+		return s
+	}
+	s := strings.TrimSpace(item.Author.Text)
+	if open := strings.Index(s, "("); open >= 0 && strings.HasSuffix(s, ")") {
+		if name := strings.TrimSpace(s[open+1 : len(s)-1]); name != "" {
+			return name
+		}
+	}
+	return s
+}
+
+// EnclosureLength parses an enclosure's length attribute, returning 0 when
+// it is missing or not a byte count.
+func EnclosureLength(e models.RSSEnclosure) int64 {
+	n, err := strconv.ParseInt(strings.TrimSpace(e.Length), 10, 64)
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
+
+// ItemLocation returns the position a GeoRSS georss:point or W3C geo:lat
+// and geo:long give for the item, if any parses to valid coordinates.
+func ItemLocation(item models.RSSItem) *models.GeoPoint {
+	candidates := []string{
+		item.GeoRSSPoint,
+		item.GeoLat + " " + item.GeoLong,
+		item.GeoPoint.Lat + " " + item.GeoPoint.Long,
+	}
+	for _, c := range candidates {
+		if strings.TrimSpace(c) == "" {
+			continue
+		}
+		if p, err := geo.ParsePoint(c); err == nil {
+			return &p
+		}
+	}
+	return nil
+}
+
+// ItemDuration parses an itunes:duration, given either as seconds or as
+// [HH:]MM:SS, returning 0 when it is missing or malformed.
+func ItemDuration(s string) time.Duration {
+	parts := strings.Split(strings.TrimSpace(s), ":")
+	if len(parts) > 3 || parts[0] == "" {
+		return 0
+	}
+	var secs float64
+	for i, p := range parts {
+		n, err := strconv.ParseFloat(p, 64)
+		if err != nil || n < 0 || (i > 0 && n >= 60) {
+			return 0
+		}
+		secs = secs*60 + n
+	}
+	return time.Duration(secs * float64(time.Second)).Truncate(time.Second)
+}
+
+// ItemNumber parses an itunes:episode or itunes:season, which must be a
+// positive integer; anything else yields 0.
+func ItemNumber(s string) int {
+	n, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
+
+// ItemThumbnail returns the URL of the item's media:thumbnail, looking
+// inside its media:group first.
+func ItemThumbnail(item models.RSSItem) string {
+	if u := strings.TrimSpace(item.MediaGroup.Thumbnail.URL); u != "" {
+		return u
+	}
+	return strings.TrimSpace(item.MediaThumbnail.URL)
+}
+
+// ItemViews parses the view count from a media:group's media:statistics,
+// returning 0 when it is missing.
+func ItemViews(item models.RSSItem) int64 {
+	n, err := strconv.ParseInt(strings.TrimSpace(item.MediaGroup.Community.Statistics.Views), 10, 64)
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
+
+var (
+	unreadable = regexp.MustCompile(`(?is)<(script|style)\b.*?</(script|style)\s*>`)
+	markup     = regexp.MustCompile(`(?s)<[^>]*>`)
+)
+
+// WordCount counts the words in an item body, HTML or plain text, leaving
+// out markup and scripts.
+func WordCount(body string) int {
+	return len(textWords(body))
+}
+
+// textWords splits an item body, HTML or plain text, into the words a
+// reader sees.
+func textWords(body string) []string {
+	text := markup.ReplaceAllString(unreadable.ReplaceAllString(body, " "), " ")
+	return strings.Fields(html.UnescapeString(text))
+}
+
+// ContentHash fingerprints an item's title and body by their text alone,
+// so a feed that regenerates its markup on every request does not look
+// changed.
+func ContentHash(title, body string) string {
+	text := strings.Join(textWords(title), " ") + "\x00" + strings.Join(textWords(body), " ")
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
+
+// ReadingTime estimates how long words take to read, at
+// models.WordsPerMinute, rounded up to a whole minute.
+func ReadingTime(words int) time.Duration {
+	if words <= 0 {
+		return 0
+	}
+	return time.Duration((words+models.WordsPerMinute-1)/models.WordsPerMinute) * time.Minute
+}
@@ -0,0 +1,207 @@
+package rss
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"rsshub/internal/models"
+)
+
+// Lint severities. Dropped marks items rsshub would not store.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityDropped = "dropped"
+)
+
+// Finding is one problem found by Lint. Item is the 1-based position of
+// the item it concerns, or 0 for the feed as a whole.
+type Finding struct {
+	Severity string
+	Item     int
+	Title    string
+	Message  string
+}
+
+// LintReport is the result of linting one feed document.
+type LintReport struct {
+	Items    int
+	Stored   int
+	Findings []Finding
+}
+
+// Lint checks a feed document against the RSS 2.0 or Atom specification and
+// against what rsshub needs to store its items: which items would be
+// dropped and why, missing or unparseable dates that fall back to others, duplicate GUIDs and links,
+// and encoding problems. contentType is the HTTP Content-Type, if known;
+// maxItems is the configured per-feed item cap.
+func Lint(body []byte, contentType string, maxItems int) LintReport {
+	var r LintReport
+	feedf := func(sev, format string, args ...any) {
+		r.Findings = append(r.Findings, Finding{Severity: sev, Message: fmt.Sprintf(format, args...)})
+	}
+
+	declared := xmlEncoding(body)
+	if _, params, err := mime.ParseMediaType(contentType); err == nil && params["charset"] != "" {
+		charset := strings.ToLower(params["charset"])
+		if declared != "" && charset != declared {
+			feedf(SeverityWarning, "HTTP charset %q disagrees with the XML declaration's encoding %q", charset, declared)
+		}
+	}
+	if declared != "" && declared != "utf-8" && declared != "us-ascii" {
+		feedf(SeverityError, "document is encoded as %q; rsshub only reads UTF-8 feeds", declared)
+		return r
+	}
+	if !utf8.Valid(body) {
+		feedf(SeverityError, "document is not valid UTF-8")
+	}
+	if bytes.HasPrefix(body, []byte("\xef\xbb\xbf")) {
+		feedf(SeverityWarning, "document starts with a byte order mark")
+	}
+
+	atom := false
+	switch root := rootElement(body); root {
+	case "rss":
+	case "feed":
+		atom = true
+	case "RDF":
+		feedf(SeverityError, "this is an RSS 1.0 (RDF) feed; rsshub reads RSS 2.0 and Atom only and would store nothing")
+		return r
+	case "":
+		feedf(SeverityError, "no XML root element found; is this a feed at all?")
+		return r
+	default:
+		feedf(SeverityError, "root element is <%s>, not <rss> or <feed>; rsshub would store nothing", root)
+		return r
+	}
+
+	guids := map[string]int{}
+	links := map[string]int{}
+	feed, err := Parse(bytes.NewReader(body), 0, func(item models.RSSItem) error {
+		r.Items++
+		n := r.Items
+		itemf := func(sev, format string, args ...any) {
+			r.Findings = append(r.Findings, Finding{Severity: sev, Item: n, Title: item.Title, Message: fmt.Sprintf(format, args...)})
+		}
+
+		dropped := false
+		_, source, unparsed := ItemPublished(item, "", time.Time{})
+		fallback := source == models.DateSourceFirstSeen
+		switch {
+		case unparsed != "" && fallback:
+			itemf(SeverityWarning, "date %q is not in a recognized format (use RFC 822, e.g. Mon, 02 Jan 2006 15:04:05 GMT); rsshub dates it by the response's Last-Modified or when it first sees it", unparsed)
+		case unparsed != "":
+			itemf(SeverityWarning, "date %q is not in a recognized format; rsshub uses its %s date instead", unparsed, source)
+		case fallback && atom:
+			itemf(SeverityWarning, "no published or updated date; rsshub dates it by the response's Last-Modified or when it first sees it")
+		case fallback:
+			itemf(SeverityWarning, "no pubDate or dc:date; rsshub dates it by the response's Last-Modified or when it first sees it")
+		}
+		if maxItems > 0 && n > maxItems {
+			itemf(SeverityDropped, "beyond CLI_APP_MAX_ITEMS_PER_FEED (%d)", maxItems)
+			dropped = true
+		}
+
+		if item.Title == "" && ItemBody(item) == "" {
+			itemf(SeverityError, "neither title nor description (RSS 2.0 requires one)")
+		}
+		if item.Link == "" {
+			itemf(SeverityWarning, "no link; rsshub identifies articles by link, so updates cannot be matched")
+		} else {
+			if u, err := url.Parse(item.Link); err != nil || !u.IsAbs() {
+				itemf(SeverityWarning, "link %q is not an absolute URL", item.Link)
+			}
+			if first, ok := links[item.Link]; ok {
+				itemf(SeverityWarning, "same link as item %d; rsshub keeps one article and treats the other as a revision", first)
+			} else {
+				links[item.Link] = n
+			}
+		}
+		if item.GUID == "" {
+			itemf(SeverityWarning, "no guid")
+		} else if first, ok := guids[item.GUID]; ok {
+			itemf(SeverityError, "duplicate guid %q (first used by item %d)", item.GUID, first)
+		} else {
+			guids[item.GUID] = n
+		}
+		if !dropped {
+			r.Stored++
+		}
+		return nil
+	})
+	if err != nil {
+		feedf(SeverityError, "parse error after %d items: %v", r.Items, err)
+		return r
+	}
+	if atom {
+		if feed.Channel.Title == "" {
+			feedf(SeverityError, "feed has no title (required by Atom)")
+		}
+		if feed.Channel.Link == "" {
+			feedf(SeverityWarning, "feed has no alternate link to its website")
+		}
+	} else {
+		if feed.Channel.Title == "" {
+			feedf(SeverityError, "channel has no title (required by RSS 2.0)")
+		}
+		if feed.Channel.Link == "" {
+			feedf(SeverityError, "channel has no link (required by RSS 2.0)")
+		}
+		if feed.Channel.Description == "" {
+			feedf(SeverityWarning, "channel has no description (required by RSS 2.0)")
+		}
+	}
+	if r.Items == 0 {
+		feedf(SeverityWarning, "feed has no items")
+	}
+	return r
+}
+
+// xmlEncoding returns the lower-cased encoding from the XML declaration.
+func xmlEncoding(body []byte) string {
+	body = bytes.TrimPrefix(body, []byte("\xef\xbb\xbf"))
+	if !bytes.HasPrefix(body, []byte("<?xml")) {
+		return ""
+	}
+	end := bytes.Index(body, []byte("?>"))
+	if end < 0 {
+		return ""
+	}
+	decl := string(body[:end])
+	i := strings.Index(decl, "encoding=")
+	if i < 0 {
+		return ""
+	}
+	v := decl[i+len("encoding="):]
+	if len(v) < 2 {
+		return ""
+	}
+	quote := v[0]
+	v = v[1:]
+	if j := strings.IndexByte(v, quote); j >= 0 {
+		return strings.ToLower(v[:j])
+	}
+	return ""
+}
+
+// rootElement returns the local name of the document's first element.
+func rootElement(body []byte) string {
+	dec := xml.NewDecoder(bytes.NewReader(body))
+	dec.CharsetReader = func(_ string, r io.Reader) (io.Reader, error) { return r, nil }
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return ""
+		}
+		if t, ok := tok.(xml.StartElement); ok {
+			return t.Name.Local
+		}
+	}
+}
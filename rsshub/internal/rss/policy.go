@@ -0,0 +1,114 @@
+package rss
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strings"
+	"syscall"
+
+	"rsshub/internal/config"
+)
+
+// ErrURLNotAllowed is returned for URLs, and the addresses they resolve
+// to, that the fetch policy rules out.
+var ErrURLNotAllowed = errors.New("url not allowed")
+
+// urlPolicy restricts what a Fetcher may reach, so that a feed URL cannot
+// be used to probe the network rsshub runs in. Schemes and hosts are
+// checked before each request, redirects included; private addresses are
+// refused when dialling, after the host is resolved, so a name that
+// resolves to one is caught too.
+type urlPolicy struct {
+	schemes []string
+	// hosts, if any, are the only hosts allowed, each with its subdomains.
+	hosts        []string
+	blockPrivate bool
+}
+
+func newURLPolicy(cfg *config.Config) urlPolicy {
+	return urlPolicy{schemes: cfg.FetchSchemes, hosts: cfg.FetchHosts, blockPrivate: cfg.BlockPrivateAddresses}
+}
+
+// nonPublic are the ranges, beyond those netip classifies, that are not
+// reachable on the public internet: "this network", shared address space
+// for carrier-grade NAT, benchmarking, and IPv6 discard and unique local.
+var nonPublic = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("100::/64"),
+	netip.MustParsePrefix("fc00::/7"),
+}
+
+// privateAddr reports whether ip is loopback, private, link-local,
+// multicast or otherwise not a public unicast address.
+func privateAddr(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return true
+	}
+	for _, p := range nonPublic {
+		if p.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// check rules on a URL about to be requested.
+func (p urlPolicy) check(u *url.URL) error {
+	if len(p.schemes) > 0 && !slices.Contains(p.schemes, strings.ToLower(u.Scheme)) {
+		return fmt.Errorf("%w: scheme %q is not one of %s", ErrURLNotAllowed, u.Scheme, strings.Join(p.schemes, ", "))
+	}
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if len(p.hosts) > 0 && !slices.ContainsFunc(p.hosts, func(h string) bool {
+		return host == h || strings.HasSuffix(host, "."+h)
+	}) {
+		return fmt.Errorf("%w: host %s is not allowed", ErrURLNotAllowed, host)
+	}
+	if ip, err := netip.ParseAddr(host); err == nil && p.blockPrivate && privateAddr(ip) {
+		return fmt.Errorf("%w: %s is a private address", ErrURLNotAllowed, host)
+	}
+	return nil
+}
+
+// control is a net.Dialer Control function refusing private addresses
+// when the policy blocks them.
+func (p urlPolicy) control(network, address string, _ syscall.RawConn) error {
+	if !p.blockPrivate {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	if privateAddr(ip) {
+		return fmt.Errorf("%w: %s is a private address", ErrURLNotAllowed, ip)
+	}
+	return nil
+}
+
+// CheckURL reports whether the fetch policy allows raw, so a feed can be
+// refused when it is added rather than on every fetch. Hosts are not
+// resolved here; their addresses are checked when fetched.
+func (f *Fetcher) CheckURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return err
+	}
+	if u.Host == "" {
+		return fmt.Errorf("%w: %q has no host", ErrURLNotAllowed, raw)
+	}
+	return f.policy.check(u)
+}
@@ -1,28 +1,480 @@
 package rss
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/xml"
+	"errors"
+	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"rsshub/internal/config"
 	"rsshub/internal/models"
+	"rsshub/internal/resolver"
+	"sync"
+	"time"
 )
 
-func FetchAndParse(url string) (*models.RSSFeed, error) {
-	resp, err := http.Get(url)
+// ErrBodyTooLarge is returned when a feed response exceeds the configured
+// maximum body size.
+var ErrBodyTooLarge = errors.New("response body exceeds maximum size")
+
+// Fetcher downloads and parses feeds with a purpose-built HTTP client.
+type Fetcher struct {
+	Client      *http.Client
+	MaxBodySize int64
+	MaxItems    int
+	// KeepRaw makes Stream set each item's Raw, see ParseRaw.
+	KeepRaw bool
+
+	policy urlPolicy
+	tlsMu  sync.Mutex
+	tls    map[string]*Fetcher
+}
+
+func NewFetcher(cfg *config.Config) *Fetcher {
+	maxRedirects := cfg.MaxRedirects
+	policy := newURLPolicy(cfg)
+	return &Fetcher{
+		Client: &http.Client{
+			Transport: NewTransport(cfg),
+			Timeout:   cfg.FetchTimeout,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) > maxRedirects {
+					return fmt.Errorf("stopped after %d redirects", maxRedirects)
+				}
+				return policy.check(req.URL)
+			},
+		},
+		MaxBodySize: cfg.MaxBodySize,
+		MaxItems:    cfg.MaxItems,
+		KeepRaw:     cfg.StoreRawItems,
+		policy:      policy,
+	}
+}
+
+// NewTransport returns a transport that dials only addresses the fetch
+// policy allows, through the configured DNS server if any. It sets no
+// timeouts beyond the dial's, so it also suits downloads that run long;
+// clients using it should check URLs and redirects with Fetcher.CheckURL.
+func NewTransport(cfg *config.Config) *http.Transport {
+	policy := newURLPolicy(cfg)
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second, Control: policy.control}
+	transport.DialContext = dialer.DialContext
+	if cfg.DNSServer != "" || cfg.DNSCacheMaxTTL > 0 {
+		// The server was checked by config.LoadConfig.
+		if r, err := resolver.New(cfg.DNSServer, cfg.DNSCacheMaxTTL); err == nil {
+			r.Dialer = *dialer
+			transport.DialContext = r.DialContext
+		}
+	}
+	return transport
+}
+
+// FetchAndParse fetches url and returns the whole feed, items included.
+func (f *Fetcher) FetchAndParse(ctx context.Context, url string) (*models.RSSFeed, error) {
+	var items []models.RSSItem
+	feed, _, err := f.Stream(ctx, url, models.FeedValidators{}, func(item models.RSSItem) error {
+		items = append(items, item)
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
+	feed.Channel.Item = items
+	return feed, nil
+}
+
+// Fetch downloads url whole, enforcing the body size limit, for tools that
+// inspect the raw document. It also returns the Content-Type header.
+func (f *Fetcher) Fetch(ctx context.Context, url string) ([]byte, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := f.policy.check(req.URL); err != nil {
+		return nil, "", err
+	}
+	req.Header.Set("Accept-Encoding", acceptEncoding)
+	resp, err := f.Client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
 	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, "", fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+	if f.MaxBodySize > 0 && resp.ContentLength > f.MaxBodySize {
+		return nil, "", ErrBodyTooLarge
+	}
+	b, _, err := f.readBody(resp)
+	return b, resp.Header.Get("Content-Type"), err
+}
+
+// readBody reads a response body whole, decoding its Content-Encoding,
+// and returns it with the number of bytes that arrived. MaxBodySize caps
+// both what arrives and what it decodes to, so a small compressed body
+// cannot expand without bound.
+func (f *Fetcher) readBody(resp *http.Response) ([]byte, int64, error) {
+	counted := &countingReader{r: resp.Body}
+	var body io.Reader = counted
+	if f.MaxBodySize > 0 {
+		body = &limitedReader{r: counted, n: f.MaxBodySize}
+	}
+	body, err := decode(body, resp.Header.Get("Content-Encoding"))
 	if err != nil {
-		return nil, err
+		return nil, counted.n, err
 	}
+	if f.MaxBodySize > 0 {
+		body = &limitedReader{r: body, n: f.MaxBodySize}
+	}
+	b, err := io.ReadAll(body)
+	return b, counted.n, err
+}
 
-	var feed models.RSSFeed
-	err = xml.Unmarshal(body, &feed)
+// ErrNotModified is returned by Stream when the feed is unchanged since
+// the fetch that produced the validators it was given.
+var ErrNotModified = errors.New("feed not modified")
+
+// Response describes the HTTP exchange behind a Stream call: the status
+// code, 0 if no response arrived, and the body bytes read, as they arrived
+// and once decompressed. Validators
+// identify the version fetched, for the next call to Stream, and MaxAge
+// is how long the server said the response stays fresh.
+type Response struct {
+	Status       int
+	Bytes        int64
+	DecodedBytes int64
+	Validators   models.FeedValidators
+	MaxAge       time.Duration
+}
+
+// Stream fetches url and hands each item to fn as soon as it is decoded,
+// so a large feed's articles are never held in memory at once. The returned
+// feed carries the channel metadata only. The Response is filled in even
+// on error. Cancelling ctx aborts the request and stops decoding before
+// the next item.
+//
+// prev, from an earlier Response, makes the request conditional; when the
+// server answers 304 Not Modified, or sends a body with the same hash,
+// Stream returns ErrNotModified without decoding anything. The body, at
+// most MaxBodySize, is decompressed and read whole before decoding so it can be hashed.
+func (f *Fetcher) Stream(ctx context.Context, url string, prev models.FeedValidators, fn func(models.RSSItem) error) (*models.RSSFeed, Response, error) {
+	var r Response
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
-		return nil, err
+		return nil, r, err
+	}
+	if err := f.policy.check(req.URL); err != nil {
+		return nil, r, err
+	}
+	req.Header.Set("Accept-Encoding", acceptEncoding)
+	if prev.ETag != "" {
+		req.Header.Set("If-None-Match", prev.ETag)
+	}
+	if prev.LastModified != "" {
+		req.Header.Set("If-Modified-Since", prev.LastModified)
+	}
+	resp, err := f.Client.Do(req)
+	if err != nil {
+		return nil, r, err
+	}
+	defer resp.Body.Close()
+
+	r.Status = resp.StatusCode
+	r.MaxAge = MaxAge(resp.Header, time.Now())
+	if resp.StatusCode == http.StatusNotModified {
+		r.Validators = prev
+		return nil, r, ErrNotModified
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, r, fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+	if f.MaxBodySize > 0 && resp.ContentLength > f.MaxBodySize {
+		return nil, r, ErrBodyTooLarge
+	}
+
+	raw, n, err := f.readBody(resp)
+	r.Bytes, r.DecodedBytes = n, int64(len(raw))
+	if err != nil {
+		return nil, r, err
+	}
+	sum := sha256.Sum256(raw)
+	r.Validators = models.FeedValidators{
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		BodyHash:     hex.EncodeToString(sum[:]),
+	}
+	if r.Validators.BodyHash == prev.BodyHash {
+		return nil, r, ErrNotModified
+	}
+	parse := func(item models.RSSItem) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		item.LastModified = r.Validators.LastModified
+		return fn(item)
+	}
+	var feed *models.RSSFeed
+	if f.KeepRaw {
+		feed, err = ParseRaw(raw, f.MaxItems, parse)
+	} else {
+		feed, err = Parse(bytes.NewReader(raw), f.MaxItems, parse)
+	}
+	return feed, r, err
+}
+
+// atomNS is the Atom 1.0 namespace.
+const atomNS = "http://www.w3.org/2005/Atom"
+
+// Parse decodes an RSS 2.0 or Atom document from r, calling fn for each
+// item in document order; Atom entries are mapped onto RSSItem. Decoding
+// stops after maxItems items when maxItems > 0, or as soon as fn returns
+// an error.
+func Parse(r io.Reader, maxItems int, fn func(models.RSSItem) error) (*models.RSSFeed, error) {
+	return parse(r, nil, maxItems, fn)
+}
+
+// ParseRaw is Parse over a document held in memory that also sets each
+// item's Raw: its markup as found, wrapped in the start tags of the
+// elements around it (the rss and channel elements, or Atom's feed), so
+// the namespace declarations it relies on come along. Raw is a document
+// of its own that Parse reads back as a one-item feed.
+func ParseRaw(doc []byte, maxItems int, fn func(models.RSSItem) error) (*models.RSSFeed, error) {
+	return parse(bytes.NewReader(doc), doc, maxItems, fn)
+}
+
+// parse implements Parse, and ParseRaw when doc, the bytes r reads, is
+// not nil.
+func parse(r io.Reader, doc []byte, maxItems int, fn func(models.RSSItem) error) (*models.RSSFeed, error) {
+	guard := &guardedTokens{d: xml.NewDecoder(r)}
+	dec := xml.NewTokenDecoder(guard)
+	var feed models.RSSFeed
+	var path []string
+	// tags holds the markup of the start tags along path, and start the
+	// offset of the token being decoded, for ParseRaw.
+	var tags [][]byte
+	var start int64
+	count := 0
+	// emit hands an item to fn and reports whether the item cap is reached.
+	emit := func(item models.RSSItem) (bool, error) {
+		if doc != nil {
+			item.Raw = rawItem(tags, doc[start:guard.d.InputOffset()])
+		}
+		if err := fn(item); err != nil {
+			return false, err
+		}
+		count++
+		return maxItems > 0 && count >= maxItems, nil
+	}
+	for {
+		start = guard.d.InputOffset()
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if len(path) == 2 && path[0] == "rss" && path[1] == "channel" {
+				var target any
+				switch t.Name.Local {
+				case "item":
+					var item models.RSSItem
+					if err := dec.DecodeElement(&item, &t); err != nil {
+						return nil, err
+					}
+					done, err := emit(item)
+					if err != nil {
+						return nil, err
+					}
+					if done {
+						return &feed, nil
+					}
+					continue
+				case "title":
+					target = &feed.Channel.Title
+				case "link":
+					target = &feed.Channel.Link
+				case "description":
+					target = &feed.Channel.Description
+				case "ttl":
+					target = &feed.Channel.TTL
+				case "skipHours":
+					target = &feed.Channel.SkipHours
+				case "skipDays":
+					target = &feed.Channel.SkipDays
+				}
+				if t.Name.Space != "" {
+					target = syndicationTarget(&feed, t.Name)
+				}
+				if target != nil {
+					if err := dec.DecodeElement(target, &t); err != nil {
+						return nil, err
+					}
+					continue
+				}
+			}
+			if len(path) == 1 && path[0] == "feed" {
+				if target := syndicationTarget(&feed, t.Name); target != nil {
+					if err := dec.DecodeElement(target, &t); err != nil {
+						return nil, err
+					}
+					continue
+				}
+			}
+			if len(path) == 1 && path[0] == "feed" && t.Name.Space == atomNS {
+				var target any
+				switch t.Name.Local {
+				case "entry":
+					var entry models.AtomEntry
+					if err := dec.DecodeElement(&entry, &t); err != nil {
+						return nil, err
+					}
+					done, err := emit(atomItem(entry))
+					if err != nil {
+						return nil, err
+					}
+					if done {
+						return &feed, nil
+					}
+					continue
+				case "link":
+					var link models.AtomLink
+					if err := dec.DecodeElement(&link, &t); err != nil {
+						return nil, err
+					}
+					if link.Rel == "" || link.Rel == "alternate" {
+						feed.Channel.Link = link.Href
+					}
+					continue
+				case "title":
+					target = &feed.Channel.Title
+				case "subtitle":
+					target = &feed.Channel.Description
+				}
+				if target != nil {
+					if err := dec.DecodeElement(target, &t); err != nil {
+						return nil, err
+					}
+					continue
+				}
+			}
+			path = append(path, t.Name.Local)
+			if doc != nil {
+				tags = append(tags, doc[start:guard.d.InputOffset()])
+			}
+		case xml.EndElement:
+			if len(path) > 0 {
+				path = path[:len(path)-1]
+			}
+			if len(tags) > 0 {
+				tags = tags[:len(tags)-1]
+			}
+		}
 	}
 	return &feed, nil
 }
+
+// rawItem wraps an item's markup in the start tags around it and the
+// matching end tags.
+func rawItem(tags [][]byte, item []byte) []byte {
+	var b bytes.Buffer
+	for _, tag := range tags {
+		b.Write(tag)
+	}
+	b.Write(item)
+	for i := len(tags) - 1; i >= 0; i-- {
+		// The tag's name as written, prefix included, ends at the first
+		// space, slash or bracket.
+		name := bytes.TrimPrefix(tags[i], []byte("<"))
+		if n := bytes.IndexAny(name, " \t\r\n/>"); n >= 0 {
+			name = name[:n]
+		}
+		fmt.Fprintf(&b, "</%s>", name)
+	}
+	return b.Bytes()
+}
+
+// syndicationNS is the RSS 1.0 syndication module, whose update hints RSS
+// 2.0 and Atom feeds also carry.
+const syndicationNS = "http://purl.org/rss/1.0/modules/syndication/"
+
+// syndicationTarget returns where a syndication module element of the
+// channel is decoded to, or nil for any other element.
+func syndicationTarget(feed *models.RSSFeed, name xml.Name) any {
+	if name.Space != syndicationNS {
+		return nil
+	}
+	switch name.Local {
+	case "updatePeriod":
+		return &feed.Channel.UpdatePeriod
+	case "updateFrequency":
+		return &feed.Channel.UpdateFrequency
+	}
+	return nil
+}
+
+// atomItem maps an Atom entry onto the RSS item fields rsshub stores.
+func atomItem(e models.AtomEntry) models.RSSItem {
+	item := models.RSSItem{
+		Title:       e.Title,
+		Description: e.Summary,
+		PubDate:     e.Published,
+		Updated:     e.Updated,
+		GUID:        e.ID,
+		Author:      e.Author,
+		Content:     e.Content,
+		MediaGroup:  e.MediaGroup,
+		VideoID:     e.VideoID,
+	}
+	for _, l := range e.Links {
+		if l.Rel == "" || l.Rel == "alternate" {
+			item.Link = l.Href
+			break
+		}
+	}
+	return item
+}
+
+// limitedReader is like io.LimitReader but fails with ErrBodyTooLarge
+// instead of silently truncating the stream.
+type limitedReader struct {
+	r io.Reader
+	n int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.n <= 0 {
+		var probe [1]byte
+		if n, _ := l.r.Read(probe[:]); n > 0 {
+			return 0, ErrBodyTooLarge
+		}
+		return 0, io.EOF
+	}
+	if int64(len(p)) > l.n {
+		p = p[:l.n]
+	}
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+	return n, err
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
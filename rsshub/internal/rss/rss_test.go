@@ -0,0 +1,55 @@
+package rss
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"rsshub/internal/models"
+)
+
+// FuzzParse feeds arbitrary documents to Parse and ParseRaw, which read
+// untrusted bodies from the network. Neither may panic, and since
+// ParseRaw only adds each item's markup they must agree on the items and
+// on whether the document is rejected.
+func FuzzParse(f *testing.F) {
+	f.Add([]byte(`<?xml version="1.0"?>
+<rss version="2.0"><channel><title>Example</title><link>https://example.com/</link>
+<item><title>First</title><link>https://example.com/1</link><pubDate>Mon, 02 Jan 2006 15:04:05 GMT</pubDate></item>
+<item><title>Second</title><link>https://example.com/2</link><description><![CDATA[<p>body</p>]]></description></item>
+</channel></rss>`))
+	f.Add([]byte(`<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom"><title>Example</title>
+<entry><title>First</title><link href="https://example.com/1"/><updated>2006-01-02T15:04:05Z</updated><content type="html">&lt;p&gt;body&lt;/p&gt;</content></entry>
+</feed>`))
+	f.Add([]byte(`<?xml version="1.0"?>
+<!DOCTYPE rss [
+<!ENTITY lol "lol">
+<!ENTITY lol2 "&lol;&lol;&lol;&lol;&lol;&lol;&lol;&lol;&lol;&lol;">
+<!ENTITY lol3 "&lol2;&lol2;&lol2;&lol2;&lol2;&lol2;&lol2;&lol2;&lol2;&lol2;">
+]>
+<rss version="2.0"><channel><item><title>&lol3;</title></item></channel></rss>`))
+	f.Add([]byte(`<rss version="2.0"><channel><item><title>` +
+		strings.Repeat("<b>", 2*maxXMLDepth) + strings.Repeat("</b>", 2*maxXMLDepth) +
+		`</title></item></channel></rss>`))
+	f.Add([]byte(strings.Repeat("<feed>", 4*maxXMLDepth)))
+	f.Add([]byte(`<rss><channel><item><title>unclosed`))
+
+	f.Fuzz(func(t *testing.T, doc []byte) {
+		var items, rawItems []models.RSSItem
+		_, err := Parse(bytes.NewReader(doc), 0, func(item models.RSSItem) error {
+			items = append(items, item)
+			return nil
+		})
+		_, rawErr := ParseRaw(doc, 0, func(item models.RSSItem) error {
+			rawItems = append(rawItems, item)
+			return nil
+		})
+		if (err == nil) != (rawErr == nil) {
+			t.Fatalf("Parse returned %v but ParseRaw %v", err, rawErr)
+		}
+		if len(items) != len(rawItems) {
+			t.Fatalf("Parse found %d items but ParseRaw %d", len(items), len(rawItems))
+		}
+	})
+}
@@ -0,0 +1,103 @@
+package rss
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"rsshub/internal/models"
+)
+
+// weekdays maps <skipDays> day names to weekdays.
+var weekdays = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
+// updatePeriods are the sy:updatePeriod values, with months and years
+// taken as 30 and 365 days.
+var updatePeriods = map[string]time.Duration{
+	"hourly":  time.Hour,
+	"daily":   24 * time.Hour,
+	"weekly":  7 * 24 * time.Hour,
+	"monthly": 30 * 24 * time.Hour,
+	"yearly":  365 * 24 * time.Hour,
+}
+
+// ChannelSchedule reads a channel's <ttl>, <skipHours>, <skipDays> and
+// syndication module hints, ignoring values that do not parse. An hour of
+// 24 is taken as midnight, as some feeds count from 1.
+func ChannelSchedule(feed *models.RSSFeed) models.FeedSchedule {
+	var s models.FeedSchedule
+	if n, err := strconv.Atoi(strings.TrimSpace(feed.Channel.TTL)); err == nil && n > 0 {
+		s.TTL = time.Duration(n) * time.Minute
+	}
+	for _, h := range feed.Channel.SkipHours.Hours {
+		n, err := strconv.Atoi(strings.TrimSpace(h))
+		if err != nil || n < 0 || n > 24 {
+			continue
+		}
+		s.SkipHours = append(s.SkipHours, n%24)
+	}
+	for _, d := range feed.Channel.SkipDays.Days {
+		if day, ok := weekdays[strings.ToLower(strings.TrimSpace(d))]; ok {
+			s.SkipDays = append(s.SkipDays, day)
+		}
+	}
+	if period, ok := updatePeriods[strings.ToLower(strings.TrimSpace(feed.Channel.UpdatePeriod))]; ok {
+		// The frequency defaults to once per period.
+		freq := 1
+		if n, err := strconv.Atoi(strings.TrimSpace(feed.Channel.UpdateFrequency)); err == nil && n > 0 {
+			freq = n
+		}
+		s.UpdateInterval = period / time.Duration(freq)
+	}
+	return s
+}
+
+// MaxAge returns how long a response with header h, received at now,
+// stays fresh: its Cache-Control max-age, or else the time from its Date
+// (now when missing) to its Expires, less the Age caches have already
+// held it for. Responses marked no-cache or no-store, and those with
+// neither header or values that do not parse, are not fresh at all.
+func MaxAge(h http.Header, now time.Time) time.Duration {
+	var lifetime time.Duration
+	maxAge := false
+	for _, line := range h.Values("Cache-Control") {
+		for _, directive := range strings.Split(line, ",") {
+			name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+			switch strings.ToLower(strings.TrimSpace(name)) {
+			case "no-cache", "no-store":
+				return 0
+			case "max-age":
+				n, err := strconv.ParseInt(strings.Trim(strings.TrimSpace(value), `"`), 10, 64)
+				if err != nil || n < 0 {
+					return 0
+				}
+				lifetime, maxAge = time.Duration(min(n, math.MaxInt32))*time.Second, true
+			}
+		}
+	}
+	if !maxAge {
+		expires, err := http.ParseTime(h.Get("Expires"))
+		if err != nil {
+			return 0
+		}
+		date, err := http.ParseTime(h.Get("Date"))
+		if err != nil {
+			date = now
+		}
+		lifetime = expires.Sub(date)
+	}
+	if n, err := strconv.ParseInt(strings.TrimSpace(h.Get("Age")), 10, 64); err == nil && n > 0 {
+		lifetime -= time.Duration(min(n, math.MaxInt32)) * time.Second
+	}
+	return max(lifetime, 0)
+}
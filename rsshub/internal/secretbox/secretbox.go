@@ -0,0 +1,85 @@
+// Package secretbox encrypts the credentials rsshub keeps in its database
+// with AES-256-GCM under a key that lives outside it.
+package secretbox
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// KeySize is the length of a key in bytes.
+const KeySize = 32
+
+// ErrWrongKey is returned by Open for values sealed under another key.
+var ErrWrongKey = errors.New("secret was encrypted with another key")
+
+// Key encrypts and decrypts secrets.
+type Key struct {
+	aead cipher.AEAD
+	id   string
+}
+
+// ParseKey decodes a key written as base64, the form NewKey returns.
+func ParseKey(s string) (*Key, error) {
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, fmt.Errorf("key is not base64: %w", err)
+	}
+	if len(raw) != KeySize {
+		return nil, fmt.Errorf("key must be %d bytes, got %d", KeySize, len(raw))
+	}
+	block, err := aes.NewCipher(raw)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(append([]byte("rsshub secret key "), raw...))
+	return &Key{aead: aead, id: hex.EncodeToString(sum[:4])}, nil
+}
+
+// NewKey returns a random key, base64-encoded.
+func NewKey() (string, error) {
+	raw := make([]byte, KeySize)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(raw), nil
+}
+
+// ID identifies the key without revealing it, so sealed values can say
+// which key they need.
+func (k *Key) ID() string {
+	return k.id
+}
+
+// Seal encrypts plaintext, bound to name so a sealed value cannot be
+// swapped for another's, and returns the nonce and ciphertext.
+func (k *Key) Seal(name string, plaintext []byte) (nonce, ciphertext []byte, err error) {
+	nonce = make([]byte, k.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, nil, err
+	}
+	return nonce, k.aead.Seal(nil, nonce, plaintext, []byte(name)), nil
+}
+
+// Open decrypts what Seal returned for name under the key with keyID.
+func (k *Key) Open(name, keyID string, nonce, ciphertext []byte) ([]byte, error) {
+	if keyID != k.id {
+		return nil, fmt.Errorf("%w %s, not %s", ErrWrongKey, keyID, k.id)
+	}
+	plaintext, err := k.aead.Open(nil, nonce, ciphertext, []byte(name))
+	if err != nil {
+		return nil, fmt.Errorf("decrypting %s: %w", name, err)
+	}
+	return plaintext, nil
+}
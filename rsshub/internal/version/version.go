@@ -0,0 +1,59 @@
+// Package version reports which build of rsshub is running. The values are
+// set at link time, for example:
+//
+//	go build -ldflags "-X rsshub/internal/version.Version=v1.2.0 \
+//	  -X rsshub/internal/version.Commit=$(git rev-parse --short HEAD) \
+//	  -X rsshub/internal/version.Date=$(date -u +%Y-%m-%dT%H:%M:%SZ)" ./cmd/rsshub
+package version
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+)
+
+var (
+	Version = "dev"
+	Commit  = ""
+	Date    = ""
+)
+
+// Info describes the running build.
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	Date      string `json:"date"`
+	GoVersion string `json:"go_version"`
+}
+
+// Get returns the build info. A commit or date not set via ldflags falls
+// back to the VCS stamp the Go toolchain embeds when building from a
+// checkout, then to "unknown".
+func Get() Info {
+	info := Info{Version: Version, Commit: Commit, Date: Date, GoVersion: runtime.Version()}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range bi.Settings {
+			switch {
+			case s.Key == "vcs.revision" && info.Commit == "":
+				info.Commit = s.Value
+				if len(info.Commit) > 12 {
+					info.Commit = info.Commit[:12]
+				}
+			case s.Key == "vcs.time" && info.Date == "":
+				info.Date = s.Value
+			}
+		}
+	}
+	if info.Commit == "" {
+		info.Commit = "unknown"
+	}
+	if info.Date == "" {
+		info.Date = "unknown"
+	}
+	return info
+}
+
+// String formats the build info on one line.
+func (i Info) String() string {
+	return fmt.Sprintf("rsshub %s (commit %s, built %s, %s)", i.Version, i.Commit, i.Date, i.GoVersion)
+}
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS articles_author_idx;
+
+ALTER TABLE archived_articles DROP COLUMN IF EXISTS author;
+ALTER TABLE articles DROP COLUMN IF EXISTS author;
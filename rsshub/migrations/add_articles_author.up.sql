@@ -0,0 +1,4 @@
+ALTER TABLE articles ADD COLUMN author TEXT;
+ALTER TABLE archived_articles ADD COLUMN author TEXT;
+
+CREATE INDEX articles_author_idx ON articles (lower(author)) WHERE author IS NOT NULL;
@@ -0,0 +1,4 @@
+-- Hash of each article's title and description as text, set at ingest and
+-- on revision, so markup-only rewrites upstream are not stored again.
+-- Existing articles get theirs the first time their text changes.
+ALTER TABLE articles ADD COLUMN content_hash TEXT;
@@ -0,0 +1,13 @@
+DROP INDEX IF EXISTS articles_feed_guid_idx;
+
+ALTER TABLE archived_articles DROP COLUMN IF EXISTS enclosure_length;
+ALTER TABLE archived_articles DROP COLUMN IF EXISTS enclosure_type;
+ALTER TABLE archived_articles DROP COLUMN IF EXISTS enclosure_url;
+ALTER TABLE archived_articles DROP COLUMN IF EXISTS comments_url;
+ALTER TABLE archived_articles DROP COLUMN IF EXISTS guid;
+
+ALTER TABLE articles DROP COLUMN IF EXISTS enclosure_length;
+ALTER TABLE articles DROP COLUMN IF EXISTS enclosure_type;
+ALTER TABLE articles DROP COLUMN IF EXISTS enclosure_url;
+ALTER TABLE articles DROP COLUMN IF EXISTS comments_url;
+ALTER TABLE articles DROP COLUMN IF EXISTS guid;
@@ -0,0 +1,13 @@
+ALTER TABLE articles ADD COLUMN guid TEXT;
+ALTER TABLE articles ADD COLUMN comments_url TEXT;
+ALTER TABLE articles ADD COLUMN enclosure_url TEXT;
+ALTER TABLE articles ADD COLUMN enclosure_type TEXT;
+ALTER TABLE articles ADD COLUMN enclosure_length BIGINT;
+
+ALTER TABLE archived_articles ADD COLUMN guid TEXT;
+ALTER TABLE archived_articles ADD COLUMN comments_url TEXT;
+ALTER TABLE archived_articles ADD COLUMN enclosure_url TEXT;
+ALTER TABLE archived_articles ADD COLUMN enclosure_type TEXT;
+ALTER TABLE archived_articles ADD COLUMN enclosure_length BIGINT;
+
+CREATE INDEX articles_feed_guid_idx ON articles (feed_id, guid) WHERE guid IS NOT NULL;
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS articles_location_idx;
+
+ALTER TABLE archived_articles DROP COLUMN IF EXISTS longitude;
+ALTER TABLE archived_articles DROP COLUMN IF EXISTS latitude;
+ALTER TABLE articles DROP COLUMN IF EXISTS longitude;
+ALTER TABLE articles DROP COLUMN IF EXISTS latitude;
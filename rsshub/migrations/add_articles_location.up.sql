@@ -0,0 +1,6 @@
+ALTER TABLE articles ADD COLUMN latitude DOUBLE PRECISION;
+ALTER TABLE articles ADD COLUMN longitude DOUBLE PRECISION;
+ALTER TABLE archived_articles ADD COLUMN latitude DOUBLE PRECISION;
+ALTER TABLE archived_articles ADD COLUMN longitude DOUBLE PRECISION;
+
+CREATE INDEX articles_location_idx ON articles (latitude, longitude) WHERE latitude IS NOT NULL;
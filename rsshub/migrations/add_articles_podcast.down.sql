@@ -0,0 +1,8 @@
+ALTER TABLE archived_articles DROP COLUMN IF EXISTS image_url;
+ALTER TABLE archived_articles DROP COLUMN IF EXISTS season;
+ALTER TABLE archived_articles DROP COLUMN IF EXISTS episode;
+ALTER TABLE archived_articles DROP COLUMN IF EXISTS duration_seconds;
+ALTER TABLE articles DROP COLUMN IF EXISTS image_url;
+ALTER TABLE articles DROP COLUMN IF EXISTS season;
+ALTER TABLE articles DROP COLUMN IF EXISTS episode;
+ALTER TABLE articles DROP COLUMN IF EXISTS duration_seconds;
@@ -0,0 +1,8 @@
+ALTER TABLE articles ADD COLUMN duration_seconds INTEGER;
+ALTER TABLE articles ADD COLUMN episode INTEGER;
+ALTER TABLE articles ADD COLUMN season INTEGER;
+ALTER TABLE articles ADD COLUMN image_url TEXT;
+ALTER TABLE archived_articles ADD COLUMN duration_seconds INTEGER;
+ALTER TABLE archived_articles ADD COLUMN episode INTEGER;
+ALTER TABLE archived_articles ADD COLUMN season INTEGER;
+ALTER TABLE archived_articles ADD COLUMN image_url TEXT;
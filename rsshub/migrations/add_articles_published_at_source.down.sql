@@ -0,0 +1,2 @@
+ALTER TABLE archived_articles DROP COLUMN IF EXISTS published_at_source;
+ALTER TABLE articles DROP COLUMN IF EXISTS published_at_source;
@@ -0,0 +1,6 @@
+-- Where each article's published_at came from: the item's own pubDate or
+-- atom:published, atom:updated or dc:date, else the feed response's
+-- Last-Modified header or the time the item was first fetched. Articles
+-- stored before this had a date of their own.
+ALTER TABLE articles ADD COLUMN published_at_source TEXT NOT NULL DEFAULT 'published';
+ALTER TABLE archived_articles ADD COLUMN published_at_source TEXT NOT NULL DEFAULT 'published';
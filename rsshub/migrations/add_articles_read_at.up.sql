@@ -0,0 +1 @@
+ALTER TABLE articles ADD COLUMN read_at TIMESTAMP;
@@ -0,0 +1,3 @@
+ALTER TABLE articles ADD COLUMN starred_at TIMESTAMP;
+
+CREATE INDEX articles_starred_idx ON articles (starred_at DESC) WHERE starred_at IS NOT NULL;
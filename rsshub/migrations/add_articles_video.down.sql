@@ -0,0 +1,6 @@
+ALTER TABLE archived_articles DROP COLUMN IF EXISTS views;
+ALTER TABLE archived_articles DROP COLUMN IF EXISTS thumbnail_url;
+ALTER TABLE archived_articles DROP COLUMN IF EXISTS video_id;
+ALTER TABLE articles DROP COLUMN IF EXISTS views;
+ALTER TABLE articles DROP COLUMN IF EXISTS thumbnail_url;
+ALTER TABLE articles DROP COLUMN IF EXISTS video_id;
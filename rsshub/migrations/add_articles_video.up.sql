@@ -0,0 +1,6 @@
+ALTER TABLE articles ADD COLUMN video_id TEXT;
+ALTER TABLE articles ADD COLUMN thumbnail_url TEXT;
+ALTER TABLE articles ADD COLUMN views BIGINT;
+ALTER TABLE archived_articles ADD COLUMN video_id TEXT;
+ALTER TABLE archived_articles ADD COLUMN thumbnail_url TEXT;
+ALTER TABLE archived_articles ADD COLUMN views BIGINT;
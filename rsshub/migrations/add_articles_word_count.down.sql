@@ -0,0 +1,2 @@
+ALTER TABLE archived_articles DROP COLUMN IF EXISTS word_count;
+ALTER TABLE articles DROP COLUMN IF EXISTS word_count;
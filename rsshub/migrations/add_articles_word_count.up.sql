@@ -0,0 +1,7 @@
+ALTER TABLE articles ADD COLUMN word_count INTEGER;
+ALTER TABLE archived_articles ADD COLUMN word_count INTEGER;
+-- Count existing articles' descriptions with their markup removed; new
+-- articles are counted at ingest.
+UPDATE articles SET word_count = COALESCE(array_length(regexp_split_to_array(
+	NULLIF(btrim(regexp_replace(description, '<[^>]*>', ' ', 'g')), ''), '\s+'), 1), 0)
+WHERE description IS NOT NULL;
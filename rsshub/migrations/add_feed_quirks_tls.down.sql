@@ -0,0 +1,2 @@
+ALTER TABLE feed_quirks DROP COLUMN IF EXISTS cert_pins;
+ALTER TABLE feed_quirks DROP COLUMN IF EXISTS ca_bundle;
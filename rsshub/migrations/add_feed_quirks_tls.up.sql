@@ -0,0 +1,2 @@
+ALTER TABLE feed_quirks ADD COLUMN ca_bundle TEXT NOT NULL DEFAULT '';
+ALTER TABLE feed_quirks ADD COLUMN cert_pins TEXT NOT NULL DEFAULT '';
@@ -0,0 +1 @@
+ALTER TABLE feed_schedules DROP COLUMN IF EXISTS max_age_seconds;
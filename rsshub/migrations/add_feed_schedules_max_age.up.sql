@@ -0,0 +1 @@
+ALTER TABLE feed_schedules ADD COLUMN max_age_seconds INTEGER NOT NULL DEFAULT 0;
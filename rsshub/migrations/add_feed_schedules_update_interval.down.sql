@@ -0,0 +1 @@
+ALTER TABLE feed_schedules DROP COLUMN IF EXISTS update_interval_seconds;
@@ -0,0 +1 @@
+ALTER TABLE feed_schedules ADD COLUMN update_interval_seconds INTEGER NOT NULL DEFAULT 0;
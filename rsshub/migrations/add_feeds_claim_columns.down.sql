@@ -0,0 +1,2 @@
+ALTER TABLE feeds DROP COLUMN IF EXISTS claimed_until;
+ALTER TABLE feeds DROP COLUMN IF EXISTS claimed_by;
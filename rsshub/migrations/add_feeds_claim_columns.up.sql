@@ -0,0 +1,2 @@
+ALTER TABLE feeds ADD COLUMN claimed_by TEXT;
+ALTER TABLE feeds ADD COLUMN claimed_until TIMESTAMP;
@@ -0,0 +1,2 @@
+DELETE FROM feeds WHERE deleted_at IS NOT NULL;
+ALTER TABLE feeds DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1 @@
+ALTER TABLE feeds ADD COLUMN deleted_at TIMESTAMP;
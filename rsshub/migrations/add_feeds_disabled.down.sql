@@ -0,0 +1,3 @@
+ALTER TABLE feeds DROP COLUMN IF EXISTS disabled_reason;
+ALTER TABLE feeds DROP COLUMN IF EXISTS disabled_at;
+ALTER TABLE feeds DROP COLUMN IF EXISTS failures;
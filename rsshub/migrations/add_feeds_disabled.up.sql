@@ -0,0 +1,3 @@
+ALTER TABLE feeds ADD COLUMN failures INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE feeds ADD COLUMN disabled_at TIMESTAMP;
+ALTER TABLE feeds ADD COLUMN disabled_reason TEXT;
@@ -0,0 +1 @@
+ALTER TABLE feeds ADD COLUMN quiet_reported_for TIMESTAMP;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS feeds_url_normalized_idx;
+ALTER TABLE feeds DROP COLUMN IF EXISTS url_normalized;
@@ -0,0 +1,3 @@
+ALTER TABLE feeds ADD COLUMN url_normalized TEXT;
+-- rsshub fills url_normalized for existing feeds on startup and creates
+-- feeds_url_normalized_idx once no two feeds share a normalized URL.
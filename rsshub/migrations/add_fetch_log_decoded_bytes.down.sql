@@ -0,0 +1 @@
+ALTER TABLE fetch_log DROP COLUMN IF EXISTS decoded_bytes;
@@ -0,0 +1 @@
+ALTER TABLE fetch_log ADD COLUMN decoded_bytes BIGINT NOT NULL DEFAULT 0;
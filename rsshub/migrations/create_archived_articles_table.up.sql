@@ -0,0 +1,17 @@
+CREATE TABLE archived_articles (
+                                   id UUID PRIMARY KEY,
+                                   feed_id UUID NOT NULL,
+                                   feed_name TEXT NOT NULL,
+                                   feed_url TEXT NOT NULL,
+                                   created_at TIMESTAMP NOT NULL,
+                                   updated_at TIMESTAMP,
+                                   title TEXT NOT NULL,
+                                   link TEXT NOT NULL,
+                                   published_at TIMESTAMP NOT NULL,
+                                   description TEXT,
+                                   read_at TIMESTAMP,
+                                   starred_at TIMESTAMP,
+                                   archived_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX archived_articles_feed_idx ON archived_articles (feed_name, published_at DESC);
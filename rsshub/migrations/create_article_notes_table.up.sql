@@ -0,0 +1,11 @@
+CREATE TABLE article_notes (
+                               id BIGSERIAL PRIMARY KEY,
+                               article_id UUID NOT NULL,
+                               feed_id UUID NOT NULL REFERENCES feeds(id) ON DELETE CASCADE,
+                               quote TEXT NOT NULL DEFAULT '',
+                               body TEXT NOT NULL,
+                               created_by TEXT NOT NULL,
+                               created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX article_notes_article_idx ON article_notes (article_id, id);
@@ -0,0 +1,11 @@
+CREATE TABLE article_revisions (
+                                   id BIGSERIAL PRIMARY KEY,
+                                   article_id UUID NOT NULL,
+                                   feed_id UUID NOT NULL REFERENCES feeds(id) ON DELETE CASCADE,
+                                   title TEXT NOT NULL,
+                                   description TEXT,
+                                   valid_from TIMESTAMP NOT NULL,
+                                   replaced_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX article_revisions_article_idx ON article_revisions (article_id, id);
@@ -0,0 +1,2 @@
+DROP TRIGGER IF EXISTS articles_notify ON articles;
+DROP FUNCTION IF EXISTS notify_article_inserted();
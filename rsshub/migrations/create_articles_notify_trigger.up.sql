@@ -0,0 +1,27 @@
+CREATE OR REPLACE FUNCTION notify_article_inserted() RETURNS trigger AS $$
+DECLARE
+	payload TEXT;
+BEGIN
+	payload := json_build_object(
+		'id', NEW.id,
+		'feed_id', NEW.feed_id,
+		'feed_name', left((SELECT name FROM feeds WHERE id = NEW.feed_id), 200),
+		'title', left(NEW.title, 300),
+		'link', left(NEW.link, 500),
+		'published_at', to_char(NEW.published_at, 'YYYY-MM-DD"T"HH24:MI:SS"Z"')
+	)::text;
+	IF octet_length(payload) >= 8000 THEN
+		payload := json_build_object(
+			'id', NEW.id,
+			'feed_id', NEW.feed_id,
+			'published_at', to_char(NEW.published_at, 'YYYY-MM-DD"T"HH24:MI:SS"Z"')
+		)::text;
+	END IF;
+	PERFORM pg_notify('rsshub_articles', payload);
+	RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS articles_notify ON articles;
+CREATE TRIGGER articles_notify AFTER INSERT ON articles
+	FOR EACH ROW EXECUTE FUNCTION notify_article_inserted();
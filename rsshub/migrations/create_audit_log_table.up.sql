@@ -0,0 +1,12 @@
+CREATE TABLE audit_log (
+                           id BIGSERIAL PRIMARY KEY,
+                           at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                           actor TEXT NOT NULL,
+                           source TEXT NOT NULL,
+                           action TEXT NOT NULL,
+                           target TEXT NOT NULL DEFAULT '',
+                           old_value TEXT,
+                           new_value TEXT
+);
+
+CREATE INDEX audit_log_target_idx ON audit_log (target, id DESC);
@@ -0,0 +1,9 @@
+CREATE TABLE downloads (
+                           feed_id UUID NOT NULL REFERENCES feeds(id) ON DELETE CASCADE,
+                           url TEXT NOT NULL,
+                           article_id UUID NOT NULL,
+                           path TEXT NOT NULL,
+                           bytes BIGINT NOT NULL,
+                           downloaded_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                           PRIMARY KEY (feed_id, url)
+);
@@ -0,0 +1,2 @@
+ALTER TABLE feeds DROP COLUMN IF EXISTS daily_byte_limit;
+DROP TABLE IF EXISTS feed_bandwidth;
@@ -0,0 +1,11 @@
+CREATE TABLE feed_bandwidth (
+                                feed_id UUID NOT NULL REFERENCES feeds(id) ON DELETE CASCADE,
+                                day DATE NOT NULL,
+                                bytes BIGINT NOT NULL DEFAULT 0,
+                                fetches INTEGER NOT NULL DEFAULT 0,
+                                PRIMARY KEY (feed_id, day)
+);
+
+-- Bytes a feed may download per day before its fetches wait for the
+-- next one; 0 means no cap.
+ALTER TABLE feeds ADD COLUMN daily_byte_limit BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,8 @@
+CREATE TABLE feed_quirks (
+                             feed_id UUID PRIMARY KEY REFERENCES feeds(id) ON DELETE CASCADE,
+                             date_layout TEXT NOT NULL DEFAULT '',
+                             insecure_tls BOOLEAN NOT NULL DEFAULT false,
+                             plain_description BOOLEAN NOT NULL DEFAULT false,
+                             strip_title_prefix TEXT NOT NULL DEFAULT '',
+                             updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,3 @@
+ALTER TABLE feeds DROP COLUMN IF EXISTS next_fetch_at;
+
+DROP TABLE IF EXISTS feed_schedules;
@@ -0,0 +1,9 @@
+CREATE TABLE feed_schedules (
+                                feed_id UUID PRIMARY KEY REFERENCES feeds(id) ON DELETE CASCADE,
+                                ttl_minutes INTEGER NOT NULL DEFAULT 0,
+                                skip_hours INTEGER[] NOT NULL DEFAULT '{}',
+                                skip_days INTEGER[] NOT NULL DEFAULT '{}',
+                                updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE feeds ADD COLUMN next_fetch_at TIMESTAMP;
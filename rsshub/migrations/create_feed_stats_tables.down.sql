@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS feed_daily_counts;
+DROP TABLE IF EXISTS feed_stats;
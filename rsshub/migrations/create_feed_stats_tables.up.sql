@@ -0,0 +1,12 @@
+CREATE TABLE feed_stats (
+                            feed_id UUID PRIMARY KEY REFERENCES feeds(id) ON DELETE CASCADE,
+                            article_count BIGINT NOT NULL DEFAULT 0,
+                            last_published_at TIMESTAMP
+);
+
+CREATE TABLE feed_daily_counts (
+                                   feed_id UUID NOT NULL REFERENCES feeds(id) ON DELETE CASCADE,
+                                   day DATE NOT NULL,
+                                   article_count BIGINT NOT NULL DEFAULT 0,
+                                   PRIMARY KEY (feed_id, day)
+);
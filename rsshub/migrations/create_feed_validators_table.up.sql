@@ -0,0 +1,7 @@
+CREATE TABLE feed_validators (
+                                 feed_id UUID PRIMARY KEY REFERENCES feeds(id) ON DELETE CASCADE,
+                                 etag TEXT NOT NULL DEFAULT '',
+                                 last_modified TEXT NOT NULL DEFAULT '',
+                                 body_hash TEXT NOT NULL DEFAULT '',
+                                 updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,16 @@
+CREATE TABLE fetch_log (
+                           id BIGSERIAL PRIMARY KEY,
+                           feed_id UUID NOT NULL REFERENCES feeds(id) ON DELETE CASCADE,
+                           instance TEXT NOT NULL,
+                           worker INTEGER NOT NULL,
+                           fetched_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                           duration_ms INTEGER NOT NULL,
+                           status INTEGER,
+                           bytes BIGINT NOT NULL DEFAULT 0,
+                           items INTEGER NOT NULL DEFAULT 0,
+                           new_items INTEGER NOT NULL DEFAULT 0,
+                           error TEXT
+);
+
+CREATE INDEX fetch_log_feed_idx ON fetch_log (feed_id, fetched_at DESC);
+CREATE INDEX fetch_log_fetched_at_idx ON fetch_log (fetched_at);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS feeds_updated_at_idx;
+DROP INDEX IF EXISTS articles_feed_published_idx;
+DROP INDEX IF EXISTS articles_published_at_idx;
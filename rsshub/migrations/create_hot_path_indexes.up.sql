@@ -0,0 +1,3 @@
+CREATE INDEX articles_published_at_idx ON articles (published_at DESC);
+CREATE INDEX articles_feed_published_idx ON articles (feed_id, published_at DESC);
+CREATE INDEX feeds_updated_at_idx ON feeds (updated_at ASC NULLS FIRST);
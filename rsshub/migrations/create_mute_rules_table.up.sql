@@ -0,0 +1,8 @@
+CREATE TABLE mute_rules (
+                            id BIGSERIAL PRIMARY KEY,
+                            keyword TEXT NOT NULL,
+                            pattern TEXT NOT NULL,
+                            created_by TEXT NOT NULL,
+                            created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                            expires_at TIMESTAMP NOT NULL
+);
@@ -0,0 +1,12 @@
+-- Fetched items' markup, kept when CLI_APP_STORE_RAW_ITEMS is on so that
+-- rsshub reparse can run them through a later parser. Rows are keyed like
+-- articles, by feed and link, and not tied to articles(id), which may be
+-- partitioned.
+CREATE TABLE raw_items (
+                           feed_id UUID NOT NULL REFERENCES feeds(id) ON DELETE CASCADE,
+                           link TEXT NOT NULL,
+                           published_at TIMESTAMP NOT NULL,
+                           raw TEXT NOT NULL,
+                           fetched_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                           PRIMARY KEY (feed_id, link)
+);
@@ -0,0 +1,15 @@
+-- Credentials rsshub needs, encrypted with AES-256-GCM under the key in
+-- CLI_APP_SECRETS_KEY, which never reaches the database. key_id names
+-- that key; rsshub secrets rotate-key re-encrypts every row under a new
+-- one. Secrets of a feed go with it.
+CREATE TABLE secrets (
+                         id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+                         name TEXT NOT NULL,
+                         feed_id UUID REFERENCES feeds(id) ON DELETE CASCADE,
+                         key_id TEXT NOT NULL,
+                         nonce BYTEA NOT NULL,
+                         ciphertext BYTEA NOT NULL,
+                         updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX secrets_scope_idx ON secrets ((COALESCE(feed_id::text, '')), name);
@@ -0,0 +1,6 @@
+CREATE TABLE shared_feeds (
+                              user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                              feed_id UUID NOT NULL REFERENCES feeds(id) ON DELETE CASCADE,
+                              shared_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                              PRIMARY KEY (user_id, feed_id)
+);
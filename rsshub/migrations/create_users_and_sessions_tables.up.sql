@@ -0,0 +1,16 @@
+CREATE TABLE users (
+                       id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+                       created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                       name TEXT NOT NULL UNIQUE,
+                       password_hash TEXT,
+                       oidc_subject TEXT UNIQUE
+);
+
+CREATE TABLE sessions (
+                          token_hash TEXT PRIMARY KEY,
+                          user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                          created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                          expires_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX sessions_user_idx ON sessions (user_id);
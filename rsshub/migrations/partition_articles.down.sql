@@ -0,0 +1,29 @@
+DROP TRIGGER IF EXISTS articles_claim_link ON articles;
+DROP FUNCTION IF EXISTS claim_article_link();
+DROP TABLE IF EXISTS article_links;
+
+ALTER TABLE articles RENAME TO articles_partitioned;
+ALTER INDEX articles_feed_link_idx RENAME TO articles_partitioned_feed_link_idx;
+DROP INDEX IF EXISTS articles_published_at_idx;
+DROP INDEX IF EXISTS articles_feed_published_idx;
+DROP INDEX IF EXISTS articles_starred_idx;
+DROP INDEX IF EXISTS articles_author_idx;
+DROP INDEX IF EXISTS articles_feed_guid_idx;
+DROP INDEX IF EXISTS articles_location_idx;
+
+CREATE TABLE articles (LIKE articles_partitioned INCLUDING DEFAULTS);
+ALTER TABLE articles ADD PRIMARY KEY (id);
+ALTER TABLE articles ADD FOREIGN KEY (feed_id) REFERENCES feeds(id) ON DELETE CASCADE;
+INSERT INTO articles SELECT * FROM articles_partitioned;
+DROP TABLE articles_partitioned;
+
+CREATE UNIQUE INDEX articles_feed_link_idx ON articles (feed_id, link);
+CREATE INDEX articles_published_at_idx ON articles (published_at DESC);
+CREATE INDEX articles_feed_published_idx ON articles (feed_id, published_at DESC);
+CREATE INDEX articles_starred_idx ON articles (starred_at DESC) WHERE starred_at IS NOT NULL;
+CREATE INDEX articles_author_idx ON articles (lower(author)) WHERE author IS NOT NULL;
+CREATE INDEX articles_feed_guid_idx ON articles (feed_id, guid) WHERE guid IS NOT NULL;
+CREATE INDEX articles_location_idx ON articles (latitude, longitude) WHERE latitude IS NOT NULL;
+
+CREATE TRIGGER articles_notify AFTER INSERT ON articles
+	FOR EACH ROW EXECUTE FUNCTION notify_article_inserted();
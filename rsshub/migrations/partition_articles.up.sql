@@ -0,0 +1,73 @@
+-- Partitions articles by month of published_at, as
+-- CLI_APP_PARTITION_ARTICLES does on startup. Unique keys on a partitioned
+-- table must include published_at, so article_links keeps each link once
+-- per feed: an article claims its feed and link there before it is
+-- inserted, and is skipped if another article holds them.
+ALTER TABLE articles RENAME TO articles_unpartitioned;
+ALTER INDEX articles_feed_link_idx RENAME TO articles_unpartitioned_feed_link_idx;
+DROP INDEX IF EXISTS articles_published_at_idx;
+DROP INDEX IF EXISTS articles_feed_published_idx;
+DROP INDEX IF EXISTS articles_starred_idx;
+DROP INDEX IF EXISTS articles_author_idx;
+DROP INDEX IF EXISTS articles_feed_guid_idx;
+DROP INDEX IF EXISTS articles_location_idx;
+
+CREATE TABLE articles (LIKE articles_unpartitioned INCLUDING DEFAULTS) PARTITION BY RANGE (published_at);
+ALTER TABLE articles ADD PRIMARY KEY (id, published_at);
+ALTER TABLE articles ADD FOREIGN KEY (feed_id) REFERENCES feeds(id) ON DELETE CASCADE;
+CREATE UNIQUE INDEX articles_feed_link_idx ON articles (feed_id, link, published_at);
+CREATE TABLE articles_default PARTITION OF articles DEFAULT;
+
+-- One partition per month from the oldest article to two months ahead.
+DO $$
+DECLARE
+	month TIMESTAMP;
+BEGIN
+	FOR month IN
+		SELECT generate_series(
+			date_trunc('month', LEAST(MIN(published_at), CURRENT_TIMESTAMP)),
+			date_trunc('month', GREATEST(MAX(published_at), CURRENT_TIMESTAMP + INTERVAL '2 months')),
+			INTERVAL '1 month')
+		FROM articles_unpartitioned
+	LOOP
+		EXECUTE format('CREATE TABLE %I PARTITION OF articles FOR VALUES FROM (%L) TO (%L)',
+			to_char(month, '"articles_y"YYYY"m"MM'), month::date, (month + INTERVAL '1 month')::date);
+	END LOOP;
+END;
+$$;
+
+INSERT INTO articles SELECT * FROM articles_unpartitioned;
+DROP TABLE articles_unpartitioned;
+
+CREATE INDEX articles_published_at_idx ON articles (published_at DESC);
+CREATE INDEX articles_feed_published_idx ON articles (feed_id, published_at DESC);
+CREATE INDEX articles_starred_idx ON articles (starred_at DESC) WHERE starred_at IS NOT NULL;
+CREATE INDEX articles_author_idx ON articles (lower(author)) WHERE author IS NOT NULL;
+CREATE INDEX articles_feed_guid_idx ON articles (feed_id, guid) WHERE guid IS NOT NULL;
+CREATE INDEX articles_location_idx ON articles (latitude, longitude) WHERE latitude IS NOT NULL;
+
+CREATE TABLE article_links (
+                               feed_id UUID NOT NULL REFERENCES feeds(id) ON DELETE CASCADE,
+                               link TEXT NOT NULL,
+                               PRIMARY KEY (feed_id, link)
+);
+INSERT INTO article_links (feed_id, link)
+	SELECT DISTINCT feed_id, link FROM articles WHERE feed_id IS NOT NULL;
+
+CREATE OR REPLACE FUNCTION claim_article_link() RETURNS trigger AS $$
+BEGIN
+	IF NEW.feed_id IS NULL THEN
+		RETURN NEW;
+	END IF;
+	INSERT INTO article_links (feed_id, link) VALUES (NEW.feed_id, NEW.link) ON CONFLICT DO NOTHING;
+	IF NOT FOUND AND TG_OP = 'INSERT' THEN
+		RETURN NULL;
+	END IF;
+	RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER articles_claim_link BEFORE INSERT OR UPDATE OF feed_id, link ON articles
+	FOR EACH ROW EXECUTE FUNCTION claim_article_link();
+CREATE TRIGGER articles_notify AFTER INSERT ON articles
+	FOR EACH ROW EXECUTE FUNCTION notify_article_inserted();